@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/SherClockHolmes/webpush-go"
@@ -32,14 +33,18 @@ import (
 	"golang.org/x/crypto/bcrypt"
 	"gopkg.in/yaml.v3"
 	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/gomuks/pkg/hicli"
 )
 
 type Config struct {
-	Web     WebConfig         `yaml:"web"`
-	Matrix  MatrixConfig      `yaml:"matrix"`
-	Push    PushConfig        `yaml:"push"`
-	Media   MediaConfig       `yaml:"media"`
-	Logging zeroconfig.Config `yaml:"logging"`
+	Web            WebConfig            `yaml:"web"`
+	Matrix         MatrixConfig         `yaml:"matrix"`
+	Push           PushConfig           `yaml:"push"`
+	Media          MediaConfig          `yaml:"media"`
+	Translation    TranslationConfig    `yaml:"translation"`
+	SecretScanning SecretScanningConfig `yaml:"secret_scanning"`
+	Logging        zeroconfig.Config    `yaml:"logging"`
 }
 
 type MatrixConfig struct {
@@ -57,6 +62,83 @@ type MediaConfig struct {
 	ThumbnailSize int `yaml:"thumbnail_size"`
 }
 
+type TranslatorType string
+
+const (
+	TranslatorTypeNone           TranslatorType = ""
+	TranslatorTypeLibreTranslate TranslatorType = "libretranslate"
+	TranslatorTypeCommand        TranslatorType = "command"
+	TranslatorTypeStub           TranslatorType = "stub"
+)
+
+type TranslationConfig struct {
+	Type TranslatorType `yaml:"type"`
+	// The default target language, e.g. "en".
+	DefaultLanguage string `yaml:"default_language"`
+	// The base URL and API key of the LibreTranslate instance for the libretranslate type.
+	URL    string `yaml:"url"`
+	APIKey string `yaml:"api_key"`
+	// The command and arguments for the command type.
+	Command []string `yaml:"command"`
+}
+
+func (tc *TranslationConfig) MakeTranslator() (hicli.Translator, error) {
+	switch tc.Type {
+	case TranslatorTypeNone:
+		return nil, nil
+	case TranslatorTypeLibreTranslate:
+		if tc.URL == "" {
+			return nil, fmt.Errorf("translation.url must be set for libretranslate")
+		}
+		return &hicli.LibreTranslateTranslator{URL: tc.URL, APIKey: tc.APIKey}, nil
+	case TranslatorTypeCommand:
+		if len(tc.Command) == 0 {
+			return nil, fmt.Errorf("translation.command must be set for command translators")
+		}
+		return &hicli.CommandTranslator{Command: tc.Command}, nil
+	case TranslatorTypeStub:
+		return hicli.StubTranslator{}, nil
+	default:
+		return nil, fmt.Errorf("unknown translator type %q", tc.Type)
+	}
+}
+
+type CustomSecretDetector struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+}
+
+type SecretScanningConfig struct {
+	// Set to true to allow sending messages that look like they contain secrets without confirmation.
+	Disabled bool `yaml:"disabled"`
+	// Set to true to only use the custom detectors.
+	DisableBuiltin bool `yaml:"disable_builtin"`
+	// Additional detectors. Patterns use Go regex syntax.
+	Custom []CustomSecretDetector `yaml:"custom"`
+}
+
+func (ssc *SecretScanningConfig) MakeDetectors() ([]*hicli.SecretDetector, error) {
+	if ssc.Disabled {
+		return nil, nil
+	}
+	var detectors []*hicli.SecretDetector
+	if !ssc.DisableBuiltin {
+		detectors = append(detectors, hicli.BuiltinSecretDetectors...)
+	}
+	for i, custom := range ssc.Custom {
+		pattern, err := regexp.Compile(custom.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern in secret_scanning.custom[%d]: %w", i, err)
+		}
+		name := custom.Name
+		if name == "" {
+			name = fmt.Sprintf("custom pattern #%d", i+1)
+		}
+		detectors = append(detectors, &hicli.SecretDetector{Name: name, Pattern: pattern})
+	}
+	return detectors, nil
+}
+
 type WebConfig struct {
 	ListenAddress   string   `yaml:"listen_address"`
 	Username        string   `yaml:"username"`
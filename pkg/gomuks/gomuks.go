@@ -216,6 +216,18 @@ func (gmx *Gomuks) initClient() error {
 	)
 	gmx.Client.Client.SyncPresence = ptr.Val(gmx.Config.Matrix.SetPresence)
 	gmx.Client.LogoutFunc = gmx.Logout
+	gmx.Client.Translator, err = gmx.Config.Translation.MakeTranslator()
+	if err != nil {
+		gmx.Log.WithLevel(zerolog.FatalLevel).Err(err).Msg("Failed to configure translator")
+		return err
+	}
+	gmx.Client.DefaultTranslationLanguage = gmx.Config.Translation.DefaultLanguage
+	gmx.Client.SecretDetectors, err = gmx.Config.SecretScanning.MakeDetectors()
+	if err != nil {
+		gmx.Log.WithLevel(zerolog.FatalLevel).Err(err).Msg("Failed to configure secret scanning")
+		return err
+	}
+	gmx.Client.DictionaryDir = filepath.Join(gmx.ConfigDir, "dictionaries")
 	httpClient := gmx.Client.Client.Client
 	if runtime.GOOS == "js" {
 		gmx.Client.Client.UserAgent = ""
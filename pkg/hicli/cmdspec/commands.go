@@ -39,6 +39,17 @@ const (
 	ConvertToRoom  = "converttoroom"
 	PowerLevel     = "powerlevel"
 	Poll           = "poll"
+	UpgradeRoom    = "upgraderoom"
+	BulkRedact     = "bulkredact"
+	BulkKick       = "bulkkick"
+	BulkBan        = "bulkban"
+	PolicySub      = "policy subscribe"
+	PolicyUnsub    = "policy unsubscribe"
+	PolicyReport   = "policy report"
+	PolicyApply    = "policy apply"
+	Ignore         = "ignore"
+	Unignore       = "unignore"
+	Snippet        = "snippet"
 )
 
 var CommandDefinitions = []*cmdschema.EventContent{{
@@ -268,4 +279,114 @@ var CommandDefinitions = []*cmdschema.EventContent{{
 		Schema:      cmdschema.Array(cmdschema.PrimitiveTypeString.Schema()),
 		Description: event.MakeExtensibleText("The possible answers to the poll"),
 	}},
+}, {
+	Command:     UpgradeRoom,
+	Description: event.MakeExtensibleText("Upgrade the current room to a new room version"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "version",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("The room version to upgrade to"),
+	}},
+}, {
+	Command:     BulkRedact,
+	Description: event.MakeExtensibleText("Redact recent messages from a user in the current room"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "user_id",
+		Schema:      cmdschema.PrimitiveTypeUserID.Schema(),
+		Description: event.MakeExtensibleText("User ID"),
+	}, {
+		Key:          "limit",
+		Schema:       cmdschema.PrimitiveTypeInteger.Schema(),
+		Description:  event.MakeExtensibleText("The maximum number of messages to redact. Defaults to 100."),
+		DefaultValue: 100,
+		Optional:     true,
+	}, {
+		Key:         "reason",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("Reason for redaction"),
+		Optional:    true,
+	}},
+	TailParam: "reason",
+}, {
+	Command:     BulkKick,
+	Description: event.MakeExtensibleText("Kick all users whose user ID matches a glob pattern from the current room"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "pattern",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("Glob pattern, e.g. @spam*:example.com"),
+	}, {
+		Key:         "reason",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("Reason for kick"),
+		Optional:    true,
+	}},
+	TailParam: "reason",
+}, {
+	Command:     BulkBan,
+	Description: event.MakeExtensibleText("Ban a user from every room in a space"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "space_id",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("Space room ID"),
+	}, {
+		Key:         "user_id",
+		Schema:      cmdschema.PrimitiveTypeUserID.Schema(),
+		Description: event.MakeExtensibleText("User ID"),
+	}, {
+		Key:         "reason",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("Reason for ban"),
+		Optional:    true,
+	}},
+	TailParam: "reason",
+}, {
+	Command:     PolicySub,
+	Description: event.MakeExtensibleText("Subscribe to the policy list in the current room"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:          "auto_ban",
+		Schema:       cmdschema.PrimitiveTypeBoolean.Schema(),
+		Description:  event.MakeExtensibleText("Whether bans from the list should be applied automatically in rooms where you have enough power"),
+		DefaultValue: false,
+	}},
+	Aliases: []string{"policy sub"},
+}, {
+	Command:     PolicyUnsub,
+	Description: event.MakeExtensibleText("Unsubscribe from the policy list in the current room"),
+	Aliases:     []string{"policy unsub"},
+}, {
+	Command:     PolicyReport,
+	Description: event.MakeExtensibleText("List the bans that subscribed policy lists recommend, without banning anyone"),
+}, {
+	Command:     PolicyApply,
+	Description: event.MakeExtensibleText("Ban all users recommended by subscribed policy lists in rooms where you have enough power"),
+}, {
+	Command:     Ignore,
+	Description: event.MakeExtensibleText("Ignore a user, hiding their messages and rejecting their invites"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "user_id",
+		Schema:      cmdschema.PrimitiveTypeUserID.Schema(),
+		Description: event.MakeExtensibleText("User ID"),
+	}},
+}, {
+	Command:     Unignore,
+	Description: event.MakeExtensibleText("Stop ignoring a user"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "user_id",
+		Schema:      cmdschema.PrimitiveTypeUserID.Schema(),
+		Description: event.MakeExtensibleText("User ID"),
+	}},
+}, {
+	Command:     Snippet,
+	Description: event.MakeExtensibleText("Send a message from a saved snippet template"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "name",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("The name of the snippet"),
+	}, {
+		Key:         "args",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("Text to insert in place of {{.Args}}"),
+		Optional:    true,
+	}},
+	TailParam: "args",
 }}
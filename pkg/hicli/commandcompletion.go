@@ -0,0 +1,461 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rs/zerolog"
+	"github.com/tidwall/gjson"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/event/cmdschema"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/cmdspec"
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+)
+
+// maxCommandCompletions is the maximum number of completions returned for members and rooms.
+const maxCommandCompletions = 50
+
+type wrappedCommand struct {
+	*cmdschema.EventContent
+	Source id.UserID
+}
+
+type commandToken struct {
+	Value string
+	Start int
+}
+
+func (h *HiClient) getRoomCommands(ctx context.Context, roomID id.RoomID) []wrappedCommand {
+	commands := make([]wrappedCommand, 0, len(cmdspec.CommandDefinitions))
+	for _, cmd := range cmdspec.CommandDefinitions {
+		commands = append(commands, wrappedCommand{EventContent: cmd, Source: cmdspec.FakeGomuksSender})
+	}
+	if roomID == "" {
+		return commands
+	}
+	state, err := h.DB.CurrentState.GetAllExceptMembers(ctx, roomID)
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Msg("Failed to get room state for bot commands")
+		return commands
+	}
+	for _, evt := range state {
+		if evt.Type != event.StateMSC4391BotCommand.Type || evt.RedactedBy != "" {
+			continue
+		}
+		var content cmdschema.EventContent
+		if json.Unmarshal(evt.Content, &content) != nil || !content.IsValid() {
+			continue
+		}
+		commands = append(commands, wrappedCommand{EventContent: &content, Source: evt.Sender})
+	}
+	return commands
+}
+
+func commandNames(cmd *cmdschema.EventContent) []string {
+	return append([]string{cmd.Command}, cmd.Aliases...)
+}
+
+// tokenizeCommandArgs splits command arguments by whitespace, respecting double quotes.
+// The returned boolean is true if the last token is complete, i.e. the input ends with unquoted whitespace.
+func tokenizeCommandArgs(input string, offset int) (tokens []commandToken, lastComplete bool) {
+	var current strings.Builder
+	inToken, inQuotes, escaped := false, false, false
+	tokenStart := 0
+	for i, r := range input {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && inQuotes:
+			escaped = true
+		case r == '"':
+			if !inToken {
+				inToken = true
+				tokenStart = i
+			}
+			inQuotes = !inQuotes
+		case unicode.IsSpace(r) && !inQuotes:
+			if inToken {
+				tokens = append(tokens, commandToken{Value: current.String(), Start: offset + tokenStart})
+				current.Reset()
+				inToken = false
+			}
+		default:
+			if !inToken {
+				inToken = true
+				tokenStart = i
+			}
+			current.WriteRune(r)
+		}
+	}
+	if inToken {
+		tokens = append(tokens, commandToken{Value: current.String(), Start: offset + tokenStart})
+		return tokens, false
+	}
+	return tokens, true
+}
+
+func quoteCommandArg(val string) string {
+	if val == "" || strings.ContainsFunc(val, func(r rune) bool { return unicode.IsSpace(r) || r == '"' }) {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(val) + `"`
+	}
+	return val
+}
+
+func formatLiteral(val any) (string, bool) {
+	switch typedVal := val.(type) {
+	case string:
+		return typedVal, true
+	case bool, float64, int, int64:
+		return fmt.Sprint(typedVal), true
+	default:
+		return "", false
+	}
+}
+
+func validatePrimitive(typ cmdschema.PrimitiveType, val string) error {
+	switch typ {
+	case cmdschema.PrimitiveTypeInteger:
+		if _, err := strconv.Atoi(val); err != nil {
+			return fmt.Errorf("not an integer")
+		}
+	case cmdschema.PrimitiveTypeBoolean:
+		if _, err := strconv.ParseBool(val); err != nil {
+			return fmt.Errorf("not a boolean")
+		}
+	case cmdschema.PrimitiveTypeServerName:
+		if val == "" || strings.ContainsAny(val, "/@#!$ ") {
+			return fmt.Errorf("not a server name")
+		}
+	case cmdschema.PrimitiveTypeUserID:
+		if strings.HasPrefix(val, "@") {
+			if _, _, err := id.UserID(val).Parse(); err != nil {
+				return fmt.Errorf("invalid user ID: %w", err)
+			}
+		} else if uri, err := id.ParseMatrixURIOrMatrixToURL(val); err != nil || uri.UserID() == "" {
+			return fmt.Errorf("not a user ID or link")
+		}
+	case cmdschema.PrimitiveTypeRoomID, cmdschema.PrimitiveTypeRoomAlias:
+		if (strings.HasPrefix(val, "!") || strings.HasPrefix(val, "#")) && strings.Contains(val, ":") {
+			return nil
+		} else if uri, err := id.ParseMatrixURIOrMatrixToURL(val); err != nil || (uri.RoomID() == "" && uri.RoomAlias() == "") {
+			return fmt.Errorf("not a room ID, alias or link")
+		}
+	case cmdschema.PrimitiveTypeEventID:
+		if strings.HasPrefix(val, "$") {
+			return nil
+		} else if uri, err := id.ParseMatrixURIOrMatrixToURL(val); err != nil || uri.EventID() == "" {
+			return fmt.Errorf("not an event ID or link")
+		}
+	}
+	return nil
+}
+
+func validateCommandArg(schema *cmdschema.ParameterSchema, val string) error {
+	switch schema.SchemaType {
+	case cmdschema.SchemaTypePrimitive:
+		return validatePrimitive(schema.Type, val)
+	case cmdschema.SchemaTypeLiteral:
+		if literal, ok := formatLiteral(schema.Value); !ok || literal != val {
+			return fmt.Errorf("expected %q", literal)
+		}
+	case cmdschema.SchemaTypeUnion:
+		var firstErr error
+		for _, variant := range schema.Variants {
+			err := validateCommandArg(variant, val)
+			if err == nil {
+				return nil
+			} else if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if allLiterals(schema.Variants) {
+			return fmt.Errorf("must be one of %s", strings.Join(literalValues(schema.Variants), ", "))
+		}
+		return firstErr
+	case cmdschema.SchemaTypeArray:
+		return validateCommandArg(schema.Items, val)
+	}
+	return nil
+}
+
+func allLiterals(schemas []*cmdschema.ParameterSchema) bool {
+	for _, schema := range schemas {
+		if schema.SchemaType != cmdschema.SchemaTypeLiteral {
+			return false
+		}
+	}
+	return true
+}
+
+func literalValues(schemas []*cmdschema.ParameterSchema) []string {
+	values := make([]string, 0, len(schemas))
+	for _, schema := range schemas {
+		if literal, ok := formatLiteral(schema.Value); ok {
+			values = append(values, literal)
+		}
+	}
+	return values
+}
+
+func matchesCompletion(prefix string, candidates ...string) bool {
+	for _, candidate := range candidates {
+		if candidate != "" && strings.Contains(strings.ToLower(candidate), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *HiClient) completeMembers(ctx context.Context, roomID id.RoomID, prefix string) []*jsoncmd.CommandCompletionItem {
+	members, err := h.DB.CurrentState.GetMembers(ctx, roomID)
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Msg("Failed to get members for command completion")
+		return nil
+	}
+	var items []*jsoncmd.CommandCompletionItem
+	for _, member := range members {
+		membership := event.Membership(gjson.GetBytes(member.Content, "membership").Str)
+		if member.StateKey == nil || (membership != event.MembershipJoin && membership != event.MembershipInvite) {
+			continue
+		}
+		displayname := gjson.GetBytes(member.Content, "displayname").Str
+		if !matchesCompletion(prefix, *member.StateKey, displayname) {
+			continue
+		}
+		items = append(items, &jsoncmd.CommandCompletionItem{Value: *member.StateKey, Label: displayname})
+		if len(items) >= maxCommandCompletions {
+			break
+		}
+	}
+	return items
+}
+
+func (h *HiClient) completeRooms(ctx context.Context, prefix string, aliasOnly bool) []*jsoncmd.CommandCompletionItem {
+	rooms, err := h.DB.Room.GetBySortTS(ctx, time.Now(), 500)
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Msg("Failed to get rooms for command completion")
+		return nil
+	}
+	var items []*jsoncmd.CommandCompletionItem
+	for _, room := range rooms {
+		var name, alias string
+		if room.Name != nil {
+			name = *room.Name
+		}
+		if room.CanonicalAlias != nil {
+			alias = room.CanonicalAlias.String()
+		}
+		if (aliasOnly && alias == "") || !matchesCompletion(prefix, room.ID.String(), alias, name) {
+			continue
+		}
+		value := alias
+		if value == "" {
+			value = room.ID.String()
+		}
+		items = append(items, &jsoncmd.CommandCompletionItem{Value: value, Label: name})
+		if len(items) >= maxCommandCompletions {
+			break
+		}
+	}
+	return items
+}
+
+func (h *HiClient) completeCommandArg(
+	ctx context.Context, roomID id.RoomID, schema *cmdschema.ParameterSchema, prefix string,
+) []*jsoncmd.CommandCompletionItem {
+	lowerPrefix := strings.ToLower(prefix)
+	switch schema.SchemaType {
+	case cmdschema.SchemaTypePrimitive:
+		switch schema.Type {
+		case cmdschema.PrimitiveTypeUserID:
+			return h.completeMembers(ctx, roomID, lowerPrefix)
+		case cmdschema.PrimitiveTypeRoomID:
+			return h.completeRooms(ctx, lowerPrefix, false)
+		case cmdschema.PrimitiveTypeRoomAlias:
+			return h.completeRooms(ctx, lowerPrefix, true)
+		case cmdschema.PrimitiveTypeBoolean:
+			var items []*jsoncmd.CommandCompletionItem
+			for _, val := range []string{"true", "false"} {
+				if strings.HasPrefix(val, lowerPrefix) {
+					items = append(items, &jsoncmd.CommandCompletionItem{Value: val})
+				}
+			}
+			return items
+		}
+	case cmdschema.SchemaTypeLiteral:
+		if literal, ok := formatLiteral(schema.Value); ok && strings.HasPrefix(strings.ToLower(literal), lowerPrefix) {
+			return []*jsoncmd.CommandCompletionItem{{Value: literal}}
+		}
+	case cmdschema.SchemaTypeUnion:
+		var items []*jsoncmd.CommandCompletionItem
+		seen := make(map[string]struct{})
+		for _, variant := range schema.Variants {
+			for _, item := range h.completeCommandArg(ctx, roomID, variant, prefix) {
+				if _, alreadySeen := seen[item.Value]; !alreadySeen {
+					seen[item.Value] = struct{}{}
+					items = append(items, item)
+				}
+			}
+		}
+		return items
+	case cmdschema.SchemaTypeArray:
+		return h.completeCommandArg(ctx, roomID, schema.Items, prefix)
+	}
+	return nil
+}
+
+func (h *HiClient) completeBuiltinCommandArg(ctx context.Context, cmd, param, prefix string) ([]*jsoncmd.CommandCompletionItem, bool) {
+	if cmd != cmdspec.Snippet || param != "name" {
+		return nil, false
+	}
+	snippets, err := h.GetSnippets(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Msg("Failed to get snippets for command completion")
+		return nil, true
+	}
+	var items []*jsoncmd.CommandCompletionItem
+	for _, name := range slices.Sorted(maps.Keys(snippets.Snippets)) {
+		if strings.HasPrefix(name, prefix) {
+			items = append(items, &jsoncmd.CommandCompletionItem{Value: name, Label: snippets.Snippets[name].Description})
+		}
+	}
+	return items, true
+}
+
+func plainExtensibleText(container *event.ExtensibleTextContainer) string {
+	if container == nil {
+		return ""
+	}
+	for _, repr := range container.Text {
+		if repr.MimeType == "text/plain" || repr.MimeType == "" {
+			return repr.Body
+		}
+	}
+	return ""
+}
+
+func completeCommandNames(commands []wrappedCommand, input string) *jsoncmd.CommandCompletion {
+	resp := &jsoncmd.CommandCompletion{Start: 1, Completions: []*jsoncmd.CommandCompletionItem{}}
+	seen := make(map[string]struct{})
+	for _, cmd := range commands {
+		for _, name := range commandNames(cmd.EventContent) {
+			if _, alreadySeen := seen[name]; alreadySeen || !strings.HasPrefix(name, input) {
+				continue
+			}
+			seen[name] = struct{}{}
+			resp.Completions = append(resp.Completions, &jsoncmd.CommandCompletionItem{
+				Value: name,
+				Label: plainExtensibleText(cmd.Description),
+			})
+		}
+	}
+	slices.SortFunc(resp.Completions, func(a, b *jsoncmd.CommandCompletionItem) int {
+		return strings.Compare(a.Value, b.Value)
+	})
+	return resp
+}
+
+// CompleteCommand parses a partially typed slash command using the parameter schemas of the built-in commands
+// and the bot commands defined in the room. It returns completions for the word at the end of the input and
+// validation errors for the arguments before it.
+func (h *HiClient) CompleteCommand(ctx context.Context, roomID id.RoomID, text string) (*jsoncmd.CommandCompletion, error) {
+	input, ok := strings.CutPrefix(text, "/")
+	if !ok {
+		return nil, fmt.Errorf("input is not a command")
+	}
+	commands := h.getRoomCommands(ctx, roomID)
+	var matched *wrappedCommand
+	var matchedName string
+	for i, cmd := range commands {
+		for _, name := range commandNames(cmd.EventContent) {
+			if len(name) > len(matchedName) && strings.HasPrefix(input, name+" ") {
+				matched, matchedName = &commands[i], name
+			}
+		}
+	}
+	if matched == nil {
+		return completeCommandNames(commands, input), nil
+	}
+	argsOffset := 1 + len(matchedName) + 1
+	tokens, lastComplete := tokenizeCommandArgs(text[argsOffset:], argsOffset)
+	current := commandToken{Start: len(text)}
+	if !lastComplete {
+		current = tokens[len(tokens)-1]
+		tokens = tokens[:len(tokens)-1]
+	}
+	resp := &jsoncmd.CommandCompletion{
+		Command:     matched.EventContent,
+		Source:      matched.Source,
+		Start:       current.Start,
+		Completions: []*jsoncmd.CommandCompletionItem{},
+		Errors:      make(map[string]string),
+	}
+	var currentParam *cmdschema.Parameter
+	tokenIdx := 0
+	for _, param := range matched.Parameters {
+		if param.Key == matched.TailParam {
+			// The tail parameter swallows the rest of the input as-is, so only its first word is completed
+			resp.Parameter = param.Key
+			if tokenIdx == len(tokens) {
+				currentParam = param
+			}
+			break
+		} else if tokenIdx == len(tokens) {
+			currentParam = param
+			break
+		}
+		isArray := param.Schema.SchemaType == cmdschema.SchemaTypeArray
+		for tokenIdx < len(tokens) {
+			if err := validateCommandArg(param.Schema, tokens[tokenIdx].Value); err != nil {
+				if _, alreadyFailed := resp.Errors[param.Key]; !alreadyFailed {
+					resp.Errors[param.Key] = err.Error()
+				}
+			}
+			tokenIdx++
+			if !isArray {
+				break
+			}
+		}
+		if isArray {
+			// Arrays consume all remaining arguments, including the one being typed
+			currentParam = param
+			break
+		}
+	}
+	if currentParam != nil {
+		resp.Parameter = currentParam.Key
+		prefix := current.Value
+		var items []*jsoncmd.CommandCompletionItem
+		var handled bool
+		if matched.Source == cmdspec.FakeGomuksSender {
+			items, handled = h.completeBuiltinCommandArg(ctx, matched.Command, currentParam.Key, prefix)
+		}
+		if !handled {
+			items = h.completeCommandArg(ctx, roomID, currentParam.Schema, prefix)
+		}
+		for _, item := range items {
+			item.Value = quoteCommandArg(item.Value)
+			resp.Completions = append(resp.Completions, item)
+		}
+	}
+	if len(resp.Errors) == 0 {
+		resp.Errors = nil
+	}
+	return resp, nil
+}
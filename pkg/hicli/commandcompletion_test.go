@@ -0,0 +1,119 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"slices"
+	"testing"
+
+	"maunium.net/go/mautrix/event/cmdschema"
+)
+
+func TestTokenizeCommandArgs(t *testing.T) {
+	tests := []struct {
+		input        string
+		tokens       []commandToken
+		lastComplete bool
+	}{
+		{input: "", tokens: nil, lastComplete: true},
+		{input: "foo", tokens: []commandToken{{"foo", 10}}, lastComplete: false},
+		{input: "foo ", tokens: []commandToken{{"foo", 10}}, lastComplete: true},
+		{input: "foo  bar", tokens: []commandToken{{"foo", 10}, {"bar", 15}}, lastComplete: false},
+		{input: `"foo bar" baz`, tokens: []commandToken{{"foo bar", 10}, {"baz", 20}}, lastComplete: false},
+		{input: `"foo bar`, tokens: []commandToken{{"foo bar", 10}}, lastComplete: false},
+		{input: `"foo \"bar\"" `, tokens: []commandToken{{`foo "bar"`, 10}}, lastComplete: true},
+		{input: `"" x`, tokens: []commandToken{{"", 10}, {"x", 13}}, lastComplete: false},
+		{input: `a\b`, tokens: []commandToken{{`a\b`, 10}}, lastComplete: false},
+		{input: "ä ö", tokens: []commandToken{{"ä", 10}, {"ö", 13}}, lastComplete: false},
+	}
+	for _, test := range tests {
+		tokens, lastComplete := tokenizeCommandArgs(test.input, 10)
+		if !slices.Equal(tokens, test.tokens) || lastComplete != test.lastComplete {
+			t.Errorf("tokenizeCommandArgs(%q) = %v, %t, want %v, %t", test.input, tokens, lastComplete, test.tokens, test.lastComplete)
+		}
+	}
+}
+
+func TestQuoteCommandArg(t *testing.T) {
+	tests := map[string]string{
+		"foo":        "foo",
+		"":           `""`,
+		"foo bar":    `"foo bar"`,
+		`say "hi"`:   `"say \"hi\""`,
+		`back\ real`: `"back\\ real"`,
+	}
+	for input, want := range tests {
+		if got := quoteCommandArg(input); got != want {
+			t.Errorf("quoteCommandArg(%q) = %q, want %q", input, got, want)
+		}
+		// Quoted values must tokenize back to the original value
+		if tokens, _ := tokenizeCommandArgs(quoteCommandArg(input), 0); len(tokens) != 1 || tokens[0].Value != input {
+			t.Errorf("quoteCommandArg(%q) didn't round trip through tokenizeCommandArgs: %v", input, tokens)
+		}
+	}
+}
+
+func TestValidateCommandArg(t *testing.T) {
+	literal := func(val any) *cmdschema.ParameterSchema {
+		return &cmdschema.ParameterSchema{SchemaType: cmdschema.SchemaTypeLiteral, Value: val}
+	}
+	onOff := &cmdschema.ParameterSchema{
+		SchemaType: cmdschema.SchemaTypeUnion,
+		Variants:   []*cmdschema.ParameterSchema{literal("on"), literal("off")},
+	}
+	intOrBool := &cmdschema.ParameterSchema{
+		SchemaType: cmdschema.SchemaTypeUnion,
+		Variants:   []*cmdschema.ParameterSchema{cmdschema.PrimitiveTypeInteger.Schema(), cmdschema.PrimitiveTypeBoolean.Schema()},
+	}
+	intArray := &cmdschema.ParameterSchema{
+		SchemaType: cmdschema.SchemaTypeArray,
+		Items:      cmdschema.PrimitiveTypeInteger.Schema(),
+	}
+	tests := []struct {
+		name   string
+		schema *cmdschema.ParameterSchema
+		value  string
+		valid  bool
+	}{
+		{"string", cmdschema.PrimitiveTypeString.Schema(), "anything goes", true},
+		{"integer", cmdschema.PrimitiveTypeInteger.Schema(), "123", true},
+		{"negative integer", cmdschema.PrimitiveTypeInteger.Schema(), "-5", true},
+		{"invalid integer", cmdschema.PrimitiveTypeInteger.Schema(), "12a", false},
+		{"boolean", cmdschema.PrimitiveTypeBoolean.Schema(), "true", true},
+		{"invalid boolean", cmdschema.PrimitiveTypeBoolean.Schema(), "yes please", false},
+		{"server name", cmdschema.PrimitiveTypeServerName.Schema(), "example.com", true},
+		{"invalid server name", cmdschema.PrimitiveTypeServerName.Schema(), "@user:example.com", false},
+		{"user ID", cmdschema.PrimitiveTypeUserID.Schema(), "@user:example.com", true},
+		{"user link", cmdschema.PrimitiveTypeUserID.Schema(), "https://matrix.to/#/@user:example.com", true},
+		{"invalid user ID", cmdschema.PrimitiveTypeUserID.Schema(), "user", false},
+		{"room ID", cmdschema.PrimitiveTypeRoomID.Schema(), "!room:example.com", true},
+		{"room alias", cmdschema.PrimitiveTypeRoomAlias.Schema(), "#room:example.com", true},
+		{"invalid room", cmdschema.PrimitiveTypeRoomID.Schema(), "room", false},
+		{"event ID", cmdschema.PrimitiveTypeEventID.Schema(), "$event", true},
+		{"invalid event ID", cmdschema.PrimitiveTypeEventID.Schema(), "event", false},
+		{"literal", literal("on"), "on", true},
+		{"wrong literal", literal("on"), "off", false},
+		{"number literal", literal(float64(5)), "5", true},
+		{"literal union", onOff, "off", true},
+		{"invalid literal union", onOff, "maybe", false},
+		{"primitive union", intOrBool, "false", true},
+		{"invalid primitive union", intOrBool, "maybe", false},
+		{"array item", intArray, "7", true},
+		{"invalid array item", intArray, "seven", false},
+	}
+	for _, test := range tests {
+		err := validateCommandArg(test.schema, test.value)
+		if test.valid && err != nil {
+			t.Errorf("%s: validateCommandArg(%q) returned error: %v", test.name, test.value, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%s: validateCommandArg(%q) didn't return an error", test.name, test.value)
+		}
+	}
+	if err := validateCommandArg(onOff, "maybe"); err == nil || err.Error() != "must be one of on, off" {
+		t.Errorf("Expected list of allowed values in literal union error, got %v", err)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"go.mau.fi/util/random"
 	"maunium.net/go/mautrix"
 	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/format"
 	"maunium.net/go/mautrix/id"
 
 	"go.mau.fi/gomuks/pkg/hicli/cmdspec"
@@ -85,6 +86,28 @@ func (h *HiClient) ProcessCommand(
 		responseText, retErr = callWithParsedArgs(ctx, roomID, cmd.Arguments, relatesTo, h.handleCmdPowerLevel)
 	case cmdspec.Poll:
 		return callWithParsedArgs(ctx, roomID, cmd.Arguments, relatesTo, h.handleCmdPoll)
+	case cmdspec.UpgradeRoom:
+		responseText, retErr = callWithParsedArgs(ctx, roomID, cmd.Arguments, relatesTo, h.handleCmdUpgradeRoom)
+	case cmdspec.BulkRedact:
+		responseText, retErr = callWithParsedArgs(ctx, roomID, cmd.Arguments, relatesTo, h.handleCmdBulkRedact)
+	case cmdspec.BulkKick:
+		responseText, retErr = callWithParsedArgs(ctx, roomID, cmd.Arguments, relatesTo, h.handleCmdBulkKick)
+	case cmdspec.BulkBan:
+		responseText, retErr = callWithParsedArgs(ctx, roomID, cmd.Arguments, relatesTo, h.handleCmdBulkBan)
+	case cmdspec.PolicySub:
+		responseText, retErr = callWithParsedArgs(ctx, roomID, cmd.Arguments, relatesTo, h.handleCmdPolicySubscribe)
+	case cmdspec.PolicyUnsub:
+		responseText = h.handleCmdPolicyUnsubscribe(ctx, roomID)
+	case cmdspec.PolicyReport:
+		responseHTML = h.handleCmdPolicyReport(ctx)
+	case cmdspec.PolicyApply:
+		responseText = h.handleCmdPolicyApply(ctx)
+	case cmdspec.Ignore:
+		responseText, retErr = callWithParsedArgs(ctx, roomID, cmd.Arguments, relatesTo, h.handleCmdIgnore)
+	case cmdspec.Unignore:
+		responseText, retErr = callWithParsedArgs(ctx, roomID, cmd.Arguments, relatesTo, h.handleCmdUnignore)
+	case cmdspec.Snippet:
+		return callWithParsedArgs(ctx, roomID, cmd.Arguments, relatesTo, h.handleCmdSnippet)
 	default:
 		responseHTML = fmt.Sprintf("Unknown command <code>%s</code>", html.EscapeString(cmd.Command))
 	}
@@ -506,3 +529,154 @@ func (h *HiClient) handleCmdPoll(ctx context.Context, roomID id.RoomID, args pol
 	}
 	return evt
 }
+
+type upgradeRoomParams struct {
+	Version string `json:"version"`
+}
+
+func (h *HiClient) handleCmdUpgradeRoom(ctx context.Context, roomID id.RoomID, args upgradeRoomParams, _ *event.RelatesTo) string {
+	newRoomID, err := h.UpgradeRoom(ctx, roomID, args.Version, nil)
+	if err != nil {
+		return fmt.Sprintf("Failed to upgrade room: %v", err)
+	}
+	return fmt.Sprintf("Upgraded room to version %s, new room ID is %s", args.Version, newRoomID)
+}
+
+type bulkRedactParams struct {
+	UserID id.UserID `json:"user_id"`
+	Limit  int       `json:"limit"`
+	Reason string    `json:"reason"`
+}
+
+func (h *HiClient) handleCmdBulkRedact(ctx context.Context, roomID id.RoomID, args bulkRedactParams, _ *event.RelatesTo) string {
+	resp, err := h.BulkRedact(ctx, args.UserID, roomID, args.Limit, args.Reason)
+	if err != nil {
+		return fmt.Sprintf("Failed to start redacting messages: %v", err)
+	}
+	return fmt.Sprintf("Started job #%d to redact %d messages from %s", resp.JobID, resp.Total, args.UserID)
+}
+
+type bulkKickParams struct {
+	Pattern string `json:"pattern"`
+	Reason  string `json:"reason"`
+}
+
+func (h *HiClient) handleCmdBulkKick(ctx context.Context, roomID id.RoomID, args bulkKickParams, _ *event.RelatesTo) string {
+	resp, err := h.BulkKick(ctx, roomID, args.Pattern, args.Reason)
+	if err != nil {
+		return fmt.Sprintf("Failed to start kicking users: %v", err)
+	}
+	return fmt.Sprintf("Started job #%d to kick %d users matching %s", resp.JobID, resp.Total, args.Pattern)
+}
+
+type bulkBanParams struct {
+	SpaceID id.RoomID `json:"space_id"`
+	UserID  id.UserID `json:"user_id"`
+	Reason  string    `json:"reason"`
+}
+
+func (h *HiClient) handleCmdBulkBan(ctx context.Context, _ id.RoomID, args bulkBanParams, _ *event.RelatesTo) string {
+	resp, err := h.BulkBan(ctx, args.SpaceID, args.UserID, args.Reason)
+	if err != nil {
+		return fmt.Sprintf("Failed to start banning user: %v", err)
+	}
+	return fmt.Sprintf("Started job #%d to ban %s from %d rooms", resp.JobID, args.UserID, resp.Total)
+}
+
+type policySubscribeParams struct {
+	AutoBan bool `json:"auto_ban"`
+}
+
+func (h *HiClient) handleCmdPolicySubscribe(ctx context.Context, roomID id.RoomID, args policySubscribeParams, _ *event.RelatesTo) string {
+	err := h.SetPolicyListSubscription(ctx, roomID, true, args.AutoBan)
+	if err != nil {
+		return fmt.Sprintf("Failed to subscribe to policy list: %v", err)
+	} else if args.AutoBan {
+		return "Subscribed to the policy list in this room with automatic bans enabled"
+	}
+	return "Subscribed to the policy list in this room"
+}
+
+func (h *HiClient) handleCmdPolicyUnsubscribe(ctx context.Context, roomID id.RoomID) string {
+	err := h.SetPolicyListSubscription(ctx, roomID, false, false)
+	if err != nil {
+		return fmt.Sprintf("Failed to unsubscribe from policy list: %v", err)
+	}
+	return "Unsubscribed from the policy list in this room"
+}
+
+func (h *HiClient) handleCmdPolicyReport(ctx context.Context) string {
+	targets, err := h.GetPolicyReport(ctx)
+	if err != nil {
+		return html.EscapeString(fmt.Sprintf("Failed to generate policy report: %v", err))
+	} else if len(targets) == 0 {
+		return "Subscribed policy lists don't recommend banning any members of your rooms"
+	}
+	var buf strings.Builder
+	_, _ = fmt.Fprintf(&buf, "Subscribed policy lists recommend %d bans:<ul>", len(targets))
+	for _, target := range targets {
+		_, _ = fmt.Fprintf(
+			&buf, "<li><code>%s</code> in <code>%s</code> (matched <code>%s</code>",
+			html.EscapeString(target.UserID.String()),
+			html.EscapeString(target.RoomID.String()),
+			html.EscapeString(target.Rule.Entity),
+		)
+		if target.Rule.Reason != "" {
+			_, _ = fmt.Fprintf(&buf, ": %s", html.EscapeString(target.Rule.Reason))
+		}
+		buf.WriteString(")</li>")
+	}
+	buf.WriteString("</ul>")
+	return buf.String()
+}
+
+func (h *HiClient) handleCmdPolicyApply(ctx context.Context) string {
+	resp, err := h.ApplyPolicyBans(ctx)
+	if err != nil {
+		return fmt.Sprintf("Failed to apply policy bans: %v", err)
+	}
+	return fmt.Sprintf("Started job #%d to apply %d bans from policy lists", resp.JobID, resp.Total)
+}
+
+type ignoreParams struct {
+	UserID id.UserID `json:"user_id"`
+}
+
+func (h *HiClient) handleCmdIgnore(ctx context.Context, _ id.RoomID, args ignoreParams, _ *event.RelatesTo) string {
+	err := h.SetIgnored(ctx, args.UserID, true)
+	if err != nil {
+		return fmt.Sprintf("Failed to ignore user: %v", err)
+	}
+	return fmt.Sprintf("Ignored %s", args.UserID)
+}
+
+func (h *HiClient) handleCmdUnignore(ctx context.Context, _ id.RoomID, args ignoreParams, _ *event.RelatesTo) string {
+	err := h.SetIgnored(ctx, args.UserID, false)
+	if err != nil {
+		return fmt.Sprintf("Failed to unignore user: %v", err)
+	}
+	return fmt.Sprintf("Stopped ignoring %s", args.UserID)
+}
+
+type snippetParams struct {
+	Name string `json:"name"`
+	Args string `json:"args"`
+}
+
+func (h *HiClient) handleCmdSnippet(ctx context.Context, roomID id.RoomID, args snippetParams, rel *event.RelatesTo) *database.Event {
+	text, mentions, err := h.RenderSnippet(ctx, roomID, args.Name, args.Args)
+	if err != nil {
+		return database.MakeFakeEvent(roomID, fmt.Sprintf("Failed to render snippet: %s", html.EscapeString(err.Error())))
+	} else if strings.TrimSpace(text) == "" {
+		return database.MakeFakeEvent(roomID, "Snippet rendered to an empty message")
+	}
+	// The rendered text is passed as pre-rendered content rather than input text,
+	// so that snippet output starting with something like /me isn't parsed as a command.
+	content := format.RenderMarkdownCustom(text, defaultNoHTML)
+	content.MsgType = event.MsgText
+	evt, err := h.SendMessage(ctx, roomID, &content, nil, "", rel, mentions, nil, false)
+	if err != nil {
+		return database.MakeFakeEvent(roomID, fmt.Sprintf("Failed to send snippet: %s", html.EscapeString(err.Error())))
+	}
+	return evt
+}
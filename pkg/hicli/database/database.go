@@ -27,6 +27,9 @@ type Database struct {
 	Media            *MediaQuery
 	SpaceEdge        *SpaceEdgeQuery
 	PushRegistration *PushRegistrationQuery
+	PolicyList       *PolicyListQuery
+	PolicyRule       *PolicyRuleQuery
+	Draft            *DraftQuery
 }
 
 func New(rawDB *dbutil.Database) *Database {
@@ -47,6 +50,9 @@ func New(rawDB *dbutil.Database) *Database {
 		Media:            &MediaQuery{QueryHelper: dbutil.MakeQueryHelper(rawDB, newMedia)},
 		SpaceEdge:        &SpaceEdgeQuery{QueryHelper: dbutil.MakeQueryHelper(rawDB, newSpaceEdge)},
 		PushRegistration: &PushRegistrationQuery{QueryHelper: dbutil.MakeQueryHelper(rawDB, newPushRegistration)},
+		PolicyList:       &PolicyListQuery{QueryHelper: dbutil.MakeQueryHelper(rawDB, newPolicyList)},
+		PolicyRule:       &PolicyRuleQuery{QueryHelper: dbutil.MakeQueryHelper(rawDB, newPolicyRule)},
+		Draft:            &DraftQuery{QueryHelper: dbutil.MakeQueryHelper(rawDB, newDraft)},
 	}
 }
 
@@ -89,3 +95,15 @@ func newSpaceEdge(_ *dbutil.QueryHelper[*SpaceEdge]) *SpaceEdge {
 func newPushRegistration(_ *dbutil.QueryHelper[*PushRegistration]) *PushRegistration {
 	return &PushRegistration{}
 }
+
+func newPolicyList(_ *dbutil.QueryHelper[*PolicyList]) *PolicyList {
+	return &PolicyList{}
+}
+
+func newPolicyRule(_ *dbutil.QueryHelper[*PolicyRule]) *PolicyRule {
+	return &PolicyRule{}
+}
+
+func newDraft(_ *dbutil.QueryHelper[*Draft]) *Draft {
+	return &Draft{}
+}
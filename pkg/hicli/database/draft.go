@@ -0,0 +1,89 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mau.fi/util/dbutil"
+	"go.mau.fi/util/jsontime"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+)
+
+const (
+	getAllDraftsQuery = `
+		SELECT room_id, thread_root, text, reply_to, edit_target, mentions, updated_at
+		FROM draft
+		ORDER BY updated_at DESC
+	`
+	putDraftQuery = `
+		INSERT INTO draft (room_id, thread_root, text, reply_to, edit_target, mentions, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (room_id, thread_root) DO UPDATE
+			SET text = excluded.text,
+			    reply_to = excluded.reply_to,
+			    edit_target = excluded.edit_target,
+			    mentions = excluded.mentions,
+			    updated_at = excluded.updated_at
+	`
+	deleteDraftQuery = `
+		DELETE FROM draft WHERE room_id = $1 AND thread_root = $2
+	`
+)
+
+type DraftQuery struct {
+	*dbutil.QueryHelper[*Draft]
+}
+
+func (dq *DraftQuery) GetAll(ctx context.Context) ([]*Draft, error) {
+	return dq.QueryMany(ctx, getAllDraftsQuery)
+}
+
+func (dq *DraftQuery) Put(ctx context.Context, draft *Draft) error {
+	return dq.Exec(ctx, putDraftQuery, draft.sqlVariables()...)
+}
+
+func (dq *DraftQuery) Delete(ctx context.Context, roomID id.RoomID, threadRoot id.EventID) error {
+	return dq.Exec(ctx, deleteDraftQuery, roomID, threadRoot)
+}
+
+// Draft is an unsent message in the composer of a room or thread.
+type Draft struct {
+	RoomID id.RoomID `json:"room_id"`
+	// The root event of the thread the draft is in. Empty for the main timeline.
+	ThreadRoot id.EventID `json:"thread_root,omitempty"`
+	// The raw text in the composer, before any markdown processing.
+	Text string `json:"text"`
+	// The event that the draft is replying to, if any.
+	ReplyTo id.EventID `json:"reply_to,omitempty"`
+	// The event that the draft is editing, if any.
+	EditTarget id.EventID      `json:"edit_target,omitempty"`
+	Mentions   *event.Mentions `json:"mentions,omitempty"`
+	// The time when the draft was last changed. This is set by the backend.
+	UpdatedAt jsontime.UnixMilli `json:"updated_at"`
+}
+
+// IsEmpty returns true if the draft doesn't contain anything worth saving.
+func (d *Draft) IsEmpty() bool {
+	return d.Text == "" && d.ReplyTo == "" && d.EditTarget == ""
+}
+
+func (d *Draft) Scan(row dbutil.Scannable) (*Draft, error) {
+	var updatedAt int64
+	err := row.Scan(&d.RoomID, &d.ThreadRoot, &d.Text, &d.ReplyTo, &d.EditTarget, dbutil.JSON{Data: &d.Mentions}, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	d.UpdatedAt = jsontime.UM(time.UnixMilli(updatedAt))
+	return d, nil
+}
+
+func (d *Draft) sqlVariables() []any {
+	return []any{d.RoomID, d.ThreadRoot, d.Text, d.ReplyTo, d.EditTarget, dbutil.JSON{Data: d.Mentions}, d.UpdatedAt.UnixMilli()}
+}
@@ -48,6 +48,11 @@ const (
 		WHERE room_id = $1 AND relates_to = $2 AND ($3 = '' OR relation_type = $3) AND ($4 = '' OR type = $4)
 		ORDER BY timestamp ASC
 	`
+	getThreadRepliesQuery = getEventBaseQuery + `
+		WHERE room_id = $1 AND relation_type = 'm.thread' AND relates_to IS NOT NULL
+		ORDER BY timestamp DESC
+		LIMIT $2
+	`
 	getMentionEventsQuery = getEventBaseQuery + `
 		WHERE timestamp <= $1 AND unread_type > 0 AND (unread_type & $2) != 0
 		ORDER BY timestamp DESC
@@ -58,6 +63,11 @@ const (
 		ORDER BY timestamp DESC
 		LIMIT $3
 	`
+	getRecentEventsBySenderQuery = getEventBaseQuery + `
+		WHERE sender = $1 AND ($2 = '' OR room_id = $2) AND state_key IS NULL AND redacted_by IS NULL
+		ORDER BY timestamp DESC
+		LIMIT $3
+	`
 	insertEventBaseQuery = `
 		INSERT INTO event (
 			room_id, event_id, sender, type, state_key, timestamp, content, decrypted, decrypted_type,
@@ -149,6 +159,17 @@ func (eq *EventQuery) GetMentions(ctx context.Context, ts time.Time, unreadType
 	return eq.QueryMany(ctx, getMentionEventsQuery, ts.UnixMilli(), unreadType, limit)
 }
 
+// GetThreadReplies returns the most recent thread replies in the given room, regardless of which thread they're in.
+func (eq *EventQuery) GetThreadReplies(ctx context.Context, roomID id.RoomID, limit int) ([]*Event, error) {
+	return eq.QueryMany(ctx, getThreadRepliesQuery, roomID, limit)
+}
+
+// GetRecentBySender returns the most recent non-state events sent by the given user that haven't been redacted yet.
+// If roomID is empty, events from all rooms are included.
+func (eq *EventQuery) GetRecentBySender(ctx context.Context, sender id.UserID, roomID id.RoomID, limit int) ([]*Event, error) {
+	return eq.QueryMany(ctx, getRecentEventsBySenderQuery, sender, roomID, limit)
+}
+
 func (eq *EventQuery) Search(
 	ctx context.Context,
 	matches, like string,
@@ -463,6 +484,20 @@ type LocalContent struct {
 	ReplyFallbackRemoved bool `json:"reply_fallback_removed,omitempty"`
 	// The push rule ID that caused this event to notify or highlight.
 	PushRuleID string `json:"push_rule_id,omitempty"`
+	// A rule in a subscribed policy list that matches the sender of the event (or the target user for member events).
+	// This is only calculated when the event is received, so it won't reflect policy changes after that.
+	PolicyMatch *PolicyRule `json:"policy_match,omitempty"`
+	// A machine translation of the message body. This is only present if it was requested with `translate_event`.
+	Translation *Translation `json:"translation,omitempty"`
+}
+
+type Translation struct {
+	// The translated plaintext body.
+	Text string `json:"text"`
+	// The language the body was translated to.
+	Language string `json:"language"`
+	// The language of the original body, if the translator detected it.
+	SourceLanguage string `json:"source_language,omitempty"`
 }
 
 func (c *LocalContent) GetReplyFallbackRemoved() bool {
@@ -476,6 +511,20 @@ func (c *LocalContent) GetPushRuleID() string {
 	return c.PushRuleID
 }
 
+func (c *LocalContent) GetPolicyMatch() *PolicyRule {
+	if c == nil {
+		return nil
+	}
+	return c.PolicyMatch
+}
+
+func (c *LocalContent) GetTranslation() *Translation {
+	if c == nil {
+		return nil
+	}
+	return c.Translation
+}
+
 // Event represents a single Matrix room event.
 type Event struct {
 	RowID EventRowID `json:"rowid"`
@@ -509,6 +558,8 @@ type Event struct {
 	Reactions     map[string]int `json:"reactions,omitempty"`
 	LastEditRowID *EventRowID    `json:"last_edit_rowid,omitempty"`
 	UnreadType    UnreadType     `json:"unread_type,omitempty"`
+	// The poll tally isn't stored in the database, it's calculated from related events when the poll is sent to clients.
+	PollTally *PollTally `json:"poll_tally,omitempty"`
 
 	StickyDuration jsontime.Milliseconds `json:"sticky_duration_ms,omitzero"`
 
@@ -0,0 +1,94 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package database
+
+import (
+	"encoding/json"
+	"slices"
+
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+)
+
+var (
+	StateImagePack                    = event.Type{Type: "m.room.image_pack", Class: event.StateEventType}
+	StateUnstableImagePack            = event.Type{Type: "im.ponies.room_emotes", Class: event.StateEventType}
+	AccountDataUserImagePack          = event.Type{Type: "im.ponies.user_emotes", Class: event.AccountDataEventType}
+	AccountDataImagePackRooms         = event.Type{Type: "m.image_pack.rooms", Class: event.AccountDataEventType}
+	AccountDataUnstableImagePackRooms = event.Type{Type: "im.ponies.emote_rooms", Class: event.AccountDataEventType}
+)
+
+type ImagePackUsage string
+
+const (
+	ImagePackUsageEmoticon ImagePackUsage = "emoticon"
+	ImagePackUsageSticker  ImagePackUsage = "sticker"
+)
+
+// ImagePackImage is a single custom emoji or sticker in an [MSC2545] image pack.
+//
+// [MSC2545]: https://github.com/matrix-org/matrix-spec-proposals/pull/2545
+type ImagePackImage struct {
+	URL  id.ContentURIString `json:"url"`
+	Body string              `json:"body,omitempty"`
+	Info *event.FileInfo     `json:"info,omitempty"`
+	// The usages of the image. If empty, the usage of the pack is used.
+	Usage []ImagePackUsage `json:"usage,omitempty"`
+}
+
+type ImagePackMeta struct {
+	DisplayName string              `json:"display_name,omitempty"`
+	AvatarURL   id.ContentURIString `json:"avatar_url,omitempty"`
+	// The default usages of images in the pack. If empty, images can be used as both emoticons and stickers.
+	Usage       []ImagePackUsage `json:"usage,omitempty"`
+	Attribution string           `json:"attribution,omitempty"`
+}
+
+// ImagePack is the content of an image pack, either in room state or in the user's account data.
+type ImagePack struct {
+	Images map[string]*ImagePackImage `json:"images"`
+	Pack   ImagePackMeta              `json:"pack"`
+}
+
+// ParseImagePack parses image pack event content. Images without a URL are dropped.
+func ParseImagePack(content json.RawMessage) (*ImagePack, error) {
+	var pack ImagePack
+	err := json.Unmarshal(content, &pack)
+	if err != nil {
+		return nil, err
+	}
+	for shortcode, image := range pack.Images {
+		if image == nil || image.URL == "" {
+			delete(pack.Images, shortcode)
+		}
+	}
+	return &pack, nil
+}
+
+// ImageHasUsage checks whether the given image can be used for the given purpose.
+func (ip *ImagePack) ImageHasUsage(image *ImagePackImage, usage ImagePackUsage) bool {
+	usages := image.Usage
+	if len(usages) == 0 {
+		usages = ip.Pack.Usage
+	}
+	return len(usages) == 0 || slices.Contains(usages, usage)
+}
+
+// ImagePackRooms is the content of the account data event that lists room image packs
+// the user has enabled globally. The inner map is keyed by pack state key.
+type ImagePackRooms struct {
+	Rooms map[id.RoomID]map[string]json.RawMessage `json:"rooms"`
+}
+
+// IsEnabled returns true if the pack in the given room with the given state key is enabled.
+func (ipr *ImagePackRooms) IsEnabled(roomID id.RoomID, packID string) bool {
+	if ipr == nil {
+		return false
+	}
+	_, ok := ipr.Rooms[roomID][packID]
+	return ok
+}
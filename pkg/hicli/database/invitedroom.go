@@ -17,18 +17,18 @@ import (
 
 const (
 	getInvitedRoomsQuery = `
-		SELECT room_id, received_at, invite_state
+		SELECT room_id, received_at, invite_state, quarantined
 		FROM invited_room
 		ORDER BY received_at DESC
 	`
 	getInvitedRoomsAfterQuery = `
-		SELECT room_id, received_at, invite_state
+		SELECT room_id, received_at, invite_state, quarantined
 		FROM invited_room
 		WHERE mod_timestamp > $1
 		ORDER BY received_at DESC
 	`
 	getInvitedRoomQuery = `
-		SELECT room_id, received_at, invite_state
+		SELECT room_id, received_at, invite_state, quarantined
 		FROM invited_room
 		WHERE room_id = $1
 	`
@@ -36,10 +36,13 @@ const (
 		DELETE FROM invited_room WHERE room_id = $1
 	`
 	upsertInvitedRoomQuery = `
-		INSERT INTO invited_room (room_id, received_at, invite_state)
-		VALUES ($1, $2, $3)
+		INSERT INTO invited_room (room_id, received_at, invite_state, quarantined)
+		VALUES ($1, $2, $3, $4)
 		ON CONFLICT (room_id) DO UPDATE
-			SET received_at = $2, invite_state = $3, mod_timestamp = unixepoch('subsec')*1000
+			SET received_at = $2, invite_state = $3, quarantined = $4, mod_timestamp = unixepoch('subsec')*1000
+	`
+	getQuarantinedInviteIDsQuery = `
+		SELECT room_id FROM invited_room WHERE quarantined = true
 	`
 )
 
@@ -59,6 +62,10 @@ func (irq *InvitedRoomQuery) Get(ctx context.Context, roomID id.RoomID) (*Invite
 	return irq.QueryOne(ctx, getInvitedRoomQuery, roomID)
 }
 
+func (irq *InvitedRoomQuery) GetQuarantinedIDs(ctx context.Context) ([]id.RoomID, error) {
+	return roomIDScanner.NewRowIter(irq.GetDB().Query(ctx, getQuarantinedInviteIDsQuery)).AsList()
+}
+
 func (irq *InvitedRoomQuery) Upsert(ctx context.Context, room *InvitedRoom) error {
 	return irq.Exec(ctx, upsertInvitedRoomQuery, room.sqlVariables()...)
 }
@@ -74,6 +81,9 @@ type InvitedRoom struct {
 	CreatedAt jsontime.UnixMilli `json:"created_at"`
 	// The (untrusted) room metadata state events for the room.
 	InviteState []*event.Event `json:"invite_state"`
+	// Whether the invite was moved to the requests section by invite rules,
+	// e.g. because the inviter doesn't share any rooms with the user.
+	Quarantined bool `json:"quarantined,omitempty"`
 }
 
 func (r *InvitedRoom) sqlVariables() []any {
@@ -81,12 +91,13 @@ func (r *InvitedRoom) sqlVariables() []any {
 		r.ID,
 		dbutil.UnixMilliPtr(r.CreatedAt.Time),
 		dbutil.JSON{Data: &r.InviteState},
+		r.Quarantined,
 	}
 }
 
 func (r *InvitedRoom) Scan(row dbutil.Scannable) (*InvitedRoom, error) {
 	var createdAt int64
-	err := row.Scan(&r.ID, &createdAt, dbutil.JSON{Data: &r.InviteState})
+	err := row.Scan(&r.ID, &createdAt, dbutil.JSON{Data: &r.InviteState}, &r.Quarantined)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,55 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"maunium.net/go/mautrix/event"
+)
+
+var (
+	StateUnstableBeaconInfo = event.Type{Type: "org.matrix.msc3672.beacon_info", Class: event.StateEventType}
+	EventUnstableBeacon     = event.Type{Type: "org.matrix.msc3672.beacon", Class: event.MessageEventType}
+)
+
+// FormatGeoURI formats the given coordinates as an RFC 5870 geo URI. The uncertainty is omitted if it's zero.
+func FormatGeoURI(latitude, longitude, uncertainty float64) string {
+	uri := fmt.Sprintf("geo:%s,%s", formatCoordinate(latitude), formatCoordinate(longitude))
+	if uncertainty > 0 {
+		uri += ";u=" + formatCoordinate(uncertainty)
+	}
+	return uri
+}
+
+func formatCoordinate(val float64) string {
+	return strconv.FormatFloat(val, 'f', -1, 64)
+}
+
+// ParseGeoURI parses the latitude and longitude from a geo URI. Altitude and parameters are ignored.
+func ParseGeoURI(uri string) (latitude, longitude float64, err error) {
+	coords, ok := strings.CutPrefix(uri, "geo:")
+	if !ok {
+		return 0, 0, fmt.Errorf("not a geo URI")
+	}
+	coords, _, _ = strings.Cut(coords, ";")
+	parts := strings.Split(coords, ",")
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("missing longitude")
+	}
+	latitude, err = strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid latitude: %w", err)
+	}
+	longitude, err = strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid longitude: %w", err)
+	}
+	return
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package database
+
+import (
+	"context"
+	"strings"
+
+	"go.mau.fi/util/dbutil"
+	"maunium.net/go/mautrix/id"
+)
+
+const (
+	getPolicyListsQuery = `
+		SELECT policy_list.room_id, auto_ban, (SELECT COUNT(*) FROM policy_rule WHERE policy_rule.room_id = policy_list.room_id)
+		FROM policy_list
+	`
+	putPolicyListQuery = `
+		INSERT INTO policy_list (room_id, auto_ban) VALUES ($1, $2)
+		ON CONFLICT (room_id) DO UPDATE SET auto_ban = excluded.auto_ban
+	`
+	deletePolicyListQuery = `
+		DELETE FROM policy_list WHERE room_id = $1
+	`
+	getSubscribedPolicyRulesQuery = `
+		SELECT policy_rule.room_id, entity_type, state_key, entity, recommendation, reason, event_rowid
+		FROM policy_rule
+		INNER JOIN policy_list ON policy_rule.room_id = policy_list.room_id
+	`
+	putPolicyRuleQuery = `
+		INSERT INTO policy_rule (room_id, entity_type, state_key, entity, recommendation, reason, event_rowid)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (room_id, entity_type, state_key) DO UPDATE
+			SET entity = excluded.entity,
+			    recommendation = excluded.recommendation,
+			    reason = excluded.reason,
+			    event_rowid = excluded.event_rowid
+	`
+	deletePolicyRuleQuery = `
+		DELETE FROM policy_rule WHERE room_id = $1 AND entity_type = $2 AND state_key = $3
+	`
+)
+
+type PolicyListQuery struct {
+	*dbutil.QueryHelper[*PolicyList]
+}
+
+func (plq *PolicyListQuery) GetAll(ctx context.Context) ([]*PolicyList, error) {
+	return plq.QueryMany(ctx, getPolicyListsQuery)
+}
+
+func (plq *PolicyListQuery) Put(ctx context.Context, roomID id.RoomID, autoBan bool) error {
+	return plq.Exec(ctx, putPolicyListQuery, roomID, autoBan)
+}
+
+func (plq *PolicyListQuery) Delete(ctx context.Context, roomID id.RoomID) error {
+	return plq.Exec(ctx, deletePolicyListQuery, roomID)
+}
+
+type PolicyRuleQuery struct {
+	*dbutil.QueryHelper[*PolicyRule]
+}
+
+// GetAllSubscribed returns all rules in policy lists that the user is subscribed to.
+func (prq *PolicyRuleQuery) GetAllSubscribed(ctx context.Context) ([]*PolicyRule, error) {
+	return prq.QueryMany(ctx, getSubscribedPolicyRulesQuery)
+}
+
+func (prq *PolicyRuleQuery) Put(ctx context.Context, rule *PolicyRule) error {
+	return prq.Exec(ctx, putPolicyRuleQuery, rule.sqlVariables()...)
+}
+
+func (prq *PolicyRuleQuery) Delete(ctx context.Context, roomID id.RoomID, entityType PolicyEntityType, stateKey string) error {
+	return prq.Exec(ctx, deletePolicyRuleQuery, roomID, entityType, stateKey)
+}
+
+type PolicyEntityType string
+
+const (
+	PolicyEntityTypeUser   PolicyEntityType = "user"
+	PolicyEntityTypeServer PolicyEntityType = "server"
+	PolicyEntityTypeRoom   PolicyEntityType = "room"
+)
+
+const policyRuleEventTypePrefix = "m.policy.rule."
+
+// PolicyEntityTypeFromEventType returns the entity type for a `m.policy.rule.*` event type,
+// or an empty string if the given event type is not a policy rule.
+func PolicyEntityTypeFromEventType(evtType string) PolicyEntityType {
+	if !strings.HasPrefix(evtType, policyRuleEventTypePrefix) {
+		return ""
+	}
+	switch entityType := PolicyEntityType(strings.TrimPrefix(evtType, policyRuleEventTypePrefix)); entityType {
+	case PolicyEntityTypeUser, PolicyEntityTypeServer, PolicyEntityTypeRoom:
+		return entityType
+	default:
+		return ""
+	}
+}
+
+type PolicyList struct {
+	RoomID id.RoomID `json:"room_id"`
+	// Whether bans recommended by this list should be applied automatically
+	// in rooms where the user has sufficient power.
+	AutoBan   bool `json:"auto_ban"`
+	RuleCount int  `json:"rule_count"`
+}
+
+func (pl *PolicyList) Scan(row dbutil.Scannable) (*PolicyList, error) {
+	err := row.Scan(&pl.RoomID, &pl.AutoBan, &pl.RuleCount)
+	if err != nil {
+		return nil, err
+	}
+	return pl, nil
+}
+
+type PolicyRule struct {
+	// The room ID of the policy list the rule is in.
+	RoomID     id.RoomID        `json:"room_id"`
+	EntityType PolicyEntityType `json:"entity_type"`
+	StateKey   string           `json:"state_key"`
+	// The glob pattern matched against user IDs, server names or room IDs depending on the entity type.
+	Entity         string     `json:"entity"`
+	Recommendation string     `json:"recommendation"`
+	Reason         string     `json:"reason,omitempty"`
+	EventRowID     EventRowID `json:"event_rowid"`
+}
+
+func (pr *PolicyRule) Scan(row dbutil.Scannable) (*PolicyRule, error) {
+	err := row.Scan(&pr.RoomID, &pr.EntityType, &pr.StateKey, &pr.Entity, &pr.Recommendation, &pr.Reason, &pr.EventRowID)
+	if err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+func (pr *PolicyRule) sqlVariables() []any {
+	return []any{pr.RoomID, pr.EntityType, pr.StateKey, pr.Entity, pr.Recommendation, pr.Reason, pr.EventRowID}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package database
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/tidwall/gjson"
+	"go.mau.fi/util/exgjson"
+	"go.mau.fi/util/jsontime"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+)
+
+var (
+	EventUnstablePollResponse = event.Type{Type: "org.matrix.msc3381.poll.response", Class: event.MessageEventType}
+	EventUnstablePollEnd      = event.Type{Type: "org.matrix.msc3381.poll.end", Class: event.MessageEventType}
+)
+
+var pollResponseAnswersPath = exgjson.Path("org.matrix.msc3381.poll.response", "answers")
+
+// PollTally contains the aggregated votes of a poll.
+type PollTally struct {
+	// The latest valid vote of each user as a list of answer IDs.
+	Votes map[id.UserID][]string `json:"votes"`
+	// The number of votes for each answer ID.
+	Counts map[string]int `json:"counts"`
+	// The timestamp and sender of the first valid end event, if the poll has been ended.
+	EndedAt jsontime.UnixMilli `json:"ended_at,omitzero"`
+	EndedBy id.UserID          `json:"ended_by,omitempty"`
+}
+
+// IsEnded returns true if a valid end event has been sent for the poll.
+func (pt *PollTally) IsEnded() bool {
+	return pt != nil && !pt.EndedAt.IsZero()
+}
+
+// GetPollStart parses the poll start content of the event. If the event isn't a poll, this returns nil.
+func (e *Event) GetPollStart() *event.PollStart {
+	if e.GetType().Type != event.EventUnstablePollStart.Type || e.RedactedBy != "" {
+		return nil
+	}
+	var content event.PollStartEventContent
+	if err := json.Unmarshal(e.GetContent(), &content); err != nil {
+		return nil
+	}
+	return &content.PollStart
+}
+
+// GetPollMaxSelections returns the maximum number of answers a single user can select,
+// clamped to the number of answers in the poll.
+func GetPollMaxSelections(poll *event.PollStart) int {
+	if poll.MaxSelections <= 0 || poll.MaxSelections > len(poll.Answers) {
+		return len(poll.Answers)
+	}
+	return poll.MaxSelections
+}
+
+// NormalizePollVote removes unknown and duplicate answers from a vote and
+// truncates it to the maximum number of selections.
+func NormalizePollVote(poll *event.PollStart, answers []string) []string {
+	maxSelections := GetPollMaxSelections(poll)
+	vote := make([]string, 0, min(len(answers), maxSelections))
+	for _, answer := range answers {
+		if len(vote) >= maxSelections {
+			break
+		}
+		isKnown := slices.ContainsFunc(poll.Answers, func(option event.PollOption) bool {
+			return option.ID == answer
+		})
+		if isKnown && !slices.Contains(vote, answer) {
+			vote = append(vote, answer)
+		}
+	}
+	return vote
+}
+
+// CalculatePollTally aggregates the given poll response and end events into a tally.
+// The related events must be sorted by timestamp. canEnd is used to check whether
+// the sender of an end event is allowed to end the poll. Votes from users for whom
+// isIgnored returns true aren't counted, but their end events are still respected.
+func CalculatePollTally(poll *event.PollStart, related []*Event, canEnd, isIgnored func(sender id.UserID) bool) *PollTally {
+	tally := &PollTally{
+		Votes:  make(map[id.UserID][]string),
+		Counts: make(map[string]int),
+	}
+	for _, evt := range related {
+		if evt.GetType().Type == EventUnstablePollEnd.Type && evt.RedactedBy == "" && canEnd(evt.Sender) {
+			tally.EndedAt = evt.Timestamp
+			tally.EndedBy = evt.Sender
+			break
+		}
+	}
+	for _, evt := range related {
+		if evt.GetType().Type != EventUnstablePollResponse.Type || evt.RedactedBy != "" || isIgnored(evt.Sender) {
+			continue
+		} else if tally.IsEnded() && evt.Timestamp.After(tally.EndedAt.Time) {
+			break
+		}
+		var answers []string
+		for _, answer := range gjson.GetBytes(evt.GetContent(), pollResponseAnswersPath).Array() {
+			answers = append(answers, answer.Str)
+		}
+		vote := NormalizePollVote(poll, answers)
+		if len(vote) == 0 {
+			// Votes with no valid answers are spoiled, which means the user's previous vote is retracted
+			delete(tally.Votes, evt.Sender)
+		} else {
+			tally.Votes[evt.Sender] = vote
+		}
+	}
+	for _, vote := range tally.Votes {
+		for _, answer := range vote {
+			tally.Counts[answer]++
+		}
+	}
+	return tally
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package database
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mau.fi/util/jsontime"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+)
+
+var testPoll = &event.PollStart{
+	MaxSelections: 2,
+	Answers: []event.PollOption{
+		{ID: "a"},
+		{ID: "b"},
+		{ID: "c"},
+	},
+}
+
+var testPollStartTime = time.UnixMilli(1700000000000)
+
+func makeTestVote(sender id.UserID, offset time.Duration, answers ...string) *Event {
+	content, _ := json.Marshal(map[string]any{
+		"org.matrix.msc3381.poll.response": map[string]any{"answers": answers},
+	})
+	return &Event{
+		Sender:    sender,
+		Type:      EventUnstablePollResponse.Type,
+		Timestamp: jsontime.UM(testPollStartTime.Add(offset)),
+		Content:   content,
+	}
+}
+
+func makeTestPollEnd(sender id.UserID, offset time.Duration) *Event {
+	return &Event{
+		Sender:    sender,
+		Type:      EventUnstablePollEnd.Type,
+		Timestamp: jsontime.UM(testPollStartTime.Add(offset)),
+		Content:   json.RawMessage(`{"org.matrix.msc3381.poll.end":{}}`),
+	}
+}
+
+func TestNormalizePollVote(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers []string
+		want    []string
+	}{
+		{"valid", []string{"a"}, []string{"a"}},
+		{"max selections", []string{"a", "b", "c"}, []string{"a", "b"}},
+		{"unknown answers", []string{"x", "b", "y"}, []string{"b"}},
+		{"duplicates", []string{"c", "c", "a"}, []string{"c", "a"}},
+		{"only invalid", []string{"x"}, []string{}},
+		{"empty", nil, []string{}},
+	}
+	for _, test := range tests {
+		if got := NormalizePollVote(testPoll, test.answers); !slices.Equal(got, test.want) {
+			t.Errorf("%s: NormalizePollVote(%v) = %v, want %v", test.name, test.answers, got, test.want)
+		}
+	}
+	unlimited := &event.PollStart{Answers: testPoll.Answers}
+	if got := NormalizePollVote(unlimited, []string{"a", "b", "c"}); len(got) != 3 {
+		t.Errorf("NormalizePollVote() without max selections = %v, want all answers", got)
+	}
+}
+
+func TestCalculatePollTally(t *testing.T) {
+	canEnd := func(sender id.UserID) bool {
+		return sender == "@creator:example.com" || sender == "@mod:example.com"
+	}
+	isIgnored := func(sender id.UserID) bool {
+		return sender == "@ignored:example.com"
+	}
+	tests := []struct {
+		name       string
+		related    []*Event
+		wantVotes  map[id.UserID][]string
+		wantCounts map[string]int
+		wantEndBy  id.UserID
+	}{{
+		name: "latest vote replaces earlier ones",
+		related: []*Event{
+			makeTestVote("@alice:example.com", time.Second, "a"),
+			makeTestVote("@bob:example.com", 2*time.Second, "b"),
+			makeTestVote("@alice:example.com", 3*time.Second, "c"),
+		},
+		wantVotes:  map[id.UserID][]string{"@alice:example.com": {"c"}, "@bob:example.com": {"b"}},
+		wantCounts: map[string]int{"b": 1, "c": 1},
+	}, {
+		name: "max selections and invalid answers",
+		related: []*Event{
+			makeTestVote("@alice:example.com", time.Second, "a", "b", "c"),
+			makeTestVote("@bob:example.com", 2*time.Second, "x", "c"),
+		},
+		wantVotes:  map[id.UserID][]string{"@alice:example.com": {"a", "b"}, "@bob:example.com": {"c"}},
+		wantCounts: map[string]int{"a": 1, "b": 1, "c": 1},
+	}, {
+		name: "invalid vote retracts previous vote",
+		related: []*Event{
+			makeTestVote("@alice:example.com", time.Second, "a"),
+			makeTestVote("@alice:example.com", 2*time.Second, "x"),
+		},
+		wantVotes:  map[id.UserID][]string{},
+		wantCounts: map[string]int{},
+	}, {
+		name: "end from user without power is ignored",
+		related: []*Event{
+			makeTestVote("@alice:example.com", time.Second, "a"),
+			makeTestPollEnd("@bob:example.com", 2*time.Second),
+			makeTestVote("@carol:example.com", 3*time.Second, "b"),
+		},
+		wantVotes:  map[id.UserID][]string{"@alice:example.com": {"a"}, "@carol:example.com": {"b"}},
+		wantCounts: map[string]int{"a": 1, "b": 1},
+	}, {
+		name: "votes after end are ignored",
+		related: []*Event{
+			makeTestVote("@alice:example.com", time.Second, "a"),
+			makeTestPollEnd("@mod:example.com", 2*time.Second),
+			makeTestVote("@alice:example.com", 3*time.Second, "b"),
+			makeTestVote("@carol:example.com", 4*time.Second, "b"),
+			makeTestPollEnd("@creator:example.com", 5*time.Second),
+		},
+		wantVotes:  map[id.UserID][]string{"@alice:example.com": {"a"}},
+		wantCounts: map[string]int{"a": 1},
+		wantEndBy:  "@mod:example.com",
+	}, {
+		name: "ignored voters are excluded",
+		related: []*Event{
+			makeTestVote("@alice:example.com", time.Second, "a"),
+			makeTestVote("@ignored:example.com", 2*time.Second, "a", "b"),
+		},
+		wantVotes:  map[id.UserID][]string{"@alice:example.com": {"a"}},
+		wantCounts: map[string]int{"a": 1},
+	}, {
+		name: "redacted votes are excluded",
+		related: []*Event{
+			makeTestVote("@alice:example.com", time.Second, "a"),
+			{
+				Sender:     "@bob:example.com",
+				Type:       EventUnstablePollResponse.Type,
+				Timestamp:  jsontime.UM(testPollStartTime.Add(2 * time.Second)),
+				Content:    json.RawMessage(`{}`),
+				RedactedBy: "$redaction",
+			},
+		},
+		wantVotes:  map[id.UserID][]string{"@alice:example.com": {"a"}},
+		wantCounts: map[string]int{"a": 1},
+	}}
+	for _, test := range tests {
+		tally := CalculatePollTally(testPoll, test.related, canEnd, isIgnored)
+		if !maps.EqualFunc(tally.Votes, test.wantVotes, slices.Equal) {
+			t.Errorf("%s: votes = %v, want %v", test.name, tally.Votes, test.wantVotes)
+		}
+		if !maps.Equal(tally.Counts, test.wantCounts) {
+			t.Errorf("%s: counts = %v, want %v", test.name, tally.Counts, test.wantCounts)
+		}
+		if tally.EndedBy != test.wantEndBy || tally.IsEnded() != (test.wantEndBy != "") {
+			t.Errorf("%s: ended by %q (ended: %t), want %q", test.name, tally.EndedBy, tally.IsEnded(), test.wantEndBy)
+		}
+	}
+}
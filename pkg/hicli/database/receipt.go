@@ -27,7 +27,11 @@ const (
 			SET event_id = excluded.event_id,
 			    timestamp = excluded.timestamp
 	`
-	getReadReceiptsQuery = `SELECT room_id, user_id, receipt_type, thread_id, event_id, timestamp FROM receipt WHERE room_id = $1 AND receipt_type='m.read' AND event_id IN ($2)`
+	getReadReceiptsQuery     = `SELECT room_id, user_id, receipt_type, thread_id, event_id, timestamp FROM receipt WHERE room_id = $1 AND receipt_type='m.read' AND event_id IN ($2)`
+	getUserReadReceiptsQuery = `
+		SELECT room_id, user_id, receipt_type, thread_id, event_id, timestamp FROM receipt
+		WHERE room_id = $1 AND user_id = $2 AND receipt_type IN ('m.read', 'm.read.private')
+	`
 )
 
 var receiptMassInserter = dbutil.NewMassInsertBuilder[*Receipt, [1]any](upsertReceiptQuery, "($1, $%d, $%d, $%d, $%d, $%d)")
@@ -56,6 +60,11 @@ func (rq *ReceiptQuery) PutMany(ctx context.Context, roomID id.RoomID, receipts
 	return rq.Exec(ctx, query, params...)
 }
 
+// GetOwn returns all read receipts of the given user in the room, including private and threaded receipts.
+func (rq *ReceiptQuery) GetOwn(ctx context.Context, roomID id.RoomID, userID id.UserID) ([]*Receipt, error) {
+	return rq.QueryMany(ctx, getUserReadReceiptsQuery, roomID, userID)
+}
+
 func (rq *ReceiptQuery) GetManyRead(ctx context.Context, roomID id.RoomID, eventIDs []id.EventID) (map[id.EventID][]*Receipt, error) {
 	args := make([]any, len(eventIDs)+1)
 	placeholders := make([]string, len(eventIDs)+1)
@@ -41,6 +41,9 @@ const (
 		) AND EXISTS(SELECT 1 FROM room WHERE room_id = space_id AND room_type = 'm.space')
 		ORDER BY room_account_data.content->>'$.order' NULLS LAST, space_id
 	`
+	getSpacesContainingChildQuery = `
+		SELECT space_id FROM space_edge WHERE child_id=$1 AND child_event_rowid IS NOT NULL
+	`
 	revalidateAllParents = `
 		UPDATE space_edge
 		SET parent_validated=(SELECT EXISTS(
@@ -241,6 +244,11 @@ func (seq *SpaceEdgeQuery) GetTopLevelIDs(ctx context.Context, userID id.UserID)
 	return roomIDScanner.NewRowIter(seq.GetDB().Query(ctx, getTopLevelSpaces, userID)).AsList()
 }
 
+// GetParentIDs returns the IDs of all spaces that have an m.space.child event pointing at the given room.
+func (seq *SpaceEdgeQuery) GetParentIDs(ctx context.Context, childID id.RoomID) ([]id.RoomID, error) {
+	return roomIDScanner.NewRowIter(seq.GetDB().Query(ctx, getSpacesContainingChildQuery, childID)).AsList()
+}
+
 type SpaceEdge struct {
 	// The room ID of the space (the parent).
 	SpaceID id.RoomID `json:"space_id,omitempty"`
@@ -42,6 +42,15 @@ const (
 	getCurrentRoomStateMembersQuery        = getCurrentRoomStateBaseQuery + `WHERE cs.room_id = $1 AND type='m.room.member'`
 	getManyCurrentRoomStateQuery           = getCurrentRoomStateBaseQuery + `WHERE (cs.room_id, cs.event_type, cs.state_key) IN (%s)`
 	getCurrentStateEventQuery              = getCurrentRoomStateBaseQuery + `WHERE cs.room_id = $1 AND cs.event_type = $2 AND cs.state_key = $3`
+	getActiveMembershipsQuery              = `
+		SELECT room_id, state_key FROM current_state
+		WHERE event_type = 'm.room.member' AND membership IN ('join', 'invite', 'knock')
+	`
+	isJoinedAnywhereQuery = `
+		SELECT EXISTS(
+			SELECT 1 FROM current_state WHERE event_type = 'm.room.member' AND state_key = $1 AND membership = 'join'
+		)
+	`
 )
 
 var massInsertCurrentStateBuilder = dbutil.NewMassInsertBuilder[*CurrentStateEntry, [1]any](addCurrentStateQuery, "($1, $%d, $%d, $%d, $%d)")
@@ -123,3 +132,25 @@ func (csq *CurrentStateQuery) GetAllExceptMembers(ctx context.Context, roomID id
 func (csq *CurrentStateQuery) GetMembers(ctx context.Context, roomID id.RoomID) ([]*Event, error) {
 	return csq.QueryMany(ctx, getCurrentRoomStateMembersQuery, roomID)
 }
+
+type RoomMembership struct {
+	RoomID id.RoomID
+	UserID id.UserID
+}
+
+func scanRoomMembership(row dbutil.Scannable) (rm RoomMembership, err error) {
+	err = row.Scan(&rm.RoomID, &rm.UserID)
+	return
+}
+
+// GetActiveMemberships returns all known joined, invited and knocking members in all rooms.
+func (csq *CurrentStateQuery) GetActiveMemberships(ctx context.Context) ([]RoomMembership, error) {
+	rows, err := csq.GetDB().Query(ctx, getActiveMembershipsQuery)
+	return dbutil.NewRowIterWithError(rows, scanRoomMembership, err).AsList()
+}
+
+// IsJoinedAnywhere returns true if the given user is known to be joined to any room the current user is in.
+func (csq *CurrentStateQuery) IsJoinedAnywhere(ctx context.Context, userID id.UserID) (joined bool, err error) {
+	err = csq.GetDB().QueryRow(ctx, isJoinedAnywhereQuery, userID).Scan(&joined)
+	return
+}
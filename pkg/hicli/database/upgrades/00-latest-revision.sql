@@ -1,4 +1,4 @@
--- v0 -> v26 (compatible with v10+): Latest revision
+-- v0 -> v30 (compatible with v10+): Latest revision
 CREATE TABLE account (
 	user_id        TEXT    NOT NULL PRIMARY KEY,
 	device_id      TEXT    NOT NULL,
@@ -54,7 +54,8 @@ CREATE TABLE invited_room (
 	room_id       TEXT    NOT NULL PRIMARY KEY,
 	received_at   INTEGER NOT NULL,
 	mod_timestamp INTEGER NOT NULL DEFAULT (unixepoch('subsec') * 1000),
-	invite_state  TEXT    NOT NULL
+	invite_state  TEXT    NOT NULL,
+	quarantined   INTEGER NOT NULL DEFAULT false
 ) STRICT;
 
 CREATE TRIGGER invited_room_delete_on_room_insert
@@ -134,6 +135,7 @@ CREATE INDEX event_relates_to_idx ON event (room_id, relates_to);
 CREATE INDEX event_megolm_session_id_idx ON event (room_id, megolm_session_id);
 CREATE INDEX event_mention_idx ON event (timestamp DESC) WHERE unread_type > 0;
 CREATE INDEX event_sticky_idx ON event (room_id, timestamp) WHERE sticky_duration IS NOT NULL;
+CREATE INDEX event_sender_idx ON event (sender, timestamp);
 
 CREATE TRIGGER event_update_redacted_by
 	AFTER INSERT
@@ -397,3 +399,37 @@ CREATE TABLE push_registration (
 
 	PRIMARY KEY (device_id)
 ) STRICT;
+
+CREATE TABLE policy_list (
+	room_id  TEXT    NOT NULL PRIMARY KEY,
+	auto_ban INTEGER NOT NULL DEFAULT false CHECK ( auto_ban IN (false, true) ),
+
+	CONSTRAINT policy_list_room_fkey FOREIGN KEY (room_id) REFERENCES room (room_id) ON DELETE CASCADE
+) STRICT;
+
+CREATE TABLE policy_rule (
+	room_id        TEXT    NOT NULL,
+	entity_type    TEXT    NOT NULL,
+	state_key      TEXT    NOT NULL,
+	entity         TEXT    NOT NULL,
+	recommendation TEXT    NOT NULL,
+	reason         TEXT    NOT NULL,
+	event_rowid    INTEGER NOT NULL,
+
+	PRIMARY KEY (room_id, entity_type, state_key),
+	CONSTRAINT policy_rule_room_fkey FOREIGN KEY (room_id) REFERENCES room (room_id) ON DELETE CASCADE,
+	CONSTRAINT policy_rule_event_fkey FOREIGN KEY (event_rowid) REFERENCES event (rowid) ON DELETE CASCADE
+) STRICT;
+
+CREATE TABLE draft (
+	room_id     TEXT    NOT NULL,
+	thread_root TEXT    NOT NULL DEFAULT '',
+	text        TEXT    NOT NULL,
+	reply_to    TEXT    NOT NULL DEFAULT '',
+	edit_target TEXT    NOT NULL DEFAULT '',
+	mentions    TEXT,
+	updated_at  INTEGER NOT NULL,
+
+	PRIMARY KEY (room_id, thread_root),
+	CONSTRAINT draft_room_fkey FOREIGN KEY (room_id) REFERENCES room (room_id) ON DELETE CASCADE
+) STRICT;
@@ -0,0 +1,35 @@
+-- v27 (compatible with v10+): Add tables for policy list subscriptions and rules
+CREATE TABLE policy_list (
+	room_id  TEXT    NOT NULL PRIMARY KEY,
+	auto_ban INTEGER NOT NULL DEFAULT false CHECK ( auto_ban IN (false, true) ),
+
+	CONSTRAINT policy_list_room_fkey FOREIGN KEY (room_id) REFERENCES room (room_id) ON DELETE CASCADE
+) STRICT;
+
+CREATE TABLE policy_rule (
+	room_id        TEXT    NOT NULL,
+	entity_type    TEXT    NOT NULL,
+	state_key      TEXT    NOT NULL,
+	entity         TEXT    NOT NULL,
+	recommendation TEXT    NOT NULL,
+	reason         TEXT    NOT NULL,
+	event_rowid    INTEGER NOT NULL,
+
+	PRIMARY KEY (room_id, entity_type, state_key),
+	CONSTRAINT policy_rule_room_fkey FOREIGN KEY (room_id) REFERENCES room (room_id) ON DELETE CASCADE,
+	CONSTRAINT policy_rule_event_fkey FOREIGN KEY (event_rowid) REFERENCES event (rowid) ON DELETE CASCADE
+) STRICT;
+
+INSERT INTO policy_rule (room_id, entity_type, state_key, entity, recommendation, reason, event_rowid)
+SELECT current_state.room_id,
+       substr(current_state.event_type, length('m.policy.rule.') + 1),
+       current_state.state_key,
+       event.content->>'$.entity',
+       event.content->>'$.recommendation',
+       COALESCE(event.content->>'$.reason', ''),
+       current_state.event_rowid
+FROM current_state
+INNER JOIN event ON current_state.event_rowid = event.rowid
+WHERE current_state.event_type IN ('m.policy.rule.user', 'm.policy.rule.server', 'm.policy.rule.room')
+	AND event.content->>'$.entity' IS NOT NULL
+	AND event.content->>'$.recommendation' IS NOT NULL;
@@ -0,0 +1,2 @@
+-- v28 (compatible with v10+): Add quarantine flag for invites from unknown users
+ALTER TABLE invited_room ADD COLUMN quarantined INTEGER NOT NULL DEFAULT false;
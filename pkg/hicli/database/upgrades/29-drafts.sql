@@ -0,0 +1,13 @@
+-- v29 (compatible with v10+): Add table for composer drafts
+CREATE TABLE draft (
+	room_id     TEXT    NOT NULL,
+	thread_root TEXT    NOT NULL DEFAULT '',
+	text        TEXT    NOT NULL,
+	reply_to    TEXT    NOT NULL DEFAULT '',
+	edit_target TEXT    NOT NULL DEFAULT '',
+	mentions    TEXT,
+	updated_at  INTEGER NOT NULL,
+
+	PRIMARY KEY (room_id, thread_root),
+	CONSTRAINT draft_room_fkey FOREIGN KEY (room_id) REFERENCES room (room_id) ON DELETE CASCADE
+) STRICT;
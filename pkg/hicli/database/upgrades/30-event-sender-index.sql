@@ -0,0 +1,2 @@
+-- v30 (compatible with v10+): Add index for finding recent events by sender
+CREATE INDEX event_sender_idx ON event (sender, timestamp);
@@ -0,0 +1,47 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"fmt"
+
+	"go.mau.fi/util/jsontime"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+)
+
+// SetDraft stores the given draft, or deletes the stored draft if the given one is empty.
+// The change is broadcast to all connected clients, so they can keep their composers in sync.
+func (h *HiClient) SetDraft(ctx context.Context, draft *database.Draft) error {
+	room, err := h.DB.Room.Get(ctx, draft.RoomID)
+	if err != nil {
+		return fmt.Errorf("failed to get room metadata: %w", err)
+	} else if room == nil {
+		return fmt.Errorf("unknown room")
+	}
+	update := &jsoncmd.DraftUpdated{
+		RoomID:     draft.RoomID,
+		ThreadRoot: draft.ThreadRoot,
+	}
+	if draft.IsEmpty() {
+		err = h.DB.Draft.Delete(ctx, draft.RoomID, draft.ThreadRoot)
+		if err != nil {
+			return fmt.Errorf("failed to delete draft: %w", err)
+		}
+	} else {
+		draft.UpdatedAt = jsontime.UnixMilliNow()
+		err = h.DB.Draft.Put(ctx, draft)
+		if err != nil {
+			return fmt.Errorf("failed to save draft: %w", err)
+		}
+		update.Draft = draft
+	}
+	h.EventHandler(update)
+	return nil
+}
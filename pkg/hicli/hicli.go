@@ -35,6 +35,7 @@ import (
 
 	"go.mau.fi/gomuks/pkg/hicli/database"
 	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+	"go.mau.fi/gomuks/pkg/spellcheck"
 )
 
 type HiClient struct {
@@ -63,6 +64,15 @@ type HiClient struct {
 	EventHandler func(evt any)
 	LogoutFunc   func(context.Context) error
 
+	// Translator is used by [HiClient.TranslateEvent]. If nil, translation is disabled.
+	Translator Translator
+	// DefaultTranslationLanguage is the target language used when a translation request doesn't specify one.
+	DefaultTranslationLanguage string
+	// SecretDetectors are used to reject outgoing messages that look like they contain secrets.
+	SecretDetectors []*SecretDetector
+	// DictionaryDir is the directory where hunspell dictionaries for [HiClient.SpellCheck] are loaded from.
+	DictionaryDir string
+
 	firstSyncReceived     bool
 	sendInitSyncToClients bool
 	syncingID             int
@@ -85,6 +95,27 @@ type HiClient struct {
 	paginationInterrupterLock sync.Mutex
 	paginationInterrupter     map[id.RoomID]context.CancelCauseFunc
 
+	moderationJobID    atomic.Int64
+	moderationJobsLock sync.Mutex
+	moderationJobs     map[int64]context.CancelCauseFunc
+
+	policyRules   atomic.Pointer[policyRuleSet]
+	policyAutoBan policyAutoBanner
+
+	ignoreLock   sync.Mutex
+	ignoredUsers atomic.Pointer[ignoredUserSet]
+	inviteRules  atomic.Pointer[compiledInviteRules]
+	mutualRooms  mutualRoomChecker
+
+	imagePackLock sync.Mutex
+	snippetLock   sync.Mutex
+
+	dictionaryLock sync.Mutex
+	dictionaries   map[string]*spellcheck.Dictionary
+
+	liveLocationLock sync.Mutex
+	liveLocations    map[id.RoomID]*liveLocationShare
+
 	sendLock     map[id.RoomID]*sync.Mutex
 	sendLockLock sync.Mutex
 
@@ -158,6 +189,8 @@ func New(rawDB, cryptoDB *dbutil.Database, log zerolog.Logger, pickleKey []byte,
 		requestQueueWakeup:     make(chan struct{}, 1),
 		jsonRequests:           make(map[int64]context.CancelCauseFunc),
 		paginationInterrupter:  make(map[id.RoomID]context.CancelCauseFunc),
+		moderationJobs:         make(map[int64]context.CancelCauseFunc),
+		liveLocations:          make(map[id.RoomID]*liveLocationShare),
 		sendLock:               make(map[id.RoomID]*sync.Mutex),
 
 		roomPerMessageProfiles: exsync.NewMap[id.RoomID, *event.PerMessageProfilesEventContent](),
@@ -0,0 +1,64 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/rs/zerolog"
+	"go.mau.fi/util/dbutil"
+	"go.mau.fi/util/jsontime"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+)
+
+const testRoomID id.RoomID = "!room:example.com"
+
+// newTestClient creates a client with an empty database and returns it along with the list of dispatched events.
+func newTestClient(t *testing.T) (*HiClient, *[]any) {
+	t.Helper()
+	rawDB, err := dbutil.NewWithDialect(filepath.Join(t.TempDir(), "hicli.db"), "sqlite3-fk-wal")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = rawDB.Close()
+	})
+	var dispatched []any
+	h := New(rawDB, nil, zerolog.Nop(), []byte("meow"), func(evt any) {
+		dispatched = append(dispatched, evt)
+	})
+	if err = h.DB.Upgrade(context.Background()); err != nil {
+		t.Fatalf("Failed to upgrade database: %v", err)
+	}
+	if err = h.DB.Room.CreateRow(context.Background(), testRoomID); err != nil {
+		t.Fatalf("Failed to create room: %v", err)
+	}
+	return h, &dispatched
+}
+
+func insertTestEvent(t *testing.T, h *HiClient, eventID id.EventID, content string, redactedBy id.EventID) {
+	t.Helper()
+	_, err := h.DB.Event.Insert(context.Background(), &database.Event{
+		RoomID:     testRoomID,
+		ID:         eventID,
+		Sender:     "@alice:example.com",
+		Type:       "m.room.message",
+		Timestamp:  jsontime.UnixMilliNow(),
+		Content:    json.RawMessage(content),
+		Unsigned:   json.RawMessage("{}"),
+		RedactedBy: redactedBy,
+	})
+	if err != nil {
+		t.Fatalf("Failed to insert event %s: %v", eventID, err)
+	}
+}
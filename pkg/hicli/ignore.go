@@ -0,0 +1,154 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/rs/zerolog"
+	"github.com/tidwall/gjson"
+	"go.mau.fi/util/exgjson"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+)
+
+var reactionKeyPath = exgjson.Path("m.relates_to", "key")
+
+type ignoredUserSet map[id.UserID]event.IgnoredUser
+
+func (h *HiClient) storeIgnoredUsers(content *event.IgnoredUserListEventContent) {
+	var users ignoredUserSet
+	if content != nil && content.IgnoredUsers != nil {
+		users = content.IgnoredUsers
+	} else {
+		users = make(ignoredUserSet)
+	}
+	h.ignoredUsers.Store(&users)
+}
+
+// getIgnoredUsers returns the parsed `m.ignored_user_list` account data, loading it from the database if necessary.
+func (h *HiClient) getIgnoredUsers(ctx context.Context) ignoredUserSet {
+	usersPtr := h.ignoredUsers.Load()
+	if usersPtr != nil {
+		return *usersPtr
+	}
+	evt, err := h.DB.AccountData.GetGlobal(ctx, h.Account.UserID, event.AccountDataIgnoredUserList)
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Msg("Failed to get ignored user list from account data")
+		return nil
+	}
+	var content event.IgnoredUserListEventContent
+	if evt != nil {
+		if err = json.Unmarshal(evt.Content, &content); err != nil {
+			zerolog.Ctx(ctx).Err(err).Msg("Failed to unmarshal ignored user list from account data")
+		}
+	}
+	h.storeIgnoredUsers(&content)
+	return *h.ignoredUsers.Load()
+}
+
+// IsIgnored returns true if the given user is in the current user's ignore list.
+func (h *HiClient) IsIgnored(ctx context.Context, userID id.UserID) bool {
+	if userID == h.Account.UserID {
+		return false
+	}
+	_, ignored := h.getIgnoredUsers(ctx)[userID]
+	return ignored
+}
+
+// shouldHideEvent returns true if the event should not be shown to the user because its sender is ignored.
+// State events and redactions are never hidden, as they affect how other events are rendered.
+func (h *HiClient) shouldHideEvent(ctx context.Context, evt *database.Event) bool {
+	return evt.StateKey == nil && evt.Type != event.EventRedaction.Type && h.IsIgnored(ctx, evt.Sender)
+}
+
+func (h *HiClient) filterIgnoredEvents(ctx context.Context, evts []*database.Event) []*database.Event {
+	if len(h.getIgnoredUsers(ctx)) == 0 {
+		return evts
+	}
+	return slices.DeleteFunc(evts, func(evt *database.Event) bool {
+		return h.shouldHideEvent(ctx, evt)
+	})
+}
+
+// excludeIgnoredReactions recalculates the reaction counts of the given events without reactions from ignored users.
+// The counts stored in the database include all reactions, so that unignoring a user doesn't require recounting.
+func (h *HiClient) excludeIgnoredReactions(ctx context.Context, roomID id.RoomID, evts []*database.Event) {
+	ignored := h.getIgnoredUsers(ctx)
+	if len(ignored) == 0 {
+		return
+	}
+	eventMap := make(map[id.EventID]*database.Event)
+	for _, evt := range evts {
+		if len(evt.Reactions) > 0 {
+			eventMap[evt.ID] = evt
+		}
+	}
+	if len(eventMap) == 0 {
+		return
+	}
+	result, err := h.DB.Event.GetReactions(ctx, roomID, slices.Collect(maps.Keys(eventMap))...)
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Msg("Failed to get reactions to exclude ignored users")
+		return
+	}
+	for evtID, res := range result {
+		counts := make(map[string]int, len(res.Counts))
+		for _, reaction := range res.Events {
+			if _, isIgnored := ignored[reaction.Sender]; isIgnored {
+				continue
+			}
+			if key := gjson.GetBytes(reaction.Content, reactionKeyPath).Str; key != "" {
+				counts[key]++
+			}
+		}
+		eventMap[evtID].Reactions = counts
+	}
+}
+
+// GetIgnoredUsers returns the list of users the current user has ignored.
+func (h *HiClient) GetIgnoredUsers(ctx context.Context) []id.UserID {
+	return slices.Sorted(maps.Keys(h.getIgnoredUsers(ctx)))
+}
+
+// SetIgnored adds the given user to the ignore list or removes them from it.
+// The list is stored in account data, so it's synced to other devices, and the server
+// will stop sending new events and invites from ignored users.
+func (h *HiClient) SetIgnored(ctx context.Context, userID id.UserID, ignore bool) error {
+	if userID == h.Account.UserID {
+		return fmt.Errorf("you can't ignore yourself")
+	}
+	h.ignoreLock.Lock()
+	defer h.ignoreLock.Unlock()
+	current := h.getIgnoredUsers(ctx)
+	if _, alreadyIgnored := current[userID]; alreadyIgnored == ignore {
+		return nil
+	}
+	newList := maps.Clone(current)
+	if newList == nil {
+		newList = make(ignoredUserSet)
+	}
+	if ignore {
+		newList[userID] = event.IgnoredUser{}
+	} else {
+		delete(newList, userID)
+	}
+	content := &event.IgnoredUserListEventContent{IgnoredUsers: newList}
+	err := h.Client.SetAccountData(ctx, event.AccountDataIgnoredUserList.Type, content)
+	if err != nil {
+		return err
+	}
+	// Update the cache immediately instead of waiting for the account data to come down sync
+	h.storeIgnoredUsers(content)
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"testing"
+
+	"go.mau.fi/util/jsontime"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+)
+
+func insertTestReaction(t *testing.T, h *HiClient, eventID id.EventID, sender id.UserID, target id.EventID, key string) {
+	t.Helper()
+	content, _ := json.Marshal(map[string]any{
+		"m.relates_to": map[string]any{"rel_type": event.RelAnnotation, "event_id": target, "key": key},
+	})
+	_, err := h.DB.Event.Insert(context.Background(), &database.Event{
+		RoomID:       testRoomID,
+		ID:           eventID,
+		Sender:       sender,
+		Type:         event.EventReaction.Type,
+		Timestamp:    jsontime.UnixMilliNow(),
+		Content:      content,
+		Unsigned:     json.RawMessage("{}"),
+		RelatesTo:    target,
+		RelationType: event.RelAnnotation,
+	})
+	if err != nil {
+		t.Fatalf("Failed to insert reaction %s: %v", eventID, err)
+	}
+}
+
+func TestExcludeIgnoredReactions(t *testing.T) {
+	h, _ := newTestClient(t)
+	ctx := context.Background()
+	insertTestEvent(t, h, "$target", `{"msgtype":"m.text","body":"hello"}`, "")
+	insertTestReaction(t, h, "$r1", "@bob:example.com", "$target", "👍")
+	insertTestReaction(t, h, "$r2", "@spammer:example.com", "$target", "👍")
+	insertTestReaction(t, h, "$r3", "@spammer:example.com", "$target", "🍌")
+	evt := &database.Event{ID: "$target", Reactions: map[string]int{"👍": 2, "🍌": 1}}
+
+	h.storeIgnoredUsers(&event.IgnoredUserListEventContent{})
+	h.excludeIgnoredReactions(ctx, testRoomID, []*database.Event{evt})
+	if want := map[string]int{"👍": 2, "🍌": 1}; !maps.Equal(evt.Reactions, want) {
+		t.Errorf("Reactions without ignored users = %v, want %v", evt.Reactions, want)
+	}
+
+	h.storeIgnoredUsers(&event.IgnoredUserListEventContent{
+		IgnoredUsers: map[id.UserID]event.IgnoredUser{"@spammer:example.com": {}},
+	})
+	h.excludeIgnoredReactions(ctx, testRoomID, []*database.Event{evt})
+	if want := map[string]int{"👍": 1}; !maps.Equal(evt.Reactions, want) {
+		t.Errorf("Reactions with ignored users = %v, want %v", evt.Reactions, want)
+	}
+}
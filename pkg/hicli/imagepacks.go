@@ -0,0 +1,392 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/rs/zerolog"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"go.mau.fi/util/exgjson"
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+)
+
+var (
+	ErrImagePackNotFound = errors.New("image pack not found")
+	ErrImagePackExists   = errors.New("image pack already exists")
+	ErrInvalidShortcode  = errors.New("invalid shortcode")
+	ErrImageNotFound     = errors.New("image not found in pack")
+	ErrShortcodeInUse    = errors.New("shortcode is already used in pack")
+)
+
+func validateShortcode(shortcode string) error {
+	if shortcode == "" || strings.ContainsFunc(shortcode, func(r rune) bool {
+		return r == ':' || unicode.IsSpace(r)
+	}) {
+		return fmt.Errorf("%w %q: shortcodes must not be empty or contain colons or whitespace", ErrInvalidShortcode, shortcode)
+	}
+	return nil
+}
+
+func isImagePackContent(content json.RawMessage) bool {
+	return content != nil && gjson.GetBytes(content, "images").IsObject()
+}
+
+// imagePackRef points at the place where an image pack is stored.
+// Packs without a room ID are stored in the user's account data.
+type imagePackRef struct {
+	roomID  id.RoomID
+	evtType event.Type
+	packID  string
+}
+
+// fetchImagePack fetches the current content of an image pack from the server. The local database isn't used,
+// as it may not have received previous edits via sync yet. If the pack doesn't exist, the content is nil.
+func (h *HiClient) fetchImagePack(ctx context.Context, roomID id.RoomID, packID string) (*imagePackRef, json.RawMessage, error) {
+	if roomID == "" {
+		ref := &imagePackRef{evtType: database.AccountDataUserImagePack}
+		var content json.RawMessage
+		err := h.Client.GetAccountData(ctx, ref.evtType.Type, &content)
+		if errors.Is(err, mautrix.MNotFound) {
+			return ref, nil, nil
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("failed to get personal image pack: %w", err)
+		} else if !isImagePackContent(content) {
+			return ref, nil, nil
+		}
+		return ref, content, nil
+	}
+	for _, evtType := range []event.Type{database.StateImagePack, database.StateUnstableImagePack} {
+		var content json.RawMessage
+		err := h.Client.StateEvent(ctx, roomID, evtType, packID, &content)
+		if errors.Is(err, mautrix.MNotFound) {
+			continue
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("failed to get image pack state: %w", err)
+		} else if isImagePackContent(content) {
+			return &imagePackRef{roomID: roomID, evtType: evtType, packID: packID}, content, nil
+		}
+	}
+	return &imagePackRef{roomID: roomID, evtType: database.StateImagePack, packID: packID}, nil, nil
+}
+
+func (h *HiClient) saveImagePack(ctx context.Context, ref *imagePackRef, content json.RawMessage) error {
+	if ref.roomID == "" {
+		return h.Client.SetAccountData(ctx, ref.evtType.Type, content)
+	}
+	_, err := h.SetState(ctx, ref.roomID, ref.evtType, ref.packID, content)
+	return err
+}
+
+func newImagePackContent(meta *database.ImagePackMeta) (json.RawMessage, error) {
+	return json.Marshal(&database.ImagePack{
+		Images: make(map[string]*database.ImagePackImage),
+		Pack:   *meta,
+	})
+}
+
+// editImagePack applies the given function to the raw content of an existing pack. The raw content is edited
+// instead of a parsed struct to avoid dropping fields that gomuks doesn't know about.
+// If createPersonal is true and the personal pack doesn't exist yet, the function is applied to an empty pack.
+func (h *HiClient) editImagePack(
+	ctx context.Context,
+	roomID id.RoomID,
+	packID string,
+	createPersonal bool,
+	fn func(content json.RawMessage) (json.RawMessage, error),
+) error {
+	h.imagePackLock.Lock()
+	defer h.imagePackLock.Unlock()
+	ref, content, err := h.fetchImagePack(ctx, roomID, packID)
+	if err != nil {
+		return err
+	} else if content == nil && createPersonal && roomID == "" {
+		content, err = newImagePackContent(&database.ImagePackMeta{})
+		if err != nil {
+			return err
+		}
+	} else if content == nil {
+		return ErrImagePackNotFound
+	}
+	content, err = fn(content)
+	if err != nil {
+		return err
+	}
+	return h.saveImagePack(ctx, ref, content)
+}
+
+// CreateImagePack creates a new empty image pack. If the room ID is empty, the user's personal pack is created.
+func (h *HiClient) CreateImagePack(ctx context.Context, roomID id.RoomID, packID string, meta *database.ImagePackMeta) error {
+	h.imagePackLock.Lock()
+	defer h.imagePackLock.Unlock()
+	ref, existing, err := h.fetchImagePack(ctx, roomID, packID)
+	if err != nil {
+		return err
+	} else if existing != nil {
+		return ErrImagePackExists
+	}
+	content, err := newImagePackContent(meta)
+	if err != nil {
+		return err
+	}
+	return h.saveImagePack(ctx, ref, content)
+}
+
+// SetPackImage adds an image to a pack, replacing any existing image with the same shortcode.
+// The personal pack is created if it doesn't exist yet.
+func (h *HiClient) SetPackImage(ctx context.Context, roomID id.RoomID, packID, shortcode string, image *database.ImagePackImage) error {
+	if err := validateShortcode(shortcode); err != nil {
+		return err
+	} else if image == nil {
+		return fmt.Errorf("image is required")
+	} else if _, err = image.URL.Parse(); err != nil {
+		return fmt.Errorf("invalid image URL: %w", err)
+	}
+	return h.editImagePack(ctx, roomID, packID, true, func(content json.RawMessage) (json.RawMessage, error) {
+		return sjson.SetBytes(content, exgjson.Path("images", shortcode), image)
+	})
+}
+
+// RenamePackImage changes the shortcode of an image in a pack.
+func (h *HiClient) RenamePackImage(ctx context.Context, roomID id.RoomID, packID, shortcode, newShortcode string) error {
+	if err := validateShortcode(newShortcode); err != nil {
+		return err
+	} else if shortcode == newShortcode {
+		return nil
+	}
+	return h.editImagePack(ctx, roomID, packID, false, func(content json.RawMessage) (json.RawMessage, error) {
+		image := gjson.GetBytes(content, exgjson.Path("images", shortcode))
+		if !image.Exists() {
+			return nil, ErrImageNotFound
+		} else if gjson.GetBytes(content, exgjson.Path("images", newShortcode)).Exists() {
+			return nil, ErrShortcodeInUse
+		}
+		content, err := sjson.SetRawBytes(content, exgjson.Path("images", newShortcode), []byte(image.Raw))
+		if err != nil {
+			return nil, err
+		}
+		return sjson.DeleteBytes(content, exgjson.Path("images", shortcode))
+	})
+}
+
+// DeletePackImage removes an image from a pack.
+func (h *HiClient) DeletePackImage(ctx context.Context, roomID id.RoomID, packID, shortcode string) error {
+	return h.editImagePack(ctx, roomID, packID, false, func(content json.RawMessage) (json.RawMessage, error) {
+		if !gjson.GetBytes(content, exgjson.Path("images", shortcode)).Exists() {
+			return nil, ErrImageNotFound
+		}
+		return sjson.DeleteBytes(content, exgjson.Path("images", shortcode))
+	})
+}
+
+// SetImagePackEnabled adds or removes a room's image pack in the list of globally enabled packs.
+// Like the web frontend, the stable account data event is only updated if it already exists,
+// while the legacy event is always updated for compatibility with other clients.
+func (h *HiClient) SetImagePackEnabled(ctx context.Context, roomID id.RoomID, packID string, enabled bool) error {
+	if roomID == "" {
+		return fmt.Errorf("only room image packs can be enabled")
+	}
+	h.imagePackLock.Lock()
+	defer h.imagePackLock.Unlock()
+	var rooms database.ImagePackRooms
+	hasStable := true
+	err := h.Client.GetAccountData(ctx, database.AccountDataImagePackRooms.Type, &rooms)
+	if errors.Is(err, mautrix.MNotFound) {
+		hasStable = false
+		err = h.Client.GetAccountData(ctx, database.AccountDataUnstableImagePackRooms.Type, &rooms)
+		if errors.Is(err, mautrix.MNotFound) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get enabled image packs: %w", err)
+	}
+	if rooms.IsEnabled(roomID, packID) == enabled {
+		return nil
+	}
+	if rooms.Rooms == nil {
+		rooms.Rooms = make(map[id.RoomID]map[string]json.RawMessage)
+	}
+	if enabled {
+		if rooms.Rooms[roomID] == nil {
+			rooms.Rooms[roomID] = make(map[string]json.RawMessage)
+		}
+		rooms.Rooms[roomID][packID] = json.RawMessage("{}")
+	} else {
+		delete(rooms.Rooms[roomID], packID)
+		if len(rooms.Rooms[roomID]) == 0 {
+			delete(rooms.Rooms, roomID)
+		}
+	}
+	if hasStable {
+		err = h.Client.SetAccountData(ctx, database.AccountDataImagePackRooms.Type, &rooms)
+		if err != nil {
+			return err
+		}
+	}
+	return h.Client.SetAccountData(ctx, database.AccountDataUnstableImagePackRooms.Type, &rooms)
+}
+
+// getEnabledImagePacks returns the globally enabled room image packs from the local account data.
+func (h *HiClient) getEnabledImagePacks(ctx context.Context) (*database.ImagePackRooms, error) {
+	for _, evtType := range []event.Type{database.AccountDataImagePackRooms, database.AccountDataUnstableImagePackRooms} {
+		ad, err := h.DB.AccountData.GetGlobal(ctx, h.Account.UserID, evtType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s account data: %w", evtType.Type, err)
+		} else if ad == nil {
+			continue
+		}
+		var rooms database.ImagePackRooms
+		err = json.Unmarshal(ad.Content, &rooms)
+		if err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Str("event_type", evtType.Type).Msg("Failed to parse enabled image packs")
+		}
+		return &rooms, nil
+	}
+	return &database.ImagePackRooms{}, nil
+}
+
+// GetImagePacks returns the user's personal pack, the packs in the given room, and all globally enabled room packs.
+func (h *HiClient) GetImagePacks(ctx context.Context, roomID id.RoomID) ([]*jsoncmd.ImagePackInfo, error) {
+	var packs []*jsoncmd.ImagePackInfo
+	userPack, err := h.DB.AccountData.GetGlobal(ctx, h.Account.UserID, database.AccountDataUserImagePack)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get personal image pack: %w", err)
+	} else if userPack != nil && isImagePackContent(userPack.Content) {
+		content, err := database.ParseImagePack(userPack.Content)
+		if err != nil {
+			zerolog.Ctx(ctx).Warn().Err(err).Msg("Failed to parse personal image pack")
+		} else {
+			packs = append(packs, &jsoncmd.ImagePackInfo{Enabled: true, Content: content})
+		}
+	}
+	enabled, err := h.getEnabledImagePacks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var stateEvts []*database.Event
+	if roomID != "" {
+		stateEvts, err = h.DB.CurrentState.GetAllExceptMembers(ctx, roomID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get room state: %w", err)
+		}
+	}
+	var keys []database.RoomStateGUID
+	for enabledRoomID, roomPacks := range enabled.Rooms {
+		if enabledRoomID == roomID {
+			continue
+		}
+		for packID := range roomPacks {
+			keys = append(keys,
+				database.RoomStateGUID{RoomID: enabledRoomID, Type: database.StateImagePack, StateKey: packID},
+				database.RoomStateGUID{RoomID: enabledRoomID, Type: database.StateUnstableImagePack, StateKey: packID},
+			)
+		}
+	}
+	if len(keys) > 0 {
+		enabledEvts, err := h.DB.CurrentState.GetMany(ctx, keys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get enabled image packs: %w", err)
+		}
+		stateEvts = append(stateEvts, enabledEvts...)
+	}
+	seen := make(map[database.RoomStateGUID]struct{})
+	// Stable packs are added first, so legacy events with the same state key are ignored
+	for _, evtType := range []event.Type{database.StateImagePack, database.StateUnstableImagePack} {
+		for _, evt := range stateEvts {
+			if evt.Type != evtType.Type || evt.StateKey == nil || evt.RedactedBy != "" || !isImagePackContent(evt.Content) {
+				continue
+			}
+			key := database.RoomStateGUID{RoomID: evt.RoomID, StateKey: *evt.StateKey}
+			if _, alreadyAdded := seen[key]; alreadyAdded {
+				continue
+			}
+			content, err := database.ParseImagePack(evt.Content)
+			if err != nil {
+				zerolog.Ctx(ctx).Warn().Err(err).
+					Stringer("room_id", evt.RoomID).
+					Str("state_key", *evt.StateKey).
+					Msg("Failed to parse image pack")
+				continue
+			}
+			seen[key] = struct{}{}
+			packs = append(packs, &jsoncmd.ImagePackInfo{
+				RoomID:  evt.RoomID,
+				PackID:  *evt.StateKey,
+				Enabled: enabled.IsEnabled(evt.RoomID, *evt.StateKey),
+				Content: content,
+			})
+		}
+	}
+	return packs, nil
+}
+
+// getStoredImagePack returns the content of an image pack from the local database.
+func (h *HiClient) getStoredImagePack(ctx context.Context, roomID id.RoomID, packID string) (*database.ImagePack, error) {
+	var content json.RawMessage
+	if roomID == "" {
+		ad, err := h.DB.AccountData.GetGlobal(ctx, h.Account.UserID, database.AccountDataUserImagePack)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get personal image pack: %w", err)
+		} else if ad != nil {
+			content = ad.Content
+		}
+	} else {
+		for _, evtType := range []event.Type{database.StateImagePack, database.StateUnstableImagePack} {
+			evt, err := h.DB.CurrentState.Get(ctx, roomID, evtType, packID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get image pack state: %w", err)
+			} else if evt != nil && evt.RedactedBy == "" && isImagePackContent(evt.Content) {
+				content = evt.Content
+				break
+			}
+		}
+	}
+	if !isImagePackContent(content) {
+		return nil, ErrImagePackNotFound
+	}
+	return database.ParseImagePack(content)
+}
+
+// SendSticker sends an image from an image pack as a sticker.
+func (h *HiClient) SendSticker(ctx context.Context, params *jsoncmd.SendStickerParams) (*database.Event, error) {
+	pack, err := h.getStoredImagePack(ctx, params.PackRoomID, params.PackID)
+	if err != nil {
+		return nil, err
+	}
+	image, ok := pack.Images[params.Shortcode]
+	if !ok {
+		return nil, ErrImageNotFound
+	} else if !pack.ImageHasUsage(image, database.ImagePackUsageSticker) {
+		return nil, fmt.Errorf("image %q can't be used as a sticker", params.Shortcode)
+	}
+	content := &event.MessageEventContent{
+		Body:      image.Body,
+		URL:       image.URL,
+		Info:      image.Info,
+		RelatesTo: params.RelatesTo,
+		Mentions:  params.Mentions,
+	}
+	if content.Body == "" {
+		content.Body = params.Shortcode
+	}
+	if content.Info == nil {
+		content.Info = &event.FileInfo{}
+	}
+	return h.Send(ctx, params.RoomID, event.EventSticker, content, false, false)
+}
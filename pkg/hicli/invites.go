@@ -0,0 +1,497 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.mau.fi/util/glob"
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+)
+
+var AccountDataInviteRules = event.Type{Type: "fi.mau.gomuks.invite_rules", Class: event.AccountDataEventType}
+
+const (
+	mutualRoomCheckTimeout = 10 * time.Second
+	// How long server-side mutual room check results are trusted before checking again.
+	mutualRoomCacheTTL = 1 * time.Hour
+	// The delay before retrying a failed mutual room check, doubled after each consecutive failure.
+	mutualRoomRetryMinDelay = 30 * time.Second
+	mutualRoomRetryMaxDelay = 30 * time.Minute
+)
+
+type inviteDecision int
+
+const (
+	inviteDecisionAccept inviteDecision = iota
+	inviteDecisionQuarantine
+	inviteDecisionReject
+)
+
+type compiledInviteRules struct {
+	*jsoncmd.InviteRules
+	blockedUsers   []glob.Glob
+	blockedServers []glob.Glob
+}
+
+func compileGlobs(patterns []string) []glob.Glob {
+	globs := make([]glob.Glob, 0, len(patterns))
+	for _, pattern := range patterns {
+		if compiled := glob.Compile(pattern); compiled != nil {
+			globs = append(globs, compiled)
+		}
+	}
+	return globs
+}
+
+func matchesAnyGlob(globs []glob.Glob, value string) bool {
+	return slices.ContainsFunc(globs, func(g glob.Glob) bool {
+		return g.Match(value)
+	})
+}
+
+func (h *HiClient) storeInviteRules(ctx context.Context, raw json.RawMessage) {
+	var rules jsoncmd.InviteRules
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &rules); err != nil {
+			zerolog.Ctx(ctx).Err(err).Msg("Failed to unmarshal invite rules from account data")
+		}
+	}
+	h.inviteRules.Store(&compiledInviteRules{
+		InviteRules:    &rules,
+		blockedUsers:   compileGlobs(rules.BlockedUsers),
+		blockedServers: compileGlobs(rules.BlockedServers),
+	})
+}
+
+// getInviteRules returns the invite rules stored in account data, loading them from the database if necessary.
+func (h *HiClient) getInviteRules(ctx context.Context) *compiledInviteRules {
+	if rules := h.inviteRules.Load(); rules != nil {
+		return rules
+	}
+	evt, err := h.DB.AccountData.GetGlobal(ctx, h.Account.UserID, AccountDataInviteRules)
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Msg("Failed to get invite rules from account data")
+		return &compiledInviteRules{InviteRules: &jsoncmd.InviteRules{}}
+	}
+	var raw json.RawMessage
+	if evt != nil {
+		raw = evt.Content
+	}
+	h.storeInviteRules(ctx, raw)
+	return h.inviteRules.Load()
+}
+
+// GetInviteRules returns the current invite filtering rules.
+func (h *HiClient) GetInviteRules(ctx context.Context) *jsoncmd.InviteRules {
+	return h.getInviteRules(ctx).InviteRules
+}
+
+// SetInviteRules replaces the invite filtering rules. The rules are stored in account data,
+// so they're synced to other devices.
+func (h *HiClient) SetInviteRules(ctx context.Context, rules *jsoncmd.InviteRules) error {
+	for _, pattern := range slices.Concat(rules.BlockedUsers, rules.BlockedServers) {
+		if glob.Compile(pattern) == nil {
+			return fmt.Errorf("invalid glob pattern %q", pattern)
+		}
+	}
+	err := h.Client.SetAccountData(ctx, AccountDataInviteRules.Type, rules)
+	if err != nil {
+		return err
+	}
+	raw, _ := json.Marshal(rules)
+	h.storeInviteRules(ctx, raw)
+	return nil
+}
+
+// getInviter returns the sender of the current user's invite event in the given invite state.
+func (h *HiClient) getInviter(inviteState []*event.Event) id.UserID {
+	for _, evt := range inviteState {
+		if evt.Type == event.StateMember && evt.GetStateKey() == h.Account.UserID.String() {
+			return evt.Sender
+		}
+	}
+	return ""
+}
+
+type mutualRoomResult int
+
+const (
+	mutualRoomUnknown mutualRoomResult = iota
+	mutualRoomShared
+	mutualRoomNotShared
+)
+
+type mutualRoomCacheEntry struct {
+	shared    bool
+	checkedAt time.Time
+}
+
+func (entry mutualRoomCacheEntry) expired() bool {
+	return time.Since(entry.checkedAt) > mutualRoomCacheTTL
+}
+
+// mutualRoomChecker asks the server whether inviters share rooms with the current user.
+// The checks are done one by one in a single background goroutine, so a burst of invites
+// doesn't cause a burst of requests, and the results are cached per inviter.
+type mutualRoomChecker struct {
+	lock    sync.Mutex
+	results map[id.UserID]mutualRoomCacheEntry
+	queue   []id.UserID
+	queued  map[id.UserID]struct{}
+	running bool
+	// The number of consecutive failed checks for each user, used for the retry backoff.
+	failures map[id.UserID]int
+	// Set if the server doesn't support the mutual rooms endpoint (MSC2666).
+	unsupported bool
+}
+
+func mutualRoomRetryDelay(failures int) time.Duration {
+	delay := mutualRoomRetryMinDelay
+	for i := 1; i < failures && delay < mutualRoomRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, mutualRoomRetryMaxDelay)
+}
+
+// getCachedMutualRoom returns the cached result of the server-side mutual room check for the given user.
+// Expired results are still returned, but the expired flag is set to signal that the server should be asked again.
+func (h *HiClient) getCachedMutualRoom(userID id.UserID) (result mutualRoomResult, expired, unsupported bool) {
+	h.mutualRooms.lock.Lock()
+	defer h.mutualRooms.lock.Unlock()
+	entry, ok := h.mutualRooms.results[userID]
+	if !ok {
+		return mutualRoomUnknown, false, h.mutualRooms.unsupported
+	} else if entry.shared {
+		return mutualRoomShared, entry.expired(), false
+	}
+	return mutualRoomNotShared, entry.expired(), false
+}
+
+// queueMutualRoomChecks queues server-side mutual room checks for the given users.
+func (h *HiClient) queueMutualRoomChecks(ctx context.Context, userIDs []id.UserID) {
+	if len(userIDs) == 0 {
+		return
+	}
+	mr := &h.mutualRooms
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+	if mr.queued == nil {
+		mr.queued = make(map[id.UserID]struct{})
+	}
+	if mr.results == nil {
+		mr.results = make(map[id.UserID]mutualRoomCacheEntry)
+	}
+	for _, userID := range userIDs {
+		if _, alreadyQueued := mr.queued[userID]; alreadyQueued {
+			continue
+		} else if entry, alreadyChecked := mr.results[userID]; alreadyChecked && !entry.expired() {
+			continue
+		}
+		mr.queued[userID] = struct{}{}
+		mr.queue = append(mr.queue, userID)
+	}
+	if !mr.running && len(mr.queue) > 0 {
+		mr.running = true
+		go h.runMutualRoomChecks(context.WithoutCancel(ctx))
+	}
+}
+
+func (h *HiClient) runMutualRoomChecks(ctx context.Context) {
+	mr := &h.mutualRooms
+	for {
+		mr.lock.Lock()
+		if len(mr.queue) == 0 || mr.unsupported {
+			clear(mr.queued)
+			mr.queue = nil
+			mr.running = false
+			mr.lock.Unlock()
+			return
+		}
+		userID := mr.queue[0]
+		mr.queue = mr.queue[1:]
+		mr.lock.Unlock()
+
+		result, err := h.fetchMutualRoom(ctx, userID)
+
+		mr.lock.Lock()
+		delete(mr.queued, userID)
+		if err != nil {
+			if mr.failures == nil {
+				mr.failures = make(map[id.UserID]int)
+			}
+			mr.failures[userID]++
+			delay := mutualRoomRetryDelay(mr.failures[userID])
+			zerolog.Ctx(ctx).Err(err).
+				Stringer("user_id", userID).
+				Stringer("retry_in", delay).
+				Msg("Failed to get mutual rooms from server")
+			time.AfterFunc(delay, func() {
+				h.recheckMutualRoom(ctx, userID)
+			})
+		} else if result == mutualRoomUnknown {
+			zerolog.Ctx(ctx).Info().Msg("Server doesn't support checking mutual rooms, only local member lists will be used for invite rules")
+			mr.unsupported = true
+		} else {
+			delete(mr.failures, userID)
+			mr.results[userID] = mutualRoomCacheEntry{shared: result == mutualRoomShared, checkedAt: time.Now()}
+			if result == mutualRoomNotShared {
+				// The user may join a shared room later, so check again once the result expires
+				time.AfterFunc(mutualRoomCacheTTL, func() {
+					h.recheckMutualRoom(ctx, userID)
+				})
+			}
+		}
+		mr.lock.Unlock()
+
+		if result == mutualRoomShared {
+			h.releaseQuarantinedInvites(ctx, userID)
+		} else if result == mutualRoomUnknown && err == nil {
+			// Without the server check, there's no reliable way to tell that there are no mutual rooms
+			h.releaseQuarantinedInvites(ctx, "")
+		}
+	}
+}
+
+// recheckMutualRoom queues a new server-side mutual room check for the given user
+// if there are still quarantined invites from them.
+func (h *HiClient) recheckMutualRoom(ctx context.Context, userID id.UserID) {
+	if !h.IsLoggedIn() {
+		return
+	}
+	roomIDs, err := h.DB.InvitedRoom.GetQuarantinedIDs(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Msg("Failed to get quarantined invites")
+		return
+	}
+	for _, roomID := range roomIDs {
+		ir, err := h.DB.InvitedRoom.Get(ctx, roomID)
+		if err != nil {
+			zerolog.Ctx(ctx).Err(err).Stringer("room_id", roomID).Msg("Failed to get quarantined invite")
+		} else if ir != nil && h.getInviter(ir.InviteState) == userID {
+			h.queueMutualRoomChecks(ctx, []id.UserID{userID})
+			return
+		}
+	}
+}
+
+// fetchMutualRoom asks the server whether the given user shares any rooms with the current user.
+// If the server doesn't support the mutual rooms endpoint, the result is unknown without an error.
+func (h *HiClient) fetchMutualRoom(ctx context.Context, userID id.UserID) (mutualRoomResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, mutualRoomCheckTimeout)
+	defer cancel()
+	resp, err := h.Client.GetMutualRooms(mautrix.WithMaxRetries(ctx, 0), userID, mautrix.ReqMutualRooms{})
+	var httpErr mautrix.HTTPError
+	if errors.Is(err, mautrix.MUnrecognized) ||
+		(errors.As(err, &httpErr) && httpErr.Response != nil && httpErr.Response.StatusCode == http.StatusNotFound) {
+		return mutualRoomUnknown, nil
+	} else if err != nil {
+		return mutualRoomUnknown, err
+	} else if len(resp.Joined) > 0 {
+		return mutualRoomShared, nil
+	}
+	return mutualRoomNotShared, nil
+}
+
+// releaseQuarantinedInvites moves quarantined invites from the given user back to the normal invite list.
+// If the user ID is empty, all quarantined invites are released.
+func (h *HiClient) releaseQuarantinedInvites(ctx context.Context, inviter id.UserID) {
+	log := zerolog.Ctx(ctx)
+	roomIDs, err := h.DB.InvitedRoom.GetQuarantinedIDs(ctx)
+	if err != nil {
+		log.Err(err).Msg("Failed to get quarantined invites")
+		return
+	}
+	var released []*database.InvitedRoom
+	for _, roomID := range roomIDs {
+		ir, err := h.DB.InvitedRoom.Get(ctx, roomID)
+		if err != nil {
+			log.Err(err).Stringer("room_id", roomID).Msg("Failed to get quarantined invite")
+			continue
+		} else if ir == nil || (inviter != "" && h.getInviter(ir.InviteState) != inviter) {
+			continue
+		}
+		ir.Quarantined = false
+		err = h.DB.InvitedRoom.Upsert(ctx, ir)
+		if err != nil {
+			log.Err(err).Stringer("room_id", roomID).Msg("Failed to release quarantined invite")
+			continue
+		}
+		released = append(released, ir)
+	}
+	if len(released) > 0 {
+		log.Debug().Int("count", len(released)).Stringer("inviter", inviter).Msg("Released quarantined invites")
+		h.EventHandler(&jsoncmd.SyncComplete{InvitedRooms: released})
+	}
+}
+
+// checkMutualRoom decides whether an invite should be quarantined by the mutual room rule.
+// Only local data is used, as this is called in the sync path. If the answer isn't known yet,
+// the invite is quarantined and needsServerCheck is set, so the server can be asked in the background.
+func (h *HiClient) checkMutualRoom(ctx context.Context, roomID id.RoomID, inviter id.UserID) (decision inviteDecision, needsServerCheck bool) {
+	log := zerolog.Ctx(ctx).With().Stringer("user_id", inviter).Logger()
+	joined, err := h.DB.CurrentState.IsJoinedAnywhere(ctx, inviter)
+	if err != nil {
+		log.Err(err).Msg("Failed to check local member lists for mutual rooms")
+	} else if joined {
+		return inviteDecisionAccept, false
+	}
+	cached, expired, unsupported := h.getCachedMutualRoom(inviter)
+	switch {
+	case cached == mutualRoomShared:
+		return inviteDecisionAccept, expired
+	case cached == mutualRoomNotShared:
+		return inviteDecisionQuarantine, expired
+	case unsupported:
+		// Local member lists may be incomplete due to lazy loading, so they can't prove there are no mutual rooms
+		return inviteDecisionAccept, false
+	}
+	existing, err := h.DB.InvitedRoom.Get(ctx, roomID)
+	if err != nil {
+		log.Err(err).Stringer("room_id", roomID).Msg("Failed to get existing invite")
+	} else if existing != nil && !existing.Quarantined {
+		// Don't move invites that were already accepted into the normal invite list back to requests
+		return inviteDecisionAccept, false
+	}
+	return inviteDecisionQuarantine, true
+}
+
+func (h *HiClient) checkInviteRules(ctx context.Context, roomID id.RoomID, inviter id.UserID) (inviteDecision, bool) {
+	if inviter == "" || inviter == h.Account.UserID {
+		return inviteDecisionAccept, false
+	} else if h.IsIgnored(ctx, inviter) {
+		return inviteDecisionReject, false
+	}
+	rules := h.getInviteRules(ctx)
+	if matchesAnyGlob(rules.blockedUsers, inviter.String()) || matchesAnyGlob(rules.blockedServers, inviter.Homeserver()) {
+		return inviteDecisionReject, false
+	} else if rules.RequireMutualRoom {
+		return h.checkMutualRoom(ctx, roomID, inviter)
+	}
+	return inviteDecisionAccept, false
+}
+
+type inviteEvaluation struct {
+	decisions map[id.RoomID]inviteDecision
+	// Inviters whose mutual rooms should be checked with the server after the sync is saved.
+	mutualRoomChecks []id.UserID
+}
+
+// evaluateInviteRules decides what to do with each invite in a sync response.
+// Invites that should be stored normally aren't included in the decisions map.
+func (h *HiClient) evaluateInviteRules(ctx context.Context, invites map[id.RoomID]*mautrix.SyncInvitedRoom) *inviteEvaluation {
+	eval := &inviteEvaluation{decisions: make(map[id.RoomID]inviteDecision)}
+	for roomID, room := range invites {
+		inviter := h.getInviter(room.State.Events)
+		decision, needsServerCheck := h.checkInviteRules(ctx, roomID, inviter)
+		if needsServerCheck {
+			eval.mutualRoomChecks = append(eval.mutualRoomChecks, inviter)
+		}
+		if decision == inviteDecisionAccept {
+			continue
+		}
+		zerolog.Ctx(ctx).Debug().
+			Stringer("room_id", roomID).
+			Stringer("inviter", inviter).
+			Int("decision", int(decision)).
+			Msg("Invite matched invite rules")
+		eval.decisions[roomID] = decision
+	}
+	return eval
+}
+
+// handleInviteDecisions rejects blocked invites and starts the server-side mutual room checks
+// for quarantined invites. It's called after the sync response has been saved.
+func (h *HiClient) handleInviteDecisions(ctx context.Context, eval *inviteEvaluation) {
+	h.rejectBlockedInvites(ctx, eval.decisions)
+	h.queueMutualRoomChecks(ctx, eval.mutualRoomChecks)
+}
+
+func (h *HiClient) rejectBlockedInvites(ctx context.Context, decisions map[id.RoomID]inviteDecision) {
+	var roomIDs []id.RoomID
+	for roomID, decision := range decisions {
+		if decision == inviteDecisionReject {
+			roomIDs = append(roomIDs, roomID)
+		}
+	}
+	if len(roomIDs) == 0 {
+		return
+	}
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		log := zerolog.Ctx(ctx)
+		resp := h.rejectInvites(ctx, roomIDs)
+		log.Info().
+			Int("rejected", len(resp.Rejected)).
+			Int("failed", len(resp.Failed)).
+			Msg("Automatically rejected invites based on invite rules")
+		for _, roomID := range resp.Rejected {
+			err := h.DB.InvitedRoom.Delete(ctx, roomID)
+			if err != nil {
+				log.Err(err).Stringer("room_id", roomID).Msg("Failed to delete rejected invite")
+			}
+		}
+		// Invites that couldn't be rejected were stored as quarantined, show them to the user in the requests list
+		var failed []*database.InvitedRoom
+		for roomID := range resp.Failed {
+			ir, err := h.DB.InvitedRoom.Get(ctx, roomID)
+			if err != nil {
+				log.Err(err).Stringer("room_id", roomID).Msg("Failed to get invite that couldn't be rejected")
+			} else if ir != nil {
+				failed = append(failed, ir)
+			}
+		}
+		if len(failed) > 0 {
+			h.EventHandler(&jsoncmd.SyncComplete{InvitedRooms: failed})
+		}
+	}()
+}
+
+func (h *HiClient) rejectInvites(ctx context.Context, roomIDs []id.RoomID) *jsoncmd.RejectInvitesResponse {
+	resp := &jsoncmd.RejectInvitesResponse{
+		Rejected: make([]id.RoomID, 0, len(roomIDs)),
+		Failed:   make(map[id.RoomID]string),
+	}
+	for _, roomID := range roomIDs {
+		_, err := h.Client.LeaveRoom(ctx, roomID, &mautrix.ReqLeave{})
+		if err != nil {
+			zerolog.Ctx(ctx).Err(err).Stringer("room_id", roomID).Msg("Failed to reject invite")
+			resp.Failed[roomID] = err.Error()
+		} else {
+			resp.Rejected = append(resp.Rejected, roomID)
+		}
+	}
+	return resp
+}
+
+// RejectInvites rejects the given invites. If includeQuarantined is true,
+// all invites in the requests section are rejected too.
+func (h *HiClient) RejectInvites(ctx context.Context, roomIDs []id.RoomID, includeQuarantined bool) (*jsoncmd.RejectInvitesResponse, error) {
+	if includeQuarantined {
+		quarantined, err := h.DB.InvitedRoom.GetQuarantinedIDs(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get quarantined invites: %w", err)
+		}
+		roomIDs = slices.Concat(roomIDs, quarantined)
+		slices.Sort(roomIDs)
+		roomIDs = slices.Compact(roomIDs)
+	}
+	return h.rejectInvites(ctx, roomIDs), nil
+}
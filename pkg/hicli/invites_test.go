@@ -0,0 +1,141 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mau.fi/util/jsontime"
+	"go.mau.fi/util/ptr"
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+)
+
+func TestInviteRuleGlobs(t *testing.T) {
+	users := compileGlobs([]string{"@spam*:example.com", "@exact:example.org", "@?bot:*"})
+	servers := compileGlobs([]string{"*.evil.example", "evil.example"})
+	tests := []struct {
+		userID  id.UserID
+		blocked bool
+	}{
+		{"@spammer:example.com", true},
+		{"@spam:example.com", true},
+		{"@spam:example.com.au", false},
+		{"@notspam:example.com", false},
+		{"@exact:example.org", true},
+		{"@exactly:example.org", false},
+		{"@abot:anywhere.example", true},
+		{"@bot:anywhere.example", false},
+		{"@user:evil.example", true},
+		{"@user:sub.evil.example", true},
+		{"@user:notevil.example", false},
+		{"@user:example.com", false},
+	}
+	for _, test := range tests {
+		blocked := matchesAnyGlob(users, test.userID.String()) || matchesAnyGlob(servers, test.userID.Homeserver())
+		if blocked != test.blocked {
+			t.Errorf("invite from %s blocked = %t, want %t", test.userID, blocked, test.blocked)
+		}
+	}
+	if matchesAnyGlob(compileGlobs(nil), "@user:example.com") {
+		t.Errorf("Empty glob list matched a user ID")
+	}
+}
+
+func TestCheckMutualRoom(t *testing.T) {
+	ctx := context.Background()
+	h, _ := newTestClient(t)
+	expiredAt := time.Now().Add(-mutualRoomCacheTTL - time.Minute)
+	h.mutualRooms.results = map[id.UserID]mutualRoomCacheEntry{
+		"@friend:example.com":          {shared: true, checkedAt: time.Now()},
+		"@stranger:example.com":        {shared: false, checkedAt: time.Now()},
+		"@former-friend:example.com":   {shared: true, checkedAt: expiredAt},
+		"@former-stranger:example.com": {shared: false, checkedAt: expiredAt},
+	}
+	err := h.DB.InvitedRoom.Upsert(ctx, &database.InvitedRoom{
+		ID:          "!accepted:example.com",
+		CreatedAt:   jsontime.UnixMilliNow(),
+		InviteState: []*event.Event{},
+	})
+	if err != nil {
+		t.Fatalf("Failed to insert invite: %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		roomID           id.RoomID
+		inviter          id.UserID
+		decision         inviteDecision
+		needsServerCheck bool
+	}{
+		{"cached shared", "!new:example.com", "@friend:example.com", inviteDecisionAccept, false},
+		{"cached not shared", "!new:example.com", "@stranger:example.com", inviteDecisionQuarantine, false},
+		{"expired shared", "!new:example.com", "@former-friend:example.com", inviteDecisionAccept, true},
+		{"expired not shared", "!new:example.com", "@former-stranger:example.com", inviteDecisionQuarantine, true},
+		{"unknown", "!new:example.com", "@unknown:example.com", inviteDecisionQuarantine, true},
+		{"unknown with existing invite", "!accepted:example.com", "@unknown:example.com", inviteDecisionAccept, false},
+	}
+	for _, test := range tests {
+		decision, needsServerCheck := h.checkMutualRoom(ctx, test.roomID, test.inviter)
+		if decision != test.decision || needsServerCheck != test.needsServerCheck {
+			t.Errorf("%s: checkMutualRoom() = %d, %t, want %d, %t", test.name, decision, needsServerCheck, test.decision, test.needsServerCheck)
+		}
+	}
+
+	// If the server doesn't support checking mutual rooms, unknown inviters are let through
+	h.mutualRooms.unsupported = true
+	if decision, needsServerCheck := h.checkMutualRoom(ctx, "!new:example.com", "@unknown:example.com"); decision != inviteDecisionAccept || needsServerCheck {
+		t.Errorf("checkMutualRoom() with unsupported server = %d, %t, want accept", decision, needsServerCheck)
+	}
+}
+
+func TestMutualRoomRetryDelay(t *testing.T) {
+	tests := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		6:  16 * time.Minute,
+		7:  mutualRoomRetryMaxDelay,
+		50: mutualRoomRetryMaxDelay,
+	}
+	for failures, want := range tests {
+		if got := mutualRoomRetryDelay(failures); got != want {
+			t.Errorf("mutualRoomRetryDelay(%d) = %s, want %s", failures, got, want)
+		}
+	}
+}
+
+func TestRejectedInviteIsStored(t *testing.T) {
+	ctx := context.Background()
+	h, _ := newTestClient(t)
+	h.Account = &database.Account{UserID: "@me:example.com"}
+	ctx = context.WithValue(ctx, inviteDecisionsContextKey, &inviteEvaluation{
+		decisions: map[id.RoomID]inviteDecision{"!spam:example.com": inviteDecisionReject},
+	})
+	err := h.processSyncInvitedRoom(ctx, "!spam:example.com", &mautrix.SyncInvitedRoom{
+		State: mautrix.SyncEventsList{Events: []*event.Event{{
+			Type:     event.StateMember,
+			StateKey: ptr.Ptr("@me:example.com"),
+			Sender:   "@spammer:example.com",
+			Content:  event.Content{Parsed: &event.MemberEventContent{Membership: event.MembershipInvite}},
+		}}},
+	})
+	if err != nil {
+		t.Fatalf("processSyncInvitedRoom() returned error: %v", err)
+	}
+	ir, err := h.DB.InvitedRoom.Get(ctx, "!spam:example.com")
+	if err != nil {
+		t.Fatalf("Failed to get invite: %v", err)
+	} else if ir == nil || !ir.Quarantined {
+		t.Errorf("Rejected invite wasn't stored as quarantined before rejecting: %+v", ir)
+	}
+}
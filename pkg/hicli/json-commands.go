@@ -93,6 +93,8 @@ func (h *HiClient) handleJSONCommand(ctx context.Context, req *JSONCommand) (any
 		return jsoncmd.GetEventByRowID.RunCtx(ctx, req.Data, h.API.GetEventByRowID)
 	case jsoncmd.ReqGetRelatedEvents:
 		return jsoncmd.GetRelatedEvents.RunCtx(ctx, req.Data, h.API.GetRelatedEvents)
+	case jsoncmd.ReqGetThreadReplies:
+		return jsoncmd.GetThreadReplies.RunCtx(ctx, req.Data, h.API.GetThreadReplies)
 	case jsoncmd.ReqGetStickyEvents:
 		return jsoncmd.GetStickyEvents.RunCtx(ctx, req.Data, h.API.GetStickyEvents)
 	case jsoncmd.ReqGetEventContext:
@@ -111,6 +113,8 @@ func (h *HiClient) handleJSONCommand(ctx context.Context, req *JSONCommand) (any
 		return jsoncmd.GetSpecificRoomState.RunCtx(ctx, req.Data, h.API.GetSpecificRoomState)
 	case jsoncmd.ReqGetReceipts:
 		return jsoncmd.GetReceipts.RunCtx(ctx, req.Data, h.API.GetReceipts)
+	case jsoncmd.ReqGetOwnReceipts:
+		return jsoncmd.GetOwnReceipts.RunCtx(ctx, req.Data, h.API.GetOwnReceipts)
 	case jsoncmd.ReqPaginate:
 		return jsoncmd.Paginate.RunCtx(ctx, req.Data, h.API.Paginate)
 	case jsoncmd.ReqGetRoomSummary:
@@ -185,6 +189,76 @@ func (h *HiClient) handleJSONCommand(ctx context.Context, req *JSONCommand) (any
 		return jsoncmd.CalculateRoomID.RunCtx(ctx, req.Data, h.API.CalculateRoomID)
 	case jsoncmd.ReqRerequestSession:
 		return jsoncmd.RerequestSession.RunCtx(ctx, req.Data, h.API.RerequestSession)
+	case jsoncmd.ReqUpgradeRoom:
+		return jsoncmd.UpgradeRoom.RunCtx(ctx, req.Data, h.API.UpgradeRoom)
+	case jsoncmd.ReqBulkRedact:
+		return jsoncmd.BulkRedact.RunCtx(ctx, req.Data, h.API.BulkRedact)
+	case jsoncmd.ReqBulkBan:
+		return jsoncmd.BulkBan.RunCtx(ctx, req.Data, h.API.BulkBan)
+	case jsoncmd.ReqBulkKick:
+		return jsoncmd.BulkKick.RunCtx(ctx, req.Data, h.API.BulkKick)
+	case jsoncmd.ReqCancelModerationJob:
+		return jsoncmd.CancelModerationJob.RunCtx(ctx, req.Data, h.API.CancelModerationJob)
+	case jsoncmd.ReqGetPolicyLists:
+		return jsoncmd.GetPolicyLists.RunCtx(ctx, req.Data, h.API.GetPolicyLists)
+	case jsoncmd.ReqSetPolicyList:
+		return jsoncmd.SetPolicyList.RunCtx(ctx, req.Data, h.API.SetPolicyList)
+	case jsoncmd.ReqGetPolicyMatches:
+		return jsoncmd.GetPolicyMatches.RunCtx(ctx, req.Data, h.API.GetPolicyMatches)
+	case jsoncmd.ReqGetPolicyReport:
+		return jsoncmd.GetPolicyReport.RunCtx(ctx, req.Data, h.API.GetPolicyReport)
+	case jsoncmd.ReqApplyPolicyBans:
+		return jsoncmd.ApplyPolicyBans.RunCtx(ctx, req.Data, h.API.ApplyPolicyBans)
+	case jsoncmd.ReqGetIgnoredUsers:
+		return jsoncmd.GetIgnoredUsers.RunCtx(ctx, req.Data, h.API.GetIgnoredUsers)
+	case jsoncmd.ReqSetIgnored:
+		return jsoncmd.SetIgnored.RunCtx(ctx, req.Data, h.API.SetIgnored)
+	case jsoncmd.ReqGetInviteRules:
+		return jsoncmd.GetInviteRules.RunCtx(ctx, req.Data, h.API.GetInviteRules)
+	case jsoncmd.ReqSetInviteRules:
+		return jsoncmd.SetInviteRules.RunCtx(ctx, req.Data, h.API.SetInviteRules)
+	case jsoncmd.ReqRejectInvites:
+		return jsoncmd.RejectInvites.RunCtx(ctx, req.Data, h.API.RejectInvites)
+	case jsoncmd.ReqVotePoll:
+		return jsoncmd.VotePoll.RunCtx(ctx, req.Data, h.API.VotePoll)
+	case jsoncmd.ReqEndPoll:
+		return jsoncmd.EndPoll.RunCtx(ctx, req.Data, h.API.EndPoll)
+	case jsoncmd.ReqSetDraft:
+		return jsoncmd.SetDraft.RunCtx(ctx, req.Data, h.API.SetDraft)
+	case jsoncmd.ReqGetDrafts:
+		return jsoncmd.GetDrafts.RunCtx(ctx, req.Data, h.API.GetDrafts)
+	case jsoncmd.ReqGetImagePacks:
+		return jsoncmd.GetImagePacks.RunCtx(ctx, req.Data, h.API.GetImagePacks)
+	case jsoncmd.ReqCreateImagePack:
+		return jsoncmd.CreateImagePack.RunCtx(ctx, req.Data, h.API.CreateImagePack)
+	case jsoncmd.ReqSetPackImage:
+		return jsoncmd.SetPackImage.RunCtx(ctx, req.Data, h.API.SetPackImage)
+	case jsoncmd.ReqRenamePackImage:
+		return jsoncmd.RenamePackImage.RunCtx(ctx, req.Data, h.API.RenamePackImage)
+	case jsoncmd.ReqDeletePackImage:
+		return jsoncmd.DeletePackImage.RunCtx(ctx, req.Data, h.API.DeletePackImage)
+	case jsoncmd.ReqSetImagePackEnabled:
+		return jsoncmd.SetImagePackEnabled.RunCtx(ctx, req.Data, h.API.SetImagePackEnabled)
+	case jsoncmd.ReqSendSticker:
+		return jsoncmd.SendSticker.RunCtx(ctx, req.Data, h.API.SendSticker)
+	case jsoncmd.ReqSendLocation:
+		return jsoncmd.SendLocation.RunCtx(ctx, req.Data, h.API.SendLocation)
+	case jsoncmd.ReqStartLiveLocation:
+		return jsoncmd.StartLiveLocation.RunCtx(ctx, req.Data, h.API.StartLiveLocation)
+	case jsoncmd.ReqUpdateLiveLocation:
+		return jsoncmd.UpdateLiveLocation.RunCtx(ctx, req.Data, h.API.UpdateLiveLocation)
+	case jsoncmd.ReqStopLiveLocation:
+		return jsoncmd.StopLiveLocation.RunCtx(ctx, req.Data, h.API.StopLiveLocation)
+	case jsoncmd.ReqGetSnippets:
+		return jsoncmd.GetSnippets.RunCtx(ctx, req.Data, h.API.GetSnippets)
+	case jsoncmd.ReqSetSnippet:
+		return jsoncmd.SetSnippet.RunCtx(ctx, req.Data, h.API.SetSnippet)
+	case jsoncmd.ReqCompleteCommand:
+		return jsoncmd.CompleteCommand.RunCtx(ctx, req.Data, h.API.CompleteCommand)
+	case jsoncmd.ReqTranslateEvent:
+		return jsoncmd.TranslateEvent.RunCtx(ctx, req.Data, h.API.TranslateEvent)
+	case jsoncmd.ReqSpellCheck:
+		return jsoncmd.SpellCheck.RunCtx(ctx, req.Data, h.API.SpellCheck)
 	default:
 		return nil, fmt.Errorf("unknown command %q", req.Command)
 	}
@@ -201,7 +275,10 @@ func (h *JSONAPI) GetState(ctx context.Context) (*jsoncmd.ClientState, error) {
 }
 
 func (h *JSONAPI) SendMessage(ctx context.Context, params *jsoncmd.SendMessageParams) (*database.Event, error) {
-	return h.HiClient.SendMessage(ctx, params.RoomID, params.BaseContent, params.Extra, params.Text, params.RelatesTo, params.Mentions, params.URLPreviews)
+	return h.HiClient.SendMessage(
+		ctx, params.RoomID, params.BaseContent, params.Extra, params.Text,
+		params.RelatesTo, params.Mentions, params.URLPreviews, params.Override,
+	)
 }
 
 func (h *JSONAPI) SendEvent(ctx context.Context, params *jsoncmd.SendEventParams) (*database.Event, error) {
@@ -387,6 +464,13 @@ func (h *JSONAPI) GetRelatedEvents(ctx context.Context, params *jsoncmd.GetRelat
 	return nonNilArray(h.DB.Event.GetRelatedEvents(ctx, params.RoomID, params.EventID, params.RelationType, params.EventType))
 }
 
+func (h *JSONAPI) GetThreadReplies(ctx context.Context, params *jsoncmd.GetThreadRepliesParams) ([]*database.Event, error) {
+	if params.Limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive")
+	}
+	return nonNilArray(h.DB.Event.GetThreadReplies(ctx, params.RoomID, params.Limit))
+}
+
 func (h *JSONAPI) GetStickyEvents(ctx context.Context, params *jsoncmd.GetStickyEventsParams) ([]*database.Event, error) {
 	return nonNilArray(h.DB.Event.GetActiveSticky(ctx, params.RoomID))
 }
@@ -407,6 +491,10 @@ func (h *JSONAPI) GetReceipts(ctx context.Context, params *jsoncmd.GetReceiptsPa
 	return h.HiClient.GetReceipts(ctx, params.RoomID, params.EventIDs)
 }
 
+func (h *JSONAPI) GetOwnReceipts(ctx context.Context, params *jsoncmd.GetOwnReceiptsParams) ([]*database.Receipt, error) {
+	return nonNilArray(h.DB.Receipt.GetOwn(ctx, params.RoomID, h.Account.UserID))
+}
+
 func (h *JSONAPI) Paginate(ctx context.Context, params *jsoncmd.PaginateParams) (*jsoncmd.PaginationResponse, error) {
 	return h.HiClient.Paginate(ctx, params.RoomID, params.MaxTimelineID, params.Limit, params.Reset)
 }
@@ -771,6 +859,150 @@ func (h *JSONAPI) RerequestSession(ctx context.Context, params *jsoncmd.Rereques
 	return nil
 }
 
+func (h *JSONAPI) UpgradeRoom(ctx context.Context, params *jsoncmd.UpgradeRoomParams) (*jsoncmd.UpgradeRoomResponse, error) {
+	newRoomID, err := h.HiClient.UpgradeRoom(mautrix.WithMaxRetries(ctx, 0), params.RoomID, params.NewVersion, params.AdditionalCreators)
+	if err != nil {
+		return nil, err
+	}
+	return &jsoncmd.UpgradeRoomResponse{ReplacementRoom: newRoomID}, nil
+}
+
+func (h *JSONAPI) BulkRedact(ctx context.Context, params *jsoncmd.BulkRedactParams) (*jsoncmd.ModerationJobResponse, error) {
+	return h.HiClient.BulkRedact(ctx, params.UserID, params.RoomID, params.Limit, params.Reason)
+}
+
+func (h *JSONAPI) BulkBan(ctx context.Context, params *jsoncmd.BulkBanParams) (*jsoncmd.ModerationJobResponse, error) {
+	return h.HiClient.BulkBan(ctx, params.SpaceID, params.UserID, params.Reason)
+}
+
+func (h *JSONAPI) BulkKick(ctx context.Context, params *jsoncmd.BulkKickParams) (*jsoncmd.ModerationJobResponse, error) {
+	return h.HiClient.BulkKick(ctx, params.RoomID, params.Pattern, params.Reason)
+}
+
+func (h *JSONAPI) CancelModerationJob(ctx context.Context, params *jsoncmd.CancelModerationJobParams) (bool, error) {
+	return h.HiClient.CancelModerationJob(params.JobID), nil
+}
+
+func (h *JSONAPI) GetPolicyLists(ctx context.Context) ([]*database.PolicyList, error) {
+	return nonNilArray(h.DB.PolicyList.GetAll(ctx))
+}
+
+func (h *JSONAPI) SetPolicyList(ctx context.Context, params *jsoncmd.SetPolicyListParams) error {
+	return h.HiClient.SetPolicyListSubscription(ctx, params.RoomID, params.Subscribe, params.AutoBan)
+}
+
+func (h *JSONAPI) GetPolicyMatches(ctx context.Context, params *jsoncmd.GetPolicyMatchesParams) (map[id.UserID]*database.PolicyRule, error) {
+	return h.HiClient.GetPolicyMatches(ctx, params.RoomID)
+}
+
+func (h *JSONAPI) GetPolicyReport(ctx context.Context) ([]*jsoncmd.PolicyBanTarget, error) {
+	return nonNilArray(h.HiClient.GetPolicyReport(ctx))
+}
+
+func (h *JSONAPI) ApplyPolicyBans(ctx context.Context) (*jsoncmd.ModerationJobResponse, error) {
+	return h.HiClient.ApplyPolicyBans(ctx)
+}
+
+func (h *JSONAPI) GetIgnoredUsers(ctx context.Context) ([]id.UserID, error) {
+	return h.HiClient.GetIgnoredUsers(ctx), nil
+}
+
+func (h *JSONAPI) SetIgnored(ctx context.Context, params *jsoncmd.SetIgnoredParams) error {
+	return h.HiClient.SetIgnored(ctx, params.UserID, params.Ignored)
+}
+
+func (h *JSONAPI) GetInviteRules(ctx context.Context) (*jsoncmd.InviteRules, error) {
+	return h.HiClient.GetInviteRules(ctx), nil
+}
+
+func (h *JSONAPI) SetInviteRules(ctx context.Context, params *jsoncmd.InviteRules) error {
+	return h.HiClient.SetInviteRules(ctx, params)
+}
+
+func (h *JSONAPI) RejectInvites(ctx context.Context, params *jsoncmd.RejectInvitesParams) (*jsoncmd.RejectInvitesResponse, error) {
+	return h.HiClient.RejectInvites(ctx, params.RoomIDs, params.Quarantined)
+}
+
+func (h *JSONAPI) VotePoll(ctx context.Context, params *jsoncmd.VotePollParams) (*database.Event, error) {
+	return h.HiClient.VotePoll(ctx, params.RoomID, params.EventID, params.Answers)
+}
+
+func (h *JSONAPI) EndPoll(ctx context.Context, params *jsoncmd.EndPollParams) (*database.Event, error) {
+	return h.HiClient.EndPoll(ctx, params.RoomID, params.EventID)
+}
+
+func (h *JSONAPI) SetDraft(ctx context.Context, params *database.Draft) error {
+	return h.HiClient.SetDraft(ctx, params)
+}
+
+func (h *JSONAPI) GetDrafts(ctx context.Context) ([]*database.Draft, error) {
+	return nonNilArray(h.DB.Draft.GetAll(ctx))
+}
+
+func (h *JSONAPI) GetImagePacks(ctx context.Context, params *jsoncmd.GetImagePacksParams) ([]*jsoncmd.ImagePackInfo, error) {
+	return nonNilArray(h.HiClient.GetImagePacks(ctx, params.RoomID))
+}
+
+func (h *JSONAPI) CreateImagePack(ctx context.Context, params *jsoncmd.CreateImagePackParams) error {
+	return h.HiClient.CreateImagePack(ctx, params.RoomID, params.PackID, &params.Pack)
+}
+
+func (h *JSONAPI) SetPackImage(ctx context.Context, params *jsoncmd.SetPackImageParams) error {
+	return h.HiClient.SetPackImage(ctx, params.RoomID, params.PackID, params.Shortcode, params.Image)
+}
+
+func (h *JSONAPI) RenamePackImage(ctx context.Context, params *jsoncmd.RenamePackImageParams) error {
+	return h.HiClient.RenamePackImage(ctx, params.RoomID, params.PackID, params.Shortcode, params.NewShortcode)
+}
+
+func (h *JSONAPI) DeletePackImage(ctx context.Context, params *jsoncmd.DeletePackImageParams) error {
+	return h.HiClient.DeletePackImage(ctx, params.RoomID, params.PackID, params.Shortcode)
+}
+
+func (h *JSONAPI) SetImagePackEnabled(ctx context.Context, params *jsoncmd.SetImagePackEnabledParams) error {
+	return h.HiClient.SetImagePackEnabled(ctx, params.RoomID, params.PackID, params.Enabled)
+}
+
+func (h *JSONAPI) SendSticker(ctx context.Context, params *jsoncmd.SendStickerParams) (*database.Event, error) {
+	return h.HiClient.SendSticker(ctx, params)
+}
+
+func (h *JSONAPI) SendLocation(ctx context.Context, params *jsoncmd.SendLocationParams) (*database.Event, error) {
+	return h.HiClient.SendLocation(ctx, params)
+}
+
+func (h *JSONAPI) StartLiveLocation(ctx context.Context, params *jsoncmd.StartLiveLocationParams) (id.EventID, error) {
+	return h.HiClient.StartLiveLocation(ctx, params)
+}
+
+func (h *JSONAPI) UpdateLiveLocation(ctx context.Context, params *jsoncmd.UpdateLiveLocationParams) error {
+	return h.HiClient.UpdateLiveLocation(params)
+}
+
+func (h *JSONAPI) StopLiveLocation(ctx context.Context, params *jsoncmd.StopLiveLocationParams) error {
+	return h.HiClient.StopLiveLocation(params.RoomID)
+}
+
+func (h *JSONAPI) GetSnippets(ctx context.Context) (*jsoncmd.Snippets, error) {
+	return h.HiClient.GetSnippets(ctx)
+}
+
+func (h *JSONAPI) SetSnippet(ctx context.Context, params *jsoncmd.SetSnippetParams) error {
+	return h.HiClient.SetSnippet(ctx, params.Name, params.Snippet)
+}
+
+func (h *JSONAPI) CompleteCommand(ctx context.Context, params *jsoncmd.CompleteCommandParams) (*jsoncmd.CommandCompletion, error) {
+	return h.HiClient.CompleteCommand(ctx, params.RoomID, params.Text)
+}
+
+func (h *JSONAPI) TranslateEvent(ctx context.Context, params *jsoncmd.TranslateEventParams) (*database.Event, error) {
+	return h.HiClient.TranslateEvent(ctx, params.RoomID, params.EventID, params.Language)
+}
+
+func (h *JSONAPI) SpellCheck(ctx context.Context, params *jsoncmd.SpellCheckParams) (*jsoncmd.SpellCheckResponse, error) {
+	return h.HiClient.SpellCheck(ctx, params.Text, params.Language)
+}
+
 func nonNilArray[T any](arr []T, err error) ([]T, error) {
 	if arr == nil && err == nil {
 		return []T{}, nil
@@ -66,10 +66,12 @@ const (
 	ReqSearchServer             Name = "search_server"
 	ReqGetMentions              Name = "get_mentions"
 	ReqGetRelatedEvents         Name = "get_related_events"
+	ReqGetThreadReplies         Name = "get_thread_replies"
 	ReqGetStickyEvents          Name = "get_sticky_events"
 	ReqGetRoomState             Name = "get_room_state"
 	ReqGetSpecificRoomState     Name = "get_specific_room_state"
 	ReqGetReceipts              Name = "get_receipts"
+	ReqGetOwnReceipts           Name = "get_own_receipts"
 	ReqPaginate                 Name = "paginate"
 	ReqGetRoomSummary           Name = "get_room_summary"
 	ReqGetSpaceHierarchy        Name = "get_space_hierarchy"
@@ -107,6 +109,41 @@ const (
 	ReqGetMediaConfig           Name = "get_media_config"
 	ReqCalculateRoomID          Name = "calculate_room_id"
 	ReqRerequestSession         Name = "rerequest_session"
+	ReqUpgradeRoom              Name = "upgrade_room"
+	ReqBulkRedact               Name = "bulk_redact"
+	ReqBulkBan                  Name = "bulk_ban"
+	ReqBulkKick                 Name = "bulk_kick"
+	ReqCancelModerationJob      Name = "cancel_moderation_job"
+	ReqGetPolicyLists           Name = "get_policy_lists"
+	ReqSetPolicyList            Name = "set_policy_list"
+	ReqGetPolicyMatches         Name = "get_policy_matches"
+	ReqGetPolicyReport          Name = "get_policy_report"
+	ReqApplyPolicyBans          Name = "apply_policy_bans"
+	ReqGetIgnoredUsers          Name = "get_ignored_users"
+	ReqSetIgnored               Name = "set_ignored"
+	ReqGetInviteRules           Name = "get_invite_rules"
+	ReqSetInviteRules           Name = "set_invite_rules"
+	ReqRejectInvites            Name = "reject_invites"
+	ReqVotePoll                 Name = "vote_poll"
+	ReqEndPoll                  Name = "end_poll"
+	ReqSetDraft                 Name = "set_draft"
+	ReqGetDrafts                Name = "get_drafts"
+	ReqGetImagePacks            Name = "get_image_packs"
+	ReqCreateImagePack          Name = "create_image_pack"
+	ReqSetPackImage             Name = "set_pack_image"
+	ReqRenamePackImage          Name = "rename_pack_image"
+	ReqDeletePackImage          Name = "delete_pack_image"
+	ReqSetImagePackEnabled      Name = "set_image_pack_enabled"
+	ReqSendSticker              Name = "send_sticker"
+	ReqSendLocation             Name = "send_location"
+	ReqStartLiveLocation        Name = "start_live_location"
+	ReqUpdateLiveLocation       Name = "update_live_location"
+	ReqStopLiveLocation         Name = "stop_live_location"
+	ReqGetSnippets              Name = "get_snippets"
+	ReqSetSnippet               Name = "set_snippet"
+	ReqCompleteCommand          Name = "complete_command"
+	ReqTranslateEvent           Name = "translate_event"
+	ReqSpellCheck               Name = "spell_check"
 
 	ReqGetAccountInfo Name = "get_account_info"
 	ReqUploadMedia    Name = "upload_media"
@@ -120,15 +157,17 @@ const (
 	ReqPing  Name = "ping"
 	RespPong Name = "pong"
 
-	EventSyncComplete    Name = "sync_complete"
-	EventSyncStatus      Name = "sync_status"
-	EventEventsDecrypted Name = "events_decrypted"
-	EventTyping          Name = "typing"
-	EventSendComplete    Name = "send_complete"
-	EventClientState     Name = "client_state"
-	EventImageAuthToken  Name = "image_auth_token"
-	EventInitComplete    Name = "init_complete"
-	EventRunID           Name = "run_id"
+	EventSyncComplete       Name = "sync_complete"
+	EventSyncStatus         Name = "sync_status"
+	EventEventsDecrypted    Name = "events_decrypted"
+	EventTyping             Name = "typing"
+	EventSendComplete       Name = "send_complete"
+	EventClientState        Name = "client_state"
+	EventImageAuthToken     Name = "image_auth_token"
+	EventInitComplete       Name = "init_complete"
+	EventRunID              Name = "run_id"
+	EventModerationProgress Name = "moderation_progress"
+	EventDraftUpdated       Name = "draft_updated"
 )
 
 // Frontend -> backend request specs
@@ -210,6 +249,9 @@ var (
 	// GetRelatedEvents returns events related to a given event from the database (e.g. reactions,
 	// edits, replies depending on relation type). This will not call the homeserver.
 	GetRelatedEvents = &CommandSpec[*GetRelatedEventsParams, []*database.Event]{Name: ReqGetRelatedEvents}
+	// GetThreadReplies returns the most recent thread replies in a room across all threads, sorted by
+	// timestamp in descending order. This will not call the homeserver.
+	GetThreadReplies = &CommandSpec[*GetThreadRepliesParams, []*database.Event]{Name: ReqGetThreadReplies}
 	// GetStickyEvents returns active sticky events in the given room. This will not call the homeserver.
 	GetStickyEvents = &CommandSpec[*GetStickyEventsParams, []*database.Event]{Name: ReqGetStickyEvents}
 	// GetRoomState returns full room state, optionally after fetching it from the homeserver.
@@ -219,6 +261,9 @@ var (
 	GetSpecificRoomState = &CommandSpec[*GetSpecificRoomStateParams, []*database.Event]{Name: ReqGetSpecificRoomState}
 	// GetReceipts returns read receipts for a set of event IDs. This will not call the homeserver.
 	GetReceipts = &CommandSpec[*GetReceiptsParams, map[id.EventID][]*database.Receipt]{Name: ReqGetReceipts}
+	// GetOwnReceipts returns the current user's public and private read receipts in a room, including
+	// threaded receipts. This will not call the homeserver.
+	GetOwnReceipts = &CommandSpec[*GetOwnReceiptsParams, []*database.Receipt]{Name: ReqGetOwnReceipts}
 	// Paginate returns older messages in the timeline. This will return locally cached timelines
 	// if available and fetch more from the homeserver if needed.
 	Paginate = &CommandSpec[*PaginateParams, *PaginationResponse]{Name: ReqPaginate}
@@ -328,6 +373,102 @@ var (
 	CalculateRoomID = &CommandSpec[*CalculateRoomIDParams, id.RoomID]{Name: ReqCalculateRoomID}
 	// RerequestSession re-requests a given Megolm session from the key backup and from other devices.
 	RerequestSession = &CommandSpecWithoutResponse[*RerequestSessionParams]{Name: ReqRerequestSession}
+	// UpgradeRoom upgrades a room to a new room version. In addition to what the server does,
+	// this copies space parents and other missing state to the new room, invites members
+	// if the room is private and replaces the room in any spaces it was in.
+	UpgradeRoom = &CommandSpec[*UpgradeRoomParams, *UpgradeRoomResponse]{Name: ReqUpgradeRoom}
+	// BulkRedact starts a background job that redacts recent messages from a user, either in a single room
+	// or in every room where the current user has the power to redact. Progress is reported using
+	// `moderation_progress` events.
+	BulkRedact = &CommandSpec[*BulkRedactParams, *ModerationJobResponse]{Name: ReqBulkRedact}
+	// BulkBan starts a background job that bans a user from a space and every joined room in it
+	// (including subspaces). Progress is reported using `moderation_progress` events.
+	BulkBan = &CommandSpec[*BulkBanParams, *ModerationJobResponse]{Name: ReqBulkBan}
+	// BulkKick starts a background job that kicks every member of a room whose user ID matches a glob pattern.
+	// Progress is reported using `moderation_progress` events.
+	BulkKick = &CommandSpec[*BulkKickParams, *ModerationJobResponse]{Name: ReqBulkKick}
+	// CancelModerationJob stops a running bulk moderation job.
+	// Returns true if the given job ID was found, false otherwise.
+	CancelModerationJob = &CommandSpec[*CancelModerationJobParams, bool]{Name: ReqCancelModerationJob}
+	// GetPolicyLists returns the policy list rooms the user is subscribed to.
+	GetPolicyLists = &CommandSpecWithoutRequest[[]*database.PolicyList]{Name: ReqGetPolicyLists}
+	// SetPolicyList subscribes to or unsubscribes from a policy list room. Subscribed lists are used to flag
+	// users in the timeline and member list, and if auto-ban is enabled, matching users are banned automatically
+	// in rooms where the current user has the power to do so.
+	SetPolicyList = &CommandSpecWithoutResponse[*SetPolicyListParams]{Name: ReqSetPolicyList}
+	// GetPolicyMatches returns the members of a room who match a rule in a subscribed policy list.
+	GetPolicyMatches = &CommandSpec[*GetPolicyMatchesParams, map[id.UserID]*database.PolicyRule]{Name: ReqGetPolicyMatches}
+	// GetPolicyReport returns the bans that `apply_policy_bans` would perform without actually banning anyone.
+	GetPolicyReport = &CommandSpecWithoutRequest[[]*PolicyBanTarget]{Name: ReqGetPolicyReport}
+	// ApplyPolicyBans starts a background job that bans all members who match ban rules in subscribed policy lists,
+	// in every room where the current user has the power to ban them. Progress is reported using
+	// `moderation_progress` events.
+	ApplyPolicyBans = &CommandSpecWithoutRequest[*ModerationJobResponse]{Name: ReqApplyPolicyBans}
+	// GetIgnoredUsers returns the list of users in the `m.ignored_user_list` account data.
+	GetIgnoredUsers = &CommandSpecWithoutRequest[[]id.UserID]{Name: ReqGetIgnoredUsers}
+	// SetIgnored adds a user to the ignore list or removes them from it. Events from ignored users are
+	// hidden from pagination and sync responses, and invites from them are rejected automatically.
+	SetIgnored = &CommandSpecWithoutResponse[*SetIgnoredParams]{Name: ReqSetIgnored}
+	// GetInviteRules returns the rules used to filter incoming invites.
+	GetInviteRules = &CommandSpecWithoutRequest[*InviteRules]{Name: ReqGetInviteRules}
+	// SetInviteRules replaces the rules used to filter incoming invites. The rules are stored in account data.
+	// Invites from blocked users or servers are rejected automatically, while invites that fail the mutual room
+	// requirement are stored with the `quarantined` flag set so the frontend can show them separately.
+	SetInviteRules = &CommandSpecWithoutResponse[*InviteRules]{Name: ReqSetInviteRules}
+	// RejectInvites rejects multiple invites at once.
+	RejectInvites = &CommandSpec[*RejectInvitesParams, *RejectInvitesResponse]{Name: ReqRejectInvites}
+	// VotePoll sends a response to a poll. The answers are validated against the poll's options and max selections.
+	// Current tallies are included in poll events as the `poll_tally` field.
+	VotePoll = &CommandSpec[*VotePollParams, *database.Event]{Name: ReqVotePoll}
+	// EndPoll ends a poll. Only the poll's sender and users who can redact events can end polls.
+	EndPoll = &CommandSpec[*EndPollParams, *database.Event]{Name: ReqEndPoll}
+	// SetDraft stores the composer draft of a room or thread. Sending an empty draft deletes the stored one.
+	// The change is broadcast to all connected clients as a `draft_updated` event.
+	SetDraft = &CommandSpecWithoutResponse[*database.Draft]{Name: ReqSetDraft}
+	// GetDrafts returns all stored drafts, most recently updated first.
+	GetDrafts = &CommandSpecWithoutRequest[[]*database.Draft]{Name: ReqGetDrafts}
+	// GetImagePacks returns the image packs usable in a room: the user's personal pack,
+	// the packs defined in the room itself and the room packs that have been enabled globally.
+	GetImagePacks = &CommandSpec[*GetImagePacksParams, []*ImagePackInfo]{Name: ReqGetImagePacks}
+	// CreateImagePack creates an empty image pack in room state, or the personal pack in account data if no room is specified.
+	CreateImagePack = &CommandSpecWithoutResponse[*CreateImagePackParams]{Name: ReqCreateImagePack}
+	// SetPackImage adds an image to a pack or replaces an existing one with the same shortcode.
+	// The personal pack is created automatically if it doesn't exist yet.
+	// The image must be uploaded first using `upload_media` (or the /upload API for HTTP clients).
+	SetPackImage = &CommandSpecWithoutResponse[*SetPackImageParams]{Name: ReqSetPackImage}
+	// RenamePackImage changes the shortcode of an image in a pack.
+	RenamePackImage = &CommandSpecWithoutResponse[*RenamePackImageParams]{Name: ReqRenamePackImage}
+	// DeletePackImage removes an image from a pack.
+	DeletePackImage = &CommandSpecWithoutResponse[*DeletePackImageParams]{Name: ReqDeletePackImage}
+	// SetImagePackEnabled enables or disables a room's image pack globally, so it can be used in all rooms.
+	SetImagePackEnabled = &CommandSpecWithoutResponse[*SetImagePackEnabledParams]{Name: ReqSetImagePackEnabled}
+	// SendSticker sends an image from a pack as an `m.sticker` event. The image's URL, info and body
+	// are taken from the locally stored pack content.
+	SendSticker = &CommandSpec[*SendStickerParams, *database.Event]{Name: ReqSendSticker}
+	// SendLocation sends a static `m.location` message with a geo URI.
+	SendLocation = &CommandSpec[*SendLocationParams, *database.Event]{Name: ReqSendLocation}
+	// StartLiveLocation starts sharing the user's live location in a room (MSC3489) and returns the beacon_info event ID.
+	// The backend posts the positions provided with `update_live_location` until the timeout expires or the share is stopped.
+	StartLiveLocation = &CommandSpec[*StartLiveLocationParams, id.EventID]{Name: ReqStartLiveLocation}
+	// UpdateLiveLocation sets the current position for an active live location share.
+	// Updates are rate limited, so clients can call this whenever their position changes.
+	UpdateLiveLocation = &CommandSpecWithoutResponse[*UpdateLiveLocationParams]{Name: ReqUpdateLiveLocation}
+	// StopLiveLocation stops sharing live location in a room.
+	StopLiveLocation = &CommandSpecWithoutResponse[*StopLiveLocationParams]{Name: ReqStopLiveLocation}
+	// GetSnippets returns the message snippets stored in account data. Snippets are sent with the `/snippet` command.
+	GetSnippets = &CommandSpecWithoutRequest[*Snippets]{Name: ReqGetSnippets}
+	// SetSnippet creates, replaces or deletes a message snippet.
+	SetSnippet = &CommandSpecWithoutResponse[*SetSnippetParams]{Name: ReqSetSnippet}
+	// CompleteCommand returns completions and validation errors for a partially typed slash command.
+	// Both built-in commands and MSC4332 bot commands defined in the room are supported.
+	CompleteCommand = &CommandSpec[*CompleteCommandParams, *CommandCompletion]{Name: ReqCompleteCommand}
+	// TranslateEvent translates the plaintext body of an event using the configured translator.
+	// The translation is stored in `local_content.translation` and the updated event is returned
+	// and also sent to all clients in an `events_decrypted` event.
+	TranslateEvent = &CommandSpec[*TranslateEventParams, *database.Event]{Name: ReqTranslateEvent}
+	// SpellCheck checks the spelling of composer text using hunspell dictionaries in the config directory.
+	// Code, links, mentions and URLs are skipped, as are words in the user's own word list (see [AccountDataSpellCheck]).
+	SpellCheck = &CommandSpec[*SpellCheckParams, *SpellCheckResponse]{Name: ReqSpellCheck}
 )
 
 // FFI-specific command specs
@@ -368,6 +509,11 @@ var (
 	SpecClientState = &EventSpec[*ClientState]{Name: EventClientState}
 	// SpecInitComplete is emitted after all post-connect payloads have been dispatched.
 	SpecInitComplete = &EventSpec[InitComplete]{Name: EventInitComplete}
+	// SpecModerationProgress is emitted when a bulk moderation job starts, after each action in the job
+	// and when the job finishes.
+	SpecModerationProgress = &EventSpec[*ModerationProgress]{Name: EventModerationProgress}
+	// SpecDraftUpdated is emitted when a draft is changed by any connected client.
+	SpecDraftUpdated = &EventSpec[*DraftUpdated]{Name: EventDraftUpdated}
 )
 
 // Websocket-specific backend -> frontend event specs
@@ -409,10 +555,12 @@ var AllNames = []Name{
 	ReqSearchServer,
 	ReqGetMentions,
 	ReqGetRelatedEvents,
+	ReqGetThreadReplies,
 	ReqGetStickyEvents,
 	ReqGetRoomState,
 	ReqGetSpecificRoomState,
 	ReqGetReceipts,
+	ReqGetOwnReceipts,
 	ReqPaginate,
 	ReqGetRoomSummary,
 	ReqGetSpaceHierarchy,
@@ -447,6 +595,41 @@ var AllNames = []Name{
 	ReqGetMediaConfig,
 	ReqCalculateRoomID,
 	ReqRerequestSession,
+	ReqUpgradeRoom,
+	ReqBulkRedact,
+	ReqBulkBan,
+	ReqBulkKick,
+	ReqCancelModerationJob,
+	ReqGetPolicyLists,
+	ReqSetPolicyList,
+	ReqGetPolicyMatches,
+	ReqGetPolicyReport,
+	ReqApplyPolicyBans,
+	ReqGetIgnoredUsers,
+	ReqSetIgnored,
+	ReqGetInviteRules,
+	ReqSetInviteRules,
+	ReqRejectInvites,
+	ReqVotePoll,
+	ReqEndPoll,
+	ReqSetDraft,
+	ReqGetDrafts,
+	ReqGetImagePacks,
+	ReqCreateImagePack,
+	ReqSetPackImage,
+	ReqRenamePackImage,
+	ReqDeletePackImage,
+	ReqSetImagePackEnabled,
+	ReqSendSticker,
+	ReqSendLocation,
+	ReqStartLiveLocation,
+	ReqUpdateLiveLocation,
+	ReqStopLiveLocation,
+	ReqGetSnippets,
+	ReqSetSnippet,
+	ReqCompleteCommand,
+	ReqTranslateEvent,
+	ReqSpellCheck,
 	ReqGetAccountInfo,
 	ReqUploadMedia,
 	ReqDownloadMedia,
@@ -465,4 +648,6 @@ var AllNames = []Name{
 	EventImageAuthToken,
 	EventInitComplete,
 	EventRunID,
+	EventModerationProgress,
+	EventDraftUpdated,
 }
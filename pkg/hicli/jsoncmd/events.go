@@ -33,6 +33,10 @@ func EventTypeName(evt any) Name {
 		return EventClientState
 	case *InitComplete:
 		return EventInitComplete
+	case *ModerationProgress:
+		return EventModerationProgress
+	case *DraftUpdated:
+		return EventDraftUpdated
 	default:
 		panic(fmt.Errorf("unknown event type %T", evt))
 	}
@@ -178,6 +182,36 @@ type ClientState struct {
 	AvatarURL   id.ContentURIString `json:"avatar_url,omitempty"`
 }
 
+type ModerationAction string
+
+const (
+	ModerationActionRedact ModerationAction = "redact"
+	ModerationActionBan    ModerationAction = "ban"
+	ModerationActionKick   ModerationAction = "kick"
+)
+
+type ModerationProgress struct {
+	JobID  int64            `json:"job_id"`
+	Action ModerationAction `json:"action"`
+	// The total number of actions in the job.
+	Total int `json:"total"`
+	// The number of actions that have been attempted so far, including failed ones.
+	Done   int `json:"done"`
+	Failed int `json:"failed"`
+	// The error message from the most recent failed action.
+	Error string `json:"error,omitempty"`
+	// Set in the last event of the job.
+	Finished  bool `json:"finished,omitempty"`
+	Cancelled bool `json:"cancelled,omitempty"`
+}
+
+type DraftUpdated struct {
+	RoomID     id.RoomID  `json:"room_id"`
+	ThreadRoot id.EventID `json:"thread_root,omitempty"`
+	// The new draft, or null if the draft was deleted.
+	Draft *database.Draft `json:"draft"`
+}
+
 type ImageAuthToken string
 
 type InitComplete struct{}
@@ -28,14 +28,17 @@ type GomuksAPI interface {
 	GetMutualRooms(ctx context.Context, params *GetMutualRoomsParams) (*mautrix.RespMutualRooms, error)
 	TrackUserDevices(ctx context.Context, params *GetProfileParams) (*ProfileEncryptionInfo, error)
 	GetProfileEncryptionInfo(ctx context.Context, params *GetProfileParams) (*ProfileEncryptionInfo, error)
+	ResetMasterKeyTOFU(ctx context.Context, params *ResetMasterKeyTOFUParams) (*ProfileEncryptionInfo, error)
 	GetOwnDevices(ctx context.Context) (*GetOwnDevicesResponse, error)
 	GetEvent(ctx context.Context, params *GetEventParams) (*database.Event, error)
 	GetEventByRowID(ctx context.Context, params *GetEventByRowIDParams) (*database.Event, error)
 	GetRelatedEvents(ctx context.Context, params *GetRelatedEventsParams) ([]*database.Event, error)
+	GetThreadReplies(ctx context.Context, params *GetThreadRepliesParams) ([]*database.Event, error)
 	GetEventContext(ctx context.Context, params *GetEventContextParams) (*EventContextResponse, error)
 	GetRoomState(ctx context.Context, params *GetRoomStateParams) ([]*database.Event, error)
 	GetSpecificRoomState(ctx context.Context, params *GetSpecificRoomStateParams) ([]*database.Event, error)
 	GetReceipts(ctx context.Context, params *GetReceiptsParams) (map[id.EventID][]*database.Receipt, error)
+	GetOwnReceipts(ctx context.Context, params *GetOwnReceiptsParams) ([]*database.Receipt, error)
 	Paginate(ctx context.Context, params *PaginateParams) (*PaginationResponse, error)
 	PaginateManual(ctx context.Context, params *PaginateManualParams) (*ManualPaginationResponse, error)
 	SearchLocal(ctx context.Context, params *SearchParams) (*ManualPaginationResponse, error)
@@ -73,4 +76,39 @@ type GomuksAPI interface {
 	GetRTCTransports(ctx context.Context) (*mautrix.RespRTCTransports, error)
 	GetMediaConfig(ctx context.Context) (*mautrix.RespMediaConfig, error)
 	CalculateRoomID(ctx context.Context, params *CalculateRoomIDParams) (id.RoomID, error)
+	UpgradeRoom(ctx context.Context, params *UpgradeRoomParams) (*UpgradeRoomResponse, error)
+	BulkRedact(ctx context.Context, params *BulkRedactParams) (*ModerationJobResponse, error)
+	BulkBan(ctx context.Context, params *BulkBanParams) (*ModerationJobResponse, error)
+	BulkKick(ctx context.Context, params *BulkKickParams) (*ModerationJobResponse, error)
+	CancelModerationJob(ctx context.Context, params *CancelModerationJobParams) (bool, error)
+	GetPolicyLists(ctx context.Context) ([]*database.PolicyList, error)
+	SetPolicyList(ctx context.Context, params *SetPolicyListParams) error
+	GetPolicyMatches(ctx context.Context, params *GetPolicyMatchesParams) (map[id.UserID]*database.PolicyRule, error)
+	GetPolicyReport(ctx context.Context) ([]*PolicyBanTarget, error)
+	ApplyPolicyBans(ctx context.Context) (*ModerationJobResponse, error)
+	GetIgnoredUsers(ctx context.Context) ([]id.UserID, error)
+	SetIgnored(ctx context.Context, params *SetIgnoredParams) error
+	GetInviteRules(ctx context.Context) (*InviteRules, error)
+	SetInviteRules(ctx context.Context, params *InviteRules) error
+	RejectInvites(ctx context.Context, params *RejectInvitesParams) (*RejectInvitesResponse, error)
+	VotePoll(ctx context.Context, params *VotePollParams) (*database.Event, error)
+	EndPoll(ctx context.Context, params *EndPollParams) (*database.Event, error)
+	SetDraft(ctx context.Context, params *database.Draft) error
+	GetDrafts(ctx context.Context) ([]*database.Draft, error)
+	GetImagePacks(ctx context.Context, params *GetImagePacksParams) ([]*ImagePackInfo, error)
+	CreateImagePack(ctx context.Context, params *CreateImagePackParams) error
+	SetPackImage(ctx context.Context, params *SetPackImageParams) error
+	RenamePackImage(ctx context.Context, params *RenamePackImageParams) error
+	DeletePackImage(ctx context.Context, params *DeletePackImageParams) error
+	SetImagePackEnabled(ctx context.Context, params *SetImagePackEnabledParams) error
+	SendSticker(ctx context.Context, params *SendStickerParams) (*database.Event, error)
+	SendLocation(ctx context.Context, params *SendLocationParams) (*database.Event, error)
+	StartLiveLocation(ctx context.Context, params *StartLiveLocationParams) (id.EventID, error)
+	UpdateLiveLocation(ctx context.Context, params *UpdateLiveLocationParams) error
+	StopLiveLocation(ctx context.Context, params *StopLiveLocationParams) error
+	GetSnippets(ctx context.Context) (*Snippets, error)
+	SetSnippet(ctx context.Context, params *SetSnippetParams) error
+	CompleteCommand(ctx context.Context, params *CompleteCommandParams) (*CommandCompletion, error)
+	TranslateEvent(ctx context.Context, params *TranslateEventParams) (*database.Event, error)
+	SpellCheck(ctx context.Context, params *SpellCheckParams) (*SpellCheckResponse, error)
 }
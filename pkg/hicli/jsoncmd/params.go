@@ -44,8 +44,14 @@ type SendMessageParams struct {
 	Mentions *event.Mentions `json:"mentions,omitempty"`
 	// Beeper URL previews to attach to the message.
 	URLPreviews []*event.BeeperLinkPreview `json:"url_previews,omitempty"`
+	// Send the message even if it looks like it contains secrets.
+	Override bool `json:"override,omitempty"`
 }
 
+// ErrCodeSecretDetected is the prefix of the error returned by send_message when the text or
+// attachment filename matches a secret detector and override isn't set.
+const ErrCodeSecretDetected = "FI.MAU.GOMUKS.SECRET_DETECTED"
+
 type SendEventParams struct {
 	RoomID            id.RoomID       `json:"room_id"`
 	EventType         event.Type      `json:"type"`
@@ -172,6 +178,12 @@ type GetRelatedEventsParams struct {
 	EventType    string             `json:"event_type,omitempty"`
 }
 
+type GetThreadRepliesParams struct {
+	RoomID id.RoomID `json:"room_id"`
+	// Maximum number of replies to return. The most recent replies are returned first.
+	Limit int `json:"limit"`
+}
+
 type GetStickyEventsParams struct {
 	RoomID id.RoomID `json:"room_id"`
 }
@@ -332,6 +344,10 @@ type GetReceiptsParams struct {
 	EventIDs []id.EventID `json:"event_ids"`
 }
 
+type GetOwnReceiptsParams struct {
+	RoomID id.RoomID `json:"room_id"`
+}
+
 type MuteRoomParams struct {
 	RoomID id.RoomID `json:"room_id"`
 	Muted  bool      `json:"muted"`
@@ -433,6 +449,215 @@ type RerequestSessionParams struct {
 	Sender    id.UserID    `json:"sender"`
 }
 
+type UpgradeRoomParams struct {
+	RoomID id.RoomID `json:"room_id"`
+	// The room version to upgrade to.
+	NewVersion string `json:"new_version"`
+	// Additional users to make creators of the new room. Only applicable to v12+ rooms.
+	AdditionalCreators []id.UserID `json:"additional_creators,omitempty"`
+}
+
+type BulkRedactParams struct {
+	// The user whose messages should be redacted.
+	UserID id.UserID `json:"user_id"`
+	// The room to redact messages in. If empty, messages in all rooms where the current user
+	// has the power to redact are included.
+	RoomID id.RoomID `json:"room_id,omitempty"`
+	// The maximum number of messages to redact. Defaults to 100.
+	Limit  int    `json:"limit,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type BulkBanParams struct {
+	SpaceID id.RoomID `json:"space_id"`
+	UserID  id.UserID `json:"user_id"`
+	Reason  string    `json:"reason,omitempty"`
+}
+
+type BulkKickParams struct {
+	RoomID id.RoomID `json:"room_id"`
+	// A glob pattern that is matched against member user IDs, e.g. `@spam*:example.com`.
+	Pattern string `json:"pattern"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type CancelModerationJobParams struct {
+	JobID int64 `json:"job_id"`
+}
+
+type SetPolicyListParams struct {
+	RoomID    id.RoomID `json:"room_id"`
+	Subscribe bool      `json:"subscribe"`
+	// Whether bans from the list should be applied automatically. Only used when subscribing.
+	AutoBan bool `json:"auto_ban,omitempty"`
+}
+
+type GetPolicyMatchesParams struct {
+	RoomID id.RoomID `json:"room_id"`
+}
+
+type SetIgnoredParams struct {
+	UserID  id.UserID `json:"user_id"`
+	Ignored bool      `json:"ignored"`
+}
+
+type InviteRules struct {
+	// Glob patterns matched against the user ID of the inviter. Matching invites are rejected automatically.
+	BlockedUsers []string `json:"blocked_users,omitempty"`
+	// Glob patterns matched against the server name of the inviter. Matching invites are rejected automatically.
+	BlockedServers []string `json:"blocked_servers,omitempty"`
+	// If true, invites from users who don't share any rooms with the current user are quarantined.
+	RequireMutualRoom bool `json:"require_mutual_room,omitempty"`
+}
+
+type RejectInvitesParams struct {
+	RoomIDs []id.RoomID `json:"room_ids"`
+	// If true, all quarantined invites are rejected in addition to the ones in `room_ids`.
+	Quarantined bool `json:"quarantined,omitempty"`
+}
+
+type VotePollParams struct {
+	RoomID  id.RoomID  `json:"room_id"`
+	EventID id.EventID `json:"event_id"`
+	// The IDs of the selected answers. An empty list retracts the previous vote.
+	Answers []string `json:"answers"`
+}
+
+type EndPollParams struct {
+	RoomID  id.RoomID  `json:"room_id"`
+	EventID id.EventID `json:"event_id"`
+}
+
+type GetImagePacksParams struct {
+	// The room where the packs will be used. If empty, only the personal and globally enabled packs are returned.
+	RoomID id.RoomID `json:"room_id,omitempty"`
+}
+
+type CreateImagePackParams struct {
+	// The room to create the pack in. If empty, the personal pack is created in account data.
+	RoomID id.RoomID `json:"room_id,omitempty"`
+	// The state key of the pack. Ignored for the personal pack.
+	PackID string                 `json:"pack_id"`
+	Pack   database.ImagePackMeta `json:"pack"`
+}
+
+type SetPackImageParams struct {
+	// The room containing the pack. If empty, the personal pack is used.
+	RoomID id.RoomID `json:"room_id,omitempty"`
+	// The state key of the pack. Ignored for the personal pack.
+	PackID    string                   `json:"pack_id"`
+	Shortcode string                   `json:"shortcode"`
+	Image     *database.ImagePackImage `json:"image"`
+}
+
+type RenamePackImageParams struct {
+	RoomID       id.RoomID `json:"room_id,omitempty"`
+	PackID       string    `json:"pack_id"`
+	Shortcode    string    `json:"shortcode"`
+	NewShortcode string    `json:"new_shortcode"`
+}
+
+type DeletePackImageParams struct {
+	RoomID    id.RoomID `json:"room_id,omitempty"`
+	PackID    string    `json:"pack_id"`
+	Shortcode string    `json:"shortcode"`
+}
+
+type SetImagePackEnabledParams struct {
+	RoomID  id.RoomID `json:"room_id"`
+	PackID  string    `json:"pack_id"`
+	Enabled bool      `json:"enabled"`
+}
+
+type SendStickerParams struct {
+	RoomID id.RoomID `json:"room_id"`
+	// The room containing the pack. If empty, the personal pack is used.
+	PackRoomID id.RoomID `json:"pack_room_id,omitempty"`
+	PackID     string    `json:"pack_id"`
+	Shortcode  string    `json:"shortcode"`
+	// Standard Matrix `m.relates_to` data (replies, threading).
+	RelatesTo *event.RelatesTo `json:"relates_to,omitempty"`
+	Mentions  *event.Mentions  `json:"mentions,omitempty"`
+}
+
+type SendLocationParams struct {
+	RoomID    id.RoomID `json:"room_id"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	// The accuracy of the position in meters.
+	Uncertainty float64          `json:"uncertainty,omitempty"`
+	Description string           `json:"description,omitempty"`
+	RelatesTo   *event.RelatesTo `json:"relates_to,omitempty"`
+}
+
+type StartLiveLocationParams struct {
+	RoomID      id.RoomID `json:"room_id"`
+	Description string    `json:"description,omitempty"`
+	// How long to share the location for in milliseconds. Defaults to 15 minutes.
+	Timeout int64 `json:"timeout,omitempty"`
+}
+
+type UpdateLiveLocationParams struct {
+	RoomID    id.RoomID `json:"room_id"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	// The accuracy of the position in meters.
+	Uncertainty float64 `json:"uncertainty,omitempty"`
+}
+
+type StopLiveLocationParams struct {
+	RoomID id.RoomID `json:"room_id"`
+}
+
+// AccountDataSnippets is the account data event type where snippets are stored. The content is [Snippets].
+var AccountDataSnippets = event.Type{Type: "fi.mau.gomuks.snippets", Class: event.AccountDataEventType}
+
+type Snippet struct {
+	// A markdown template in Go text/template syntax. The available variables are `{{.Args}}`, `{{.ArgList}}`,
+	// `{{.Date}}`, `{{.Time}}`, `{{.RoomName}}` and `{{.Me}}`. Other users can be mentioned with `{{mention "@user:example.com"}}`.
+	Template    string `json:"template"`
+	Description string `json:"description,omitempty"`
+}
+
+type Snippets struct {
+	Snippets map[string]*Snippet `json:"snippets"`
+}
+
+type SetSnippetParams struct {
+	Name string `json:"name"`
+	// The new snippet content. If null, the snippet is deleted.
+	Snippet *Snippet `json:"snippet"`
+}
+
+type CompleteCommandParams struct {
+	RoomID id.RoomID `json:"room_id"`
+	// The text in the composer up to the cursor, including the leading slash.
+	Text string `json:"text"`
+}
+
+type TranslateEventParams struct {
+	RoomID  id.RoomID  `json:"room_id"`
+	EventID id.EventID `json:"event_id"`
+	// The language to translate to. If empty, the default language from the config is used.
+	Language string `json:"language,omitempty"`
+}
+
+// AccountDataSpellCheck is the account data event type where the user's own spell check word list is stored.
+// The content is [SpellCheckWords].
+var AccountDataSpellCheck = event.Type{Type: "fi.mau.gomuks.spellcheck", Class: event.AccountDataEventType}
+
+type SpellCheckWords struct {
+	// Words that are always considered correctly spelled.
+	Words []string `json:"words"`
+}
+
+type SpellCheckParams struct {
+	// The composer text in the same format as send_message input.
+	Text string `json:"text"`
+	// The dictionary to use, e.g. `en_US`. If empty, the first dictionary alphabetically is used.
+	Language string `json:"language,omitempty"`
+}
+
 type OAuthSimpleDeviceCodeParams struct {
 	HomeserverURL string    `json:"homeserver_url"`
 	UserIDHint    id.UserID `json:"user_id_hint,omitempty"`
@@ -10,6 +10,7 @@ import (
 	"go.mau.fi/util/jsonbytes"
 	"maunium.net/go/mautrix"
 	"maunium.net/go/mautrix/crypto/ssss"
+	"maunium.net/go/mautrix/event/cmdschema"
 	"maunium.net/go/mautrix/id"
 	"maunium.net/go/mautrix/oauth"
 
@@ -93,3 +94,75 @@ type GetProfileResponse struct {
 	Profile *mautrix.RespUserProfile `json:"profile"`
 	Bio     *ProfileBio              `json:"bio,omitempty"`
 }
+
+type UpgradeRoomResponse struct {
+	ReplacementRoom id.RoomID `json:"replacement_room"`
+}
+
+type ModerationJobResponse struct {
+	// The ID of the job, used in `moderation_progress` events and for cancelling the job.
+	JobID int64 `json:"job_id"`
+	// The number of actions the job will perform. Rooms where the current user lacks power are not included.
+	Total int `json:"total"`
+}
+
+type RejectInvitesResponse struct {
+	Rejected []id.RoomID `json:"rejected"`
+	// Error messages for invites that couldn't be rejected.
+	Failed map[id.RoomID]string `json:"failed"`
+}
+
+type PolicyBanTarget struct {
+	RoomID id.RoomID `json:"room_id"`
+	UserID id.UserID `json:"user_id"`
+	// The policy rule that matched the user.
+	Rule *database.PolicyRule `json:"rule"`
+}
+
+type ImagePackInfo struct {
+	// The room the pack is defined in. Empty for the personal pack.
+	RoomID id.RoomID `json:"room_id,omitempty"`
+	// The state key of the pack. Empty for the personal pack.
+	PackID string `json:"pack_id"`
+	// Whether the pack is enabled globally. The personal pack is always enabled.
+	Enabled bool                `json:"enabled"`
+	Content *database.ImagePack `json:"content"`
+}
+
+type CommandCompletionItem struct {
+	// The text that should replace the word being completed.
+	Value string `json:"value"`
+	// A human-readable label for the completion, e.g. the displayname of a user.
+	Label string `json:"label,omitempty"`
+}
+
+type CommandCompletion struct {
+	// The command that the input matched. Null if the command name itself is still being typed.
+	Command *cmdschema.EventContent `json:"command,omitempty"`
+	// The user who defines the command. This is `@gomuks` for built-in commands.
+	Source id.UserID `json:"source,omitempty"`
+	// The key of the parameter being typed at the end of the input, if any.
+	Parameter string `json:"parameter,omitempty"`
+	// The byte offset in the input where the word being completed starts.
+	Start int `json:"start"`
+	// Possible values for the word at the end of the input.
+	Completions []*CommandCompletionItem `json:"completions"`
+	// Validation errors for arguments that have already been typed, keyed by parameter key.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+type Misspelling struct {
+	// The byte offsets of the misspelled word in the input text. End is exclusive.
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// The misspelled word itself.
+	Word string `json:"word"`
+	// Possible corrections, best first. Only the last 10 misspellings in the text have suggestions.
+	Suggestions []string `json:"suggestions"`
+}
+
+type SpellCheckResponse struct {
+	// The dictionary that was used.
+	Language     string         `json:"language"`
+	Misspellings []*Misspelling `json:"misspellings"`
+}
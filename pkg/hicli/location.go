@@ -0,0 +1,220 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+)
+
+// liveLocationInterval is the minimum interval between beacons in a live location share.
+// Position updates from the client that arrive faster are coalesced, only the latest one is sent.
+const liveLocationInterval = 5 * time.Second
+
+const defaultLiveLocationTimeout = 15 * time.Minute
+
+var (
+	ErrLiveLocationActive    = errors.New("live location sharing is already active in this room")
+	ErrLiveLocationNotActive = errors.New("live location sharing is not active in this room")
+	ErrInvalidLocation       = errors.New("invalid location")
+)
+
+type liveLocationShare struct {
+	beaconInfoID id.EventID
+	content      map[string]any
+	cancel       context.CancelFunc
+	updated      chan struct{}
+
+	lock    sync.Mutex
+	pending *jsoncmd.UpdateLiveLocationParams
+}
+
+func validateCoordinates(latitude, longitude, uncertainty float64) error {
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 || uncertainty < 0 {
+		return fmt.Errorf("%w: coordinates out of range", ErrInvalidLocation)
+	}
+	return nil
+}
+
+func makeLocationAsset() map[string]any {
+	return map[string]any{"type": "m.self"}
+}
+
+// SendLocation sends a static m.location message. Both the legacy `geo_uri` and the MSC3488 extensible fields are included.
+func (h *HiClient) SendLocation(ctx context.Context, params *jsoncmd.SendLocationParams) (*database.Event, error) {
+	if err := validateCoordinates(params.Latitude, params.Longitude, params.Uncertainty); err != nil {
+		return nil, err
+	}
+	geoURI := database.FormatGeoURI(params.Latitude, params.Longitude, params.Uncertainty)
+	body := fmt.Sprintf("Location: %s", geoURI)
+	if params.Description != "" {
+		body = fmt.Sprintf("Location: %s (%s)", params.Description, geoURI)
+	}
+	location := map[string]any{"uri": geoURI}
+	if params.Description != "" {
+		location["description"] = params.Description
+	}
+	base := &event.MessageEventContent{
+		MsgType: event.MsgLocation,
+		Body:    body,
+		GeoURI:  geoURI,
+	}
+	extra := map[string]any{
+		"org.matrix.msc1767.text":     body,
+		"org.matrix.msc3488.location": location,
+		"org.matrix.msc3488.asset":    makeLocationAsset(),
+		"org.matrix.msc3488.ts":       time.Now().UnixMilli(),
+	}
+	return h.SendMessage(ctx, params.RoomID, base, extra, "", params.RelatesTo, nil, nil, false)
+}
+
+// StartLiveLocation creates a beacon_info state event and starts a background loop that sends the positions
+// provided via [HiClient.UpdateLiveLocation] as beacons until the share expires or is stopped.
+func (h *HiClient) StartLiveLocation(ctx context.Context, params *jsoncmd.StartLiveLocationParams) (id.EventID, error) {
+	timeout := time.Duration(params.Timeout) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultLiveLocationTimeout
+	}
+	h.liveLocationLock.Lock()
+	defer h.liveLocationLock.Unlock()
+	if _, active := h.liveLocations[params.RoomID]; active {
+		return "", ErrLiveLocationActive
+	}
+	content := map[string]any{
+		"live":                     true,
+		"timeout":                  timeout.Milliseconds(),
+		"org.matrix.msc3488.ts":    time.Now().UnixMilli(),
+		"org.matrix.msc3488.asset": makeLocationAsset(),
+	}
+	if params.Description != "" {
+		content["description"] = params.Description
+	}
+	beaconInfoID, err := h.SetState(ctx, params.RoomID, database.StateUnstableBeaconInfo, h.Account.UserID.String(), content)
+	if err != nil {
+		return "", fmt.Errorf("failed to send beacon info: %w", err)
+	}
+	log := zerolog.Ctx(ctx).With().
+		Stringer("room_id", params.RoomID).
+		Stringer("beacon_info_id", beaconInfoID).
+		Logger()
+	// The share outlives the request that started it
+	loopCtx, cancel := context.WithTimeout(log.WithContext(context.WithoutCancel(ctx)), timeout)
+	share := &liveLocationShare{
+		beaconInfoID: beaconInfoID,
+		content:      content,
+		cancel:       cancel,
+		updated:      make(chan struct{}, 1),
+	}
+	h.liveLocations[params.RoomID] = share
+	go h.runLiveLocation(loopCtx, params.RoomID, share)
+	return beaconInfoID, nil
+}
+
+// UpdateLiveLocation sets the latest position of an active live location share.
+func (h *HiClient) UpdateLiveLocation(params *jsoncmd.UpdateLiveLocationParams) error {
+	if err := validateCoordinates(params.Latitude, params.Longitude, params.Uncertainty); err != nil {
+		return err
+	}
+	h.liveLocationLock.Lock()
+	share, ok := h.liveLocations[params.RoomID]
+	h.liveLocationLock.Unlock()
+	if !ok {
+		return ErrLiveLocationNotActive
+	}
+	share.lock.Lock()
+	share.pending = params
+	share.lock.Unlock()
+	select {
+	case share.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// StopLiveLocation stops an active live location share. The beacon_info event is updated in the background.
+func (h *HiClient) StopLiveLocation(roomID id.RoomID) error {
+	h.liveLocationLock.Lock()
+	share, ok := h.liveLocations[roomID]
+	h.liveLocationLock.Unlock()
+	if !ok {
+		return ErrLiveLocationNotActive
+	}
+	share.cancel()
+	return nil
+}
+
+func (h *HiClient) runLiveLocation(ctx context.Context, roomID id.RoomID, share *liveLocationShare) {
+	log := zerolog.Ctx(ctx)
+	log.Info().Msg("Started live location sharing")
+	ticker := time.NewTicker(liveLocationInterval)
+	defer ticker.Stop()
+	var lastSent time.Time
+Loop:
+	for {
+		select {
+		case <-share.updated:
+			if time.Since(lastSent) < liveLocationInterval {
+				// The ticker will send the update later
+				continue
+			}
+		case <-ticker.C:
+		case <-ctx.Done():
+			break Loop
+		}
+		share.lock.Lock()
+		pos := share.pending
+		share.pending = nil
+		share.lock.Unlock()
+		if pos == nil {
+			continue
+		}
+		lastSent = time.Now()
+		err := h.sendBeacon(ctx, roomID, share.beaconInfoID, pos)
+		if err != nil {
+			log.Err(err).Msg("Failed to send beacon")
+		}
+	}
+	h.liveLocationLock.Lock()
+	delete(h.liveLocations, roomID)
+	h.liveLocationLock.Unlock()
+
+	ctx = context.WithoutCancel(ctx)
+	share.content["live"] = false
+	_, err := h.SetState(ctx, roomID, database.StateUnstableBeaconInfo, h.Account.UserID.String(), share.content)
+	if err != nil {
+		log.Err(err).Msg("Failed to mark beacon info as no longer live")
+	} else {
+		log.Info().Msg("Stopped live location sharing")
+	}
+}
+
+func (h *HiClient) sendBeacon(ctx context.Context, roomID id.RoomID, beaconInfoID id.EventID, pos *jsoncmd.UpdateLiveLocationParams) error {
+	content := map[string]any{
+		"m.relates_to": map[string]any{
+			"rel_type": event.RelReference,
+			"event_id": beaconInfoID,
+		},
+		"org.matrix.msc3488.location": map[string]any{
+			"uri": database.FormatGeoURI(pos.Latitude, pos.Longitude, pos.Uncertainty),
+		},
+		"org.matrix.msc3488.ts": time.Now().UnixMilli(),
+	}
+	ctx = mautrix.WithMaxRetries(ctx, h.Client.DefaultHTTPRetries)
+	_, err := h.send(ctx, roomID, database.EventUnstableBeacon, content, "", false, true, true, 0)
+	return err
+}
@@ -0,0 +1,274 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tidwall/gjson"
+	"go.mau.fi/util/glob"
+	"go.mau.fi/util/ptr"
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+)
+
+// moderationActionDelay is the delay between individual actions in bulk moderation jobs.
+// Rate limit errors are still retried by the mautrix client, but spacing out the requests
+// avoids hitting the limits constantly.
+const moderationActionDelay = 500 * time.Millisecond
+
+const defaultBulkRedactLimit = 100
+
+type moderationTask func(ctx context.Context) error
+
+var errModerationJobCancelled = errors.New("job cancelled")
+
+// canModerate checks whether the current user has at least the level returned by requiredLevel in the given room.
+// If target is set, the current user's level must also be higher than the target's.
+func (h *HiClient) canModerate(ctx context.Context, roomID id.RoomID, target id.UserID, requiredLevel func(*event.PowerLevelsEventContent) int) (bool, error) {
+	pl, err := h.ClientStore.GetPowerLevels(ctx, roomID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get power levels of %s: %w", roomID, err)
+	} else if pl == nil {
+		return false, nil
+	}
+	ownLevel := pl.GetUserLevel(h.Account.UserID)
+	if ownLevel < requiredLevel(pl) {
+		return false, nil
+	}
+	return target == "" || ownLevel > pl.GetUserLevel(target), nil
+}
+
+// BulkRedact starts a background job that redacts the most recent messages sent by the given user.
+// If roomID is empty, messages in all rooms where the current user has the power to redact are included.
+func (h *HiClient) BulkRedact(ctx context.Context, userID id.UserID, roomID id.RoomID, limit int, reason string) (*jsoncmd.ModerationJobResponse, error) {
+	if limit <= 0 {
+		limit = defaultBulkRedactLimit
+	}
+	evts, err := h.DB.Event.GetRecentBySender(ctx, userID, roomID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent events: %w", err)
+	}
+	allowedRooms := make(map[id.RoomID]bool)
+	tasks := make([]moderationTask, 0, len(evts))
+	for _, evt := range evts {
+		allowed, checked := allowedRooms[evt.RoomID]
+		if !checked {
+			allowed, err = h.canModerate(ctx, evt.RoomID, "", (*event.PowerLevelsEventContent).Redact)
+			if err != nil {
+				return nil, err
+			}
+			allowedRooms[evt.RoomID] = allowed
+		}
+		if !allowed {
+			continue
+		}
+		tasks = append(tasks, func(ctx context.Context) error {
+			_, err := h.Client.RedactEvent(ctx, evt.RoomID, evt.ID, mautrix.ReqRedact{Reason: reason})
+			return err
+		})
+	}
+	if roomID != "" && !allowedRooms[roomID] && len(evts) > 0 {
+		return nil, fmt.Errorf("you don't have permission to redact events in this room")
+	}
+	return h.startModerationJob(ctx, jsoncmd.ModerationActionRedact, tasks), nil
+}
+
+// BulkBan starts a background job that bans the given user from the space and every room in it,
+// including rooms in subspaces. Rooms where the current user doesn't have the power to ban are skipped.
+func (h *HiClient) BulkBan(ctx context.Context, spaceID id.RoomID, userID id.UserID, reason string) (*jsoncmd.ModerationJobResponse, error) {
+	roomIDs, err := h.collectSpaceRooms(ctx, spaceID)
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]moderationTask, 0, len(roomIDs))
+	for _, roomID := range roomIDs {
+		if allowed, err := h.canModerate(ctx, roomID, userID, (*event.PowerLevelsEventContent).Ban); err != nil {
+			return nil, err
+		} else if !allowed {
+			continue
+		}
+		if memberEvt, err := h.DB.CurrentState.Get(ctx, roomID, event.StateMember, userID.String()); err != nil {
+			return nil, fmt.Errorf("failed to get member event in %s: %w", roomID, err)
+		} else if memberEvt != nil && gjson.GetBytes(memberEvt.Content, "membership").Str == string(event.MembershipBan) {
+			continue
+		}
+		tasks = append(tasks, func(ctx context.Context) error {
+			_, err := h.Client.BanUser(ctx, roomID, &mautrix.ReqBanUser{UserID: userID, Reason: reason})
+			return err
+		})
+	}
+	return h.startModerationJob(ctx, jsoncmd.ModerationActionBan, tasks), nil
+}
+
+// collectSpaceRooms returns the given space and all joined rooms in it, recursing into subspaces.
+func (h *HiClient) collectSpaceRooms(ctx context.Context, spaceID id.RoomID) ([]id.RoomID, error) {
+	visited := map[id.RoomID]struct{}{spaceID: {}}
+	roomIDs := []id.RoomID{spaceID}
+	queue := []id.RoomID{spaceID}
+	for len(queue) > 0 {
+		edges, err := h.DB.SpaceEdge.GetAll(ctx, queue[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to get children of %s: %w", queue[0], err)
+		}
+		queue = queue[1:]
+		for _, children := range edges {
+			for _, child := range children {
+				if _, alreadyVisited := visited[child.ChildID]; alreadyVisited {
+					continue
+				}
+				visited[child.ChildID] = struct{}{}
+				room, err := h.DB.Room.Get(ctx, child.ChildID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get room %s: %w", child.ChildID, err)
+				} else if room == nil {
+					// Not joined
+					continue
+				}
+				roomIDs = append(roomIDs, child.ChildID)
+				if room.GetType() == event.RoomTypeSpace {
+					queue = append(queue, child.ChildID)
+				}
+			}
+		}
+	}
+	return roomIDs, nil
+}
+
+// BulkKick starts a background job that kicks all members of the room whose user ID matches the given glob pattern.
+// Members with a power level equal to or higher than the current user are skipped.
+func (h *HiClient) BulkKick(ctx context.Context, roomID id.RoomID, pattern, reason string) (*jsoncmd.ModerationJobResponse, error) {
+	matcher := glob.Compile(pattern)
+	if matcher == nil {
+		return nil, fmt.Errorf("invalid glob pattern")
+	}
+	room, err := h.DB.Room.Get(ctx, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get room metadata: %w", err)
+	} else if room == nil {
+		return nil, fmt.Errorf("unknown room")
+	} else if err = h.loadMembers(ctx, room); err != nil {
+		return nil, err
+	}
+	pl, err := h.ClientStore.GetPowerLevels(ctx, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get power levels: %w", err)
+	} else if pl == nil {
+		return nil, fmt.Errorf("room has no power levels")
+	}
+	ownLevel := pl.GetUserLevel(h.Account.UserID)
+	if ownLevel < pl.Kick() {
+		return nil, fmt.Errorf("you don't have permission to kick users in this room")
+	}
+	members, err := h.DB.CurrentState.GetMembers(ctx, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get room members: %w", err)
+	}
+	tasks := make([]moderationTask, 0)
+	for _, member := range members {
+		userID := id.UserID(*member.StateKey)
+		switch event.Membership(gjson.GetBytes(member.Content, "membership").Str) {
+		case event.MembershipJoin, event.MembershipInvite, event.MembershipKnock:
+		default:
+			continue
+		}
+		if userID == h.Account.UserID || !matcher.Match(userID.String()) || pl.GetUserLevel(userID) >= ownLevel {
+			continue
+		}
+		tasks = append(tasks, func(ctx context.Context) error {
+			_, err := h.Client.KickUser(ctx, roomID, &mautrix.ReqKickUser{UserID: userID, Reason: reason})
+			return err
+		})
+	}
+	return h.startModerationJob(ctx, jsoncmd.ModerationActionKick, tasks), nil
+}
+
+// CancelModerationJob stops a running bulk moderation job. Returns false if the job wasn't found.
+func (h *HiClient) CancelModerationJob(jobID int64) bool {
+	h.moderationJobsLock.Lock()
+	cancel, ok := h.moderationJobs[jobID]
+	h.moderationJobsLock.Unlock()
+	if ok {
+		cancel(errModerationJobCancelled)
+	}
+	return ok
+}
+
+func (h *HiClient) startModerationJob(ctx context.Context, action jsoncmd.ModerationAction, tasks []moderationTask) *jsoncmd.ModerationJobResponse {
+	resp, run := h.prepareModerationJob(ctx, action, tasks)
+	go run()
+	return resp
+}
+
+// prepareModerationJob registers a bulk moderation job and returns a function that runs it to completion.
+func (h *HiClient) prepareModerationJob(ctx context.Context, action jsoncmd.ModerationAction, tasks []moderationTask) (*jsoncmd.ModerationJobResponse, func()) {
+	jobID := h.moderationJobID.Add(1)
+	log := zerolog.Ctx(ctx).With().
+		Int64("moderation_job_id", jobID).
+		Str("moderation_action", string(action)).
+		Logger()
+	// The job outlives the request that started it, and rate limits should be retried even if the
+	// request context disabled retries.
+	ctx = mautrix.WithMaxRetries(log.WithContext(context.WithoutCancel(ctx)), h.Client.DefaultHTTPRetries)
+	ctx, cancel := context.WithCancelCause(ctx)
+	h.moderationJobsLock.Lock()
+	h.moderationJobs[jobID] = cancel
+	h.moderationJobsLock.Unlock()
+	return &jsoncmd.ModerationJobResponse{JobID: jobID, Total: len(tasks)}, func() {
+		defer func() {
+			h.moderationJobsLock.Lock()
+			delete(h.moderationJobs, jobID)
+			h.moderationJobsLock.Unlock()
+			cancel(nil)
+		}()
+		h.runModerationJob(ctx, &jsoncmd.ModerationProgress{
+			JobID:  jobID,
+			Action: action,
+			Total:  len(tasks),
+		}, tasks)
+	}
+}
+
+func (h *HiClient) runModerationJob(ctx context.Context, progress *jsoncmd.ModerationProgress, tasks []moderationTask) {
+	log := zerolog.Ctx(ctx)
+	log.Info().Int("task_count", len(tasks)).Msg("Starting bulk moderation job")
+	h.EventHandler(ptr.Clone(progress))
+	for i, task := range tasks {
+		if i > 0 {
+			select {
+			case <-time.After(moderationActionDelay):
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			progress.Cancelled = true
+			break
+		}
+		err := task(ctx)
+		progress.Done++
+		if err != nil {
+			log.Err(err).Int("task_index", i).Msg("Bulk moderation action failed")
+			progress.Failed++
+			progress.Error = err.Error()
+		}
+		h.EventHandler(ptr.Clone(progress))
+	}
+	progress.Finished = true
+	h.EventHandler(ptr.Clone(progress))
+	log.Info().
+		Int("done", progress.Done).
+		Int("failed", progress.Failed).
+		Bool("cancelled", progress.Cancelled).
+		Msg("Bulk moderation job finished")
+}
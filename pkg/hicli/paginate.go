@@ -329,6 +329,9 @@ func (h *HiClient) Paginate(ctx context.Context, roomID id.RoomID, maxTimelineID
 			return nil, err
 		}
 	}
+	resp.Events = h.filterIgnoredEvents(ctx, resp.Events)
+	h.FillPollTallies(ctx, resp.Events)
+	h.excludeIgnoredReactions(ctx, roomID, resp.Events)
 	resp.RelatedEvents = make([]*database.Event, 0)
 	eventIDs := make([]id.EventID, len(resp.Events))
 	eventMap := make(map[id.EventID]struct{})
@@ -0,0 +1,382 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"github.com/tidwall/gjson"
+	"go.mau.fi/util/glob"
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+)
+
+const (
+	PolicyRecommendationBan       = "m.ban"
+	PolicyRecommendationLegacyBan = "org.matrix.mjolnir.ban"
+)
+
+type compiledPolicyRule struct {
+	*database.PolicyRule
+	glob    glob.Glob
+	autoBan bool
+}
+
+func (rule *compiledPolicyRule) matches(userID id.UserID, server string) bool {
+	switch rule.EntityType {
+	case database.PolicyEntityTypeUser:
+		return rule.glob.Match(userID.String())
+	case database.PolicyEntityTypeServer:
+		return server != "" && rule.glob.Match(server)
+	default:
+		return false
+	}
+}
+
+func (rule *compiledPolicyRule) isBan() bool {
+	return rule.Recommendation == PolicyRecommendationBan || rule.Recommendation == PolicyRecommendationLegacyBan
+}
+
+type policyRuleSet struct {
+	rules []*compiledPolicyRule
+}
+
+var emptyPolicyRuleSet = &policyRuleSet{}
+
+// Match returns the first rule that matches the given user, or nil if no rules match.
+func (rs *policyRuleSet) Match(userID id.UserID) *database.PolicyRule {
+	if len(rs.rules) == 0 || userID == "" {
+		return nil
+	}
+	server := userID.Homeserver()
+	for _, rule := range rs.rules {
+		if rule.matches(userID, server) {
+			return rule.PolicyRule
+		}
+	}
+	return nil
+}
+
+// MatchBan returns the first ban rule that matches the given user. If autoBanOnly is true,
+// only rules from lists with automatic bans enabled are considered.
+func (rs *policyRuleSet) MatchBan(userID id.UserID, autoBanOnly bool) *database.PolicyRule {
+	if len(rs.rules) == 0 || userID == "" {
+		return nil
+	}
+	server := userID.Homeserver()
+	for _, rule := range rs.rules {
+		if rule.isBan() && (rule.autoBan || !autoBanOnly) && rule.matches(userID, server) {
+			return rule.PolicyRule
+		}
+	}
+	return nil
+}
+
+func (rs *policyRuleSet) hasAutoBan() bool {
+	return slices.ContainsFunc(rs.rules, func(rule *compiledPolicyRule) bool {
+		return rule.autoBan && rule.isBan()
+	})
+}
+
+// getPolicyRules returns the compiled rules of all subscribed policy lists, loading them from the database if necessary.
+func (h *HiClient) getPolicyRules(ctx context.Context) *policyRuleSet {
+	if rs := h.policyRules.Load(); rs != nil {
+		return rs
+	}
+	rs, err := h.loadPolicyRules(ctx)
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Msg("Failed to load policy rules")
+		return emptyPolicyRuleSet
+	}
+	h.policyRules.Store(rs)
+	return rs
+}
+
+func (h *HiClient) loadPolicyRules(ctx context.Context) (*policyRuleSet, error) {
+	lists, err := h.DB.PolicyList.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy lists: %w", err)
+	} else if len(lists) == 0 {
+		return emptyPolicyRuleSet, nil
+	}
+	autoBan := make(map[id.RoomID]bool, len(lists))
+	for _, list := range lists {
+		autoBan[list.RoomID] = list.AutoBan
+	}
+	rules, err := h.DB.PolicyRule.GetAllSubscribed(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy rules: %w", err)
+	}
+	rs := &policyRuleSet{rules: make([]*compiledPolicyRule, 0, len(rules))}
+	for _, rule := range rules {
+		compiled := glob.Compile(rule.Entity)
+		if compiled == nil {
+			continue
+		}
+		rs.rules = append(rs.rules, &compiledPolicyRule{
+			PolicyRule: rule,
+			glob:       compiled,
+			autoBan:    autoBan[rule.RoomID],
+		})
+	}
+	return rs, nil
+}
+
+func policyTargetUser(evt *event.Event) id.UserID {
+	if evt.Type == event.StateMember && evt.StateKey != nil {
+		return id.UserID(*evt.StateKey)
+	}
+	return evt.Sender
+}
+
+func (h *HiClient) processPolicyRuleEvent(ctx context.Context, entityType database.PolicyEntityType, evt *event.Event, rowID database.EventRowID) error {
+	entity := gjson.GetBytes(evt.Content.VeryRaw, "entity").Str
+	recommendation := gjson.GetBytes(evt.Content.VeryRaw, "recommendation").Str
+	if entity == "" || recommendation == "" {
+		return h.DB.PolicyRule.Delete(ctx, evt.RoomID, entityType, *evt.StateKey)
+	}
+	return h.DB.PolicyRule.Put(ctx, &database.PolicyRule{
+		RoomID:         evt.RoomID,
+		EntityType:     entityType,
+		StateKey:       *evt.StateKey,
+		Entity:         entity,
+		Recommendation: recommendation,
+		Reason:         gjson.GetBytes(evt.Content.VeryRaw, "reason").Str,
+		EventRowID:     rowID,
+	})
+}
+
+// policyAutoBanner bans users matching auto-ban policy rules in a single background goroutine,
+// so that consecutive syncs don't start overlapping scans and ban jobs for the same users.
+type policyAutoBanner struct {
+	lock    sync.Mutex
+	running bool
+	// Set if all room members need to be checked, e.g. because the policy rules changed.
+	fullScan bool
+	// New room members that need to be checked.
+	pending map[database.RoomMembership]struct{}
+	// Memberships that have already been banned automatically. Entries are removed when the user joins again,
+	// which prevents banning the same user multiple times before the ban comes down sync.
+	banned map[database.RoomMembership]struct{}
+}
+
+// skipAlreadyBanned removes targets that have already been banned automatically and marks the rest as banned.
+func (ab *policyAutoBanner) skipAlreadyBanned(targets []*jsoncmd.PolicyBanTarget) []*jsoncmd.PolicyBanTarget {
+	ab.lock.Lock()
+	defer ab.lock.Unlock()
+	if ab.banned == nil {
+		ab.banned = make(map[database.RoomMembership]struct{})
+	}
+	return slices.DeleteFunc(targets, func(target *jsoncmd.PolicyBanTarget) bool {
+		membership := database.RoomMembership{RoomID: target.RoomID, UserID: target.UserID}
+		_, alreadyBanned := ab.banned[membership]
+		ab.banned[membership] = struct{}{}
+		return alreadyBanned
+	})
+}
+
+// forgetBanned allows the given memberships to be banned automatically again, e.g. after the user rejoined.
+// Must be called with the lock held.
+func (ab *policyAutoBanner) forgetBanned(memberships map[database.RoomMembership]struct{}) {
+	for membership := range memberships {
+		delete(ab.banned, membership)
+	}
+}
+
+// handleSyncPolicyChanges reloads the policy rule cache if rules changed in the sync,
+// and automatically bans matching users if there are lists with auto-ban enabled.
+func (h *HiClient) handleSyncPolicyChanges(ctx context.Context, syncCtx *syncContext) {
+	if !syncCtx.policyRulesChanged && len(syncCtx.newMembers) == 0 {
+		return
+	}
+	if syncCtx.policyRulesChanged {
+		h.policyRules.Store(nil)
+	}
+	ab := &h.policyAutoBan
+	ab.lock.Lock()
+	defer ab.lock.Unlock()
+	ab.forgetBanned(syncCtx.newMembers)
+	if !h.getPolicyRules(ctx).hasAutoBan() {
+		return
+	}
+	if syncCtx.policyRulesChanged {
+		ab.fullScan = true
+	} else if !ab.fullScan {
+		if ab.pending == nil {
+			ab.pending = make(map[database.RoomMembership]struct{}, len(syncCtx.newMembers))
+		}
+		maps.Copy(ab.pending, syncCtx.newMembers)
+	}
+	if !ab.running {
+		ab.running = true
+		go h.runPolicyAutoBans(context.WithoutCancel(ctx))
+	}
+}
+
+func (h *HiClient) runPolicyAutoBans(ctx context.Context) {
+	log := zerolog.Ctx(ctx)
+	ab := &h.policyAutoBan
+	for {
+		ab.lock.Lock()
+		if !ab.fullScan && len(ab.pending) == 0 {
+			ab.running = false
+			ab.lock.Unlock()
+			return
+		}
+		var filter map[database.RoomMembership]struct{}
+		if !ab.fullScan {
+			filter = ab.pending
+		}
+		ab.fullScan = false
+		ab.pending = nil
+		ab.lock.Unlock()
+
+		targets, err := h.findPolicyBanTargets(ctx, true, filter)
+		if err != nil {
+			log.Err(err).Msg("Failed to find users to ban automatically based on policy lists")
+			continue
+		}
+		targets = ab.skipAlreadyBanned(targets)
+		if len(targets) > 0 {
+			log.Info().Int("target_count", len(targets)).Msg("Automatically banning users based on policy lists")
+			// Run the job synchronously so that new members are checked only after the previous bans are done
+			_, run := h.preparePolicyBanJob(ctx, targets)
+			run()
+		}
+	}
+}
+
+// findPolicyBanTargets finds known room members who match ban rules in subscribed policy lists
+// in rooms where the current user has the power to ban them. If filter is non-nil,
+// only the memberships in the filter are checked.
+func (h *HiClient) findPolicyBanTargets(ctx context.Context, autoBanOnly bool, filter map[database.RoomMembership]struct{}) ([]*jsoncmd.PolicyBanTarget, error) {
+	rs := h.getPolicyRules(ctx)
+	if len(rs.rules) == 0 {
+		return nil, nil
+	}
+	var memberships []database.RoomMembership
+	if filter != nil {
+		memberships = make([]database.RoomMembership, 0, len(filter))
+		for membership := range filter {
+			memberships = append(memberships, membership)
+		}
+	} else {
+		var err error
+		memberships, err = h.DB.CurrentState.GetActiveMemberships(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get room members: %w", err)
+		}
+	}
+	var targets []*jsoncmd.PolicyBanTarget
+	for _, membership := range memberships {
+		if membership.UserID == h.Account.UserID {
+			continue
+		}
+		rule := rs.MatchBan(membership.UserID, autoBanOnly)
+		if rule == nil {
+			continue
+		}
+		allowed, err := h.canModerate(ctx, membership.RoomID, membership.UserID, (*event.PowerLevelsEventContent).Ban)
+		if err != nil {
+			return nil, err
+		} else if !allowed {
+			continue
+		}
+		targets = append(targets, &jsoncmd.PolicyBanTarget{
+			RoomID: membership.RoomID,
+			UserID: membership.UserID,
+			Rule:   rule,
+		})
+	}
+	return targets, nil
+}
+
+func (h *HiClient) startPolicyBanJob(ctx context.Context, targets []*jsoncmd.PolicyBanTarget) *jsoncmd.ModerationJobResponse {
+	resp, run := h.preparePolicyBanJob(ctx, targets)
+	go run()
+	return resp
+}
+
+func (h *HiClient) preparePolicyBanJob(ctx context.Context, targets []*jsoncmd.PolicyBanTarget) (*jsoncmd.ModerationJobResponse, func()) {
+	tasks := make([]moderationTask, len(targets))
+	for i, target := range targets {
+		tasks[i] = func(ctx context.Context) error {
+			_, err := h.Client.BanUser(ctx, target.RoomID, &mautrix.ReqBanUser{
+				UserID: target.UserID,
+				Reason: target.Rule.Reason,
+			})
+			return err
+		}
+	}
+	return h.prepareModerationJob(ctx, jsoncmd.ModerationActionBan, tasks)
+}
+
+// GetPolicyReport returns the users who would be banned by ApplyPolicyBans without actually banning anyone.
+func (h *HiClient) GetPolicyReport(ctx context.Context) ([]*jsoncmd.PolicyBanTarget, error) {
+	return h.findPolicyBanTargets(ctx, false, nil)
+}
+
+// ApplyPolicyBans starts a background job that bans all known room members who match ban rules
+// in subscribed policy lists, in rooms where the current user has the power to ban them.
+func (h *HiClient) ApplyPolicyBans(ctx context.Context) (*jsoncmd.ModerationJobResponse, error) {
+	targets, err := h.findPolicyBanTargets(ctx, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	return h.startPolicyBanJob(ctx, targets), nil
+}
+
+// SetPolicyListSubscription subscribes to or unsubscribes from the policy list in the given room.
+func (h *HiClient) SetPolicyListSubscription(ctx context.Context, roomID id.RoomID, subscribe, autoBan bool) error {
+	var err error
+	if subscribe {
+		var room *database.Room
+		room, err = h.DB.Room.Get(ctx, roomID)
+		if err != nil {
+			return fmt.Errorf("failed to get room: %w", err)
+		} else if room == nil {
+			return fmt.Errorf("can't subscribe to policy lists in rooms that aren't joined")
+		}
+		err = h.DB.PolicyList.Put(ctx, roomID, autoBan)
+	} else {
+		err = h.DB.PolicyList.Delete(ctx, roomID)
+	}
+	if err != nil {
+		return err
+	}
+	h.policyRules.Store(nil)
+	return nil
+}
+
+// GetPolicyMatches returns the known members of the given room who match a rule in a subscribed policy list.
+func (h *HiClient) GetPolicyMatches(ctx context.Context, roomID id.RoomID) (map[id.UserID]*database.PolicyRule, error) {
+	rs := h.getPolicyRules(ctx)
+	matches := make(map[id.UserID]*database.PolicyRule)
+	if len(rs.rules) == 0 {
+		return matches, nil
+	}
+	members, err := h.DB.CurrentState.GetMembers(ctx, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get room members: %w", err)
+	}
+	for _, member := range members {
+		userID := id.UserID(*member.StateKey)
+		if match := rs.Match(userID); match != nil {
+			matches[userID] = match
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"go.mau.fi/util/glob"
+	"go.mau.fi/util/jsontime"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+)
+
+const (
+	testAutoBanListID id.RoomID = "!autoban:example.com"
+	testManualListID  id.RoomID = "!manual:example.com"
+)
+
+func makeTestPolicyRule(listID id.RoomID, entityType database.PolicyEntityType, entity, recommendation string, autoBan bool) *compiledPolicyRule {
+	return &compiledPolicyRule{
+		PolicyRule: &database.PolicyRule{
+			RoomID:         listID,
+			EntityType:     entityType,
+			StateKey:       entity,
+			Entity:         entity,
+			Recommendation: recommendation,
+		},
+		glob:    glob.Compile(entity),
+		autoBan: autoBan,
+	}
+}
+
+func TestPolicyRuleSetMatchBan(t *testing.T) {
+	rs := &policyRuleSet{rules: []*compiledPolicyRule{
+		makeTestPolicyRule(testAutoBanListID, database.PolicyEntityTypeUser, "@spam*:example.com", PolicyRecommendationBan, true),
+		makeTestPolicyRule(testAutoBanListID, database.PolicyEntityTypeServer, "*.evil.example", PolicyRecommendationBan, true),
+		makeTestPolicyRule(testAutoBanListID, database.PolicyEntityTypeUser, "@legacy:example.com", PolicyRecommendationLegacyBan, true),
+		makeTestPolicyRule(testAutoBanListID, database.PolicyEntityTypeUser, "@other:example.com", "org.example.mute", true),
+		makeTestPolicyRule(testManualListID, database.PolicyEntityTypeUser, "@manual:example.com", PolicyRecommendationBan, false),
+		makeTestPolicyRule(testManualListID, database.PolicyEntityTypeServer, "manual.example", PolicyRecommendationBan, false),
+	}}
+	tests := []struct {
+		userID      id.UserID
+		autoBanOnly bool
+		wantEntity  string
+	}{
+		{"@spammer:example.com", true, "@spam*:example.com"},
+		{"@spam:example.com", false, "@spam*:example.com"},
+		{"@notspam:example.com", false, ""},
+		{"@user:sub.evil.example", true, "*.evil.example"},
+		{"@user:evil.example", true, ""},
+		{"@evil.example:example.com", true, ""},
+		{"@legacy:example.com", true, "@legacy:example.com"},
+		{"@other:example.com", false, ""},
+		{"@manual:example.com", true, ""},
+		{"@manual:example.com", false, "@manual:example.com"},
+		{"@user:manual.example", true, ""},
+		{"@user:manual.example", false, "manual.example"},
+		{"", false, ""},
+	}
+	for _, test := range tests {
+		var entity string
+		if rule := rs.MatchBan(test.userID, test.autoBanOnly); rule != nil {
+			entity = rule.Entity
+		}
+		if entity != test.wantEntity {
+			t.Errorf("MatchBan(%q, %t) matched %q, want %q", test.userID, test.autoBanOnly, entity, test.wantEntity)
+		}
+	}
+	if !rs.hasAutoBan() {
+		t.Errorf("hasAutoBan() = false with auto-ban rules")
+	}
+	manualOnly := &policyRuleSet{rules: rs.rules[4:]}
+	if manualOnly.hasAutoBan() {
+		t.Errorf("hasAutoBan() = true without auto-ban rules")
+	}
+}
+
+func insertTestStateEvent(t *testing.T, h *HiClient, roomID id.RoomID, evtType event.Type, stateKey string, content any) database.EventRowID {
+	t.Helper()
+	contentJSON, _ := json.Marshal(content)
+	rowID, err := h.DB.Event.Insert(context.Background(), &database.Event{
+		RoomID:    roomID,
+		ID:        id.EventID("$" + evtType.Type + "-" + stateKey),
+		Sender:    "@mod:example.com",
+		Type:      evtType.Type,
+		StateKey:  &stateKey,
+		Timestamp: jsontime.UnixMilliNow(),
+		Content:   contentJSON,
+		Unsigned:  json.RawMessage("{}"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to insert %s event: %v", evtType.Type, err)
+	}
+	err = h.DB.CurrentState.Set(context.Background(), roomID, evtType, stateKey, rowID, "")
+	if err != nil {
+		t.Fatalf("Failed to set current state: %v", err)
+	}
+	return rowID
+}
+
+func addTestPolicyList(t *testing.T, h *HiClient, listID id.RoomID, autoBan bool, rules map[string]string) {
+	t.Helper()
+	ctx := context.Background()
+	if err := h.DB.Room.CreateRow(ctx, listID); err != nil {
+		t.Fatalf("Failed to create policy list room: %v", err)
+	} else if err = h.DB.PolicyList.Put(ctx, listID, autoBan); err != nil {
+		t.Fatalf("Failed to subscribe to policy list: %v", err)
+	}
+	for entity, recommendation := range rules {
+		entityType := database.PolicyEntityTypeUser
+		if entity[0] != '@' {
+			entityType = database.PolicyEntityTypeServer
+		}
+		evtType := event.Type{Type: "m.policy.rule." + string(entityType), Class: event.StateEventType}
+		rowID := insertTestStateEvent(t, h, listID, evtType, entity, map[string]any{
+			"entity":         entity,
+			"recommendation": recommendation,
+		})
+		err := h.DB.PolicyRule.Put(ctx, &database.PolicyRule{
+			RoomID:         listID,
+			EntityType:     entityType,
+			StateKey:       entity,
+			Entity:         entity,
+			Recommendation: recommendation,
+			EventRowID:     rowID,
+		})
+		if err != nil {
+			t.Fatalf("Failed to insert policy rule: %v", err)
+		}
+	}
+}
+
+func TestFindPolicyBanTargets(t *testing.T) {
+	ctx := context.Background()
+	h, _ := newTestClient(t)
+	h.Account = &database.Account{UserID: "@mod:example.com"}
+	insertTestStateEvent(t, h, testRoomID, event.StatePowerLevels, "", map[string]any{
+		"users": map[id.UserID]int{"@mod:example.com": 50, "@spamadmin:example.com": 100},
+		"ban":   50,
+	})
+	addTestPolicyList(t, h, testAutoBanListID, true, map[string]string{
+		"@spam*:example.com":  PolicyRecommendationBan,
+		"*.evil.example":      PolicyRecommendationBan,
+		"@legacy:example.com": PolicyRecommendationLegacyBan,
+		"@other:example.com":  "org.example.mute",
+		"@mod:example.com":    PolicyRecommendationBan,
+	})
+	addTestPolicyList(t, h, testManualListID, false, map[string]string{
+		"@manual:example.com": PolicyRecommendationBan,
+	})
+	filter := make(map[database.RoomMembership]struct{})
+	for _, userID := range []id.UserID{
+		"@spammer:example.com", "@user:sub.evil.example", "@legacy:example.com", "@other:example.com",
+		"@manual:example.com", "@friend:example.com", "@mod:example.com", "@spamadmin:example.com",
+	} {
+		filter[database.RoomMembership{RoomID: testRoomID, UserID: userID}] = struct{}{}
+	}
+
+	tests := []struct {
+		autoBanOnly bool
+		want        []id.UserID
+	}{
+		{true, []id.UserID{"@legacy:example.com", "@spammer:example.com", "@user:sub.evil.example"}},
+		{false, []id.UserID{"@legacy:example.com", "@manual:example.com", "@spammer:example.com", "@user:sub.evil.example"}},
+	}
+	for _, test := range tests {
+		targets, err := h.findPolicyBanTargets(ctx, test.autoBanOnly, filter)
+		if err != nil {
+			t.Fatalf("findPolicyBanTargets(%t) returned error: %v", test.autoBanOnly, err)
+		}
+		userIDs := make([]id.UserID, len(targets))
+		for i, target := range targets {
+			userIDs[i] = target.UserID
+			if target.RoomID != testRoomID || target.Rule == nil {
+				t.Errorf("findPolicyBanTargets(%t) returned invalid target %+v", test.autoBanOnly, target)
+			}
+		}
+		slices.Sort(userIDs)
+		if !slices.Equal(userIDs, test.want) {
+			t.Errorf("findPolicyBanTargets(%t) = %v, want %v", test.autoBanOnly, userIDs, test.want)
+		}
+	}
+}
+
+func TestPolicyAutoBannerSkipsAlreadyBanned(t *testing.T) {
+	makeTargets := func(userIDs ...id.UserID) []*jsoncmd.PolicyBanTarget {
+		targets := make([]*jsoncmd.PolicyBanTarget, len(userIDs))
+		for i, userID := range userIDs {
+			targets[i] = &jsoncmd.PolicyBanTarget{RoomID: testRoomID, UserID: userID}
+		}
+		return targets
+	}
+	getUserIDs := func(targets []*jsoncmd.PolicyBanTarget) []id.UserID {
+		userIDs := make([]id.UserID, len(targets))
+		for i, target := range targets {
+			userIDs[i] = target.UserID
+		}
+		return userIDs
+	}
+
+	var ab policyAutoBanner
+	got := getUserIDs(ab.skipAlreadyBanned(makeTargets("@a:example.com", "@b:example.com")))
+	if want := []id.UserID{"@a:example.com", "@b:example.com"}; !slices.Equal(got, want) {
+		t.Errorf("First skipAlreadyBanned() = %v, want %v", got, want)
+	}
+	got = getUserIDs(ab.skipAlreadyBanned(makeTargets("@a:example.com", "@c:example.com")))
+	if want := []id.UserID{"@c:example.com"}; !slices.Equal(got, want) {
+		t.Errorf("Second skipAlreadyBanned() = %v, want %v", got, want)
+	}
+
+	// Rejoining makes the user bannable again
+	ab.forgetBanned(map[database.RoomMembership]struct{}{
+		{RoomID: testRoomID, UserID: "@a:example.com"}: {},
+	})
+	got = getUserIDs(ab.skipAlreadyBanned(makeTargets("@a:example.com", "@b:example.com")))
+	if want := []id.UserID{"@a:example.com"}; !slices.Equal(got, want) {
+		t.Errorf("skipAlreadyBanned() after rejoin = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+)
+
+var (
+	ErrNotAPoll    = errors.New("event is not a poll")
+	ErrPollEnded   = errors.New("poll has already ended")
+	ErrInvalidVote = errors.New("invalid poll answer")
+)
+
+func isPollRelation(evt *database.Event) bool {
+	if evt.RelationType != event.RelReference {
+		return false
+	}
+	evtType := evt.GetType().Type
+	return evtType == database.EventUnstablePollResponse.Type || evtType == database.EventUnstablePollEnd.Type
+}
+
+// canEndPoll returns a function that checks whether a user is allowed to end the given poll.
+// The poll sender can always end their own poll, other users need the power to redact events.
+func (h *HiClient) canEndPoll(ctx context.Context, pollEvt *database.Event) (func(id.UserID) bool, error) {
+	pl, err := h.ClientStore.GetPowerLevels(ctx, pollEvt.RoomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get power levels: %w", err)
+	}
+	return func(userID id.UserID) bool {
+		return userID == pollEvt.Sender || (pl != nil && pl.GetUserLevel(userID) >= pl.Redact())
+	}, nil
+}
+
+func (h *HiClient) calculatePollTally(ctx context.Context, pollEvt *database.Event, poll *event.PollStart) (*database.PollTally, error) {
+	related, err := h.DB.Event.GetRelatedEvents(ctx, pollEvt.RoomID, pollEvt.ID, event.RelReference, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get poll responses: %w", err)
+	}
+	canEnd, err := h.canEndPoll(ctx, pollEvt)
+	if err != nil {
+		return nil, err
+	}
+	isIgnored := func(sender id.UserID) bool {
+		return h.IsIgnored(ctx, sender)
+	}
+	return database.CalculatePollTally(poll, related, canEnd, isIgnored), nil
+}
+
+// FillPollTallies calculates the current tally for all poll start events in the given list.
+func (h *HiClient) FillPollTallies(ctx context.Context, events []*database.Event) {
+	for _, evt := range events {
+		poll := evt.GetPollStart()
+		if poll == nil {
+			continue
+		}
+		tally, err := h.calculatePollTally(ctx, evt, poll)
+		if err != nil {
+			zerolog.Ctx(ctx).Err(err).
+				Stringer("room_id", evt.RoomID).
+				Stringer("event_id", evt.ID).
+				Msg("Failed to calculate poll tally")
+			continue
+		}
+		evt.PollTally = tally
+	}
+}
+
+func (h *HiClient) getPoll(ctx context.Context, roomID id.RoomID, eventID id.EventID) (*database.Event, *event.PollStart, error) {
+	pollEvt, err := h.DB.Event.GetByID(ctx, roomID, eventID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get poll event: %w", err)
+	} else if pollEvt == nil {
+		return nil, nil, fmt.Errorf("poll event not found")
+	}
+	poll := pollEvt.GetPollStart()
+	if poll == nil {
+		return nil, nil, ErrNotAPoll
+	}
+	return pollEvt, poll, nil
+}
+
+func makePollReference(eventID id.EventID) map[string]any {
+	return map[string]any{
+		"rel_type": event.RelReference,
+		"event_id": eventID,
+	}
+}
+
+// VotePoll sends a response to the given poll. Sending an empty list of answers retracts the previous vote.
+func (h *HiClient) VotePoll(ctx context.Context, roomID id.RoomID, eventID id.EventID, answers []string) (*database.Event, error) {
+	pollEvt, poll, err := h.getPoll(ctx, roomID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if len(answers) > database.GetPollMaxSelections(poll) {
+		return nil, fmt.Errorf("%w: at most %d answers can be selected", ErrInvalidVote, database.GetPollMaxSelections(poll))
+	} else if normalized := database.NormalizePollVote(poll, answers); len(normalized) != len(answers) {
+		return nil, fmt.Errorf("%w: unknown or duplicate answer IDs", ErrInvalidVote)
+	}
+	tally, err := h.calculatePollTally(ctx, pollEvt, poll)
+	if err != nil {
+		return nil, err
+	} else if tally.IsEnded() {
+		return nil, ErrPollEnded
+	}
+	if answers == nil {
+		answers = []string{}
+	}
+	content := map[string]any{
+		"m.relates_to": makePollReference(eventID),
+		"org.matrix.msc3381.poll.response": map[string]any{
+			"answers": answers,
+		},
+	}
+	return h.send(ctx, roomID, database.EventUnstablePollResponse, content, "", false, false, true, 0)
+}
+
+// EndPoll ends the given poll, after which new votes are no longer counted.
+func (h *HiClient) EndPoll(ctx context.Context, roomID id.RoomID, eventID id.EventID) (*database.Event, error) {
+	pollEvt, poll, err := h.getPoll(ctx, roomID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	canEnd, err := h.canEndPoll(ctx, pollEvt)
+	if err != nil {
+		return nil, err
+	} else if !canEnd(h.Account.UserID) {
+		return nil, fmt.Errorf("you don't have permission to end this poll")
+	}
+	tally, err := h.calculatePollTally(ctx, pollEvt, poll)
+	if err != nil {
+		return nil, err
+	} else if tally.IsEnded() {
+		return nil, ErrPollEnded
+	}
+	content := map[string]any{
+		"m.relates_to":                makePollReference(eventID),
+		"org.matrix.msc3381.poll.end": map[string]any{},
+		"org.matrix.msc1767.text":     getPollEndText(poll, tally),
+	}
+	return h.send(ctx, roomID, database.EventUnstablePollEnd, content, "", false, false, true, 0)
+}
+
+func getPollEndText(poll *event.PollStart, tally *database.PollTally) string {
+	var winners []string
+	maxVotes := 0
+	for _, answer := range poll.Answers {
+		count := tally.Counts[answer.ID]
+		if count > maxVotes {
+			maxVotes = count
+			winners = []string{answer.Text}
+		} else if count == maxVotes && count > 0 {
+			winners = append(winners, answer.Text)
+		}
+	}
+	if len(winners) == 0 {
+		return "The poll has ended. No votes were cast."
+	} else if len(winners) == 1 {
+		return fmt.Sprintf("The poll has ended. Top answer: %s", winners[0])
+	}
+	return fmt.Sprintf("The poll has ended. Top answers: %s", strings.Join(winners, ", "))
+}
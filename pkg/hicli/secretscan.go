@@ -0,0 +1,73 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+)
+
+// SecretDetector is a named pattern that matches secrets which shouldn't be sent to rooms.
+type SecretDetector struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// BuiltinSecretDetectors contains detectors for common credential formats.
+var BuiltinSecretDetectors = []*SecretDetector{
+	{Name: "AWS access key ID", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[A-Z2-7]{16}\b`)},
+	{Name: "AWS secret access key", Pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?[A-Za-z0-9/+]{40}\b`)},
+	{Name: "private key", Pattern: regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+	{Name: "JSON web token", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{Name: "GitHub token", Pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{82})\b`)},
+	{Name: "Slack token", Pattern: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{Name: "Matrix access token", Pattern: regexp.MustCompile(`\bsyt_[A-Za-z0-9]+_[A-Za-z0-9]{20}_[A-Za-z0-9]{6}\b`)},
+}
+
+// SecretDetectedError is returned by [HiClient.CheckOutgoingSecrets] when a message looks like it contains secrets.
+// The error message starts with [jsoncmd.ErrCodeSecretDetected] so that RPC clients can recognize it.
+type SecretDetectedError struct {
+	Detectors []string
+}
+
+func (sde *SecretDetectedError) Error() string {
+	return fmt.Sprintf("%s: message appears to contain a secret (%s)", jsoncmd.ErrCodeSecretDetected, strings.Join(sde.Detectors, ", "))
+}
+
+// ScanForSecrets returns the names of all configured detectors that match any of the given strings.
+func (h *HiClient) ScanForSecrets(inputs ...string) []string {
+	var matched []string
+	for _, detector := range h.SecretDetectors {
+		if slices.ContainsFunc(inputs, detector.Pattern.MatchString) {
+			matched = append(matched, detector.Name)
+		}
+	}
+	return matched
+}
+
+// CheckOutgoingSecrets scans the text and attachment filename of an outgoing message
+// and returns a [SecretDetectedError] if any detector matches.
+func (h *HiClient) CheckOutgoingSecrets(text string, base *event.MessageEventContent) error {
+	if len(h.SecretDetectors) == 0 {
+		return nil
+	}
+	inputs := []string{text}
+	if base != nil {
+		// Body is the filename for media without a caption
+		inputs = append(inputs, base.FileName, base.Body)
+	}
+	if matched := h.ScanForSecrets(inputs...); len(matched) > 0 {
+		return &SecretDetectedError{Detectors: matched}
+	}
+	return nil
+}
@@ -162,6 +162,8 @@ func inputTextToExtensible(text string) *event.ExtensibleTextContainer {
 	return container
 }
 
+// SendMessage sends a message to the given room. Unless override is true, messages that look like
+// they contain secrets are rejected with a [SecretDetectedError].
 func (h *HiClient) SendMessage(
 	ctx context.Context,
 	roomID id.RoomID,
@@ -171,11 +173,17 @@ func (h *HiClient) SendMessage(
 	relatesTo *event.RelatesTo,
 	mentions *event.Mentions,
 	urlPreviews []*event.BeeperLinkPreview,
+	override bool,
 ) (*database.Event, error) {
 	hasCommand := base != nil && base.MSC4391BotCommand != nil
 	if hasCommand && mentions.Has(cmdspec.FakeGomuksSender) && len(mentions.UserIDs) == 1 {
 		return h.ProcessCommand(ctx, roomID, base.MSC4391BotCommand, base, relatesTo)
 	}
+	if !override {
+		if err := h.CheckOutgoingSecrets(text, base); err != nil {
+			return nil, err
+		}
+	}
 	var unencrypted bool
 	var ts int64
 	var rawInputBody bool
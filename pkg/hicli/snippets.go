@@ -0,0 +1,176 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/tidwall/gjson"
+	"go.mau.fi/util/ptr"
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+)
+
+var ErrSnippetNotFound = errors.New("snippet not found")
+
+// snippetVars contains the variables available in snippet templates.
+type snippetVars struct {
+	// All arguments passed to the snippet as a single string.
+	Args string
+	// The arguments split by whitespace.
+	ArgList  []string
+	Date     string
+	Time     string
+	RoomName string
+	// A mention of the current user.
+	Me string
+}
+
+// GetSnippets returns the snippets stored in account data.
+func (h *HiClient) GetSnippets(ctx context.Context) (*jsoncmd.Snippets, error) {
+	evt, err := h.DB.AccountData.GetGlobal(ctx, h.Account.UserID, jsoncmd.AccountDataSnippets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snippets from account data: %w", err)
+	}
+	var snippets jsoncmd.Snippets
+	if evt != nil {
+		if err = json.Unmarshal(evt.Content, &snippets); err != nil {
+			zerolog.Ctx(ctx).Err(err).Msg("Failed to unmarshal snippets from account data")
+		}
+	}
+	if snippets.Snippets == nil {
+		snippets.Snippets = make(map[string]*jsoncmd.Snippet)
+	}
+	return &snippets, nil
+}
+
+// SetSnippet creates or replaces a snippet, or deletes it if the snippet is nil.
+// The template is validated before saving.
+func (h *HiClient) SetSnippet(ctx context.Context, name string, snippet *jsoncmd.Snippet) error {
+	if name == "" || strings.ContainsFunc(name, func(r rune) bool { return r == ' ' || r == '\n' }) {
+		return fmt.Errorf("snippet names must not be empty or contain whitespace")
+	}
+	if snippet != nil {
+		if _, err := h.parseSnippetTemplate(ctx, "", name, snippet.Template, nil); err != nil {
+			return err
+		}
+	}
+	h.snippetLock.Lock()
+	defer h.snippetLock.Unlock()
+	var snippets jsoncmd.Snippets
+	// Fetch the current value from the server in case previous changes haven't come down sync yet
+	err := h.Client.GetAccountData(ctx, jsoncmd.AccountDataSnippets.Type, &snippets)
+	if err != nil && !errors.Is(err, mautrix.MNotFound) {
+		return fmt.Errorf("failed to get snippets: %w", err)
+	}
+	if snippets.Snippets == nil {
+		snippets.Snippets = make(map[string]*jsoncmd.Snippet)
+	}
+	if snippet == nil {
+		if _, ok := snippets.Snippets[name]; !ok {
+			return ErrSnippetNotFound
+		}
+		delete(snippets.Snippets, name)
+	} else {
+		snippets.Snippets[name] = snippet
+	}
+	return h.Client.SetAccountData(ctx, jsoncmd.AccountDataSnippets.Type, &snippets)
+}
+
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"`", "\\`",
+	"*", "\\*",
+	"_", "\\_",
+	"[", "\\[",
+	"]", "\\]",
+	"(", "\\(",
+	")", "\\)",
+	"<", "&lt;",
+	">", "&gt;",
+	"\n", " ",
+)
+
+// makeMention returns a markdown mention pill for the given user and adds the user to mentions if it's not nil.
+func (h *HiClient) makeMention(ctx context.Context, roomID id.RoomID, userID id.UserID, mentions *event.Mentions) string {
+	displayname := userID.String()
+	if roomID != "" {
+		memberEvt, err := h.DB.CurrentState.Get(ctx, roomID, event.StateMember, userID.String())
+		if err != nil {
+			zerolog.Ctx(ctx).Err(err).Stringer("user_id", userID).Msg("Failed to get member event for mention")
+		} else if memberEvt != nil {
+			if name := gjson.GetBytes(memberEvt.Content, "displayname").Str; name != "" {
+				displayname = name
+			}
+		}
+	}
+	if mentions != nil {
+		mentions.Add(userID)
+	}
+	return fmt.Sprintf("[%s](%s)", markdownEscaper.Replace(displayname), userID.URI().MatrixToURL())
+}
+
+func (h *HiClient) parseSnippetTemplate(ctx context.Context, roomID id.RoomID, name, tpl string, mentions *event.Mentions) (*template.Template, error) {
+	parsed, err := template.New(name).Funcs(template.FuncMap{
+		"mention": func(userID string) string {
+			return h.makeMention(ctx, roomID, id.UserID(userID), mentions)
+		},
+	}).Parse(tpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snippet template: %w", err)
+	}
+	return parsed, nil
+}
+
+// RenderSnippet renders the snippet with the given name into markdown for the given room.
+// The users mentioned with the mention function are returned too.
+func (h *HiClient) RenderSnippet(ctx context.Context, roomID id.RoomID, name, args string) (string, *event.Mentions, error) {
+	snippets, err := h.GetSnippets(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	snippet, ok := snippets.Snippets[name]
+	if !ok {
+		return "", nil, ErrSnippetNotFound
+	}
+	mentions := &event.Mentions{}
+	tpl, err := h.parseSnippetTemplate(ctx, roomID, name, snippet.Template, mentions)
+	if err != nil {
+		return "", nil, err
+	}
+	room, err := h.DB.Room.Get(ctx, roomID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get room metadata: %w", err)
+	} else if room == nil {
+		return "", nil, fmt.Errorf("unknown room")
+	}
+	now := time.Now()
+	var buf strings.Builder
+	err = tpl.Execute(&buf, &snippetVars{
+		Args:     args,
+		ArgList:  strings.Fields(args),
+		Date:     now.Format(time.DateOnly),
+		Time:     now.Format("15:04"),
+		RoomName: ptr.Val(room.Name),
+		// The current user isn't added to mentions, as mentioning yourself doesn't do anything
+		Me: h.makeMention(ctx, roomID, h.Account.UserID, nil),
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to render snippet: %w", err)
+	}
+	return buf.String(), mentions, nil
+}
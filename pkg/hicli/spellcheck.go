@@ -0,0 +1,227 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+	"go.mau.fi/gomuks/pkg/spellcheck"
+)
+
+var (
+	ErrNoDictionaries     = errors.New("no spell check dictionaries installed")
+	ErrInvalidDictionary  = errors.New("invalid dictionary name")
+	ErrDictionaryNotFound = errors.New("dictionary not found")
+)
+
+const maxSpellCheckSuggestions = 5
+
+// maxSuggestedMisspellings is the maximum number of misspellings per spell check request that get suggestions.
+// The last misspellings in the text are preferred, as that's usually where the user is typing.
+const maxSuggestedMisspellings = 10
+
+var dictionaryNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func (h *HiClient) getDictionary(language string) (string, *spellcheck.Dictionary, error) {
+	if h.DictionaryDir == "" {
+		return "", nil, ErrNoDictionaries
+	}
+	h.dictionaryLock.Lock()
+	defer h.dictionaryLock.Unlock()
+	if language == "" {
+		files, err := filepath.Glob(filepath.Join(h.DictionaryDir, "*.dic"))
+		if err != nil {
+			return "", nil, err
+		} else if len(files) == 0 {
+			return "", nil, ErrNoDictionaries
+		}
+		slices.Sort(files)
+		language = strings.TrimSuffix(filepath.Base(files[0]), ".dic")
+	} else if !dictionaryNameRegex.MatchString(language) {
+		return "", nil, ErrInvalidDictionary
+	}
+	if dict, ok := h.dictionaries[language]; ok {
+		return language, dict, nil
+	}
+	basePath := filepath.Join(h.DictionaryDir, language)
+	dict, err := spellcheck.Load(basePath+".dic", basePath+".aff")
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil, fmt.Errorf("%w: %s", ErrDictionaryNotFound, language)
+	} else if err != nil {
+		return "", nil, fmt.Errorf("failed to load dictionary %s: %w", language, err)
+	}
+	if h.dictionaries == nil {
+		h.dictionaries = make(map[string]*spellcheck.Dictionary)
+	}
+	h.dictionaries[language] = dict
+	return language, dict, nil
+}
+
+func (h *HiClient) getSpellCheckWords(ctx context.Context) map[string]struct{} {
+	evt, err := h.DB.AccountData.GetGlobal(ctx, h.Account.UserID, jsoncmd.AccountDataSpellCheck)
+	if err != nil {
+		zerolog.Ctx(ctx).Err(err).Msg("Failed to get spell check word list from account data")
+		return nil
+	} else if evt == nil {
+		return nil
+	}
+	var content jsoncmd.SpellCheckWords
+	if err = json.Unmarshal(evt.Content, &content); err != nil {
+		zerolog.Ctx(ctx).Err(err).Msg("Failed to unmarshal spell check word list")
+		return nil
+	}
+	words := make(map[string]struct{}, len(content.Words))
+	for _, word := range content.Words {
+		words[strings.ToLower(word)] = struct{}{}
+	}
+	return words
+}
+
+type textRange struct {
+	start, end int
+}
+
+// collectSpellCheckRanges returns the byte ranges of the input that contain prose.
+// Code spans, images, autolinks, mention pills and raw HTML are excluded.
+func collectSpellCheckRanges(source []byte) []textRange {
+	doc := defaultNoHTML.Parser().Parse(text.NewReader(source))
+	var ranges []textRange
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.CodeSpan, *ast.Image, *ast.AutoLink, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Link:
+			dest := string(node.Destination)
+			if strings.HasPrefix(dest, "https://matrix.to/") || strings.HasPrefix(dest, "matrix:") {
+				return ast.WalkSkipChildren, nil
+			}
+		case *ast.Text:
+			// Adjacent text nodes are merged so that tokens aren't split at emphasis delimiters
+			if len(ranges) > 0 && ranges[len(ranges)-1].end == node.Segment.Start {
+				ranges[len(ranges)-1].end = node.Segment.Stop
+			} else {
+				ranges = append(ranges, textRange{node.Segment.Start, node.Segment.Stop})
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return ranges
+}
+
+func isSpellCheckableToken(token string) bool {
+	if strings.Contains(token, "://") || strings.HasPrefix(token, "www.") {
+		return false
+	}
+	switch token[0] {
+	case '@', '#', '!', '+':
+		// Matrix identifiers
+		if strings.ContainsRune(token, ':') {
+			return false
+		}
+	case '/':
+		// Commands and paths
+		return false
+	}
+	return !strings.ContainsRune(token, '@')
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r)
+}
+
+// forEachWord calls the callback for each word in the token. Apostrophes are only included inside words.
+func forEachWord(token string, offset int, fn func(word string, start int)) {
+	wordStart := -1
+	for i, r := range token {
+		isApostrophe := r == '\'' || r == '’'
+		if isWordRune(r) || (isApostrophe && wordStart >= 0) {
+			if wordStart < 0 {
+				wordStart = i
+			}
+			continue
+		}
+		if wordStart >= 0 {
+			fn(strings.TrimRight(token[wordStart:i], "'’"), offset+wordStart)
+			wordStart = -1
+		}
+	}
+	if wordStart >= 0 {
+		fn(strings.TrimRight(token[wordStart:], "'’"), offset+wordStart)
+	}
+}
+
+// SpellCheck finds misspelled words in composer text. Markdown is parsed the same way as when sending messages,
+// so that only prose is checked. Words containing digits, URLs and Matrix identifiers are always skipped.
+func (h *HiClient) SpellCheck(ctx context.Context, input, language string) (*jsoncmd.SpellCheckResponse, error) {
+	language, dict, err := h.getDictionary(language)
+	if err != nil {
+		return nil, err
+	}
+	userWords := h.getSpellCheckWords(ctx)
+	resp := &jsoncmd.SpellCheckResponse{
+		Language:     language,
+		Misspellings: make([]*jsoncmd.Misspelling, 0),
+	}
+	base := 0
+	if strings.HasPrefix(input, "/") {
+		// Don't check the name of the command
+		cmdEnd := strings.IndexFunc(input, unicode.IsSpace)
+		if cmdEnd < 0 {
+			return resp, nil
+		}
+		base = len(input) - len(strings.TrimLeftFunc(input[cmdEnd:], unicode.IsSpace))
+	}
+	source := []byte(input[base:])
+	for _, rng := range collectSpellCheckRanges(source) {
+		rng.start += base
+		rng.end += base
+		segment := input[rng.start:rng.end]
+		offset := 0
+		for _, token := range strings.Fields(segment) {
+			tokenStart := offset + strings.Index(segment[offset:], token)
+			offset = tokenStart + len(token)
+			if !isSpellCheckableToken(token) {
+				continue
+			}
+			forEachWord(token, rng.start+tokenStart, func(word string, start int) {
+				if word == "" || strings.ContainsFunc(word, unicode.IsDigit) || utf8.RuneCountInString(word) < 2 {
+					return
+				} else if _, ok := userWords[strings.ToLower(word)]; ok || dict.Check(word) {
+					return
+				}
+				resp.Misspellings = append(resp.Misspellings, &jsoncmd.Misspelling{
+					Start:       start,
+					End:         start + len(word),
+					Word:        word,
+					Suggestions: []string{},
+				})
+			})
+		}
+	}
+	for _, misspelling := range resp.Misspellings[max(0, len(resp.Misspellings)-maxSuggestedMisspellings):] {
+		misspelling.Suggestions = dict.Suggest(misspelling.Word, maxSpellCheckSuggestions)
+	}
+	return resp, nil
+}
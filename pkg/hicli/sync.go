@@ -43,6 +43,16 @@ type syncContext struct {
 
 	changedSpaces []id.RoomID
 	changedDMs    map[id.RoomID]id.UserID
+
+	policyRulesChanged bool
+	newMembers         map[database.RoomMembership]struct{}
+}
+
+func (sc *syncContext) addNewMember(roomID id.RoomID, userID id.UserID) {
+	if sc.newMembers == nil {
+		sc.newMembers = make(map[database.RoomMembership]struct{})
+	}
+	sc.newMembers[database.RoomMembership{RoomID: roomID, UserID: userID}] = struct{}{}
 }
 
 func (sc *syncContext) getChangedDM(roomID id.RoomID) (id.UserID, bool) {
@@ -190,6 +200,12 @@ func (h *HiClient) postProcessSyncResponse(ctx context.Context, resp *mautrix.Re
 	if !ok || syncCtx.shouldWakeupRequestQueue {
 		h.WakeupRequestQueue()
 	}
+	if ok {
+		h.handleSyncPolicyChanges(ctx, syncCtx)
+	}
+	if eval, ok := ctx.Value(inviteDecisionsContextKey).(*inviteEvaluation); ok {
+		h.handleInviteDecisions(ctx, eval)
+	}
 	if !h.firstSyncReceived {
 		h.firstSyncReceived = true
 		if tp, ok := h.Client.Client.Transport.(*http.Transport); ok {
@@ -290,6 +306,12 @@ func (h *HiClient) processSyncResponse(ctx context.Context, resp *mautrix.RespSy
 			_ = evt.Content.ParseRaw(evt.Type)
 			content, _ := evt.Content.Parsed.(*event.PerMessageProfilesEventContent)
 			h.globalPerMessageProfiles.Store(&content)
+		case event.AccountDataIgnoredUserList:
+			_ = evt.Content.ParseRaw(evt.Type)
+			content, _ := evt.Content.Parsed.(*event.IgnoredUserListEventContent)
+			h.storeIgnoredUsers(content)
+		case AccountDataInviteRules:
+			h.storeInviteRules(ctx, evt.Content.VeryRaw)
 		}
 	}
 	if syncCtx != nil {
@@ -382,12 +404,21 @@ func (h *HiClient) processSyncInvitedRoom(ctx context.Context, roomID id.RoomID,
 			break
 		}
 	}
+	var decision inviteDecision
+	if eval, ok := ctx.Value(inviteDecisionsContextKey).(*inviteEvaluation); ok {
+		decision = eval.decisions[roomID]
+	}
+	switch decision {
+	case inviteDecisionReject, inviteDecisionQuarantine:
+		ir.Quarantined = true
+	}
 	err := h.DB.InvitedRoom.Upsert(ctx, ir)
 	if err != nil {
 		return fmt.Errorf("failed to save invited room: %w", err)
 	}
 	syncCtx, ok := ctx.Value(syncContextKey).(*syncContext)
-	if ok {
+	// Rejected invites are stored until the rejection succeeds, but only sent to clients if it fails
+	if ok && decision != inviteDecisionReject {
 		syncCtx.evt.InvitedRooms = append(syncCtx.evt.InvitedRooms, ir)
 	}
 	return nil
@@ -720,6 +751,8 @@ func (h *HiClient) calculateLocalContent(ctx context.Context, dbEvt *database.Ev
 			EditSource:           editSource,
 			ReplyFallbackRemoved: dbEvt.LocalContent.GetReplyFallbackRemoved(),
 			PushRuleID:           dbEvt.LocalContent.GetPushRuleID(),
+			PolicyMatch:          dbEvt.LocalContent.GetPolicyMatch(),
+			Translation:          dbEvt.LocalContent.GetTranslation(),
 		}, inlineImages
 	}
 	return dbEvt.LocalContent, nil
@@ -755,6 +788,14 @@ func (h *HiClient) postDecryptProcess(ctx context.Context, llSummary *mautrix.La
 			dbEvt.LocalContent.PushRuleID = pushRuleID
 		}
 	}
+	if evt.Sender != h.Account.UserID {
+		if match := h.getPolicyRules(ctx).Match(policyTargetUser(evt)); match != nil {
+			if dbEvt.LocalContent == nil {
+				dbEvt.LocalContent = &database.LocalContent{}
+			}
+			dbEvt.LocalContent.PolicyMatch = match
+		}
+	}
 	dbEvt.LocalContent, inlineImages = h.calculateLocalContent(ctx, dbEvt, evt)
 	return
 }
@@ -905,6 +946,7 @@ func (h *HiClient) processStateAndTimeline(
 	decryptionQueue := make(map[id.SessionID]*database.SessionRequest)
 	allNewEvents := make([]*database.Event, 0, len(state.Events)+len(sticky.Events)+len(timeline.Events))
 	addedEvents := make(map[database.EventRowID]struct{})
+	hiddenEvents := make(map[database.EventRowID]struct{})
 	newNotifications := make([]jsoncmd.SyncNotification, 0)
 	var recalculatePreviewEvent, unreadMessagesWereMaybeRedacted bool
 	var newUnreadCounts database.UnreadCounts
@@ -937,7 +979,7 @@ func (h *HiClient) processStateAndTimeline(
 		if dbEvt.UnreadType > 0 {
 			unreadMessagesWereMaybeRedacted = true
 		}
-		if dbEvt.RelationType == event.RelReplace || dbEvt.RelationType == event.RelAnnotation {
+		if dbEvt.RelationType == event.RelReplace || dbEvt.RelationType == event.RelAnnotation || isPollRelation(dbEvt) {
 			_, err = addOldEvent(0, dbEvt.RelatesTo)
 			if err != nil {
 				return fmt.Errorf("failed to get relation target of redaction target: %w", err)
@@ -956,7 +998,15 @@ func (h *HiClient) processStateAndTimeline(
 		if err != nil {
 			return -1, err
 		}
-		if isUnread {
+		// Events from ignored users are stored so that they can be shown if the user is unignored,
+		// but they're not sent to the frontend and don't affect unread counts or room previews.
+		// Relations are still processed below, so that reaction counts and poll tallies of the
+		// target event are updated (ignored senders are excluded from those separately).
+		hidden := isTimeline && h.shouldHideEvent(ctx, dbEvt)
+		if hidden {
+			hiddenEvents[dbEvt.RowID] = struct{}{}
+		}
+		if isUnread && !hidden {
 			if dbEvt.UnreadType.Is(database.UnreadTypeNotify) && h.firstSyncReceived {
 				newNotifications = append(newNotifications, jsoncmd.SyncNotification{
 					RowID:     dbEvt.RowID,
@@ -968,7 +1018,7 @@ func (h *HiClient) processStateAndTimeline(
 			}
 			newUnreadCounts.AddOne(dbEvt.UnreadType)
 		}
-		if isTimeline {
+		if isTimeline && !hidden {
 			if dbEvt.CanUseForPreview() {
 				updatedRoom.PreviewEventRowID = dbEvt.RowID
 				recalculatePreviewEvent = false
@@ -987,6 +1037,9 @@ func (h *HiClient) processStateAndTimeline(
 				}
 				if evt.GetStateKey() == h.Account.UserID.String() {
 					go h.maybeUpdateOwnProfile(evt.Content.VeryRaw)
+				} else if syncCtx != nil && h.firstSyncReceived &&
+					(membership == event.MembershipJoin || membership == event.MembershipInvite || membership == event.MembershipKnock) {
+					syncCtx.addNewMember(room.ID, id.UserID(*evt.StateKey))
 				}
 			} else if evt.Type == event.StateElementFunctionalMembers {
 				heroesChanged = true
@@ -995,16 +1048,26 @@ func (h *HiClient) processStateAndTimeline(
 			if err != nil {
 				return -1, fmt.Errorf("failed to save current state event ID %s for %s/%s: %w", evt.ID, evt.Type.Type, *evt.StateKey, err)
 			}
+			if entityType := database.PolicyEntityTypeFromEventType(evt.Type.Type); entityType != "" {
+				err = h.processPolicyRuleEvent(ctx, entityType, evt, dbEvt.RowID)
+				if err != nil {
+					return -1, fmt.Errorf("failed to save policy rule %s: %w", evt.ID, err)
+				} else if syncCtx != nil {
+					syncCtx.policyRulesChanged = true
+				}
+			}
 			processImportantEvent(ctx, evt, room, updatedRoom, dbEvt.RowID, sdc)
 		}
-		allNewEvents = append(allNewEvents, dbEvt)
-		addedEvents[dbEvt.RowID] = struct{}{}
+		if !hidden {
+			allNewEvents = append(allNewEvents, dbEvt)
+			addedEvents[dbEvt.RowID] = struct{}{}
+		}
 		if evt.Type == event.EventRedaction && evt.Redacts != "" {
 			err = processRedaction(evt)
 			if err != nil {
 				return -1, fmt.Errorf("failed to process redaction: %w", err)
 			}
-		} else if dbEvt.RelationType == event.RelReplace || dbEvt.RelationType == event.RelAnnotation {
+		} else if dbEvt.RelationType == event.RelReplace || dbEvt.RelationType == event.RelAnnotation || isPollRelation(dbEvt) {
 			_, err = addOldEvent(0, dbEvt.RelatesTo)
 			if err != nil {
 				return -1, fmt.Errorf("failed to get relation target of event: %w", err)
@@ -1134,6 +1197,12 @@ func (h *HiClient) processStateAndTimeline(
 		if err != nil {
 			return fmt.Errorf("failed to append timeline: %w", err)
 		}
+		if len(hiddenEvents) > 0 {
+			timelineRowTuples = slices.DeleteFunc(timelineRowTuples, func(tuple database.TimelineRowTuple) bool {
+				_, hidden := hiddenEvents[tuple.Event]
+				return hidden
+			})
+		}
 	} else {
 		timelineRowTuples = make([]database.TimelineRowTuple, 0)
 	}
@@ -1198,6 +1267,8 @@ func (h *HiClient) processStateAndTimeline(
 		for _, receipt := range receipts {
 			receipt.RoomID = ""
 		}
+		h.FillPollTallies(ctx, allNewEvents)
+		h.excludeIgnoredReactions(ctx, room.ID, allNewEvents)
 		roomID := room.ID
 		if !syncRoomChanged {
 			room = nil
@@ -29,6 +29,7 @@ type contextKey int
 const (
 	syncContextKey contextKey = iota
 	eventDecryptionLockContextKey
+	inviteDecisionsContextKey
 )
 
 var isDatabaseBusyError = func(error) bool {
@@ -48,6 +49,9 @@ func (h *hiSyncer) ProcessResponse(ctx context.Context, resp *mautrix.RespSync,
 		}})
 	}
 	hasEncrypted := c.preProcessSyncResponse(ctx, resp)
+	if len(resp.Rooms.Invite) > 0 {
+		ctx = context.WithValue(ctx, inviteDecisionsContextKey, c.evaluateInviteRules(ctx, resp.Rooms.Invite))
+	}
 	for i := 0; ; i++ {
 		doProcessTxn := func(ctx context.Context) error {
 			return c.DB.DoTxn(ctx, nil, func(ctx context.Context) error {
@@ -0,0 +1,196 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"go.mau.fi/util/exgjson"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+)
+
+var (
+	ErrTranslationDisabled = errors.New("translation is not configured")
+	ErrNoTranslationTarget = errors.New("no target language specified")
+	ErrNothingToTranslate  = errors.New("event doesn't have a text body")
+)
+
+// Translator translates plaintext into another language.
+type Translator interface {
+	Translate(ctx context.Context, text, targetLanguage string) (*database.Translation, error)
+}
+
+// LibreTranslateTranslator uses the HTTP API of a [LibreTranslate] instance.
+//
+// [LibreTranslate]: https://libretranslate.com
+type LibreTranslateTranslator struct {
+	URL        string
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+var _ Translator = (*LibreTranslateTranslator)(nil)
+
+type libreTranslateRequest struct {
+	Query          string `json:"q"`
+	SourceLanguage string `json:"source"`
+	TargetLanguage string `json:"target"`
+	Format         string `json:"format"`
+	APIKey         string `json:"api_key,omitempty"`
+}
+
+type libreTranslateResponse struct {
+	TranslatedText   string `json:"translatedText"`
+	DetectedLanguage *struct {
+		Language string `json:"language"`
+	} `json:"detectedLanguage,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func (lt *LibreTranslateTranslator) Translate(ctx context.Context, text, targetLanguage string) (*database.Translation, error) {
+	reqBody, err := json.Marshal(&libreTranslateRequest{
+		Query:          text,
+		SourceLanguage: "auto",
+		TargetLanguage: targetLanguage,
+		Format:         "text",
+		APIKey:         lt.APIKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(lt.URL, "/")+"/translate", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	cli := lt.HTTPClient
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+	resp, err := cli.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	var respData libreTranslateResponse
+	if err = json.NewDecoder(resp.Body).Decode(&respData); err != nil {
+		return nil, fmt.Errorf("failed to parse response (HTTP %d): %w", resp.StatusCode, err)
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("translator returned HTTP %d: %s", resp.StatusCode, respData.Error)
+	}
+	translation := &database.Translation{
+		Text:     respData.TranslatedText,
+		Language: targetLanguage,
+	}
+	if respData.DetectedLanguage != nil {
+		translation.SourceLanguage = respData.DetectedLanguage.Language
+	}
+	return translation, nil
+}
+
+// CommandTranslator runs a local command to translate text. The text is passed via stdin,
+// the target language in the GOMUKS_TRANSLATE_TARGET environment variable,
+// and the translation is read from stdout.
+type CommandTranslator struct {
+	Command []string
+}
+
+var _ Translator = (*CommandTranslator)(nil)
+
+func (ct *CommandTranslator) Translate(ctx context.Context, text, targetLanguage string) (*database.Translation, error) {
+	if len(ct.Command) == 0 {
+		return nil, fmt.Errorf("translation command not configured")
+	}
+	cmd := exec.CommandContext(ctx, ct.Command[0], ct.Command[1:]...)
+	cmd.Env = append(os.Environ(), "GOMUKS_TRANSLATE_TARGET="+targetLanguage)
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("translation command failed: %w (stderr: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+	return &database.Translation{
+		Text:     strings.TrimRight(string(output), "\n"),
+		Language: targetLanguage,
+	}, nil
+}
+
+// StubTranslator is a translator that doesn't actually translate anything,
+// it only prefixes the text with the target language. It's meant for testing.
+type StubTranslator struct{}
+
+var _ Translator = StubTranslator{}
+
+func (StubTranslator) Translate(_ context.Context, text, targetLanguage string) (*database.Translation, error) {
+	return &database.Translation{
+		Text:     fmt.Sprintf("[%s] %s", targetLanguage, text),
+		Language: targetLanguage,
+	}, nil
+}
+
+// TranslateEvent translates the plaintext body of the given event and caches the result in the event's local content.
+// To translate an edited message, the ID of the latest edit event should be passed.
+// The updated event is also dispatched to all clients. If the event already has a translation in the target language,
+// the cached translation is returned without calling the translator.
+func (h *HiClient) TranslateEvent(ctx context.Context, roomID id.RoomID, eventID id.EventID, targetLanguage string) (*database.Event, error) {
+	if h.Translator == nil {
+		return nil, ErrTranslationDisabled
+	}
+	if targetLanguage == "" {
+		targetLanguage = h.DefaultTranslationLanguage
+		if targetLanguage == "" {
+			return nil, ErrNoTranslationTarget
+		}
+	}
+	evt, err := h.DB.Event.GetByID(ctx, roomID, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	} else if evt == nil {
+		return nil, fmt.Errorf("event not found")
+	} else if cached := evt.LocalContent.GetTranslation(); cached != nil && cached.Language == targetLanguage {
+		return evt, nil
+	}
+	content := evt.Content
+	if evt.Decrypted != nil {
+		content = evt.Decrypted
+	}
+	// Edits are translated based on the new content
+	body := gjson.GetBytes(content, exgjson.Path("m.new_content", "body")).Str
+	if body == "" {
+		body = gjson.GetBytes(content, "body").Str
+	}
+	if strings.TrimSpace(body) == "" || evt.RedactedBy != "" {
+		return nil, ErrNothingToTranslate
+	}
+	translation, err := h.Translator.Translate(ctx, body, targetLanguage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to translate: %w", err)
+	}
+	if evt.LocalContent == nil {
+		evt.LocalContent = &database.LocalContent{}
+	}
+	evt.LocalContent.Translation = translation
+	err = h.DB.Event.UpdateLocalContent(ctx, evt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save translation: %w", err)
+	}
+	h.EventHandler(&jsoncmd.EventsDecrypted{RoomID: roomID, Events: []*database.Event{evt}})
+	return evt, nil
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+)
+
+type countingTranslator struct {
+	StubTranslator
+	calls int
+}
+
+func (ct *countingTranslator) Translate(ctx context.Context, text, targetLanguage string) (*database.Translation, error) {
+	ct.calls++
+	return ct.StubTranslator.Translate(ctx, text, targetLanguage)
+}
+
+func TestTranslateEvent(t *testing.T) {
+	ctx := context.Background()
+	h, dispatched := newTestClient(t)
+	translator := &countingTranslator{}
+	h.Translator = translator
+	insertTestEvent(t, h, "$plain", `{"msgtype":"m.text","body":"hello"}`, "")
+	insertTestEvent(t, h, "$edit", `{"msgtype":"m.text","body":"* hello","m.new_content":{"msgtype":"m.text","body":"hello world"}}`, "")
+	insertTestEvent(t, h, "$redacted", `{"msgtype":"m.text","body":"secret"}`, "$redaction")
+	insertTestEvent(t, h, "$empty", `{"msgtype":"m.text","body":"  "}`, "")
+
+	evt, err := h.TranslateEvent(ctx, testRoomID, "$plain", "fi")
+	if err != nil {
+		t.Fatalf("TranslateEvent returned error: %v", err)
+	} else if got := evt.LocalContent.GetTranslation(); got == nil || got.Text != "[fi] hello" || got.Language != "fi" {
+		t.Errorf("TranslateEvent returned translation %+v", got)
+	}
+	if len(*dispatched) != 1 {
+		t.Errorf("Expected one dispatched event, got %d", len(*dispatched))
+	} else if decrypted, ok := (*dispatched)[0].(*jsoncmd.EventsDecrypted); !ok || len(decrypted.Events) != 1 {
+		t.Errorf("Expected an EventsDecrypted event with the translated event, got %+v", (*dispatched)[0])
+	}
+
+	stored, err := h.DB.Event.GetByID(ctx, testRoomID, "$plain")
+	if err != nil {
+		t.Fatalf("Failed to get event: %v", err)
+	} else if got := stored.LocalContent.GetTranslation(); got == nil || got.Text != "[fi] hello" {
+		t.Errorf("Translation wasn't saved in local content: %+v", got)
+	}
+
+	// The cached translation is returned without calling the translator
+	_, err = h.TranslateEvent(ctx, testRoomID, "$plain", "fi")
+	if err != nil {
+		t.Fatalf("TranslateEvent returned error for cached translation: %v", err)
+	} else if translator.calls != 1 {
+		t.Errorf("Translator was called %d times, expected cached translation to be used", translator.calls)
+	}
+	// A different language replaces the cached translation
+	h.DefaultTranslationLanguage = "de"
+	evt, err = h.TranslateEvent(ctx, testRoomID, "$plain", "")
+	if err != nil {
+		t.Fatalf("TranslateEvent returned error: %v", err)
+	} else if translator.calls != 2 || evt.LocalContent.GetTranslation().Text != "[de] hello" {
+		t.Errorf("Expected translation to default language, got %+v", evt.LocalContent.GetTranslation())
+	}
+
+	evt, err = h.TranslateEvent(ctx, testRoomID, "$edit", "fi")
+	if err != nil {
+		t.Fatalf("TranslateEvent returned error for edit: %v", err)
+	} else if got := evt.LocalContent.GetTranslation().Text; got != "[fi] hello world" {
+		t.Errorf("Edit was translated as %q, expected the new content to be used", got)
+	}
+
+	for _, eventID := range []id.EventID{"$redacted", "$empty"} {
+		_, err = h.TranslateEvent(ctx, testRoomID, eventID, "fi")
+		if !errors.Is(err, ErrNothingToTranslate) {
+			t.Errorf("TranslateEvent(%s) returned %v, expected ErrNothingToTranslate", eventID, err)
+		}
+	}
+
+	h.DefaultTranslationLanguage = ""
+	if _, err = h.TranslateEvent(ctx, testRoomID, "$plain", ""); !errors.Is(err, ErrNoTranslationTarget) {
+		t.Errorf("TranslateEvent without language returned %v, expected ErrNoTranslationTarget", err)
+	}
+	h.Translator = nil
+	if _, err = h.TranslateEvent(ctx, testRoomID, "$plain", "fi"); !errors.Is(err, ErrTranslationDisabled) {
+		t.Errorf("TranslateEvent without translator returned %v, expected ErrTranslationDisabled", err)
+	}
+}
+
+func TestLibreTranslateTranslator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req libreTranslateRequest
+		if r.URL.Path != "/translate" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"Not found"}`))
+		} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"Invalid request"}`))
+		} else if req.TargetLanguage == "xx" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"xx is not supported"}`))
+		} else if req.TargetLanguage == "broken" {
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`<html>Bad gateway</html>`))
+		} else if req.APIKey != "key" || req.SourceLanguage != "auto" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":"Invalid API key"}`))
+		} else {
+			_, _ = w.Write([]byte(`{"translatedText":"hei maailma","detectedLanguage":{"confidence":90,"language":"en"}}`))
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	lt := &LibreTranslateTranslator{URL: server.URL + "/", APIKey: "key"}
+
+	translation, err := lt.Translate(ctx, "hello world", "fi")
+	if err != nil {
+		t.Fatalf("Translate returned error: %v", err)
+	} else if translation.Text != "hei maailma" || translation.Language != "fi" || translation.SourceLanguage != "en" {
+		t.Errorf("Translate returned %+v", translation)
+	}
+
+	_, err = lt.Translate(ctx, "hello", "xx")
+	if err == nil || !strings.Contains(err.Error(), "HTTP 400") || !strings.Contains(err.Error(), "xx is not supported") {
+		t.Errorf("Expected error with HTTP status and message, got %v", err)
+	}
+	_, err = lt.Translate(ctx, "hello", "broken")
+	if err == nil || !strings.Contains(err.Error(), "failed to parse response (HTTP 502)") {
+		t.Errorf("Expected parse error for non-JSON response, got %v", err)
+	}
+}
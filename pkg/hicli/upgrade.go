@@ -0,0 +1,231 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/rs/zerolog"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"go.mau.fi/util/exgjson"
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+)
+
+type reqUpgradeRoom struct {
+	NewVersion         string      `json:"new_version"`
+	AdditionalCreators []id.UserID `json:"additional_creators,omitempty"`
+}
+
+type respUpgradeRoom struct {
+	ReplacementRoom id.RoomID `json:"replacement_room"`
+}
+
+// State event types that are copied from the old room if the server didn't already copy them.
+// Space parents are never copied by the server, the rest are usually copied but not guaranteed.
+var upgradeCopiedStateTypes = []event.Type{
+	event.StateRoomName,
+	event.StateTopic,
+	event.StateRoomAvatar,
+	event.StatePowerLevels,
+	event.StateCanonicalAlias,
+	event.StateJoinRules,
+	event.StateSpaceParent,
+}
+
+// Unstable room versions where the room creators have infinite power and can't be listed in the power levels.
+var unstableCreatorRoomVersions = []string{"org.matrix.hydra.11"}
+
+// roomVersionHasPrivilegedCreators checks if the given room version is known to give room creators
+// infinite power. Unknown versions are assumed to not have privileged creators.
+func roomVersionHasPrivilegedCreators(version string) bool {
+	if slices.Contains(unstableCreatorRoomVersions, version) {
+		return true
+	}
+	// Stable room versions are plain integers, 12 is the first one with privileged creators
+	numericVersion, err := strconv.Atoi(version)
+	return err == nil && numericVersion >= 12
+}
+
+// getRoomCreators returns the creators of a room based on its create event.
+// Nil is returned for room versions where creators are not special.
+func getRoomCreators(createEvt *event.Event) []id.UserID {
+	if createEvt == nil || !roomVersionHasPrivilegedCreators(gjson.GetBytes(createEvt.Content.VeryRaw, "room_version").Str) {
+		return nil
+	}
+	creators := []id.UserID{createEvt.Sender}
+	for _, userID := range gjson.GetBytes(createEvt.Content.VeryRaw, "additional_creators").Array() {
+		creators = append(creators, id.UserID(userID.Str))
+	}
+	return creators
+}
+
+// stripCreatorsFromPowerLevels removes the given users from the users object of a power level event,
+// as the creators of v12+ rooms aren't allowed to be listed there.
+func stripCreatorsFromPowerLevels(content json.RawMessage, creators []id.UserID) (json.RawMessage, error) {
+	var err error
+	for _, userID := range creators {
+		path := exgjson.Path("users", userID.String())
+		if !gjson.GetBytes(content, path).Exists() {
+			continue
+		}
+		content, err = sjson.DeleteBytes(content, path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return content, nil
+}
+
+// UpgradeRoom upgrades the given room to a new room version using the /upgrade endpoint,
+// then copies over state that the server didn't copy, invites members if the room is private
+// and updates the m.space.child events in any spaces the old room was in.
+//
+// Only the actual upgrade call can fail, errors in the following steps are logged and ignored,
+// as the old room has already been tombstoned at that point.
+func (h *HiClient) UpgradeRoom(ctx context.Context, roomID id.RoomID, newVersion string, additionalCreators []id.UserID) (id.RoomID, error) {
+	room, err := h.DB.Room.Get(ctx, roomID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get room metadata: %w", err)
+	} else if room == nil {
+		return "", fmt.Errorf("unknown room")
+	} else if room.Tombstone != nil && room.Tombstone.ReplacementRoom != "" {
+		return "", fmt.Errorf("room has already been replaced by %s", room.Tombstone.ReplacementRoom)
+	}
+	// Make sure the member list is available before the old room is tombstoned
+	err = h.loadMembers(ctx, room)
+	if err != nil {
+		return "", err
+	}
+	var resp respUpgradeRoom
+	_, err = h.Client.MakeRequest(ctx, http.MethodPost, h.Client.BuildClientURL("v3", "rooms", roomID, "upgrade"), &reqUpgradeRoom{
+		NewVersion:         newVersion,
+		AdditionalCreators: additionalCreators,
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	newRoomID := resp.ReplacementRoom
+	log := zerolog.Ctx(ctx).With().
+		Stringer("old_room_id", roomID).
+		Stringer("new_room_id", newRoomID).
+		Logger()
+	ctx = log.WithContext(ctx)
+	log.Info().Str("new_version", newVersion).Msg("Upgraded room")
+	h.copyStateToUpgradedRoom(ctx, roomID, newRoomID)
+	h.inviteMembersToUpgradedRoom(ctx, roomID, newRoomID)
+	h.updateSpaceChildrenForUpgradedRoom(ctx, roomID, newRoomID)
+	return newRoomID, nil
+}
+
+func (h *HiClient) copyStateToUpgradedRoom(ctx context.Context, oldRoomID, newRoomID id.RoomID) {
+	log := zerolog.Ctx(ctx)
+	newState, err := h.Client.State(ctx, newRoomID)
+	if err != nil {
+		log.Err(err).Msg("Failed to get state of upgraded room, not copying state")
+		return
+	}
+	oldState, err := h.DB.CurrentState.GetAllExceptMembers(ctx, oldRoomID)
+	if err != nil {
+		log.Err(err).Msg("Failed to get state of old room, not copying state")
+		return
+	}
+	for _, evt := range oldState {
+		evtType := event.Type{Type: evt.Type, Class: event.StateEventType}
+		if !slices.Contains(upgradeCopiedStateTypes, evtType) || evt.StateKey == nil {
+			continue
+		} else if _, alreadyCopied := newState[evtType][*evt.StateKey]; alreadyCopied {
+			continue
+		} else if len(evt.Content) == 0 || string(evt.Content) == "{}" {
+			continue
+		}
+		content := json.RawMessage(evt.Content)
+		if evtType == event.StatePowerLevels {
+			content, err = stripCreatorsFromPowerLevels(content, getRoomCreators(newState[event.StateCreate][""]))
+			if err != nil {
+				log.Err(err).Msg("Failed to remove creators from power levels, not copying them")
+				continue
+			}
+		}
+		_, err = h.Client.SendStateEvent(ctx, newRoomID, evtType, *evt.StateKey, content)
+		if err != nil {
+			log.Err(err).
+				Stringer("event_type", &evtType).
+				Str("state_key", *evt.StateKey).
+				Msg("Failed to copy state event to upgraded room")
+		}
+	}
+}
+
+func (h *HiClient) inviteMembersToUpgradedRoom(ctx context.Context, oldRoomID, newRoomID id.RoomID) {
+	log := zerolog.Ctx(ctx)
+	joinRulesEvt, err := h.DB.CurrentState.Get(ctx, oldRoomID, event.StateJoinRules, "")
+	if err != nil {
+		log.Err(err).Msg("Failed to get join rules of old room, not inviting members")
+		return
+	} else if joinRulesEvt == nil {
+		return
+	}
+	switch event.JoinRule(gjson.GetBytes(joinRulesEvt.Content, "join_rule").Str) {
+	case event.JoinRulePublic, event.JoinRuleRestricted, event.JoinRuleKnockRestricted:
+		// Anyone (or anyone in a space) can join the new room themselves
+		return
+	}
+	members, err := h.DB.CurrentState.GetMembers(ctx, oldRoomID)
+	if err != nil {
+		log.Err(err).Msg("Failed to get members of old room, not inviting them")
+		return
+	}
+	for _, member := range members {
+		userID := id.UserID(*member.StateKey)
+		membership := event.Membership(gjson.GetBytes(member.Content, "membership").Str)
+		if userID == h.Account.UserID || (membership != event.MembershipJoin && membership != event.MembershipInvite) {
+			continue
+		}
+		_, err = h.Client.InviteUser(ctx, newRoomID, &mautrix.ReqInviteUser{UserID: userID})
+		if err != nil {
+			log.Err(err).Stringer("user_id", userID).Msg("Failed to invite user to upgraded room")
+		}
+	}
+}
+
+func (h *HiClient) updateSpaceChildrenForUpgradedRoom(ctx context.Context, oldRoomID, newRoomID id.RoomID) {
+	log := zerolog.Ctx(ctx)
+	spaceIDs, err := h.DB.SpaceEdge.GetParentIDs(ctx, oldRoomID)
+	if err != nil {
+		log.Err(err).Msg("Failed to get spaces containing old room")
+		return
+	}
+	for _, spaceID := range spaceIDs {
+		var childEvt *database.Event
+		childEvt, err = h.DB.CurrentState.Get(ctx, spaceID, event.StateSpaceChild, oldRoomID.String())
+		if err != nil {
+			log.Err(err).Stringer("space_id", spaceID).Msg("Failed to get space child event for old room")
+			continue
+		} else if childEvt == nil || len(childEvt.Content) == 0 || string(childEvt.Content) == "{}" {
+			continue
+		}
+		_, err = h.SetState(ctx, spaceID, event.StateSpaceChild, newRoomID.String(), json.RawMessage(childEvt.Content))
+		if err != nil {
+			log.Err(err).Stringer("space_id", spaceID).Msg("Failed to add upgraded room to space")
+			continue
+		}
+		_, err = h.SetState(ctx, spaceID, event.StateSpaceChild, oldRoomID.String(), struct{}{})
+		if err != nil {
+			log.Err(err).Stringer("space_id", spaceID).Msg("Failed to remove old room from space")
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hicli
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+)
+
+func TestGetRoomCreators(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		want    []id.UserID
+	}{
+		"v11":          {`{"room_version":"11"}`, nil},
+		"no version":   {`{}`, nil},
+		"unknown":      {`{"room_version":"org.example.custom"}`, nil},
+		"hydra":        {`{"room_version":"org.matrix.hydra.11"}`, []id.UserID{"@alice:example.com"}},
+		"v12":          {`{"room_version":"12"}`, []id.UserID{"@alice:example.com"}},
+		"v12 creators": {`{"room_version":"12","additional_creators":["@bob:example.com"]}`, []id.UserID{"@alice:example.com", "@bob:example.com"}},
+	}
+	for name, test := range tests {
+		evt := &event.Event{Sender: "@alice:example.com", Content: event.Content{VeryRaw: json.RawMessage(test.content)}}
+		if got := getRoomCreators(evt); !slices.Equal(got, test.want) {
+			t.Errorf("%s: getRoomCreators() = %v, want %v", name, got, test.want)
+		}
+	}
+}
+
+func TestStripCreatorsFromPowerLevels(t *testing.T) {
+	content := json.RawMessage(`{"users":{"@alice:example.com":100,"@bob:example.com":50,"@carol:example.com":50},"ban":50}`)
+	got, err := stripCreatorsFromPowerLevels(content, []id.UserID{"@alice:example.com", "@carol:example.com", "@dave:example.com"})
+	if err != nil {
+		t.Fatalf("stripCreatorsFromPowerLevels() returned error: %v", err)
+	}
+	if want := `{"users":{"@bob:example.com":50},"ban":50}`; string(got) != want {
+		t.Errorf("stripCreatorsFromPowerLevels() = %s, want %s", got, want)
+	}
+}
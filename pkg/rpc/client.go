@@ -10,9 +10,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -20,11 +22,14 @@ import (
 	"github.com/coder/websocket"
 	"golang.org/x/net/publicsuffix"
 	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/event"
 	"maunium.net/go/mautrix/id"
 
 	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
 )
 
+const progressStreamMime = "application/x-mau-progress-stream+json"
+
 type EventHandler = func(ctx context.Context, event any)
 
 type GomuksRPC struct {
@@ -142,3 +147,77 @@ func (gr *GomuksRPC) DownloadMedia(ctx context.Context, params DownloadMediaPara
 	}
 	return resp, err
 }
+
+// UploadMedia uploads the data in the given reader to the backend using the streaming progress mode.
+// The path field in params is ignored. The progress callback is called with values between 0 and 1.
+func (gr *GomuksRPC) UploadMedia(
+	ctx context.Context,
+	reader io.Reader,
+	params *jsoncmd.UploadMediaParams,
+	progress func(float64),
+) (*event.MessageEventContent, error) {
+	query := url.Values{"progress": {"true"}}
+	if params.Filename != "" {
+		query.Set("filename", params.Filename)
+	}
+	if params.Encrypt {
+		query.Set("encrypt", "true")
+	}
+	if params.VoiceMessage {
+		query.Set("voice_message", "true")
+	}
+	if params.ForceFile {
+		query.Set("force_file", "true")
+	}
+	if params.EncodeTo != "" {
+		query.Set("encode_to", params.EncodeTo)
+		if params.ResizeWidth > 0 && params.ResizeHeight > 0 {
+			query.Set("resize_width", strconv.Itoa(params.ResizeWidth))
+			query.Set("resize_height", strconv.Itoa(params.ResizeHeight))
+		} else if params.ResizePercent > 0 {
+			query.Set("resize_percent", strconv.Itoa(params.ResizePercent))
+		}
+		if params.Quality > 0 {
+			query.Set("quality", strconv.Itoa(params.Quality))
+		}
+	}
+	addr := gr.BuildURLWithQuery(GomuksURLPath{"upload"}, query)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, addr, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare request: %w", err)
+	}
+	req.Header.Set("User-Agent", gr.UserAgent)
+	req.Header.Set("Accept", progressStreamMime)
+	resp, err := gr.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload media: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to upload media: HTTP %d", resp.StatusCode)
+	}
+	// The response is a stream of JSON values: numbers for progress updates,
+	// followed by either the message content or an error object.
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var val json.RawMessage
+		if err = dec.Decode(&val); err != nil {
+			return nil, fmt.Errorf("failed to read upload response: %w", err)
+		} else if len(val) == 0 || val[0] != '{' {
+			var progressVal float64
+			if json.Unmarshal(val, &progressVal) == nil && progress != nil {
+				progress(progressVal)
+			}
+			continue
+		}
+		var respErr mautrix.RespError
+		if err = json.Unmarshal(val, &respErr); err == nil && respErr.ErrCode != "" {
+			return nil, fmt.Errorf("failed to upload media: %w", respErr)
+		}
+		var content event.MessageEventContent
+		if err = json.Unmarshal(val, &content); err != nil {
+			return nil, fmt.Errorf("failed to parse upload response: %w", err)
+		}
+		return &content, nil
+	}
+}
@@ -11,11 +11,14 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"os"
+	"path/filepath"
 	"slices"
 	"strconv"
 	"sync"
 
 	"go.mau.fi/util/exsync"
+	"maunium.net/go/mautrix/event"
 	"maunium.net/go/mautrix/id"
 
 	"go.mau.fi/gomuks/pkg/hicli/database"
@@ -83,6 +86,8 @@ func (gc *GomuksClient) handleEvent(ctx context.Context, rawEvt any) {
 		gc.GomuksStore.ImageAuthToken = string(*evt)
 	case *jsoncmd.Typing:
 		callRoomMethod(gc, evt.RoomID, (*store.RoomStore).ApplyTyping, evt.UserIDs)
+	case *jsoncmd.DraftUpdated:
+		gc.GomuksStore.ApplyDraftUpdate(evt)
 	}
 	if gc.EventHandler != nil {
 		gc.EventHandler(ctx, rawEvt)
@@ -97,6 +102,15 @@ func callRoomMethod[T any](gc *GomuksClient, roomID id.RoomID, fn func(room *sto
 	fn(room, val)
 }
 
+func (gc *GomuksClient) LoadDrafts(ctx context.Context) error {
+	drafts, err := gc.GomuksAPI.GetDrafts(ctx)
+	if err != nil {
+		return err
+	}
+	gc.GomuksStore.SetDrafts(drafts)
+	return nil
+}
+
 func (gc *GomuksClient) RequestEvent(ctx context.Context, room *store.RoomStore, eventID id.EventID) {
 
 }
@@ -186,9 +200,9 @@ func (gc *GomuksClient) LoadMoreHistory(ctx context.Context, roomID id.RoomID) e
 		return fmt.Errorf("already paginating room")
 	}
 	defer room.Paginating.Store(false)
-	oldestRowID, count := room.GetPaginationParams()
+	paginationRoomID, oldestRowID, count := room.GetPaginationParams()
 	resp, err := gc.GomuksAPI.Paginate(ctx, &jsoncmd.PaginateParams{
-		RoomID:        room.ID,
+		RoomID:        paginationRoomID,
 		MaxTimelineID: oldestRowID,
 		Limit:         count,
 		Reset:         false,
@@ -200,6 +214,53 @@ func (gc *GomuksClient) LoadMoreHistory(ctx context.Context, roomID id.RoomID) e
 	return nil
 }
 
+// threadListReplyLimit is the maximum number of recent thread replies that are used to build the thread list.
+const threadListReplyLimit = 1000
+
+// GetThreads fetches recent thread replies in the room and the user's read receipts from the backend,
+// and returns summaries of the threads, most recently active first.
+func (gc *GomuksClient) GetThreads(ctx context.Context, room *store.RoomStore) ([]*store.ThreadSummary, error) {
+	replies, err := gc.GomuksAPI.GetThreadReplies(ctx, &jsoncmd.GetThreadRepliesParams{
+		RoomID: room.ID,
+		Limit:  threadListReplyLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread replies: %w", err)
+	}
+	receipts, err := gc.GomuksAPI.GetOwnReceipts(ctx, &jsoncmd.GetOwnReceiptsParams{RoomID: room.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get read receipts: %w", err)
+	}
+	return room.GetThreads(replies, receipts), nil
+}
+
+func (gc *GomuksClient) LoadThreadHistory(ctx context.Context, thread *store.ThreadStore) error {
+	if !thread.HasMoreHistory() {
+		return nil
+	} else if !thread.Paginating.CompareAndSwap(false, true) {
+		return fmt.Errorf("already paginating thread")
+	}
+	defer thread.Paginating.Store(false)
+	params := thread.GetPaginationParams()
+	resp, err := gc.GomuksAPI.PaginateManual(ctx, params)
+	if err != nil {
+		return err
+	}
+	thread.ApplyPagination(resp)
+	if !thread.HasMoreHistory() && thread.GetRoot() == nil {
+		root, err := gc.GomuksAPI.GetEvent(ctx, &jsoncmd.GetEventParams{
+			RoomID:  params.RoomID,
+			EventID: thread.RootID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get thread root: %w", err)
+		} else if root != nil {
+			thread.ApplyRoot(root)
+		}
+	}
+	return nil
+}
+
 func (gc *GomuksClient) GetDownloadURL(mxc id.ContentURI, encrypted, preauthed bool) string {
 	query := url.Values{
 		"encrypted": {strconv.FormatBool(encrypted)},
@@ -223,3 +284,22 @@ func (gc *GomuksClient) Download(mxc id.ContentURI, encrypted bool) ([]byte, err
 	defer resp.Body.Close()
 	return io.ReadAll(resp.Body)
 }
+
+// Upload uploads a file from the local disk. Unlike the upload_media command, the file is read by the client,
+// so this works even if the backend is running on a different machine.
+func (gc *GomuksClient) Upload(
+	ctx context.Context,
+	params *jsoncmd.UploadMediaParams,
+	progress func(float64),
+) (*event.MessageEventContent, error) {
+	file, err := os.Open(params.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+	if params.Filename == "" {
+		params.Filename = filepath.Base(params.Path)
+	}
+	// TODO fix uploads with non-remote backend
+	return gc.GomuksAPI.(*rpc.GomuksRPC).UploadMedia(ctx, file, params, progress)
+}
@@ -111,6 +111,10 @@ func (gr *GomuksRPC) GetRelatedEvents(ctx context.Context, params *jsoncmd.GetRe
 	return executeRequest(gr, ctx, jsoncmd.GetRelatedEvents, params)
 }
 
+func (gr *GomuksRPC) GetThreadReplies(ctx context.Context, params *jsoncmd.GetThreadRepliesParams) ([]*database.Event, error) {
+	return executeRequest(gr, ctx, jsoncmd.GetThreadReplies, params)
+}
+
 func (gr *GomuksRPC) GetStickyEvents(ctx context.Context, params *jsoncmd.GetStickyEventsParams) ([]*database.Event, error) {
 	return executeRequest(gr, ctx, jsoncmd.GetStickyEvents, params)
 }
@@ -131,6 +135,10 @@ func (gr *GomuksRPC) GetReceipts(ctx context.Context, params *jsoncmd.GetReceipt
 	return executeRequest(gr, ctx, jsoncmd.GetReceipts, params)
 }
 
+func (gr *GomuksRPC) GetOwnReceipts(ctx context.Context, params *jsoncmd.GetOwnReceiptsParams) ([]*database.Receipt, error) {
+	return executeRequest(gr, ctx, jsoncmd.GetOwnReceipts, params)
+}
+
 func (gr *GomuksRPC) Paginate(ctx context.Context, params *jsoncmd.PaginateParams) (*jsoncmd.PaginationResponse, error) {
 	return executeRequest(gr, ctx, jsoncmd.Paginate, params)
 }
@@ -282,3 +290,143 @@ func (gr *GomuksRPC) CalculateRoomID(ctx context.Context, params *jsoncmd.Calcul
 func (gr *GomuksRPC) RerequestSession(ctx context.Context, params *jsoncmd.RerequestSessionParams) error {
 	return executeRequestNoResponse(gr, ctx, jsoncmd.RerequestSession, params)
 }
+
+func (gr *GomuksRPC) UpgradeRoom(ctx context.Context, params *jsoncmd.UpgradeRoomParams) (*jsoncmd.UpgradeRoomResponse, error) {
+	return executeRequest(gr, ctx, jsoncmd.UpgradeRoom, params)
+}
+
+func (gr *GomuksRPC) BulkRedact(ctx context.Context, params *jsoncmd.BulkRedactParams) (*jsoncmd.ModerationJobResponse, error) {
+	return executeRequest(gr, ctx, jsoncmd.BulkRedact, params)
+}
+
+func (gr *GomuksRPC) BulkBan(ctx context.Context, params *jsoncmd.BulkBanParams) (*jsoncmd.ModerationJobResponse, error) {
+	return executeRequest(gr, ctx, jsoncmd.BulkBan, params)
+}
+
+func (gr *GomuksRPC) BulkKick(ctx context.Context, params *jsoncmd.BulkKickParams) (*jsoncmd.ModerationJobResponse, error) {
+	return executeRequest(gr, ctx, jsoncmd.BulkKick, params)
+}
+
+func (gr *GomuksRPC) CancelModerationJob(ctx context.Context, params *jsoncmd.CancelModerationJobParams) (bool, error) {
+	return executeRequest(gr, ctx, jsoncmd.CancelModerationJob, params)
+}
+
+func (gr *GomuksRPC) GetPolicyLists(ctx context.Context) ([]*database.PolicyList, error) {
+	return executeRequest(gr, ctx, jsoncmd.GetPolicyLists, nil)
+}
+
+func (gr *GomuksRPC) SetPolicyList(ctx context.Context, params *jsoncmd.SetPolicyListParams) error {
+	return executeRequestNoResponse(gr, ctx, jsoncmd.SetPolicyList, params)
+}
+
+func (gr *GomuksRPC) GetPolicyMatches(ctx context.Context, params *jsoncmd.GetPolicyMatchesParams) (map[id.UserID]*database.PolicyRule, error) {
+	return executeRequest(gr, ctx, jsoncmd.GetPolicyMatches, params)
+}
+
+func (gr *GomuksRPC) GetPolicyReport(ctx context.Context) ([]*jsoncmd.PolicyBanTarget, error) {
+	return executeRequest(gr, ctx, jsoncmd.GetPolicyReport, nil)
+}
+
+func (gr *GomuksRPC) ApplyPolicyBans(ctx context.Context) (*jsoncmd.ModerationJobResponse, error) {
+	return executeRequest(gr, ctx, jsoncmd.ApplyPolicyBans, nil)
+}
+
+func (gr *GomuksRPC) GetIgnoredUsers(ctx context.Context) ([]id.UserID, error) {
+	return executeRequest(gr, ctx, jsoncmd.GetIgnoredUsers, nil)
+}
+
+func (gr *GomuksRPC) SetIgnored(ctx context.Context, params *jsoncmd.SetIgnoredParams) error {
+	return executeRequestNoResponse(gr, ctx, jsoncmd.SetIgnored, params)
+}
+
+func (gr *GomuksRPC) GetInviteRules(ctx context.Context) (*jsoncmd.InviteRules, error) {
+	return executeRequest(gr, ctx, jsoncmd.GetInviteRules, nil)
+}
+
+func (gr *GomuksRPC) SetInviteRules(ctx context.Context, params *jsoncmd.InviteRules) error {
+	return executeRequestNoResponse(gr, ctx, jsoncmd.SetInviteRules, params)
+}
+
+func (gr *GomuksRPC) RejectInvites(ctx context.Context, params *jsoncmd.RejectInvitesParams) (*jsoncmd.RejectInvitesResponse, error) {
+	return executeRequest(gr, ctx, jsoncmd.RejectInvites, params)
+}
+
+func (gr *GomuksRPC) VotePoll(ctx context.Context, params *jsoncmd.VotePollParams) (*database.Event, error) {
+	return executeRequest(gr, ctx, jsoncmd.VotePoll, params)
+}
+
+func (gr *GomuksRPC) EndPoll(ctx context.Context, params *jsoncmd.EndPollParams) (*database.Event, error) {
+	return executeRequest(gr, ctx, jsoncmd.EndPoll, params)
+}
+
+func (gr *GomuksRPC) SetDraft(ctx context.Context, params *database.Draft) error {
+	return executeRequestNoResponse(gr, ctx, jsoncmd.SetDraft, params)
+}
+
+func (gr *GomuksRPC) GetDrafts(ctx context.Context) ([]*database.Draft, error) {
+	return executeRequest(gr, ctx, jsoncmd.GetDrafts, nil)
+}
+
+func (gr *GomuksRPC) GetImagePacks(ctx context.Context, params *jsoncmd.GetImagePacksParams) ([]*jsoncmd.ImagePackInfo, error) {
+	return executeRequest(gr, ctx, jsoncmd.GetImagePacks, params)
+}
+
+func (gr *GomuksRPC) CreateImagePack(ctx context.Context, params *jsoncmd.CreateImagePackParams) error {
+	return executeRequestNoResponse(gr, ctx, jsoncmd.CreateImagePack, params)
+}
+
+func (gr *GomuksRPC) SetPackImage(ctx context.Context, params *jsoncmd.SetPackImageParams) error {
+	return executeRequestNoResponse(gr, ctx, jsoncmd.SetPackImage, params)
+}
+
+func (gr *GomuksRPC) RenamePackImage(ctx context.Context, params *jsoncmd.RenamePackImageParams) error {
+	return executeRequestNoResponse(gr, ctx, jsoncmd.RenamePackImage, params)
+}
+
+func (gr *GomuksRPC) DeletePackImage(ctx context.Context, params *jsoncmd.DeletePackImageParams) error {
+	return executeRequestNoResponse(gr, ctx, jsoncmd.DeletePackImage, params)
+}
+
+func (gr *GomuksRPC) SetImagePackEnabled(ctx context.Context, params *jsoncmd.SetImagePackEnabledParams) error {
+	return executeRequestNoResponse(gr, ctx, jsoncmd.SetImagePackEnabled, params)
+}
+
+func (gr *GomuksRPC) SendSticker(ctx context.Context, params *jsoncmd.SendStickerParams) (*database.Event, error) {
+	return executeRequest(gr, ctx, jsoncmd.SendSticker, params)
+}
+
+func (gr *GomuksRPC) SendLocation(ctx context.Context, params *jsoncmd.SendLocationParams) (*database.Event, error) {
+	return executeRequest(gr, ctx, jsoncmd.SendLocation, params)
+}
+
+func (gr *GomuksRPC) StartLiveLocation(ctx context.Context, params *jsoncmd.StartLiveLocationParams) (id.EventID, error) {
+	return executeRequest(gr, ctx, jsoncmd.StartLiveLocation, params)
+}
+
+func (gr *GomuksRPC) UpdateLiveLocation(ctx context.Context, params *jsoncmd.UpdateLiveLocationParams) error {
+	return executeRequestNoResponse(gr, ctx, jsoncmd.UpdateLiveLocation, params)
+}
+
+func (gr *GomuksRPC) StopLiveLocation(ctx context.Context, params *jsoncmd.StopLiveLocationParams) error {
+	return executeRequestNoResponse(gr, ctx, jsoncmd.StopLiveLocation, params)
+}
+
+func (gr *GomuksRPC) GetSnippets(ctx context.Context) (*jsoncmd.Snippets, error) {
+	return executeRequest(gr, ctx, jsoncmd.GetSnippets, nil)
+}
+
+func (gr *GomuksRPC) SetSnippet(ctx context.Context, params *jsoncmd.SetSnippetParams) error {
+	return executeRequestNoResponse(gr, ctx, jsoncmd.SetSnippet, params)
+}
+
+func (gr *GomuksRPC) CompleteCommand(ctx context.Context, params *jsoncmd.CompleteCommandParams) (*jsoncmd.CommandCompletion, error) {
+	return executeRequest(gr, ctx, jsoncmd.CompleteCommand, params)
+}
+
+func (gr *GomuksRPC) TranslateEvent(ctx context.Context, params *jsoncmd.TranslateEventParams) (*database.Event, error) {
+	return executeRequest(gr, ctx, jsoncmd.TranslateEvent, params)
+}
+
+func (gr *GomuksRPC) SpellCheck(ctx context.Context, params *jsoncmd.SpellCheckParams) (*jsoncmd.SpellCheckResponse, error) {
+	return executeRequest(gr, ctx, jsoncmd.SpellCheck, params)
+}
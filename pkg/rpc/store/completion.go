@@ -0,0 +1,120 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package store
+
+import (
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+)
+
+// maxCachedCommandCompletions is the number of command completion responses cached per room.
+const maxCachedCommandCompletions = 100
+
+func isImagePackAccountData(evtType event.Type) bool {
+	switch evtType.Type {
+	case database.AccountDataUserImagePack.Type,
+		database.AccountDataImagePackRooms.Type,
+		database.AccountDataUnstableImagePackRooms.Type:
+		return true
+	default:
+		return false
+	}
+}
+
+func hasStateType(state map[event.Type]map[string]database.EventRowID, types ...event.Type) bool {
+	for evtType := range state {
+		for _, target := range types {
+			if evtType.Type == target.Type {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// invalidateCompletionCaches drops cached image packs and command completions that may have been changed by the sync.
+// Must be called with the store lock held.
+func (gs *GomuksStore) invalidateCompletionCaches(sync *jsoncmd.SyncComplete) {
+	invalidated := len(sync.LeftRooms) > 0
+	for evtType := range sync.AccountData {
+		if isImagePackAccountData(evtType) {
+			clear(gs.imagePacks)
+			invalidated = true
+			break
+		}
+	}
+	for roomID, data := range sync.Rooms {
+		if data.Reset || hasStateType(data.State, database.StateImagePack, database.StateUnstableImagePack) {
+			// Packs from other rooms can be enabled globally, so all rooms need to be refetched
+			clear(gs.imagePacks)
+			invalidated = true
+		}
+		if data.Reset || hasStateType(data.State, event.StateMSC4391BotCommand, event.StateMember) {
+			delete(gs.commandCompletions, roomID)
+			invalidated = true
+		}
+	}
+	for _, roomID := range sync.LeftRooms {
+		delete(gs.imagePacks, roomID)
+		delete(gs.commandCompletions, roomID)
+	}
+	if invalidated {
+		// Bump the generation so that requests which started before this sync don't cache outdated responses
+		gs.completionCacheGen++
+	}
+}
+
+// CompletionCacheGeneration returns a counter that must be passed to [GomuksStore.CacheImagePacks]
+// and [GomuksStore.CacheCommandCompletion]. It should be read before making the request whose response is cached.
+func (gs *GomuksStore) CompletionCacheGeneration() uint64 {
+	gs.lock.RLock()
+	defer gs.lock.RUnlock()
+	return gs.completionCacheGen
+}
+
+// GetCachedImagePacks returns the cached image packs available in the given room.
+// The second return value is false if the packs haven't been fetched since they last changed.
+func (gs *GomuksStore) GetCachedImagePacks(roomID id.RoomID) ([]*jsoncmd.ImagePackInfo, bool) {
+	gs.lock.RLock()
+	defer gs.lock.RUnlock()
+	packs, ok := gs.imagePacks[roomID]
+	return packs, ok
+}
+
+// CacheImagePacks caches the image packs available in the given room.
+func (gs *GomuksStore) CacheImagePacks(roomID id.RoomID, packs []*jsoncmd.ImagePackInfo, generation uint64) {
+	gs.lock.Lock()
+	defer gs.lock.Unlock()
+	if generation == gs.completionCacheGen {
+		gs.imagePacks[roomID] = packs
+	}
+}
+
+// GetCachedCommandCompletion returns a cached command completion response for the given input text.
+func (gs *GomuksStore) GetCachedCommandCompletion(roomID id.RoomID, text string) *jsoncmd.CommandCompletion {
+	gs.lock.RLock()
+	defer gs.lock.RUnlock()
+	return gs.commandCompletions[roomID][text]
+}
+
+// CacheCommandCompletion caches a command completion response for the given input text.
+func (gs *GomuksStore) CacheCommandCompletion(roomID id.RoomID, text string, resp *jsoncmd.CommandCompletion, generation uint64) {
+	gs.lock.Lock()
+	defer gs.lock.Unlock()
+	if generation != gs.completionCacheGen {
+		return
+	}
+	roomCache, ok := gs.commandCompletions[roomID]
+	if !ok || len(roomCache) >= maxCachedCommandCompletions {
+		roomCache = make(map[string]*jsoncmd.CommandCompletion)
+		gs.commandCompletions[roomID] = roomCache
+	}
+	roomCache[text] = resp
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"encoding/json"
 	"reflect"
 
 	"maunium.net/go/mautrix/event"
@@ -66,3 +67,31 @@ var DefaultPreferences = Preferences{
 func init() {
 	event.TypeMap[AccountDataGomuksPreferences] = reflect.TypeOf(Preferences{})
 }
+
+type cachedPreferences struct {
+	version uint64
+	prefs   *Preferences
+}
+
+// GetPreferences returns the effective preferences in the room, which are the global preferences
+// overridden by the room-specific preferences. The result is cached until preferences account data changes,
+// so the returned struct must not be modified.
+func (rs *RoomStore) GetPreferences() *Preferences {
+	version := rs.parent.preferencesVersion.Load()
+	if cached := rs.preferences.Load(); cached != nil && cached.version == version {
+		return cached.prefs
+	}
+	prefs := DefaultPreferences
+	// The global account data must be read before taking the room lock, as the store lock is held while applying syncs.
+	if global := rs.parent.GetAccountData(AccountDataGomuksPreferences); global != nil {
+		_ = json.Unmarshal(global.Content, &prefs)
+	}
+	rs.lock.RLock()
+	roomPrefs := rs.accountData[AccountDataGomuksPreferences]
+	rs.lock.RUnlock()
+	if roomPrefs != nil {
+		_ = json.Unmarshal(roomPrefs.Content, &prefs)
+	}
+	rs.preferences.Store(&cachedPreferences{version: version, prefs: &prefs})
+	return &prefs
+}
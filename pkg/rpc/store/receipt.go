@@ -0,0 +1,89 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package store
+
+import (
+	"slices"
+
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+)
+
+type userReceipt struct {
+	*database.Receipt
+	timelineRowID database.TimelineRowID
+}
+
+// applyReceipts adds receipts for the given event. Only the latest receipt of each user is kept.
+// If override is true, the existing receipts of the event are replaced. This must be called with the lock held.
+func (rs *RoomStore) applyReceipts(eventID id.EventID, receipts []*database.Receipt, override bool, changed map[id.EventID]struct{}) {
+	evt, ok := rs.eventsByID[eventID]
+	if !ok || evt.TimelineRowID == 0 {
+		return
+	}
+	filtered := make([]*database.Receipt, 0, len(receipts))
+	for _, receipt := range receipts {
+		if rs.applyReceipt(receipt, evt, changed) {
+			filtered = append(filtered, receipt)
+		}
+	}
+	slices.SortFunc(filtered, func(a, b *database.Receipt) int {
+		return a.Timestamp.Compare(b.Timestamp.Time)
+	})
+	if override {
+		rs.receiptsByEventID[eventID] = filtered
+	} else {
+		rs.receiptsByEventID[eventID] = append(rs.receiptsByEventID[eventID], filtered...)
+	}
+	changed[eventID] = struct{}{}
+}
+
+func (rs *RoomStore) applyReceipt(receipt *database.Receipt, evt *database.Event, changed map[id.EventID]struct{}) bool {
+	if existing, ok := rs.receiptsByUserID[receipt.UserID]; ok {
+		if existing.timelineRowID >= evt.TimelineRowID {
+			return false
+		}
+		oldReceipts := rs.receiptsByEventID[existing.EventID]
+		updated := slices.DeleteFunc(oldReceipts, func(r *database.Receipt) bool {
+			return r == existing.Receipt
+		})
+		if len(updated) == 0 {
+			delete(rs.receiptsByEventID, existing.EventID)
+		} else {
+			rs.receiptsByEventID[existing.EventID] = updated
+		}
+		changed[existing.EventID] = struct{}{}
+	}
+	rs.receiptsByUserID[receipt.UserID] = &userReceipt{Receipt: receipt, timelineRowID: evt.TimelineRowID}
+	return true
+}
+
+// emitReceiptChanges notifies listeners about events whose receipts changed. This must be called with the lock held.
+func (rs *RoomStore) emitReceiptChanges(changed map[id.EventID]struct{}) {
+	if len(changed) > 0 {
+		rs.receiptsVersion.Add(1)
+		eventIDs := make([]id.EventID, 0, len(changed))
+		for eventID := range changed {
+			eventIDs = append(eventIDs, eventID)
+		}
+		rs.ReceiptsUpdated.Emit(eventIDs)
+	}
+}
+
+// ReceiptsVersion returns a counter that changes whenever any read receipts in the room change.
+// It can be used to cache the results of [RoomStore.GetReceipts].
+func (rs *RoomStore) ReceiptsVersion() uint64 {
+	return rs.receiptsVersion.Load()
+}
+
+// GetReceipts returns the latest read receipts that point at the given event, sorted by timestamp.
+func (rs *RoomStore) GetReceipts(eventID id.EventID) []*database.Receipt {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+	return slices.Clone(rs.receiptsByEventID[eventID])
+}
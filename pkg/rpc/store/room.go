@@ -19,6 +19,7 @@ import (
 	badGlobalLog "github.com/rs/zerolog/log"
 	"github.com/tidwall/gjson"
 	"go.mau.fi/util/exmaps"
+	"go.mau.fi/util/jsontime"
 	"maunium.net/go/mautrix/event"
 	"maunium.net/go/mautrix/event/cmdschema"
 	"maunium.net/go/mautrix/id"
@@ -53,6 +54,7 @@ type RoomStore struct {
 	accountData       map[event.Type]*database.AccountData
 	timeline          []database.TimelineRowTuple
 	hasMoreHistory    bool
+	paginationRoomID  id.RoomID
 	editTargets       []database.EventRowID
 	eventsByRowID     map[database.EventRowID]*database.Event
 	eventsByID        map[id.EventID]*database.Event
@@ -71,6 +73,18 @@ type RoomStore struct {
 	Typing            EventDispatcher[[]id.UserID]
 	PreferenceCache   EventDispatcher[*Preferences]
 	lastMarkedRead    database.EventRowID
+	threads           map[id.EventID]*ThreadStore
+	unreadThreads     exmaps.Set[id.EventID]
+	threadsViewedAt   map[id.EventID]jsontime.UnixMilli
+
+	receiptsByEventID map[id.EventID][]*database.Receipt
+	receiptsByUserID  map[id.UserID]*userReceipt
+	// ReceiptsUpdated is emitted with the IDs of events whose read receipts changed.
+	ReceiptsUpdated EventDispatcher[[]id.EventID]
+	receiptsVersion atomic.Uint64
+
+	// The effective preferences in the room, see [RoomStore.GetPreferences].
+	preferences atomic.Pointer[cachedPreferences]
 }
 
 type WrappedCommand struct {
@@ -86,17 +100,42 @@ func NewRoomStore(parent *GomuksStore, meta *database.Room) *RoomStore {
 		accountData:      make(map[event.Type]*database.AccountData),
 		state:            make(map[event.Type]map[string]database.EventRowID),
 		hasMoreHistory:   true,
+		paginationRoomID: meta.ID,
 		eventsByRowID:    make(map[database.EventRowID]*database.Event),
 		eventsByID:       make(map[id.EventID]*database.Event),
 		requestedEvents:  make(exmaps.Set[database.EventRowID]),
 		requestedMembers: make(exmaps.Set[id.UserID]),
+		unreadThreads:    make(exmaps.Set[id.EventID]),
+
+		receiptsByEventID: make(map[id.EventID][]*database.Receipt),
+		receiptsByUserID:  make(map[id.UserID]*userReceipt),
 	}
 }
 
-func (rs *RoomStore) GetPaginationParams() (oldestRowID database.TimelineRowID, count int) {
+// GetPaginationParams returns the parameters for the next pagination request. After the history of the room
+// itself has been fully paginated, this moves on to the predecessor room if it's known, so that the timeline
+// of an upgraded room includes the history of the old room.
+func (rs *RoomStore) GetPaginationParams() (roomID id.RoomID, oldestRowID database.TimelineRowID, count int) {
 	rs.lock.RLock()
-	defer rs.lock.RUnlock()
-	if len(rs.timeline) > 0 {
+	roomID = rs.paginationRoomID
+	hasMoreHistory := rs.hasMoreHistory
+	rs.lock.RUnlock()
+	var predecessorID id.RoomID
+	if paginationRoom := rs.parent.GetRoom(roomID); !hasMoreHistory && paginationRoom != nil {
+		predecessorID = paginationRoom.Meta.Current().CreationContent.GetPredecessor().RoomID
+		if predecessorID != "" && rs.parent.GetRoom(predecessorID) == nil {
+			predecessorID = ""
+		}
+	}
+
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	if predecessorID != "" && rs.paginationRoomID == roomID {
+		rs.paginationRoomID = predecessorID
+		rs.hasMoreHistory = true
+		// Timeline row IDs aren't comparable across rooms, so start from the end of the predecessor's timeline
+		roomID = predecessorID
+	} else if len(rs.timeline) > 0 {
 		oldestRowID = rs.timeline[0].Timeline
 	}
 	if len(rs.timeline) < 100 {
@@ -131,6 +170,7 @@ func (rs *RoomStore) notifyTimelineWatchers() {
 	}
 	rs.TimelineCache.Emit(&timelineCache)
 	rs.editTargets = ownMessages
+	rs.notifyThreadWatchers()
 }
 
 func (rs *RoomStore) ApplySync(sync *jsoncmd.SyncRoom) {
@@ -150,8 +190,11 @@ func (rs *RoomStore) ApplySync(sync *jsoncmd.SyncRoom) {
 			parsedPreferences := DefaultPreferences
 			_ = json.Unmarshal(ad.Content, &parsedPreferences)
 			rs.PreferenceCache.Emit(&parsedPreferences)
+			rs.accountData[evtType] = ad
+			rs.parent.preferencesVersion.Add(1)
+		} else {
+			rs.accountData[evtType] = ad
 		}
-		rs.accountData[evtType] = ad
 		rs.AccountDataSubs.Notify(evtType)
 	}
 	for evtType, stateMap := range sync.State {
@@ -164,15 +207,33 @@ func (rs *RoomStore) ApplySync(sync *jsoncmd.SyncRoom) {
 		maps.Copy(cacheMap, stateMap)
 		rs.invalidateStateCaches(evtType, slices.Collect(maps.Keys(stateMap))...)
 	}
+	for _, tuple := range sync.Timeline {
+		evt, ok := rs.eventsByRowID[tuple.Event]
+		if ok && evt.RelationType == event.RelThread && evt.Sender != rs.parent.UserID {
+			if _, isOpen := rs.threads[evt.RelatesTo]; !isOpen {
+				rs.unreadThreads.Add(evt.RelatesTo)
+			}
+		}
+	}
 	if sync.Reset {
 		rs.timeline = sync.Timeline
 		rs.pendingEvents = rs.pendingEvents[:0]
+		rs.paginationRoomID = rs.ID
+		rs.hasMoreHistory = true
+		clear(rs.receiptsByEventID)
+		clear(rs.receiptsByUserID)
+		rs.receiptsVersion.Add(1)
 	} else {
 		rs.timeline = append(rs.timeline, sync.Timeline...)
 	}
 	if sync.Reset || len(sync.Timeline) > 0 {
 		rs.notifyTimelineWatchers()
 	}
+	changedReceipts := make(map[id.EventID]struct{})
+	for eventID, receipts := range sync.Receipts {
+		rs.applyReceipts(eventID, receipts, false, changedReceipts)
+	}
+	rs.emitReceiptChanges(changedReceipts)
 }
 
 func (rs *RoomStore) ApplyTyping(typing []id.UserID) {
@@ -200,6 +261,7 @@ func (rs *RoomStore) ApplyPending(evt *database.Event) {
 			Timeline: evt.TimelineRowID,
 			Event:    evt.RowID,
 		})
+		rs.addFakeEventToThreads(evt)
 	}
 	rs.notifyTimelineWatchers()
 }
@@ -233,6 +295,11 @@ func (rs *RoomStore) ApplyPagination(resp *jsoncmd.PaginationResponse) {
 	}
 	rs.timeline = append(newTimeline, rs.timeline...)
 	rs.notifyTimelineWatchers()
+	changedReceipts := make(map[id.EventID]struct{})
+	for eventID, receipts := range resp.Receipts {
+		rs.applyReceipts(eventID, receipts, true, changedReceipts)
+	}
+	rs.emitReceiptChanges(changedReceipts)
 }
 
 func (rs *RoomStore) ApplyDecrypted(resp *jsoncmd.EventsDecrypted) {
@@ -249,6 +316,8 @@ func (rs *RoomStore) ApplyDecrypted(resp *jsoncmd.EventsDecrypted) {
 	}
 	if timelineChanged {
 		rs.notifyTimelineWatchers()
+	} else {
+		rs.notifyThreadWatchers()
 	}
 	if resp.PreviewEventRowID != 0 {
 		meta := rs.Meta.Current()
@@ -344,6 +413,9 @@ func (rs *RoomStore) applyEvent(evt *database.Event, pending bool) {
 			rs.pendingEvents = slices.Delete(rs.pendingEvents, pendingIdx, pendingIdx+1)
 		}
 	}
+	if len(rs.threads) > 0 {
+		rs.trackThreadEvent(evt)
+	}
 	rs.EventSubs.Notify(evt.ID)
 }
 
@@ -499,6 +571,10 @@ func (rs *RoomStore) GetDisplayname(userID id.UserID) string {
 }
 
 func (rs *RoomStore) GetMarkAsReadParams() *jsoncmd.MarkReadParams {
+	receiptType := event.ReceiptTypeReadPrivate
+	if rs.GetPreferences().SendReadReceipts {
+		receiptType = event.ReceiptTypeRead
+	}
 	rs.lock.RLock()
 	defer rs.lock.RUnlock()
 	if len(rs.timeline) == 0 {
@@ -520,8 +596,6 @@ func (rs *RoomStore) GetMarkAsReadParams() *jsoncmd.MarkReadParams {
 	if readEvt == nil {
 		return nil
 	}
-	// TODO get receipt type from preferences
-	receiptType := event.ReceiptTypeReadPrivate
 	return &jsoncmd.MarkReadParams{
 		RoomID:      rs.ID,
 		EventID:     readEvt.ID,
@@ -0,0 +1,134 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package store
+
+import (
+	"go.mau.fi/util/exmaps"
+	"go.mau.fi/util/ptr"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/database"
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+)
+
+// SpaceEntry is a single space in the flattened space tree returned by [GomuksStore.GetSpaceTree].
+type SpaceEntry struct {
+	RoomID id.RoomID
+	Name   string
+	// How deeply the space is nested. Top-level spaces have depth 0.
+	Depth int
+	// The sum of unread counts in all rooms in the space, including rooms in subspaces.
+	database.UnreadCounts
+	MarkedUnread int
+}
+
+func (gs *GomuksStore) isSpace(roomID id.RoomID) bool {
+	if _, hasEdges := gs.spaceEdges[roomID]; hasEdges {
+		return true
+	}
+	room, ok := gs.rooms[roomID]
+	return ok && room.Meta.Current().CreationContent.Type == event.RoomTypeSpace
+}
+
+// applySpaceSync updates the space edges and top-level spaces from a sync. This must be called with the lock held.
+func (gs *GomuksStore) applySpaceSync(sync *jsoncmd.SyncComplete) {
+	if len(sync.SpaceEdges) == 0 && sync.TopLevelSpaces == nil {
+		return
+	}
+	for spaceID, edges := range sync.SpaceEdges {
+		gs.spaceEdges[spaceID] = edges
+	}
+	if sync.TopLevelSpaces != nil {
+		gs.topLevelSpaces = sync.TopLevelSpaces
+	}
+	clear(gs.spaceRooms)
+	for spaceID := range gs.spaceEdges {
+		gs.collectSpaceRooms(spaceID, make(exmaps.Set[id.RoomID]))
+	}
+}
+
+// collectSpaceRooms returns all rooms in the space and its subspaces. The visited set protects against loops.
+func (gs *GomuksStore) collectSpaceRooms(spaceID id.RoomID, visited exmaps.Set[id.RoomID]) exmaps.Set[id.RoomID] {
+	if cached, ok := gs.spaceRooms[spaceID]; ok {
+		return cached
+	}
+	visited.Add(spaceID)
+	rooms := make(exmaps.Set[id.RoomID])
+	for _, edge := range gs.spaceEdges[spaceID] {
+		rooms.Add(edge.ChildID)
+		if visited.Has(edge.ChildID) || !gs.isSpace(edge.ChildID) {
+			continue
+		}
+		for roomID := range gs.collectSpaceRooms(edge.ChildID, visited) {
+			rooms.Add(roomID)
+		}
+	}
+	visited.Remove(spaceID)
+	gs.spaceRooms[spaceID] = rooms
+	return rooms
+}
+
+// IsRoomInSpace checks if the given room is in the given space, either directly or through subspaces.
+// An empty space ID matches all rooms.
+func (gs *GomuksStore) IsRoomInSpace(spaceID, roomID id.RoomID) bool {
+	if spaceID == "" {
+		return true
+	}
+	gs.lock.RLock()
+	defer gs.lock.RUnlock()
+	return gs.spaceRooms[spaceID].Has(roomID)
+}
+
+// GetSpaceTree returns the spaces the user is in, in depth-first order starting from the top-level spaces.
+func (gs *GomuksStore) GetSpaceTree() []*SpaceEntry {
+	gs.lock.RLock()
+	defer gs.lock.RUnlock()
+	roomListEntries := make(map[id.RoomID]*RoomListEntry, len(gs.roomList))
+	for _, entry := range gs.roomList {
+		roomListEntries[entry.RoomID] = entry
+	}
+	var tree []*SpaceEntry
+	visited := make(exmaps.Set[id.RoomID])
+	var addSpace func(spaceID id.RoomID, depth int)
+	addSpace = func(spaceID id.RoomID, depth int) {
+		room, ok := gs.rooms[spaceID]
+		if !ok || visited.Has(spaceID) {
+			return
+		}
+		visited.Add(spaceID)
+		entry := &SpaceEntry{
+			RoomID: spaceID,
+			Name:   ptr.Val(room.Meta.Current().Name),
+			Depth:  depth,
+		}
+		if entry.Name == "" {
+			entry.Name = "Unnamed space"
+		}
+		for roomID := range gs.spaceRooms[spaceID] {
+			if roomEntry, ok := roomListEntries[roomID]; ok {
+				entry.UnreadHighlights += roomEntry.UnreadHighlights
+				entry.UnreadNotifications += roomEntry.UnreadNotifications
+				entry.UnreadMessages += roomEntry.UnreadMessages
+				if roomEntry.MarkedUnread {
+					entry.MarkedUnread++
+				}
+			}
+		}
+		tree = append(tree, entry)
+		for _, edge := range gs.spaceEdges[spaceID] {
+			if gs.isSpace(edge.ChildID) {
+				addSpace(edge.ChildID, depth+1)
+			}
+		}
+		visited.Remove(spaceID)
+	}
+	for _, spaceID := range gs.topLevelSpaces {
+		addSpace(spaceID, 0)
+	}
+	return tree
+}
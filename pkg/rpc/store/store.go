@@ -10,8 +10,10 @@ import (
 	"encoding/json"
 	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"go.mau.fi/util/exmaps"
 	"go.mau.fi/util/ptr"
 	"maunium.net/go/mautrix/event"
 	"maunium.net/go/mautrix/id"
@@ -38,21 +40,35 @@ type GomuksStore struct {
 	jsoncmd.ClientState
 	ImageAuthToken string
 
-	lock             sync.RWMutex
-	invitedRooms     map[id.RoomID]*InvitedRoom
-	rooms            map[id.RoomID]*RoomStore
-	roomList         []*RoomListEntry
-	ReversedRoomList EventDispatcher[[]*RoomListEntry]
-	accountData      map[event.Type]*database.AccountData
-	AccountDataSubs  MultiNotifier[event.Type]
-	PreferenceCache  EventDispatcher[*Preferences]
+	lock               sync.RWMutex
+	invitedRooms       map[id.RoomID]*InvitedRoom
+	rooms              map[id.RoomID]*RoomStore
+	roomList           []*RoomListEntry
+	ReversedRoomList   EventDispatcher[[]*RoomListEntry]
+	accountData        map[event.Type]*database.AccountData
+	drafts             map[draftKey]*database.Draft
+	spaceEdges         map[id.RoomID][]*database.SpaceEdge
+	spaceRooms         map[id.RoomID]exmaps.Set[id.RoomID]
+	topLevelSpaces     []id.RoomID
+	imagePacks         map[id.RoomID][]*jsoncmd.ImagePackInfo
+	commandCompletions map[id.RoomID]map[string]*jsoncmd.CommandCompletion
+	completionCacheGen uint64
+	AccountDataSubs    MultiNotifier[event.Type]
+	PreferenceCache    EventDispatcher[*Preferences]
+	// Incremented whenever global or room preferences change to invalidate the effective preferences of rooms.
+	preferencesVersion atomic.Uint64
 }
 
 func NewStore() *GomuksStore {
 	gs := &GomuksStore{
-		rooms:        make(map[id.RoomID]*RoomStore),
-		invitedRooms: make(map[id.RoomID]*InvitedRoom),
-		accountData:  make(map[event.Type]*database.AccountData),
+		rooms:              make(map[id.RoomID]*RoomStore),
+		invitedRooms:       make(map[id.RoomID]*InvitedRoom),
+		accountData:        make(map[event.Type]*database.AccountData),
+		drafts:             make(map[draftKey]*database.Draft),
+		spaceEdges:         make(map[id.RoomID][]*database.SpaceEdge),
+		spaceRooms:         make(map[id.RoomID]exmaps.Set[id.RoomID]),
+		imagePacks:         make(map[id.RoomID][]*jsoncmd.ImagePackInfo),
+		commandCompletions: make(map[id.RoomID]map[string]*jsoncmd.CommandCompletion),
 	}
 	return gs
 }
@@ -121,14 +137,18 @@ func (gs *GomuksStore) ApplySync(sync *jsoncmd.SyncComplete) {
 	defer gs.lock.Unlock()
 	resyncRoomList := len(gs.roomList) == 0
 	changedRoomListEntries := make(map[id.RoomID]*RoomListEntry)
+	gs.invalidateCompletionCaches(sync)
 	for evtType, ad := range sync.AccountData {
 		evtType.Class = event.AccountDataEventType
 		if evtType == AccountDataGomuksPreferences {
 			parsedPreferences := DefaultPreferences
 			_ = json.Unmarshal(ad.Content, &parsedPreferences)
 			gs.PreferenceCache.Emit(&parsedPreferences)
+			gs.accountData[evtType] = ad
+			gs.preferencesVersion.Add(1)
+		} else {
+			gs.accountData[evtType] = ad
 		}
-		gs.accountData[evtType] = ad
 		gs.AccountDataSubs.Notify(evtType)
 	}
 	for _, data := range sync.InvitedRooms {
@@ -141,6 +161,13 @@ func (gs *GomuksStore) ApplySync(sync *jsoncmd.SyncComplete) {
 	for roomID, data := range sync.Rooms {
 		data.Meta.EnsureNotNil()
 		roomStore, existingRoom := gs.rooms[roomID]
+		if _, wasInvited := gs.invitedRooms[roomID]; wasInvited {
+			// The invite was accepted, the room list entry will be replaced with the joined room
+			delete(gs.invitedRooms, roomID)
+			if !resyncRoomList {
+				changedRoomListEntries[roomID] = nil
+			}
+		}
 		if !existingRoom {
 			roomStore = NewRoomStore(gs, data.Meta)
 			gs.rooms[roomID] = roomStore
@@ -156,8 +183,10 @@ func (gs *GomuksStore) ApplySync(sync *jsoncmd.SyncComplete) {
 	}
 	for _, roomID := range sync.LeftRooms {
 		delete(gs.rooms, roomID)
+		delete(gs.invitedRooms, roomID)
 		changedRoomListEntries[roomID] = nil
 	}
+	gs.applySpaceSync(sync)
 	var updatedRoomList []*RoomListEntry
 	if resyncRoomList {
 		updatedRoomList = make([]*RoomListEntry, 0, len(gs.rooms)+len(gs.invitedRooms))
@@ -218,13 +247,60 @@ func (gs *GomuksStore) GetInviteRoom(roomID id.RoomID) *InvitedRoom {
 	return gs.invitedRooms[roomID]
 }
 
+func (gs *GomuksStore) GetAccountData(evtType event.Type) *database.AccountData {
+	gs.lock.RLock()
+	defer gs.lock.RUnlock()
+	evtType.Class = event.AccountDataEventType
+	return gs.accountData[evtType]
+}
+
+type draftKey struct {
+	RoomID     id.RoomID
+	ThreadRoot id.EventID
+}
+
+// SetDrafts replaces all cached drafts with the given list.
+func (gs *GomuksStore) SetDrafts(drafts []*database.Draft) {
+	gs.lock.Lock()
+	defer gs.lock.Unlock()
+	clear(gs.drafts)
+	for _, draft := range drafts {
+		gs.drafts[draftKey{draft.RoomID, draft.ThreadRoot}] = draft
+	}
+}
+
+func (gs *GomuksStore) ApplyDraftUpdate(update *jsoncmd.DraftUpdated) {
+	gs.lock.Lock()
+	defer gs.lock.Unlock()
+	key := draftKey{update.RoomID, update.ThreadRoot}
+	if update.Draft != nil {
+		gs.drafts[key] = update.Draft
+	} else {
+		delete(gs.drafts, key)
+	}
+}
+
+func (gs *GomuksStore) GetDraft(roomID id.RoomID, threadRoot id.EventID) *database.Draft {
+	gs.lock.RLock()
+	defer gs.lock.RUnlock()
+	return gs.drafts[draftKey{roomID, threadRoot}]
+}
+
 func (gs *GomuksStore) Clear() {
 	gs.lock.Lock()
 	defer gs.lock.Unlock()
 	clear(gs.rooms)
 	clear(gs.invitedRooms)
 	clear(gs.accountData)
+	clear(gs.spaceEdges)
+	clear(gs.spaceRooms)
+	clear(gs.imagePacks)
+	clear(gs.drafts)
+	clear(gs.commandCompletions)
+	gs.completionCacheGen++
+	gs.topLevelSpaces = nil
 	gs.PreferenceCache.Emit(nil)
+	gs.preferencesVersion.Add(1)
 	gs.roomList = nil
 	gs.ReversedRoomList.Emit([]*RoomListEntry{})
 }
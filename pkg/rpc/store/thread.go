@@ -0,0 +1,315 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package store
+
+import (
+	"cmp"
+	"slices"
+	"sync/atomic"
+
+	"github.com/tidwall/gjson"
+	"go.mau.fi/util/exgjson"
+	"go.mau.fi/util/jsontime"
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/cmdspec"
+	"go.mau.fi/gomuks/pkg/hicli/database"
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+)
+
+// ThreadStore contains the timeline of a single thread. Events are shared with the parent room store,
+// and the thread is kept up to date by the room store while it's open.
+type ThreadStore struct {
+	room       *RoomStore
+	RootID     id.EventID
+	Timeline   EventDispatcher[*[]*database.Event]
+	Paginating atomic.Bool
+
+	events    []database.EventRowID
+	nextBatch string
+	hasMore   bool
+	dirty     bool
+}
+
+// ThreadSummary contains the locally known information about a thread in a room.
+type ThreadSummary struct {
+	RootID      id.EventID
+	Root        *database.Event
+	LatestReply *database.Event
+	ReplyCount  int
+	Unread      bool
+}
+
+var threadSummaryPath = exgjson.Path("m.relations", "m.thread")
+
+// OpenThread starts tracking the timeline of the given thread. Thread events that are already in the store
+// are included immediately, and new ones are added as they arrive. Opening a thread also marks it as read.
+func (rs *RoomStore) OpenThread(rootID id.EventID) *ThreadStore {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	rs.unreadThreads.Remove(rootID)
+	rs.markThreadViewed(rootID)
+	if thread, ok := rs.threads[rootID]; ok {
+		return thread
+	}
+	thread := &ThreadStore{
+		room:    rs,
+		RootID:  rootID,
+		hasMore: true,
+	}
+	for rowID, evt := range rs.eventsByRowID {
+		if evt.RelationType == event.RelThread && evt.RelatesTo == rootID {
+			thread.events = append(thread.events, rowID)
+		}
+	}
+	if rs.threads == nil {
+		rs.threads = make(map[id.EventID]*ThreadStore)
+	}
+	rs.threads[rootID] = thread
+	thread.notify()
+	return thread
+}
+
+// CloseThread stops tracking the timeline of the given thread.
+func (rs *RoomStore) CloseThread(rootID id.EventID) {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	rs.markThreadViewed(rootID)
+	delete(rs.threads, rootID)
+}
+
+// markThreadViewed remembers when the thread was last viewed, because read receipts are sent without a thread ID
+// and therefore don't always cover threads that have been read. This must be called with the room lock held.
+func (rs *RoomStore) markThreadViewed(rootID id.EventID) {
+	if rs.threadsViewedAt == nil {
+		rs.threadsViewedAt = make(map[id.EventID]jsontime.UnixMilli)
+	}
+	rs.threadsViewedAt[rootID] = jsontime.UnixMilliNow()
+}
+
+// UnreadThreadCount returns the number of threads that have received replies from other users
+// since they were last opened.
+func (rs *RoomStore) UnreadThreadCount() int {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+	return rs.unreadThreads.Size()
+}
+
+// GetThreads builds summaries of the threads in the room, most recently active first.
+//
+// The replies should be fetched from the backend using GetThreadReplies, and ownReceipts with GetOwnReceipts.
+// Threads are marked as unread if they have replies from other users after the user's latest unthreaded
+// or thread-specific read receipt.
+func (rs *RoomStore) GetThreads(replies []*database.Event, ownReceipts []*database.Receipt) []*ThreadSummary {
+	rs.lock.RLock()
+	defer rs.lock.RUnlock()
+	repliesByID := make(map[id.EventID]*database.Event, len(replies))
+	summaries := make(map[id.EventID]*ThreadSummary)
+	latestFromOthers := make(map[id.EventID]*database.Event)
+	for _, evt := range replies {
+		if evt.RelationType != event.RelThread || evt.RelatesTo == "" {
+			continue
+		}
+		repliesByID[evt.ID] = evt
+		summary, ok := summaries[evt.RelatesTo]
+		if !ok {
+			summary = &ThreadSummary{
+				RootID: evt.RelatesTo,
+				Root:   rs.eventsByID[evt.RelatesTo],
+			}
+			summaries[evt.RelatesTo] = summary
+		}
+		summary.ReplyCount++
+		if summary.LatestReply == nil || summary.LatestReply.Timestamp.Before(evt.Timestamp.Time) {
+			summary.LatestReply = evt
+		}
+		if latest := latestFromOthers[evt.RelatesTo]; evt.Sender != rs.parent.UserID &&
+			(latest == nil || latest.Timestamp.Before(evt.Timestamp.Time)) {
+			latestFromOthers[evt.RelatesTo] = evt
+		}
+	}
+
+	var unthreadedReadAt jsontime.UnixMilli
+	threadReadAt := make(map[id.EventID]jsontime.UnixMilli)
+	for _, receipt := range ownReceipts {
+		// Prefer the timestamp of the event the receipt points at, as the receipt timestamp is when it was sent
+		readAt := receipt.Timestamp
+		if evt, ok := repliesByID[receipt.EventID]; ok {
+			readAt = evt.Timestamp
+		} else if evt, ok = rs.eventsByID[receipt.EventID]; ok {
+			readAt = evt.Timestamp
+		}
+		if receipt.ThreadID == "" {
+			if readAt.After(unthreadedReadAt.Time) {
+				unthreadedReadAt = readAt
+			}
+		} else if rootID := id.EventID(receipt.ThreadID); readAt.After(threadReadAt[rootID].Time) {
+			threadReadAt[rootID] = readAt
+		}
+	}
+
+	list := make([]*ThreadSummary, 0, len(summaries))
+	for rootID, summary := range summaries {
+		if summary.Root != nil && len(summary.Root.Unsigned) > 0 {
+			if count := gjson.GetBytes(summary.Root.Unsigned, threadSummaryPath+".count"); count.Exists() {
+				summary.ReplyCount = max(summary.ReplyCount, int(count.Int()))
+			}
+		}
+		if latest := latestFromOthers[rootID]; latest != nil {
+			summary.Unread = latest.Timestamp.After(unthreadedReadAt.Time) &&
+				latest.Timestamp.After(threadReadAt[rootID].Time) &&
+				latest.Timestamp.After(rs.threadsViewedAt[rootID].Time)
+		}
+		list = append(list, summary)
+	}
+	slices.SortFunc(list, func(a, b *ThreadSummary) int {
+		return b.lastActivity().Compare(a.lastActivity().Time)
+	})
+	return list
+}
+
+func (summary *ThreadSummary) lastActivity() jsontime.UnixMilli {
+	if summary.LatestReply != nil {
+		return summary.LatestReply.Timestamp
+	} else if summary.Root != nil {
+		return summary.Root.Timestamp
+	}
+	return jsontime.UnixMilli{}
+}
+
+// trackThreadEvent adds the event to the open thread it belongs to and marks threads containing it as changed.
+// This must be called with the room lock held.
+func (rs *RoomStore) trackThreadEvent(evt *database.Event) {
+	for rootID, thread := range rs.threads {
+		if evt.ID == rootID || slices.Contains(thread.events, evt.RowID) {
+			thread.dirty = true
+		} else if evt.RelationType == event.RelThread && evt.RelatesTo == rootID {
+			thread.events = append(thread.events, evt.RowID)
+			thread.dirty = true
+		}
+	}
+}
+
+// addFakeEventToThreads makes local service messages visible in open threads too.
+// This must be called with the room lock held.
+func (rs *RoomStore) addFakeEventToThreads(evt *database.Event) {
+	for _, thread := range rs.threads {
+		thread.events = append(thread.events, evt.RowID)
+		thread.dirty = true
+	}
+}
+
+func (rs *RoomStore) notifyThreadWatchers() {
+	for _, thread := range rs.threads {
+		if thread.dirty {
+			thread.notify()
+		}
+	}
+}
+
+func (ts *ThreadStore) notify() {
+	rs := ts.room
+	ts.dirty = false
+	ts.events = slices.DeleteFunc(ts.events, func(rowID database.EventRowID) bool {
+		_, ok := rs.eventsByRowID[rowID]
+		return !ok
+	})
+	slices.SortStableFunc(ts.events, func(a, b database.EventRowID) int {
+		evtA, evtB := rs.eventsByRowID[a], rs.eventsByRowID[b]
+		if evtA.Pending != evtB.Pending {
+			if evtA.Pending {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Or(evtA.Timestamp.Compare(evtB.Timestamp.Time), cmp.Compare(a, b))
+	})
+	timeline := make([]*database.Event, 0, len(ts.events)+1)
+	if root, ok := rs.eventsByID[ts.RootID]; ok && !ts.hasMore {
+		timeline = append(timeline, root)
+	}
+	for _, rowID := range ts.events {
+		timeline = append(timeline, rs.eventsByRowID[rowID])
+	}
+	ts.Timeline.Emit(&timeline)
+}
+
+// GetRoot returns the thread root event, or nil if it hasn't been loaded.
+func (ts *ThreadStore) GetRoot() *database.Event {
+	return ts.room.GetEventByID(ts.RootID)
+}
+
+// HasMoreHistory returns whether there are older events in the thread that haven't been loaded yet.
+func (ts *ThreadStore) HasMoreHistory() bool {
+	ts.room.lock.RLock()
+	defer ts.room.lock.RUnlock()
+	return ts.hasMore
+}
+
+// GetPaginationParams returns the parameters for the next manual pagination request for the thread.
+func (ts *ThreadStore) GetPaginationParams() *jsoncmd.PaginateManualParams {
+	ts.room.lock.RLock()
+	defer ts.room.lock.RUnlock()
+	return &jsoncmd.PaginateManualParams{
+		RoomID:     ts.room.ID,
+		ThreadRoot: ts.RootID,
+		Since:      ts.nextBatch,
+		Direction:  mautrix.DirectionBackward,
+		Limit:      50,
+	}
+}
+
+// GetMarkAsReadParams returns the parameters for marking the room as read while the thread is being viewed.
+// Read receipts are sent without a thread ID, so this only returns a request if the most recent event
+// in the room timeline is part of the thread.
+func (ts *ThreadStore) GetMarkAsReadParams() *jsoncmd.MarkReadParams {
+	rs := ts.room
+	rs.lock.RLock()
+	var latest *database.Event
+	for i := len(rs.timeline) - 1; i >= 0; i-- {
+		evt, ok := rs.eventsByRowID[rs.timeline[i].Event]
+		if ok && evt.Sender != cmdspec.FakeGomuksSender {
+			latest = evt
+			break
+		}
+	}
+	rs.lock.RUnlock()
+	if latest == nil || (latest.ID != ts.RootID && (latest.RelationType != event.RelThread || latest.RelatesTo != ts.RootID)) {
+		return nil
+	}
+	return rs.GetMarkAsReadParams()
+}
+
+// ApplyPagination adds the events from a backwards pagination request to the thread timeline.
+func (ts *ThreadStore) ApplyPagination(resp *jsoncmd.ManualPaginationResponse) {
+	rs := ts.room
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	for _, evt := range resp.Events {
+		rs.applyEvent(evt, false)
+		if !slices.Contains(ts.events, evt.RowID) {
+			ts.events = append(ts.events, evt.RowID)
+		}
+	}
+	ts.nextBatch = resp.NextBatch
+	ts.hasMore = resp.NextBatch != ""
+	ts.dirty = true
+	rs.notifyThreadWatchers()
+}
+
+// ApplyRoot adds the thread root event to the store if it wasn't loaded yet.
+func (ts *ThreadStore) ApplyRoot(evt *database.Event) {
+	rs := ts.room
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	if _, ok := rs.eventsByRowID[evt.RowID]; !ok {
+		rs.applyEvent(evt, false)
+	}
+	ts.notify()
+}
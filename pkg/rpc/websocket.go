@@ -283,6 +283,10 @@ func parseEvent(ctx context.Context, evt *jsoncmd.Container[json.RawMessage]) an
 		data = &jsoncmd.ClientState{}
 	case jsoncmd.EventRunID:
 		data = &jsoncmd.RunData{}
+	case jsoncmd.EventModerationProgress:
+		data = &jsoncmd.ModerationProgress{}
+	case jsoncmd.EventDraftUpdated:
+		data = &jsoncmd.DraftUpdated{}
 	case jsoncmd.EventImageAuthToken:
 		data = ptr.Ptr(jsoncmd.ImageAuthToken(""))
 	case jsoncmd.EventInitComplete:
@@ -0,0 +1,548 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package spellcheck implements a spell checker that reads hunspell-format dictionaries.
+//
+// Only the subset of the affix file format needed for basic checking is supported: flag types, prefixes and
+// suffixes (including cross products), the TRY and REP suggestion hints, as well as FORBIDDENWORD and NEEDAFFIX.
+// Compounding and affix continuation classes are ignored. Dictionaries must be encoded in UTF-8 or ISO8859-1.
+package spellcheck
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+type flagType int
+
+const (
+	flagTypeChar flagType = iota
+	flagTypeLong
+	flagTypeNum
+	flagTypeUTF8
+)
+
+type affixRule struct {
+	strip     string
+	add       string
+	condition *regexp.Regexp
+}
+
+type affixClass struct {
+	suffix       bool
+	crossProduct bool
+	rules        []affixRule
+}
+
+// Dictionary is a parsed hunspell dictionary. All words are expanded when loading,
+// so checking is a simple map lookup. Dictionaries are safe for concurrent use.
+type Dictionary struct {
+	words     map[string]struct{}
+	forbidden map[string]struct{}
+	try       []rune
+	rep       [][2]string
+
+	suggestionCacheLock sync.Mutex
+	suggestionCache     map[suggestionCacheKey][]string
+}
+
+type suggestionCacheKey struct {
+	word string
+	max  int
+}
+
+// maxSuggestionCacheSize is the number of words whose suggestions are cached before the cache is cleared.
+const maxSuggestionCacheSize = 1000
+
+// Load reads a dictionary from the given .dic and .aff files.
+func Load(dicPath, affPath string) (*Dictionary, error) {
+	aff, err := os.Open(affPath)
+	if err != nil {
+		return nil, err
+	}
+	defer aff.Close()
+	dic, err := os.Open(dicPath)
+	if err != nil {
+		return nil, err
+	}
+	defer dic.Close()
+	return Parse(dic, aff)
+}
+
+type affixFile struct {
+	encoding  string
+	flagType  flagType
+	forbidden string
+	needAffix string
+	classes   map[string]*affixClass
+}
+
+func (af *affixFile) splitFlags(flags string) []string {
+	if flags == "" {
+		return nil
+	}
+	switch af.flagType {
+	case flagTypeLong:
+		out := make([]string, 0, len(flags)/2)
+		for i := 0; i+1 < len(flags); i += 2 {
+			out = append(out, flags[i:i+2])
+		}
+		return out
+	case flagTypeNum:
+		return strings.Split(flags, ",")
+	case flagTypeUTF8:
+		out := make([]string, 0, utf8.RuneCountInString(flags))
+		for _, r := range flags {
+			out = append(out, string(r))
+		}
+		return out
+	default:
+		out := make([]string, len(flags))
+		for i := 0; i < len(flags); i++ {
+			out[i] = flags[i : i+1]
+		}
+		return out
+	}
+}
+
+// supportedEncodings contains the values of the SET option that can be decoded.
+var supportedEncodings = map[string]struct{}{
+	"UTF-8":     {},
+	"ISO8859-1": {},
+}
+
+func (af *affixFile) decode(line string) string {
+	if af.encoding != "ISO8859-1" {
+		return line
+	}
+	runes := make([]rune, len(line))
+	for i := 0; i < len(line); i++ {
+		runes[i] = rune(line[i])
+	}
+	return string(runes)
+}
+
+// compileCondition converts a hunspell affix condition into a regular expression.
+// Conditions only support literal characters, dots and (negated) character groups.
+func compileCondition(cond string, suffix bool) (*regexp.Regexp, error) {
+	if cond == "" || cond == "." {
+		return nil, nil
+	}
+	var buf strings.Builder
+	if !suffix {
+		buf.WriteByte('^')
+	}
+	inGroup := false
+	for i, r := range cond {
+		switch {
+		case !inGroup && r == '[':
+			inGroup = true
+			buf.WriteByte('[')
+			if strings.HasPrefix(cond[i+1:], "^") {
+				buf.WriteByte('^')
+			}
+		case inGroup && r == ']':
+			inGroup = false
+			buf.WriteByte(']')
+		case inGroup && r == '^' && cond[i-1] == '[':
+			// already written above
+		case !inGroup && r == '.':
+			buf.WriteByte('.')
+		case inGroup && r == '-':
+			buf.WriteString(`\-`)
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	if suffix {
+		buf.WriteByte('$')
+	}
+	return regexp.Compile(buf.String())
+}
+
+func (af *affixFile) parse(r io.Reader) (*Dictionary, error) {
+	dict := &Dictionary{
+		words:     make(map[string]struct{}),
+		forbidden: make(map[string]struct{}),
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	var currentClass *affixClass
+	var remainingRules, remainingRep int
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if lineNum == 1 {
+			line = strings.TrimPrefix(line, "\uFEFF")
+		}
+		line = af.decode(line)
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch {
+		case remainingRules > 0 && (fields[0] == "PFX" || fields[0] == "SFX"):
+			remainingRules--
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("invalid affix rule on line %d", lineNum)
+			}
+			rule := affixRule{strip: fields[2], add: fields[3]}
+			if rule.strip == "0" {
+				rule.strip = ""
+			}
+			// Continuation classes aren't supported, so just drop them
+			rule.add, _, _ = strings.Cut(rule.add, "/")
+			if rule.add == "0" {
+				rule.add = ""
+			}
+			cond := "."
+			if len(fields) > 4 {
+				cond = fields[4]
+			}
+			var err error
+			rule.condition, err = compileCondition(cond, currentClass.suffix)
+			if err != nil {
+				return nil, fmt.Errorf("invalid affix condition %q on line %d: %w", cond, lineNum, err)
+			}
+			currentClass.rules = append(currentClass.rules, rule)
+		case remainingRep > 0 && fields[0] == "REP":
+			remainingRep--
+			if len(fields) >= 3 {
+				dict.rep = append(dict.rep, [2]string{
+					strings.ReplaceAll(fields[1], "_", " "),
+					strings.ReplaceAll(fields[2], "_", " "),
+				})
+			}
+		case len(fields) < 2:
+			continue
+		case fields[0] == "SET":
+			af.encoding = strings.ToUpper(fields[1])
+			if _, ok := supportedEncodings[af.encoding]; !ok {
+				return nil, fmt.Errorf("unsupported encoding %s", fields[1])
+			}
+		case fields[0] == "FLAG":
+			switch fields[1] {
+			case "long":
+				af.flagType = flagTypeLong
+			case "num":
+				af.flagType = flagTypeNum
+			case "UTF-8":
+				af.flagType = flagTypeUTF8
+			}
+		case fields[0] == "TRY":
+			dict.try = []rune(fields[1])
+		case fields[0] == "FORBIDDENWORD":
+			af.forbidden = fields[1]
+		case fields[0] == "NEEDAFFIX":
+			af.needAffix = fields[1]
+		case fields[0] == "REP":
+			remainingRep, _ = strconv.Atoi(fields[1])
+		case (fields[0] == "PFX" || fields[0] == "SFX") && len(fields) >= 4:
+			currentClass = &affixClass{
+				suffix:       fields[0] == "SFX",
+				crossProduct: fields[2] == "Y",
+			}
+			af.classes[fields[1]] = currentClass
+			remainingRules, _ = strconv.Atoi(fields[3])
+		}
+	}
+	return dict, scanner.Err()
+}
+
+func (af *affixFile) expand(dict *Dictionary, word string, flags []string) {
+	var prefixes, suffixes []*affixClass
+	forbidden, needAffix := false, false
+	for _, flag := range flags {
+		switch flag {
+		case af.forbidden:
+			forbidden = true
+		case af.needAffix:
+			needAffix = true
+		}
+		if class, ok := af.classes[flag]; ok {
+			if class.suffix {
+				suffixes = append(suffixes, class)
+			} else {
+				prefixes = append(prefixes, class)
+			}
+		}
+	}
+	if forbidden {
+		dict.forbidden[word] = struct{}{}
+		return
+	}
+	if !needAffix {
+		dict.words[word] = struct{}{}
+	}
+	var suffixed []string
+	for _, class := range suffixes {
+		for _, rule := range class.rules {
+			if !strings.HasSuffix(word, rule.strip) || (rule.condition != nil && !rule.condition.MatchString(word)) {
+				continue
+			}
+			form := word[:len(word)-len(rule.strip)] + rule.add
+			dict.words[form] = struct{}{}
+			if class.crossProduct {
+				suffixed = append(suffixed, form)
+			}
+		}
+	}
+	for _, class := range prefixes {
+		for _, rule := range class.rules {
+			if !strings.HasPrefix(word, rule.strip) || (rule.condition != nil && !rule.condition.MatchString(word)) {
+				continue
+			}
+			dict.words[rule.add+word[len(rule.strip):]] = struct{}{}
+			if !class.crossProduct {
+				continue
+			}
+			for _, form := range suffixed {
+				if strings.HasPrefix(form, rule.strip) {
+					dict.words[rule.add+form[len(rule.strip):]] = struct{}{}
+				}
+			}
+		}
+	}
+}
+
+// Parse reads a dictionary from the given .dic and .aff file contents.
+func Parse(dic, aff io.Reader) (*Dictionary, error) {
+	af := &affixFile{classes: make(map[string]*affixClass)}
+	dict, err := af.parse(aff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse affix file: %w", err)
+	}
+	scanner := bufio.NewScanner(dic)
+	scanner.Buffer(nil, 1024*1024)
+	first := true
+	for scanner.Scan() {
+		line := af.decode(scanner.Text())
+		if first {
+			// The first line is the approximate word count
+			first = false
+			continue
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// Morphological fields are separated by whitespace
+		if idx := strings.IndexAny(line, " \t"); idx >= 0 {
+			line = line[:idx]
+		}
+		word, flags, _ := strings.Cut(line, "/")
+		af.expand(dict, strings.ReplaceAll(word, `\/`, "/"), af.splitFlags(flags))
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dictionary file: %w", err)
+	}
+	return dict, nil
+}
+
+type wordCase int
+
+const (
+	caseLower wordCase = iota
+	caseTitle
+	caseUpper
+	caseMixed
+)
+
+func getCase(word string) wordCase {
+	upper, lower := 0, 0
+	firstUpper := false
+	for i, r := range word {
+		if unicode.IsUpper(r) {
+			upper++
+			if i == 0 {
+				firstUpper = true
+			}
+		} else if unicode.IsLower(r) {
+			lower++
+		}
+	}
+	switch {
+	case upper == 0:
+		return caseLower
+	case lower == 0:
+		return caseUpper
+	case upper == 1 && firstUpper:
+		return caseTitle
+	default:
+		return caseMixed
+	}
+}
+
+func toTitle(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(r)) + word[size:]
+}
+
+func (d *Dictionary) has(word string) bool {
+	_, ok := d.words[word]
+	return ok
+}
+
+// Check returns true if the given word is spelled correctly. Capitalized and all-caps words are
+// also accepted if the lowercase or capitalized form is in the dictionary.
+func (d *Dictionary) Check(word string) bool {
+	if _, forbidden := d.forbidden[word]; forbidden {
+		return false
+	} else if d.has(word) {
+		return true
+	}
+	switch getCase(word) {
+	case caseTitle:
+		return d.has(strings.ToLower(word))
+	case caseUpper:
+		lower := strings.ToLower(word)
+		return d.has(lower) || d.has(toTitle(lower))
+	default:
+		return false
+	}
+}
+
+func (d *Dictionary) tryChars(word []rune) []rune {
+	if len(d.try) > 0 {
+		return d.try
+	}
+	chars := []rune("esianrtolcdugmphbyfvkwzxjq")
+	for _, r := range word {
+		if !strings.ContainsRune(string(chars), r) {
+			chars = append(chars, r)
+		}
+	}
+	return chars
+}
+
+// forEachEdit calls fn with every string one edit (transposition, deletion, replacement or insertion)
+// away from the given word. If fn returns false, iteration is stopped and false is returned.
+func (d *Dictionary) forEachEdit(word string, fn func(string) bool) bool {
+	runes := []rune(word)
+	try := d.tryChars(runes)
+	for i := range runes {
+		// Transposition
+		if i+1 < len(runes) && !fn(string(runes[:i])+string(runes[i+1])+string(runes[i])+string(runes[i+2:])) {
+			return false
+		}
+		// Deletion
+		if !fn(string(runes[:i]) + string(runes[i+1:])) {
+			return false
+		}
+		// Replacement
+		for _, r := range try {
+			if r != runes[i] && !fn(string(runes[:i])+string(r)+string(runes[i+1:])) {
+				return false
+			}
+		}
+	}
+	// Insertion
+	for i := 0; i <= len(runes); i++ {
+		for _, r := range try {
+			if !fn(string(runes[:i]) + string(r) + string(runes[i:])) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+const (
+	// maxSecondEditLength is the maximum word length for which suggestions two edits away are searched for.
+	maxSecondEditLength = 12
+	// maxSecondEditCandidates is the maximum number of candidates two edits away that are checked.
+	maxSecondEditCandidates = 100_000
+)
+
+// Suggest returns up to max suggestions for a misspelled word. Suggestions from REP rules come first,
+// followed by words one edit away and finally words two edits away if nothing closer was found.
+// The case of the input word is preserved. Results are cached, so repeated calls for the same word are cheap.
+func (d *Dictionary) Suggest(word string, max int) []string {
+	key := suggestionCacheKey{word: word, max: max}
+	d.suggestionCacheLock.Lock()
+	cached, ok := d.suggestionCache[key]
+	d.suggestionCacheLock.Unlock()
+	if ok {
+		return cached
+	}
+	out := d.suggest(word, max)
+	d.suggestionCacheLock.Lock()
+	if d.suggestionCache == nil || len(d.suggestionCache) >= maxSuggestionCacheSize {
+		d.suggestionCache = make(map[suggestionCacheKey][]string)
+	}
+	d.suggestionCache[key] = out
+	d.suggestionCacheLock.Unlock()
+	return out
+}
+
+func (d *Dictionary) suggest(word string, max int) []string {
+	wc := getCase(word)
+	lower := word
+	if wc == caseTitle || wc == caseUpper {
+		lower = strings.ToLower(word)
+	}
+	seen := map[string]struct{}{word: {}}
+	out := make([]string, 0, max)
+	// add adds the candidate to the output if it's a correctly spelled word and returns false once max is reached.
+	add := func(candidate string) bool {
+		if !d.Check(candidate) {
+			return true
+		} else if _, ok := seen[candidate]; ok {
+			return true
+		}
+		seen[candidate] = struct{}{}
+		switch wc {
+		case caseTitle:
+			candidate = toTitle(candidate)
+		case caseUpper:
+			candidate = strings.ToUpper(candidate)
+		}
+		out = append(out, candidate)
+		return len(out) < max
+	}
+	for _, rep := range d.rep {
+		for idx := strings.Index(lower, rep[0]); idx >= 0; {
+			if !add(lower[:idx] + rep[1] + lower[idx+len(rep[0]):]) {
+				return out
+			}
+			next := strings.Index(lower[idx+1:], rep[0])
+			if next < 0 {
+				break
+			}
+			idx += next + 1
+		}
+	}
+	var firstEdits []string
+	if !d.forEachEdit(lower, func(candidate string) bool {
+		firstEdits = append(firstEdits, candidate)
+		return add(candidate)
+	}) {
+		return out
+	}
+	if len(out) > 0 || utf8.RuneCountInString(lower) > maxSecondEditLength {
+		return out
+	}
+	// Second edits aren't collected anywhere, only dictionary words are kept
+	budget := maxSecondEditCandidates
+	for _, first := range firstEdits {
+		if !d.forEachEdit(first, func(candidate string) bool {
+			budget--
+			return add(candidate) && budget > 0
+		}) {
+			break
+		}
+	}
+	return out
+}
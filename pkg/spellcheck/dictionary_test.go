@@ -0,0 +1,196 @@
+// Copyright (c) 2026 Tulir Asokan
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package spellcheck
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testAff = `SET UTF-8
+TRY esianrtolcdugmphbyfvkwzxjq
+FORBIDDENWORD !
+NEEDAFFIX ?
+
+REP 1
+REP f ph
+
+PFX U Y 1
+PFX U 0 un .
+
+SFX S Y 2
+SFX S y ies [^aeiou]y
+SFX S 0 s [^y]
+
+SFX D N 1
+SFX D 0 ed [^e]
+`
+
+const testDic = `9
+cat/S
+fly/S
+do/U
+lock/USD
+root/?S
+teh/!
+NASA
+iPhone
+phone
+`
+
+func parseTestDictionary(t *testing.T) *Dictionary {
+	t.Helper()
+	dict, err := Parse(strings.NewReader(testDic), strings.NewReader(testAff))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+	return dict
+}
+
+func TestCompileCondition(t *testing.T) {
+	tests := []struct {
+		cond    string
+		suffix  bool
+		matches []string
+		rejects []string
+	}{
+		{cond: ".", suffix: true, matches: []string{"a", "anything"}},
+		{cond: "y", suffix: true, matches: []string{"fly"}, rejects: []string{"yes"}},
+		{cond: "[^aeiou]y", suffix: true, matches: []string{"fly", "sky"}, rejects: []string{"day", "y"}},
+		{cond: "[^y]", suffix: true, matches: []string{"cat"}, rejects: []string{"fly"}},
+		{cond: "[ab]c", suffix: false, matches: []string{"acorn", "bc"}, rejects: []string{"cab", "xac"}},
+		{cond: "a.c", suffix: false, matches: []string{"abc", "azcd"}, rejects: []string{"ac", "bac"}},
+		{cond: "[a-]", suffix: true, matches: []string{"a", "x-"}, rejects: []string{"b"}},
+		{cond: "+", suffix: true, matches: []string{"c+"}, rejects: []string{"c"}},
+	}
+	for _, test := range tests {
+		re, err := compileCondition(test.cond, test.suffix)
+		if err != nil {
+			t.Errorf("compileCondition(%q) returned error: %v", test.cond, err)
+			continue
+		}
+		for _, word := range test.matches {
+			if re != nil && !re.MatchString(word) {
+				t.Errorf("condition %q (suffix=%t) doesn't match %q", test.cond, test.suffix, word)
+			}
+		}
+		for _, word := range test.rejects {
+			if re == nil || re.MatchString(word) {
+				t.Errorf("condition %q (suffix=%t) matches %q", test.cond, test.suffix, word)
+			}
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dict := parseTestDictionary(t)
+	tests := map[string]bool{
+		"cat":        true,
+		"cats":       true,
+		"fly":        true,
+		"flies":      true,
+		"flys":       false,
+		"do":         true,
+		"undo":       true,
+		"lock":       true,
+		"unlock":     true,
+		"locks":      true,
+		"locked":     true,
+		"unlocks":    true,
+		"unlocked":   false, // D isn't a cross product
+		"roots":      true,
+		"root":       false, // NEEDAFFIX
+		"teh":        false, // FORBIDDENWORD
+		"uncat":      false,
+		"catsed":     false,
+		"NASA":       true,
+		"iPhone":     true,
+		"nonsense":   false,
+		"unlockings": false,
+	}
+	for word, want := range tests {
+		if got := dict.has(word); got != want {
+			t.Errorf("dictionary contains %q = %t, want %t", word, got, want)
+		}
+	}
+}
+
+func TestCheckCasing(t *testing.T) {
+	dict := parseTestDictionary(t)
+	tests := map[string]bool{
+		"cat":    true,
+		"Cat":    true,
+		"CAT":    true,
+		"cAt":    false,
+		"Unlock": true,
+		"NASA":   true,
+		"nasa":   false,
+		"Nasa":   false,
+		"iPhone": true,
+		"iphone": false,
+		"IPHONE": false,
+		"teh":    false,
+		"Teh":    false,
+	}
+	for word, want := range tests {
+		if got := dict.Check(word); got != want {
+			t.Errorf("Check(%q) = %t, want %t", word, got, want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	dict := parseTestDictionary(t)
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{word: "cta", want: []string{"cat"}},
+		{word: "Cta", want: []string{"Cat"}},
+		{word: "CTA", want: []string{"CAT"}},
+		{word: "lcok", want: []string{"lock"}},
+		{word: "flys", want: []string{"fly"}},
+		// Two edits away
+		{word: "unlcoks", want: []string{"unlocks"}},
+		{word: "xyzzyqwerty", want: []string{}},
+	}
+	for _, test := range tests {
+		got := dict.Suggest(test.word, 5)
+		for _, want := range test.want {
+			if !slices.Contains(got, want) {
+				t.Errorf("Suggest(%q) = %v, want it to contain %q", test.word, got, want)
+			}
+		}
+		if len(test.want) == 0 && len(got) != 0 {
+			t.Errorf("Suggest(%q) = %v, want no suggestions", test.word, got)
+		}
+		if slices.Contains(got, "teh") {
+			t.Errorf("Suggest(%q) suggested a forbidden word", test.word)
+		}
+	}
+	// REP rules find suggestions that are more than one edit away, and they come first
+	if got := dict.Suggest("fone", 5); len(got) == 0 || got[0] != "phone" {
+		t.Errorf("Suggest(%q) = %v, want %q first", "fone", got, "phone")
+	}
+	if got := dict.Suggest("cta", 1); len(got) != 1 {
+		t.Errorf("Suggest(%q, 1) returned %d suggestions", "cta", len(got))
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	dict, err := Parse(strings.NewReader("1\ncaf\xe9\n"), strings.NewReader("SET ISO8859-1\n"))
+	if err != nil {
+		t.Fatalf("Parse() with ISO8859-1 returned error: %v", err)
+	} else if !dict.Check("café") {
+		t.Errorf("ISO8859-1 word wasn't decoded correctly")
+	}
+	_, err = Parse(strings.NewReader("1\nword\n"), strings.NewReader("SET ISO8859-2\n"))
+	if err == nil {
+		t.Errorf("Parse() with ISO8859-2 didn't return an error")
+	}
+}
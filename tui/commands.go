@@ -23,20 +23,39 @@ import (
 	"github.com/tidwall/gjson"
 	"maunium.net/go/mautrix/event"
 	"maunium.net/go/mautrix/event/cmdschema"
+	"maunium.net/go/mautrix/id"
 
 	"go.mau.fi/gomuks/pkg/hicli/cmdspec"
 	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
 	"go.mau.fi/gomuks/pkg/rpc/store"
+	"go.mau.fi/gomuks/tui/config"
 	"go.mau.fi/gomuks/tui/debug"
 )
 
 const (
-	CmdReply  = "reply"
-	CmdReact  = "react"
-	CmdRedact = "redact"
-	CmdQuit   = "quit"
-	CmdEdit   = "edit"
-	CmdCopy   = "copy"
+	CmdReply     = "reply"
+	CmdReact     = "react"
+	CmdRedact    = "redact"
+	CmdQuit      = "quit"
+	CmdEdit      = "edit"
+	CmdCopy      = "copy"
+	CmdVote      = "vote"
+	CmdEndPoll   = "endpoll"
+	CmdSticker   = "sticker"
+	CmdAddEmoji  = "addemoji"
+	CmdTranslate = "translate"
+	CmdThread    = "thread"
+	CmdThreads   = "threads"
+	CmdSpaces    = "spaces"
+	CmdUpload    = "upload"
+	CmdDownload  = "download"
+	CmdView      = "view"
+	CmdVerify    = "verify"
+	CmdDevices   = "devices"
+	CmdCreate    = "create"
+	CmdDM        = "dm"
+	CmdSettings  = "settings"
+	CmdTheme     = "theme"
 )
 
 var LocalCommands = []*cmdschema.EventContent{{
@@ -81,6 +100,129 @@ var LocalCommands = []*cmdschema.EventContent{{
 		DefaultValue: "clipboard",
 	}},
 	TailParam: "clipboard",
+}, {
+	Command:     CmdVote,
+	Description: event.MakeExtensibleText("Vote in a poll"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "answers",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("The numbers of the answers to vote for, or nothing to retract the vote"),
+		Optional:    true,
+	}},
+	TailParam: "answers",
+}, {
+	Command:     CmdEndPoll,
+	Description: event.MakeExtensibleText("End a poll"),
+}, {
+	Command:     CmdSticker,
+	Description: event.MakeExtensibleText("Send a sticker from an image pack"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "shortcode",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("The shortcode of the sticker"),
+	}},
+}, {
+	Command:     CmdAddEmoji,
+	Description: event.MakeExtensibleText("Upload an image and add it to your personal image pack"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "shortcode",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("The shortcode for the image"),
+	}, {
+		Key:         "path",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("The path to the image file, or an mxc:// URI of an already uploaded image"),
+	}},
+	TailParam: "path",
+}, {
+	Command:     CmdTranslate,
+	Description: event.MakeExtensibleText("Translate a message or toggle between the original and the translation"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "language",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("The language to translate to"),
+		Optional:    true,
+	}},
+}, {
+	Command:     CmdThread,
+	Description: event.MakeExtensibleText("Open the thread of a message"),
+}, {
+	Command:     CmdThreads,
+	Description: event.MakeExtensibleText("List the threads in the room"),
+}, {
+	Command:     CmdSpaces,
+	Description: event.MakeExtensibleText("Filter the room list by space"),
+}, {
+	Command:     CmdUpload,
+	Description: event.MakeExtensibleText("Upload a file"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "path",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("The path to the file. If omitted, the upload dialog is opened."),
+		Optional:    true,
+	}, {
+		Key:         "caption",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("The caption to send with the file"),
+		Optional:    true,
+	}},
+	TailParam: "caption",
+}, {
+	Command:     CmdDownload,
+	Description: event.MakeExtensibleText("Download the file in a message"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "path",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("The path to save the file to. Defaults to the file name."),
+		Optional:    true,
+	}},
+	TailParam: "path",
+}, {
+	Command:     CmdView,
+	Description: event.MakeExtensibleText("Open the full-size file in a message with an external viewer"),
+}, {
+	Command:     CmdVerify,
+	Description: event.MakeExtensibleText("Verify this session with a recovery key or recovery phrase"),
+}, {
+	Command:     CmdDevices,
+	Description: event.MakeExtensibleText("Show the devices and cross-signing status of a user"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "user_id",
+		Schema:      cmdschema.PrimitiveTypeUserID.Schema(),
+		Description: event.MakeExtensibleText("The user to show. Defaults to yourself."),
+		Optional:    true,
+	}},
+}, {
+	Command:     CmdCreate,
+	Description: event.MakeExtensibleText("Create a new room"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "name",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("The name of the room"),
+		Optional:    true,
+	}},
+	TailParam: "name",
+}, {
+	Command:     CmdDM,
+	Aliases:     []string{"pm"},
+	Description: event.MakeExtensibleText("Open or create a direct chat with a user"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "user_id",
+		Schema:      cmdschema.PrimitiveTypeUserID.Schema(),
+		Description: event.MakeExtensibleText("The user to chat with"),
+	}},
+}, {
+	Command:     CmdSettings,
+	Description: event.MakeExtensibleText("Edit the name, topic, permissions and other settings of the room"),
+}, {
+	Command:     CmdTheme,
+	Description: event.MakeExtensibleText("Switch to another theme or reload the current one"),
+	Parameters: []*cmdschema.Parameter{{
+		Key:         "name",
+		Schema:      cmdschema.PrimitiveTypeString.Schema(),
+		Description: event.MakeExtensibleText("The name of the theme. Defaults to reloading the current theme."),
+		Optional:    true,
+	}},
 }, {
 	Command:     CmdQuit,
 	Description: event.MakeExtensibleText("Quit gomuks terminal"),
@@ -132,6 +274,7 @@ func (view *RoomView) HandleCommand(cmd *event.MessageEventContent) {
 		len(cmd.Mentions.UserIDs) == 1 &&
 		view.handleInternalCommand(cmd.MSC4391BotCommand) {
 		// Handled internally
+		view.messageSent()
 		return
 	}
 	mentions := cmd.Mentions
@@ -139,10 +282,13 @@ func (view *RoomView) HandleCommand(cmd *event.MessageEventContent) {
 	err := view.parent.matrix.SendMessage(context.TODO(), &jsoncmd.SendMessageParams{
 		RoomID:      view.Room.ID,
 		BaseContent: cmd,
+		RelatesTo:   view.makeRelatesTo(nil),
 		Mentions:    mentions,
 	})
 	if err != nil {
 		debug.Print("Failed to send message:", err)
+	} else {
+		view.messageSent()
 	}
 	view.parent.parent.Render()
 }
@@ -159,6 +305,50 @@ func (view *RoomView) handleInternalCommand(cmd *event.MSC4391BotCommandInput) b
 		view.StartSelecting(SelectEdit, "")
 	case CmdCopy:
 		view.StartSelecting(SelectCopy, gjson.GetBytes(cmd.Arguments, "register").Str)
+	case CmdVote:
+		view.StartSelecting(SelectVote, gjson.GetBytes(cmd.Arguments, "answers").Str)
+	case CmdEndPoll:
+		view.StartSelecting(SelectEndPoll, "")
+	case CmdSticker:
+		go view.SendSticker(gjson.GetBytes(cmd.Arguments, "shortcode").Str)
+	case CmdAddEmoji:
+		go view.AddPackImage(gjson.GetBytes(cmd.Arguments, "shortcode").Str, gjson.GetBytes(cmd.Arguments, "path").Str)
+	case CmdTranslate:
+		view.StartSelecting(SelectTranslate, gjson.GetBytes(cmd.Arguments, "language").Str)
+	case CmdThread:
+		view.StartSelecting(SelectThread, "")
+	case CmdThreads:
+		view.parent.ShowModal(NewThreadListModal(view, 60, 16))
+		view.parent.parent.Render()
+	case CmdSpaces:
+		view.parent.ShowSpaceSwitcher()
+	case CmdUpload:
+		view.StartUpload(gjson.GetBytes(cmd.Arguments, "path").Str, gjson.GetBytes(cmd.Arguments, "caption").Str)
+	case CmdDownload:
+		view.StartSelecting(SelectDownload, gjson.GetBytes(cmd.Arguments, "path").Str)
+	case CmdView:
+		view.StartSelecting(SelectOpen, "")
+	case CmdVerify:
+		view.parent.ShowVerifyModal()
+	case CmdDevices:
+		userID := id.UserID(gjson.GetBytes(cmd.Arguments, "user_id").Str)
+		if userID == "" {
+			userID = view.parent.matrix.UserID
+		}
+		view.parent.ShowEncryptionInfo(userID)
+	case CmdCreate:
+		view.parent.ShowCreateRoomModal(gjson.GetBytes(cmd.Arguments, "name").Str)
+	case CmdDM:
+		go view.StartDM(id.UserID(gjson.GetBytes(cmd.Arguments, "user_id").Str))
+	case CmdSettings:
+		view.ShowSettings()
+	case CmdTheme:
+		err := view.parent.SetTheme(gjson.GetBytes(cmd.Arguments, "name").Str)
+		if err != nil {
+			view.AddServiceMessage("Failed to load theme: %v", err)
+		} else {
+			view.AddServiceMessage("Using the %s theme", config.CurrentTheme.Name)
+		}
 	case CmdQuit:
 		view.parent.parent.Stop()
 	default:
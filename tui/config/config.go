@@ -25,6 +25,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 
 	"codeberg.org/tslocum/cbind"
 	"github.com/gdamore/tcell/v2"
@@ -35,6 +36,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"go.mau.fi/gomuks/tui/debug"
+	"go.mau.fi/gomuks/tui/lib/termimage"
 )
 
 type UserPreferences struct {
@@ -50,11 +52,14 @@ type UserPreferences struct {
 	DisableDownloads     bool `yaml:"disable_downloads"`
 	DisableNotifications bool `yaml:"disable_notifications"`
 	DisableShowURLs      bool `yaml:"disable_show_urls"`
+	DisableSpellCheck    bool `yaml:"disable_spell_check"`
 
 	InlineURLMode string `yaml:"inline_url_mode"`
+	ImageProtocol string `yaml:"image_protocol"`
 }
 
 var InlineURLsProbablySupported bool
+var DetectedImageProtocol termimage.Protocol
 
 func init() {
 	vteVersion, _ := strconv.Atoi(os.Getenv("VTE_VERSION"))
@@ -64,23 +69,60 @@ func init() {
 		os.Getenv("TERM_PROGRAM") == "iTerm.app" ||
 		term == "foot" ||
 		term == "xterm-kitty"
+	DetectedImageProtocol = termimage.Detect()
 }
 
 func (up *UserPreferences) EnableInlineURLs() bool {
 	return up.InlineURLMode == "enable" || (InlineURLsProbablySupported && up.InlineURLMode != "disable")
 }
 
+func (up *UserPreferences) GetImageProtocol() termimage.Protocol {
+	switch protocol := termimage.Protocol(up.ImageProtocol); protocol {
+	case termimage.ProtocolANSI, termimage.ProtocolKitty, termimage.ProtocolITerm2, termimage.ProtocolSixel:
+		return protocol
+	default:
+		return DetectedImageProtocol
+	}
+}
+
 type Keybind struct {
 	Mod tcell.ModMask
 	Key tcell.Key
 	Ch  rune
 }
 
+// SequenceKeybindings contains keybindings that may consist of multiple keys pressed one after another,
+// like "g g" in the vim-style normal mode.
+type SequenceKeybindings struct {
+	actions  map[string]string
+	prefixes map[string]struct{}
+}
+
+func sequenceKey(seq []Keybind) string {
+	var buf strings.Builder
+	for _, kb := range seq {
+		_, _ = fmt.Fprintf(&buf, "%d:%d:%d;", kb.Mod, kb.Key, kb.Ch)
+	}
+	return buf.String()
+}
+
+// Get returns the action bound to the given key sequence. If no action is bound to the sequence,
+// but it's the start of a longer sequence, partial is true.
+func (skb *SequenceKeybindings) Get(seq []Keybind) (action string, partial bool) {
+	key := sequenceKey(seq)
+	action = skb.actions[key]
+	if action == "" {
+		_, partial = skb.prefixes[key]
+	}
+	return
+}
+
 type ParsedKeybindings struct {
 	Main   map[Keybind]string
 	Room   map[Keybind]string
 	Modal  map[Keybind]string
 	Visual map[Keybind]string
+	Normal *SequenceKeybindings
 }
 
 type RawKeybindings struct {
@@ -88,6 +130,7 @@ type RawKeybindings struct {
 	Room   map[string]string `yaml:"room,omitempty"`
 	Modal  map[string]string `yaml:"modal,omitempty"`
 	Visual map[string]string `yaml:"visual,omitempty"`
+	Normal map[string]string `yaml:"normal,omitempty"`
 }
 
 // Config contains the main config of gomuks.
@@ -103,6 +146,16 @@ type Config struct {
 
 	AlwaysClearScreen bool `yaml:"always_clear_screen"`
 
+	// The protocol used to draw images: kitty, iterm2, sixel or ansi. Empty means autodetect.
+	ImageProtocol string `yaml:"image_protocol"`
+
+	// The name of the theme to use. Themes are loaded from the themes directory in the config directory,
+	// falling back to the bundled dark, light and solarized themes.
+	// Use ThemeName and SetThemeName instead of accessing this directly,
+	// as the theme watcher reads it from another goroutine.
+	Theme     string       `yaml:"theme"`
+	themeLock sync.RWMutex `yaml:"-"`
+
 	LogConfig zeroconfig.Config `yaml:"log_config"`
 
 	Dir string `yaml:"-"`
@@ -146,6 +199,7 @@ func NewConfig() *Config {
 		NotifySound:           true,
 		Backspace1RemovesWord: true,
 		AlwaysClearScreen:     true,
+		Theme:                 DefaultThemeName,
 
 		LogConfig: zeroconfig.Config{
 			Writers: []zeroconfig.WriterConfig{{
@@ -165,6 +219,10 @@ func NewConfig() *Config {
 func (config *Config) LoadAll() {
 	config.Load()
 	config.LoadKeybindings()
+	err := config.LoadTheme()
+	if err != nil {
+		debug.Print("Failed to load theme:", err)
+	}
 }
 
 // Load loads the config from config.yaml in the directory given to the config struct.
@@ -173,6 +231,8 @@ func (config *Config) Load() {
 	if err != nil {
 		panic(fmt.Errorf("failed to load config.yaml: %w", err))
 	}
+	// Preferences aren't persisted yet, so the image protocol is read from the main config.
+	config.Preferences.ImageProtocol = config.ImageProtocol
 }
 
 func (config *Config) SaveAll() {
@@ -187,25 +247,51 @@ func (config *Config) Save() {
 //go:embed keybindings.yaml
 var DefaultKeybindings string
 
+func parseKeybind(shortcut, action string) Keybind {
+	mod, key, ch, err := cbind.Decode(shortcut)
+	if err != nil {
+		panic(fmt.Errorf("failed to parse keybinding %s -> %s: %w", shortcut, action, err))
+	}
+	// TODO find out if other keys are parsed incorrectly like this
+	if key == tcell.KeyEscape {
+		ch = 0
+	}
+	return Keybind{
+		Mod: mod,
+		Key: key,
+		Ch:  ch,
+	}
+}
+
 func parseKeybindings(input map[string]string) (output map[Keybind]string) {
 	output = make(map[Keybind]string, len(input))
 	for shortcut, action := range input {
-		mod, key, ch, err := cbind.Decode(shortcut)
-		if err != nil {
-			panic(fmt.Errorf("failed to parse keybinding %s -> %s: %w", shortcut, action, err))
+		output[parseKeybind(shortcut, action)] = action
+	}
+	return
+}
+
+// parseSequenceKeybindings parses keybindings where the keys in a sequence are separated by spaces.
+func parseSequenceKeybindings(input map[string]string) *SequenceKeybindings {
+	output := &SequenceKeybindings{
+		actions:  make(map[string]string, len(input)),
+		prefixes: make(map[string]struct{}),
+	}
+	for shortcut, action := range input {
+		keys := strings.Fields(shortcut)
+		if len(keys) == 0 {
+			panic(fmt.Errorf("empty keybinding for %s", action))
 		}
-		// TODO find out if other keys are parsed incorrectly like this
-		if key == tcell.KeyEscape {
-			ch = 0
+		seq := make([]Keybind, len(keys))
+		for i, key := range keys {
+			seq[i] = parseKeybind(key, action)
 		}
-		parsedShortcut := Keybind{
-			Mod: mod,
-			Key: key,
-			Ch:  ch,
+		output.actions[sequenceKey(seq)] = action
+		for i := 1; i < len(seq); i++ {
+			output.prefixes[sequenceKey(seq[:i])] = struct{}{}
 		}
-		output[parsedShortcut] = action
 	}
-	return
+	return output
 }
 
 func (config *Config) LoadKeybindings() {
@@ -221,6 +307,7 @@ func (config *Config) LoadKeybindings() {
 	config.Keybindings.Room = parseKeybindings(inputConfig.Room)
 	config.Keybindings.Modal = parseKeybindings(inputConfig.Modal)
 	config.Keybindings.Visual = parseKeybindings(inputConfig.Visual)
+	config.Keybindings.Normal = parseSequenceKeybindings(inputConfig.Normal)
 }
 
 func (config *Config) SaveKeybindings() {
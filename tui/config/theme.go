@@ -0,0 +1,345 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2026 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package config
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"go.mau.fi/util/exerrors"
+	"gopkg.in/yaml.v3"
+
+	"go.mau.fi/gomuks/tui/debug"
+)
+
+// Layout contains the sizes and positions of the panels in the main view.
+type Layout struct {
+	RoomListWidth      int    `yaml:"room_list_width"`
+	RoomListPosition   string `yaml:"room_list_position"`
+	MemberListWidth    int    `yaml:"member_list_width"`
+	MemberListPosition string `yaml:"member_list_position"`
+}
+
+const (
+	PositionLeft  = "left"
+	PositionRight = "right"
+)
+
+func (layout *Layout) validate() error {
+	if layout.RoomListWidth < 10 {
+		return fmt.Errorf("room list width must be at least 10 (got %d)", layout.RoomListWidth)
+	} else if layout.MemberListWidth < 10 {
+		return fmt.Errorf("member list width must be at least 10 (got %d)", layout.MemberListWidth)
+	} else if layout.RoomListPosition != PositionLeft && layout.RoomListPosition != PositionRight {
+		return fmt.Errorf("invalid room list position %q", layout.RoomListPosition)
+	} else if layout.MemberListPosition != PositionLeft && layout.MemberListPosition != PositionRight {
+		return fmt.Errorf("invalid member list position %q", layout.MemberListPosition)
+	}
+	return nil
+}
+
+// Theme contains the colors and layout used to draw the terminal UI.
+//
+// Themes are yaml files with a colors map where the keys are the yaml tags of the color fields below,
+// an optional list of sender_colors and an optional layout section. Colors can be tcell color names,
+// #rrggbb hex codes or "default" for the default color of the terminal.
+type Theme struct {
+	Name string `yaml:"-"`
+
+	// Colors used by all widgets
+	Background         tcell.Color `yaml:"background"`
+	Text               tcell.Color `yaml:"text"`
+	Border             tcell.Color `yaml:"border"`
+	ContrastBackground tcell.Color `yaml:"contrast_background"`
+	InputText          tcell.Color `yaml:"input_text"`
+	Placeholder        tcell.Color `yaml:"placeholder"`
+	SecondaryText      tcell.Color `yaml:"secondary_text"`
+	ButtonText         tcell.Color `yaml:"button_text"`
+	ButtonBackground   tcell.Color `yaml:"button_background"`
+	SearchText         tcell.Color `yaml:"search_text"`
+	SearchBackground   tcell.Color `yaml:"search_background"`
+	Error              tcell.Color `yaml:"error"`
+	Warning            tcell.Color `yaml:"warning"`
+
+	// Room list colors
+	RoomListText               tcell.Color `yaml:"room_list_text"`
+	RoomListSelectedText       tcell.Color `yaml:"room_list_selected_text"`
+	RoomListSelectedBackground tcell.Color `yaml:"room_list_selected_background"`
+	RoomListHeader             tcell.Color `yaml:"room_list_header"`
+	SpaceHeaderText            tcell.Color `yaml:"space_header_text"`
+	SpaceHeaderBackground      tcell.Color `yaml:"space_header_background"`
+
+	// Room view colors
+	TopicText            tcell.Color `yaml:"topic_text"`
+	TopicBackground      tcell.Color `yaml:"topic_background"`
+	StatusBarText        tcell.Color `yaml:"status_bar_text"`
+	StatusBarBackground  tcell.Color `yaml:"status_bar_background"`
+	PowerLevelBackground tcell.Color `yaml:"power_level_background"`
+
+	// Timeline colors
+	Timestamp           tcell.Color `yaml:"timestamp"`
+	Notice              tcell.Color `yaml:"notice"`
+	Pending             tcell.Color `yaml:"pending"`
+	SendError           tcell.Color `yaml:"send_error"`
+	PolicyMatch         tcell.Color `yaml:"policy_match"`
+	Highlight           tcell.Color `yaml:"highlight"`
+	StateEvent          tcell.Color `yaml:"state_event"`
+	StateEventRemoval   tcell.Color `yaml:"state_event_removal"`
+	TimelineHint        tcell.Color `yaml:"timeline_hint"`
+	ReactionBackground  tcell.Color `yaml:"reaction_background"`
+	ReplyHeader         tcell.Color `yaml:"reply_header"`
+	EditedMarker        tcell.Color `yaml:"edited_marker"`
+	ThreadMarker        tcell.Color `yaml:"thread_marker"`
+	Redacted            tcell.Color `yaml:"redacted"`
+	Scrollbar           tcell.Color `yaml:"scrollbar"`
+	ReceiptOverflow     tcell.Color `yaml:"receipt_overflow"`
+	Spoiler             tcell.Color `yaml:"spoiler"`
+	CodeBlockText       tcell.Color `yaml:"code_block_text"`
+	CodeBlockBackground tcell.Color `yaml:"code_block_background"`
+
+	// The colors that sender names are hashed to. If empty, the built-in list of colors is used.
+	SenderColors []tcell.Color `yaml:"-"`
+
+	Layout Layout `yaml:"-"`
+}
+
+// themeColorFields maps the yaml keys of theme colors to the indexes of the fields in the Theme struct.
+// It must be declared before CurrentTheme, as parsing the default theme depends on it.
+var themeColorFields = getThemeColorFields()
+
+func getThemeColorFields() map[string]int {
+	fields := make(map[string]int)
+	themeType := reflect.TypeOf(Theme{})
+	colorType := reflect.TypeOf(tcell.Color(0))
+	for i := 0; i < themeType.NumField(); i++ {
+		field := themeType.Field(i)
+		if field.Type == colorType {
+			fields[field.Tag.Get("yaml")] = i
+		}
+	}
+	return fields
+}
+
+func parseColor(name string) (tcell.Color, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "default" || name == "" {
+		return tcell.ColorDefault, nil
+	}
+	color := tcell.GetColor(name)
+	if color == tcell.ColorDefault {
+		return color, fmt.Errorf("unknown color %q", name)
+	}
+	return color, nil
+}
+
+type rawTheme struct {
+	Colors       map[string]string `yaml:"colors"`
+	SenderColors []string          `yaml:"sender_colors"`
+	Layout       yaml.Node         `yaml:"layout"`
+}
+
+// UnmarshalYAML overlays the values in the given yaml node on top of the theme.
+// Fields that aren't present in the yaml keep their previous values.
+func (theme *Theme) UnmarshalYAML(node *yaml.Node) error {
+	var raw rawTheme
+	err := node.Decode(&raw)
+	if err != nil {
+		return err
+	}
+	themeValue := reflect.ValueOf(theme).Elem()
+	for key, value := range raw.Colors {
+		fieldIndex, ok := themeColorFields[key]
+		if !ok {
+			return fmt.Errorf("unknown theme color %q", key)
+		}
+		color, err := parseColor(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+		themeValue.Field(fieldIndex).Set(reflect.ValueOf(color))
+	}
+	if raw.SenderColors != nil {
+		theme.SenderColors = make([]tcell.Color, len(raw.SenderColors))
+		for i, value := range raw.SenderColors {
+			theme.SenderColors[i], err = parseColor(value)
+			if err != nil {
+				return fmt.Errorf("invalid sender color: %w", err)
+			}
+		}
+	}
+	if !raw.Layout.IsZero() {
+		err = raw.Layout.Decode(&theme.Layout)
+		if err != nil {
+			return fmt.Errorf("failed to parse layout: %w", err)
+		}
+	}
+	return nil
+}
+
+const DefaultThemeName = "dark"
+
+// ThemePollInterval is how often the theme files are checked for changes.
+const ThemePollInterval = 2 * time.Second
+
+//go:embed themes/*.yaml
+var bundledThemes embed.FS
+
+// CurrentTheme is the theme that the UI is currently drawn with.
+var CurrentTheme = exerrors.Must(loadBundledTheme(&Theme{Name: DefaultThemeName}, DefaultThemeName))
+
+func loadBundledTheme(theme *Theme, name string) (*Theme, error) {
+	data, err := bundledThemes.ReadFile("themes/" + name + ".yaml")
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(data, theme)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bundled theme %s: %w", name, err)
+	}
+	return theme, nil
+}
+
+// BundledThemes returns the names of the themes that are included in gomuks.
+func BundledThemes() []string {
+	entries, _ := bundledThemes.ReadDir("themes")
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = strings.TrimSuffix(entry.Name(), ".yaml")
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ThemeName returns the name of the theme selected in the config.
+func (config *Config) ThemeName() string {
+	config.themeLock.RLock()
+	defer config.themeLock.RUnlock()
+	if config.Theme == "" {
+		return DefaultThemeName
+	}
+	return config.Theme
+}
+
+// SetThemeName changes the theme selected in the config. The theme isn't loaded automatically.
+func (config *Config) SetThemeName(name string) {
+	config.themeLock.Lock()
+	config.Theme = name
+	config.themeLock.Unlock()
+}
+
+func (config *Config) themeFiles(themeName string) []string {
+	return []string{
+		filepath.Join(config.Dir, "themes", themeName+".yaml"),
+		filepath.Join(config.Dir, "terminal-theme.yaml"),
+	}
+}
+
+// LoadTheme loads the theme selected in the config and replaces CurrentTheme with it.
+// CurrentTheme is read while drawing, so this must only be called on the UI goroutine.
+func (config *Config) LoadTheme() error {
+	theme, err := config.ReadTheme()
+	if err != nil {
+		return err
+	}
+	CurrentTheme = theme
+	return nil
+}
+
+// ReadTheme loads the theme selected in the config without applying it.
+//
+// The dark theme is always used as the base, so custom themes only need to specify the colors they change.
+// On top of that, the selected theme is loaded from the themes directory in the config directory
+// or from the bundled themes, and finally the overrides in terminal-theme.yaml are applied.
+func (config *Config) ReadTheme() (*Theme, error) {
+	themeName := config.ThemeName()
+	if filepath.Base(themeName) != themeName {
+		return nil, fmt.Errorf("invalid theme name %q", themeName)
+	}
+	theme, err := loadBundledTheme(&Theme{Name: themeName}, DefaultThemeName)
+	if err != nil {
+		return nil, err
+	}
+	themeFiles := config.themeFiles(themeName)
+	data, err := os.ReadFile(themeFiles[0])
+	if err == nil {
+		err = yaml.Unmarshal(data, theme)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", themeFiles[0], err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	} else if themeName != DefaultThemeName {
+		_, err = loadBundledTheme(theme, themeName)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("theme %q not found (bundled themes: %s)", themeName, strings.Join(BundledThemes(), ", "))
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	err = config.load("theme", config.Dir, "terminal-theme.yaml", theme)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse terminal-theme.yaml: %w", err)
+	}
+	err = theme.Layout.validate()
+	if err != nil {
+		return nil, err
+	}
+	return theme, nil
+}
+
+// themeFileState returns the modification times of the theme files, which is used to detect changes.
+func (config *Config) themeFileState() (state [2]int64) {
+	for i, path := range config.themeFiles(config.ThemeName()) {
+		info, err := os.Stat(path)
+		if err == nil {
+			state[i] = info.ModTime().UnixNano()
+		}
+	}
+	return
+}
+
+// WatchTheme starts polling the theme files for changes. When a file changes,
+// the theme is reloaded and passed to onChange. The callback is called from the polling goroutine,
+// so it must hand the theme over to the UI goroutine rather than applying it directly.
+func (config *Config) WatchTheme(onChange func(*Theme)) {
+	go func() {
+		defer debug.Recover()
+		lastState := config.themeFileState()
+		for {
+			time.Sleep(ThemePollInterval)
+			state := config.themeFileState()
+			if state == lastState {
+				continue
+			}
+			lastState = state
+			theme, err := config.ReadTheme()
+			if err != nil {
+				debug.Print("Failed to reload theme:", err)
+				continue
+			}
+			onChange(theme)
+		}
+	}()
+}
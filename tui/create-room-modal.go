@@ -0,0 +1,322 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2026 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gdamore/tcell/v2"
+	"go.mau.fi/mauview"
+	"go.mau.fi/util/ptr"
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+	"go.mau.fi/gomuks/pkg/rpc/store"
+	"go.mau.fi/gomuks/tui/config"
+	"go.mau.fi/gomuks/tui/debug"
+)
+
+type CreateRoomModal struct {
+	mauview.Component
+
+	form *mauview.Form
+
+	name       *mauview.InputField
+	topic      *mauview.InputField
+	alias      *mauview.InputField
+	invite     *mauview.InputField
+	visibility *mauview.Button
+	encrypt    *mauview.Button
+	space      *mauview.Button
+	status     *mauview.TextField
+
+	public    bool
+	encrypted bool
+	// The first entry is nil, which means the room isn't added to any space.
+	spaces   []*store.SpaceEntry
+	spaceIdx int
+	// Set while the room is being created in a background goroutine.
+	creating atomic.Bool
+	// Set by the background goroutine when the modal should be closed.
+	closed atomic.Bool
+
+	parent *MainView
+}
+
+func (view *MainView) ShowCreateRoomModal(name string) {
+	view.ShowModal(NewCreateRoomModal(view, name))
+	view.parent.Render()
+}
+
+func NewCreateRoomModal(parent *MainView, name string) *CreateRoomModal {
+	cm := &CreateRoomModal{
+		form:       mauview.NewForm(),
+		name:       mauview.NewInputField(),
+		topic:      mauview.NewInputField(),
+		alias:      mauview.NewInputField(),
+		invite:     mauview.NewInputField(),
+		visibility: mauview.NewButton(""),
+		encrypt:    mauview.NewButton(""),
+		space:      mauview.NewButton(""),
+		status:     mauview.NewTextField(),
+		encrypted:  true,
+		spaces:     append([]*store.SpaceEntry{nil}, parent.matrix.GetSpaceTree()...),
+		parent:     parent,
+	}
+	currentSpace := parent.roomList.SelectedSpace()
+	cm.spaceIdx = max(0, slices.IndexFunc(cm.spaces, func(entry *store.SpaceEntry) bool {
+		return entry != nil && entry.RoomID == currentSpace
+	}))
+
+	cm.name.SetPlaceholder("Meow room").SetTextColor(config.CurrentTheme.InputText).SetText(name)
+	cm.topic.SetPlaceholder("A room for meowing").SetTextColor(config.CurrentTheme.InputText)
+	cm.alias.
+		SetPlaceholder(fmt.Sprintf("meow for #meow:%s", parent.matrix.UserID.Homeserver())).
+		SetTextColor(config.CurrentTheme.InputText)
+	cm.invite.SetPlaceholder("Space-separated user IDs").SetTextColor(config.CurrentTheme.InputText)
+	cm.visibility.SetOnClick(func() {
+		cm.public = !cm.public
+		// Encryption is rarely useful in public rooms, as anyone can join and read the messages anyway.
+		cm.encrypted = !cm.public
+		cm.updateButtons()
+	})
+	cm.encrypt.SetOnClick(func() {
+		cm.encrypted = !cm.encrypted
+		cm.updateButtons()
+	})
+	cm.space.SetOnClick(func() {
+		cm.spaceIdx = (cm.spaceIdx + 1) % len(cm.spaces)
+		cm.updateButtons()
+	})
+	cancel := mauview.NewButton("Cancel").SetOnClick(parent.HideModal)
+	submit := mauview.NewButton("Create").SetOnClick(cm.Submit)
+
+	cm.form.
+		SetColumns([]int{1, 10, 1, -1, 1}).
+		SetRows([]int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1})
+	cm.form.
+		AddFormItem(cm.name, 3, 1, 1, 1).
+		AddFormItem(cm.topic, 3, 2, 1, 1).
+		AddFormItem(cm.alias, 3, 3, 1, 1).
+		AddFormItem(cm.invite, 3, 4, 1, 1).
+		AddFormItem(cm.visibility, 3, 6, 1, 1).
+		AddFormItem(cm.encrypt, 3, 7, 1, 1).
+		AddFormItem(cm.space, 3, 8, 1, 1).
+		AddFormItem(submit, 3, 10, 1, 1).
+		AddFormItem(cancel, 1, 10, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Name"), 1, 1, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Topic"), 1, 2, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Alias"), 1, 3, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Invite"), 1, 4, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Visibility"), 1, 6, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Encrypt"), 1, 7, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Space"), 1, 8, 1, 1).
+		AddComponent(cm.status, 3, 9, 1, 1)
+	cm.updateButtons()
+
+	box := mauview.NewBox(cm.form).
+		SetBorder(true).
+		SetTitle("Create room").
+		SetBlurCaptureFunc(func() bool {
+			cm.parent.HideModal()
+			return true
+		})
+	center := mauview.Center(box, 64, 14).SetAlwaysFocusChild(true)
+	center.Focus()
+	cm.form.FocusNextItem()
+	cm.Component = center
+	return cm
+}
+
+func (cm *CreateRoomModal) updateButtons() {
+	if cm.public {
+		cm.visibility.SetText("Public, listed in the room directory")
+	} else {
+		cm.visibility.SetText("Private, invite only")
+	}
+	if cm.encrypted {
+		cm.encrypt.SetText("Yes")
+	} else {
+		cm.encrypt.SetText("No")
+	}
+	if space := cm.spaces[cm.spaceIdx]; space != nil {
+		cm.space.SetText(space.Name)
+	} else {
+		cm.space.SetText("None")
+	}
+}
+
+func (cm *CreateRoomModal) setStatus(color tcell.Color, text string) {
+	cm.status.SetTextColor(color)
+	cm.status.SetText(text)
+}
+
+// parseUserIDs parses a list of user IDs separated by spaces or commas.
+func parseUserIDs(input string) ([]id.UserID, error) {
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	userIDs := make([]id.UserID, len(fields))
+	for i, field := range fields {
+		userIDs[i] = id.UserID(field)
+		if _, _, err := userIDs[i].Parse(); err != nil {
+			return nil, fmt.Errorf("invalid user ID %s", field)
+		}
+	}
+	return userIDs, nil
+}
+
+func (cm *CreateRoomModal) Submit() {
+	if cm.creating.Load() {
+		return
+	}
+	alias := strings.TrimPrefix(strings.TrimSpace(cm.alias.GetText()), "#")
+	if strings.ContainsAny(alias, ": ") {
+		cm.setStatus(config.CurrentTheme.Error, "Only enter the part of the alias before the colon")
+		return
+	}
+	invite, err := parseUserIDs(cm.invite.GetText())
+	if err != nil {
+		cm.setStatus(config.CurrentTheme.Error, err.Error())
+		return
+	}
+	req := &mautrix.ReqCreateRoom{
+		Name:          strings.TrimSpace(cm.name.GetText()),
+		Topic:         strings.TrimSpace(cm.topic.GetText()),
+		RoomAliasName: alias,
+		Invite:        invite,
+		Preset:        "private_chat",
+		Visibility:    "private",
+	}
+	if cm.public {
+		req.Preset = "public_chat"
+		req.Visibility = "public"
+	}
+	if cm.encrypted {
+		req.InitialState = append(req.InitialState, makeEncryptionEvent())
+	}
+	space := cm.spaces[cm.spaceIdx]
+	via := []string{cm.parent.matrix.UserID.Homeserver()}
+	if space != nil {
+		req.InitialState = append(req.InitialState, &event.Event{
+			Type:     event.StateSpaceParent,
+			StateKey: ptr.Ptr(space.RoomID.String()),
+			Content:  event.Content{Parsed: &event.SpaceParentEventContent{Via: via, Canonical: true}},
+		})
+	}
+	cm.creating.Store(true)
+	cm.setStatus(config.CurrentTheme.Warning, "Creating room...")
+	go cm.create(req, space, via)
+}
+
+func (cm *CreateRoomModal) create(req *mautrix.ReqCreateRoom, space *store.SpaceEntry, via []string) {
+	defer debug.Recover()
+	defer cm.parent.parent.Render()
+	resp, err := cm.parent.matrix.CreateRoom(context.TODO(), req)
+	if err != nil {
+		cm.creating.Store(false)
+		debug.Print("Failed to create room:", err)
+		cm.setStatus(config.CurrentTheme.Error, fmt.Sprintf("Failed to create room: %v", err))
+		return
+	}
+	debug.Print("Created room", resp.RoomID)
+	cm.parent.SwitchToNewRoom(resp.RoomID)
+	if space != nil {
+		content, _ := json.Marshal(&event.SpaceChildEventContent{Via: via})
+		_, err = cm.parent.matrix.SetState(context.TODO(), &jsoncmd.SendStateEventParams{
+			RoomID:    space.RoomID,
+			EventType: event.StateSpaceChild,
+			StateKey:  resp.RoomID.String(),
+			Content:   content,
+		})
+		if err != nil {
+			// The room was created, so the dialog can't be used to retry. Leave it open to show the error.
+			debug.Print("Failed to add", resp.RoomID, "to space", space.RoomID, err)
+			cm.setStatus(config.CurrentTheme.Error, fmt.Sprintf("Room created, but adding it to the space failed: %v", err))
+			return
+		}
+	}
+	cm.closed.Store(true)
+}
+
+func (cm *CreateRoomModal) shouldClose() bool {
+	return cm.closed.Load()
+}
+
+func (cm *CreateRoomModal) OnKeyEvent(event mauview.KeyEvent) bool {
+	kb := config.Keybind{
+		Key: event.Key(),
+		Ch:  event.Rune(),
+		Mod: event.Modifiers(),
+	}
+	if cm.parent.config.Keybindings.Modal[kb] == "cancel" {
+		cm.parent.HideModal()
+		return true
+	}
+	return cm.Component.OnKeyEvent(event)
+}
+
+func makeEncryptionEvent() *event.Event {
+	return &event.Event{
+		Type:     event.StateEncryption,
+		StateKey: ptr.Ptr(""),
+		Content:  event.Content{Parsed: &event.EncryptionEventContent{Algorithm: id.AlgorithmMegolmV1}},
+	}
+}
+
+// StartDM switches to the existing direct chat with the given user, or creates a new one if there isn't one.
+// The new chat is encrypted if the user has any devices that support encryption.
+func (view *RoomView) StartDM(userID id.UserID) {
+	defer debug.Recover()
+	defer view.parent.parent.Render()
+	if _, _, err := userID.Parse(); err != nil {
+		view.AddServiceMessage("Invalid user ID %s", userID)
+		return
+	}
+	for _, entry := range view.parent.matrix.ReversedRoomList.Current() {
+		if entry.DMUserID == userID && !entry.IsInvite {
+			view.parent.SwitchToNewRoom(entry.RoomID)
+			return
+		}
+	}
+	view.AddServiceMessage("Creating a direct chat with %s...", userID)
+	req := &mautrix.ReqCreateRoom{
+		Preset:   "trusted_private_chat",
+		IsDirect: true,
+		Invite:   []id.UserID{userID},
+	}
+	encInfo, err := view.parent.matrix.TrackUserDevices(context.TODO(), &jsoncmd.GetProfileParams{UserID: userID})
+	if err != nil {
+		debug.Print("Failed to check encryption support of", userID, err)
+	} else if len(encInfo.Devices) > 0 {
+		req.InitialState = append(req.InitialState, makeEncryptionEvent())
+	}
+	resp, err := view.parent.matrix.CreateRoom(context.TODO(), req)
+	if err != nil {
+		view.AddServiceMessage("Failed to create direct chat: %v", err)
+		return
+	}
+	debug.Print("Created direct chat", resp.RoomID, "with", userID)
+	view.parent.SwitchToNewRoom(resp.RoomID)
+}
@@ -21,7 +21,6 @@ import (
 	"sort"
 	"strconv"
 
-	"github.com/gdamore/tcell/v2"
 	"github.com/lithammer/fuzzysearch/fuzzy"
 	"go.mau.fi/mauview"
 
@@ -60,8 +59,8 @@ func NewFuzzySearchModal(mainView *MainView, width int, height int) *FuzzySearch
 	fs.results = mauview.NewTextView().SetRegions(true)
 	fs.search = mauview.NewInputArea().
 		SetChangedFunc(fs.changeHandler).
-		SetTextColor(tcell.ColorWhite).
-		SetBackgroundColor(tcell.ColorDarkCyan)
+		SetTextColor(config.CurrentTheme.SearchText).
+		SetBackgroundColor(config.CurrentTheme.SearchBackground)
 	fs.search.Focus()
 
 	flex := mauview.NewFlex().
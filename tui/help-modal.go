@@ -1,7 +1,6 @@
 package tui
 
 import (
-	"github.com/gdamore/tcell/v2"
 	"go.mau.fi/mauview"
 
 	"go.mau.fi/gomuks/tui/config"
@@ -14,11 +13,45 @@ const helpText = `# General
 /logout         - Log out of Matrix.
 /toggle <thing> - Temporary command to toggle various UI features.
                   Run /toggle without arguments to see the list of toggles.
+/ignore <user id>   - Hide messages and reject invites from a user.
+/unignore <user id> - Stop ignoring a user.
 
 # Media
 /download [path] - Downloads file from selected message.
-/open [path]     - Download file from selected message and open it with xdg-open.
-/upload <path>   - Upload the file at the given path to the current room.
+/view            - Open the full-size file from selected message in an external viewer.
+                   Clicking a file message while holding a modifier does the same.
+/upload [path] [caption] - Upload the file at the given path to the current room.
+                           Without a path, opens the upload dialog with options for
+                           re-encoding. Press tab to complete the path. Pasting
+                           the path of a file also opens the upload dialog.
+
+Images are shown inline using the kitty graphics protocol, iTerm2 inline images
+or sixel depending on the terminal. Set image_protocol in terminal.yaml to
+kitty, iterm2, sixel or ansi to override the detected protocol.
+
+# Themes
+/theme [name] - Switch to the given theme, or reload the current theme.
+                The bundled themes are dark, light and solarized.
+
+Custom themes can be placed in the themes directory inside the config
+directory, and terminal-theme.yaml in the config directory can override
+individual colors and the layout (panel widths and positions) of any theme.
+Changes to theme files are applied automatically, but state events and code
+blocks that were already loaded may keep the colors of the previous theme.
+
+# Normal mode
+Press Alt+n in a room to enter the vim-style normal mode, and Escape, i or a
+to go back to typing. In normal mode, j/k select the next and previous message,
+gg/G jump to the oldest and newest loaded message, / searches the loaded
+timeline and n/N jump to the next and previous match. r, e, d, y and + reply to,
+edit, redact, copy and react to the selected message, t opens its thread and o
+opens its file. Most keys accept a count prefix, e.g. 5j. With a count, d only
+redacts your own messages and asks for confirmation first, e.g. 3d then y.
+
+The input can also be edited from normal mode with h/l, w/b, 0/$, x, D, u and
+Ctrl+r. All keys can be changed in the normal section of
+terminal-keybindings.yaml. Bind Escape to normal_mode in the room section
+to enter normal mode with it.
 
 # Sending special messages
 /me <message>        - Send an emote message.
@@ -29,38 +62,51 @@ const helpText = `# General
 /react <reaction>    - React to the selected message.
 /redact [reason]     - Redact the selected message.
 /edit                - Edit the selected message.
+/vote [answers]      - Vote in the selected poll using answer numbers.
+                       Without answers, the previous vote is retracted.
+/endpoll             - End the selected poll.
+/sticker <shortcode> - Send a sticker from an image pack. Press tab to
+                       complete the shortcode.
+/addemoji <shortcode> <path> - Upload an image and add it to your personal
+                               image pack. The path may also be an mxc:// URI.
+/snippet <name> [args] - Send a message snippet from your account data.
+                         Press tab to complete the snippet name.
+/translate [language] - Translate the selected message. Without a language,
+                        toggles between the original and the translation.
+
+/thread  - Open the thread of the selected message. Messages sent while a
+           thread is open are sent to the thread. Press Escape to close it.
+/threads - List threads in the current room and open the selected one.
+
+Misspelled words are listed in the status bar if hunspell dictionaries are
+installed in the dictionaries directory inside the config directory.
+Press tab at the end of a misspelled word to replace it with a suggestion.
 
 # Encryption
-/fingerprint - View the fingerprint of your device.
-
-/devices <user id>               - View the device list of a user.
-/device <user id> <device id>    - Show info about a specific device.
-/unverify <user id> <device id>  - Un-verify a device.
-/blacklist <user id> <device id> - Blacklist a device.
-/verify <user id> - Verify a user with in-room verification. Probably broken.
-/verify-device <user id> <device id> [fingerprint]
-    - Verify a device. If the fingerprint is not provided,
-      interactive emoji verification will be started.
-/reset-session - Reset the outbound Megolm session in the current room.
-
-/import <file> - Import encryption keys
-/export <file> - Export encryption keys
-/export-room <file> - Export encryption keys for the current room.
-
-/cross-signing <subcommand> [...]
-    - Cross-signing commands. Somewhat experimental.
-      Run without arguments for help. (alias: /cs)
-/ssss <subcommand> [...]
-    - Secure Secret Storage (and Sharing) commands. Very experimental.
-      Run without arguments for help.
+/verify            - Verify this session with your recovery key or recovery
+                     phrase. The dialog also opens automatically on startup
+                     if the session isn't verified.
+/devices [user id] - Show the devices and cross-signing status of a user.
+                     If the master key of the user has changed, the new key
+                     can be trusted from the dialog after confirming it.
+/discardsession    - Discard the outbound Megolm session in the current room.
 
 # Rooms
-/pm <user id> <...>   - Create a private chat with the given user(s).
-/create [room name]   - Create a room.
+/dm <user id>         - Open the direct chat with the given user, or create one
+                        if there isn't one yet. (alias: /pm)
+/create [room name]   - Create a room. The dialog has options for encryption,
+                        visibility, the alias, users to invite and the space
+                        to add the room to.
+/settings             - Edit the name, topic, avatar, join rule, history
+                        visibility and power levels of the current room.
+/spaces               - Filter the room list by space. Alt+Left and Alt+Right
+                        cycle through spaces, including nested subspaces.
 
 /join <room> [server] - Join a room.
 /accept               - Accept the invite.
-/reject               - Reject the invite.
+/reject [reason]      - Reject the invite.
+/block [reason]       - Reject the invite and ignore the inviter.
+                        Invites are listed at the top of the room list.
 
 /invite <user id>     - Invite the given user to the room.
 /roomnick <name>      - Change your per-room displayname.
@@ -68,11 +114,21 @@ const helpText = `# General
 /untag <tag>          - Remove the room from <tag>.
 /tags                 - List the tags the room is in.
 /alias <act> <name>   - Add or remove local addresses.
+/upgraderoom <version> - Upgrade the room to a new room version.
 
 /leave                     - Leave the current room.
 /kick   <user id> [reason] - Kick a user.
 /ban    <user id> [reason] - Ban a user.
-/unban  <user id>          - Unban a user.`
+/unban  <user id>          - Unban a user.
+
+/bulkredact <user id> [limit] [reason]  - Redact recent messages from a user.
+/bulkkick   <pattern> [reason]          - Kick all users matching a glob pattern.
+/bulkban    <space> <user id> [reason]  - Ban a user from every room in a space.
+
+/policy subscribe [auto ban] - Subscribe to the policy list in the current room.
+/policy unsubscribe          - Unsubscribe from the policy list in the current room.
+/policy report               - List bans recommended by subscribed policy lists.
+/policy apply                - Apply bans recommended by subscribed policy lists.`
 
 type HelpModal struct {
 	mauview.FocusableComponent
@@ -86,7 +142,7 @@ func NewHelpModal(parent *MainView) *HelpModal {
 		SetText(helpText).
 		SetScrollable(true).
 		SetWrap(false).
-		SetTextColor(tcell.ColorDefault)
+		SetTextColor(config.CurrentTheme.Text)
 
 	box := mauview.NewBox(text).
 		SetBorder(true).
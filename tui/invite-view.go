@@ -0,0 +1,285 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2026 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mau.fi/mauview"
+
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+	"go.mau.fi/gomuks/pkg/rpc/store"
+	"go.mau.fi/gomuks/tui/config"
+	"go.mau.fi/gomuks/tui/debug"
+)
+
+// InviteView shows a preview of a room the user has been invited to,
+// based on the stripped state included in the invite.
+type InviteView struct {
+	topic  *mauview.TextView
+	info   *mauview.TextView
+	status *mauview.TextField
+	input  *mauview.InputArea
+	Invite *store.InvitedRoom
+
+	topicScreen  *mauview.ProxyScreen
+	infoScreen   *mauview.ProxyScreen
+	statusScreen *mauview.ProxyScreen
+	inputScreen  *mauview.ProxyScreen
+
+	prevScreen mauview.Screen
+
+	parent *MainView
+	config *config.Config
+}
+
+func NewInviteView(parent *MainView, invite *store.InvitedRoom) *InviteView {
+	view := &InviteView{
+		topic:  mauview.NewTextView(),
+		info:   mauview.NewTextView(),
+		status: mauview.NewTextField(),
+		input:  mauview.NewInputArea(),
+		Invite: invite,
+
+		topicScreen:  &mauview.ProxyScreen{OffsetX: 0, OffsetY: 0, Height: TopicBarHeight},
+		infoScreen:   &mauview.ProxyScreen{OffsetX: 1, OffsetY: TopicBarHeight + 1},
+		statusScreen: &mauview.ProxyScreen{OffsetX: 0, Height: StatusBarHeight},
+		inputScreen:  &mauview.ProxyScreen{OffsetX: 0},
+
+		parent: parent,
+		config: parent.config,
+	}
+
+	view.input.SetPlaceholder("Type /accept, /reject or /block...")
+	view.topic.SetText(strings.ReplaceAll(invite.Topic, "\n", " "))
+	view.info.
+		SetWrap(true).
+		SetWordWrap(true).
+		SetText(view.describeInvite())
+	view.applyTheme()
+
+	return view
+}
+
+// applyTheme sets the colors of the widgets in the invite view from the current theme.
+func (view *InviteView) applyTheme() {
+	theme := config.CurrentTheme
+	view.input.
+		SetTextColor(theme.Text).
+		SetBackgroundColor(theme.Background).
+		SetPlaceholderTextColor(theme.Placeholder)
+	view.topic.
+		SetTextColor(theme.TopicText).
+		SetBackgroundColor(theme.TopicBackground)
+	view.info.SetTextColor(theme.Text)
+	view.status.
+		SetTextColor(theme.StatusBarText).
+		SetBackgroundColor(theme.StatusBarBackground)
+}
+
+func (view *InviteView) inviterName() string {
+	if view.Invite.InviterProfile != nil && view.Invite.InviterProfile.Displayname != "" {
+		return fmt.Sprintf("%s (%s)", view.Invite.InviterProfile.Displayname, view.Invite.InvitedBy)
+	}
+	return view.Invite.InvitedBy.String()
+}
+
+func (view *InviteView) describeInvite() string {
+	invite := view.Invite
+	var buf strings.Builder
+	if invite.IsDirect {
+		_, _ = fmt.Fprintf(&buf, "%s wants to chat with you.\n\n", view.inviterName())
+	} else if invite.InvitedBy != "" {
+		_, _ = fmt.Fprintf(&buf, "%s invited you to %s.\n\n", view.inviterName(), invite.Name)
+	} else {
+		_, _ = fmt.Fprintf(&buf, "You have been invited to %s.\n\n", invite.Name)
+	}
+	if invite.CanonicalAlias != "" {
+		_, _ = fmt.Fprintf(&buf, "Address:      %s\n", invite.CanonicalAlias)
+	}
+	if invite.Topic != "" {
+		_, _ = fmt.Fprintf(&buf, "Topic:        %s\n", invite.Topic)
+	}
+	if invite.Encryption != "" {
+		_, _ = fmt.Fprintf(&buf, "Encryption:   %s\n", invite.Encryption)
+	} else {
+		buf.WriteString("Encryption:   not encrypted\n")
+	}
+	if invite.JoinRule != "" {
+		_, _ = fmt.Fprintf(&buf, "Join rule:    %s\n", invite.JoinRule)
+	}
+	if invite.RoomVersion != "" {
+		_, _ = fmt.Fprintf(&buf, "Room version: %s\n", invite.RoomVersion)
+	}
+	_, _ = fmt.Fprintf(&buf, "Room ID:      %s\n\n", invite.RoomID)
+	buf.WriteString("/accept         - Join the room.\n")
+	buf.WriteString("/reject [reason] - Reject the invite.\n")
+	if invite.InvitedBy != "" {
+		buf.WriteString("/block [reason] - Reject the invite and ignore the inviter.\n")
+	}
+	return buf.String()
+}
+
+func (view *InviteView) Focus() {
+	view.input.Focus()
+}
+
+func (view *InviteView) Blur() {
+	view.input.Blur()
+}
+
+func (view *InviteView) Draw(screen mauview.Screen) {
+	width, height := screen.Size()
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	if view.prevScreen != screen {
+		view.topicScreen.Parent = screen
+		view.infoScreen.Parent = screen
+		view.statusScreen.Parent = screen
+		view.inputScreen.Parent = screen
+		view.prevScreen = screen
+	}
+
+	view.applyTheme()
+	view.input.PrepareDraw(width)
+	inputHeight := min(max(view.input.GetTextHeight(), 1), MaxInputHeight)
+
+	view.topicScreen.Width = width
+	view.infoScreen.Width = width - 2
+	view.infoScreen.Height = height - inputHeight - TopicBarHeight - StatusBarHeight - 1
+	view.statusScreen.OffsetY = height - inputHeight - StatusBarHeight
+	view.statusScreen.Width = width
+	view.inputScreen.Width = width
+	view.inputScreen.OffsetY = view.statusScreen.YEnd()
+	view.inputScreen.Height = inputHeight
+
+	view.topic.Draw(view.topicScreen)
+	view.info.Draw(view.infoScreen)
+	view.status.Draw(view.statusScreen)
+	view.input.Draw(view.inputScreen)
+}
+
+func (view *InviteView) OnKeyEvent(event mauview.KeyEvent) bool {
+	kb := config.Keybind{
+		Key: event.Key(),
+		Ch:  event.Rune(),
+		Mod: event.Modifiers(),
+	}
+	switch view.config.Keybindings.Room[kb] {
+	case "clear":
+		view.input.SetText("")
+		return true
+	case "send":
+		view.InputSubmit(view.input.GetText())
+		return true
+	}
+	return view.input.OnKeyEvent(event)
+}
+
+func (view *InviteView) OnPasteEvent(event mauview.PasteEvent) bool {
+	return view.input.OnPasteEvent(event)
+}
+
+func (view *InviteView) OnMouseEvent(event mauview.MouseEvent) bool {
+	switch {
+	case view.infoScreen.IsInArea(event.Position()):
+		return view.info.OnMouseEvent(view.infoScreen.OffsetMouseEvent(event))
+	case view.inputScreen.IsInArea(event.Position()):
+		return view.input.OnMouseEvent(view.inputScreen.OffsetMouseEvent(event))
+	}
+	return false
+}
+
+func (view *InviteView) setStatus(text string, args ...any) {
+	if len(args) > 0 {
+		text = fmt.Sprintf(text, args...)
+	}
+	view.status.SetText(text)
+	view.parent.parent.Render()
+}
+
+func (view *InviteView) InputSubmit(text string) {
+	text = strings.TrimSpace(text)
+	if len(text) == 0 {
+		return
+	}
+	command, args, _ := strings.Cut(text, " ")
+	args = strings.TrimSpace(args)
+	switch command {
+	case "/accept", "/join":
+		go view.Accept()
+	case "/reject", "/leave":
+		go view.Reject(args)
+	case "/block":
+		if view.Invite.InvitedBy == "" {
+			view.setStatus("The inviter of this room is unknown")
+			return
+		}
+		go view.Block(args)
+	default:
+		view.setStatus("You must accept the invite before sending messages")
+		return
+	}
+	view.input.SetText("")
+}
+
+func (view *InviteView) Accept() {
+	defer debug.Recover()
+	view.setStatus("Joining room...")
+	_, err := view.parent.matrix.JoinRoom(context.TODO(), &jsoncmd.JoinRoomParams{
+		RoomIDOrAlias: view.Invite.RoomID.String(),
+		FromInvite:    true,
+	})
+	if err != nil {
+		view.setStatus("Failed to join room: %v", err)
+		return
+	}
+	view.setStatus("Joined room, waiting for sync...")
+	view.parent.SetPendingJoin(view.Invite.RoomID)
+}
+
+func (view *InviteView) Reject(reason string) {
+	defer debug.Recover()
+	view.setStatus("Rejecting invite...")
+	_, err := view.parent.matrix.LeaveRoom(context.TODO(), &jsoncmd.LeaveRoomParams{
+		RoomID: view.Invite.RoomID,
+		Reason: reason,
+	})
+	if err != nil {
+		view.setStatus("Failed to reject invite: %v", err)
+		return
+	}
+	view.setStatus("Invite rejected")
+}
+
+func (view *InviteView) Block(reason string) {
+	defer debug.Recover()
+	view.setStatus("Ignoring %s...", view.Invite.InvitedBy)
+	err := view.parent.matrix.SetIgnored(context.TODO(), &jsoncmd.SetIgnoredParams{
+		UserID:  view.Invite.InvitedBy,
+		Ignored: true,
+	})
+	if err != nil {
+		view.setStatus("Failed to ignore %s: %v", view.Invite.InvitedBy, err)
+		return
+	}
+	view.Reject(reason)
+}
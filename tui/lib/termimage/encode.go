@@ -0,0 +1,154 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2026 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package termimage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/png"
+)
+
+// The maximum size of a single base64 chunk in the kitty graphics protocol.
+const kittyChunkSize = 4096
+
+const kittyDeleteAll = "\x1b_Ga=d,d=a,q=2\x1b\\"
+
+func encodePNG(img image.Image) []byte {
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// writeKittyTransmit uploads the image to the terminal without displaying it.
+// Transmitting with an existing ID replaces the previous image.
+func writeKittyTransmit(buf *bytes.Buffer, id uint32, img image.Image) {
+	data := base64.StdEncoding.EncodeToString(encodePNG(img))
+	for i := 0; i < len(data); i += kittyChunkSize {
+		end := min(i+kittyChunkSize, len(data))
+		more := 0
+		if end < len(data) {
+			more = 1
+		}
+		if i == 0 {
+			_, _ = fmt.Fprintf(buf, "\x1b_Ga=t,f=100,i=%d,q=2,m=%d;", id, more)
+		} else {
+			_, _ = fmt.Fprintf(buf, "\x1b_Gm=%d;", more)
+		}
+		buf.WriteString(data[i:end])
+		buf.WriteString("\x1b\\")
+	}
+}
+
+// writeKittyPlace displays a previously transmitted image at the cursor.
+// The crop rectangle is in pixels of the transmitted image.
+func writeKittyPlace(buf *bytes.Buffer, id uint32, crop image.Rectangle, cols, rows int) {
+	_, _ = fmt.Fprintf(
+		buf, "\x1b_Ga=p,i=%d,x=%d,y=%d,w=%d,h=%d,c=%d,r=%d,C=1,q=2\x1b\\",
+		id, crop.Min.X, crop.Min.Y, crop.Dx(), crop.Dy(), cols, rows,
+	)
+}
+
+// writeITerm2 displays the image at the cursor using the iTerm2 inline image protocol.
+func writeITerm2(buf *bytes.Buffer, img image.Image, cols, rows int) {
+	data := encodePNG(img)
+	_, _ = fmt.Fprintf(buf, "\x1b]1337;File=inline=1;size=%d;width=%d;height=%d;preserveAspectRatio=0:", len(data), cols, rows)
+	enc := base64.NewEncoder(base64.StdEncoding, buf)
+	_, _ = enc.Write(data)
+	_ = enc.Close()
+	buf.WriteByte('\a')
+}
+
+// writeSixel displays the image at the cursor as sixel graphics. The image is dithered to a 256 color palette.
+func writeSixel(buf *bytes.Buffer, img image.Image) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	paletted := image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9)
+	draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), img, bounds.Min)
+
+	// The second parameter makes pixels that aren't set keep the existing background.
+	_, _ = fmt.Fprintf(buf, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+	used := make([]bool, len(paletted.Palette))
+	for _, idx := range paletted.Pix {
+		used[idx] = true
+	}
+	for idx, isUsed := range used {
+		if !isUsed {
+			continue
+		}
+		r, g, b, _ := paletted.Palette[idx].RGBA()
+		_, _ = fmt.Fprintf(buf, "#%d;2;%d;%d;%d", idx, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+	}
+	for bandY := 0; bandY < height; bandY += 6 {
+		clear(used)
+		bandHeight := min(6, height-bandY)
+		for y := bandY; y < bandY+bandHeight; y++ {
+			for _, idx := range paletted.Pix[y*paletted.Stride : y*paletted.Stride+width] {
+				used[idx] = true
+			}
+		}
+		first := true
+		for idx, isUsed := range used {
+			if !isUsed {
+				continue
+			}
+			if !first {
+				// Return to the start of the band to draw the next color.
+				buf.WriteByte('$')
+			}
+			first = false
+			_, _ = fmt.Fprintf(buf, "#%d", idx)
+			var prev byte
+			run := 0
+			for x := 0; x < width; x++ {
+				var bits byte
+				for dy := 0; dy < bandHeight; dy++ {
+					if paletted.Pix[(bandY+dy)*paletted.Stride+x] == uint8(idx) {
+						bits |= 1 << dy
+					}
+				}
+				char := '?' + bits
+				if char == prev {
+					run++
+					continue
+				}
+				writeSixelRun(buf, prev, run)
+				prev, run = char, 1
+			}
+			writeSixelRun(buf, prev, run)
+		}
+		if bandY+6 < height {
+			buf.WriteByte('-')
+		}
+	}
+	buf.WriteString("\x1b\\")
+}
+
+func writeSixelRun(buf *bytes.Buffer, char byte, count int) {
+	switch {
+	case count <= 0:
+	case count > 3:
+		_, _ = fmt.Fprintf(buf, "!%d%c", count, char)
+	default:
+		for range count {
+			buf.WriteByte(char)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2026 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package termimage
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"slices"
+
+	"github.com/gdamore/tcell/v2"
+	"go.mau.fi/mauview"
+
+	"go.mau.fi/gomuks/tui/debug"
+)
+
+type placement struct {
+	image *Image
+	// The area of the whole image on the screen in cells. This may extend outside the screen.
+	area image.Rectangle
+	// The part of the area that is actually visible.
+	visible image.Rectangle
+}
+
+type encodeKey struct {
+	image *Image
+	size  image.Point
+	// The visible part of the image in cells, relative to the top-left corner of the image.
+	crop image.Rectangle
+}
+
+// Renderer collects the images drawn during a frame and writes them to the terminal after the rest of the frame
+// has been drawn. The cells covered by images are locked so that tcell doesn't draw over them.
+type Renderer struct {
+	Protocol Protocol
+	// OnResize is called when images were skipped because the terminal was resized.
+	// It should trigger a redraw so that the images are drawn again.
+	OnResize func()
+
+	wrapped *imageScreen
+
+	frame    []placement
+	prev     []placement
+	prevSize image.Point
+	resized  bool
+
+	encoded     map[encodeKey][]byte
+	transmitted map[uint32]image.Point
+}
+
+func NewRenderer(protocol Protocol) *Renderer {
+	return &Renderer{
+		Protocol:    protocol,
+		encoded:     make(map[encodeKey][]byte),
+		transmitted: make(map[uint32]image.Point),
+	}
+}
+
+type imageScreen struct {
+	mauview.Screen
+	renderer *Renderer
+}
+
+// Wrap returns a screen that collects images drawn with [Draw] into the current frame.
+// It must be called at the start of every frame, before any images are drawn.
+func (r *Renderer) Wrap(screen mauview.Screen) mauview.Screen {
+	if r.Protocol == ProtocolANSI {
+		return screen
+	}
+	if tcellScreen, ok := screen.(tcell.Screen); ok {
+		width, height := tcellScreen.Size()
+		if size := image.Pt(width, height); size != r.prevSize {
+			r.prevSize = size
+			r.resized = true
+			if tty, ok := tcellScreen.Tty(); ok {
+				r.updateCellSize(tty)
+			}
+		}
+	}
+	if r.wrapped == nil || r.wrapped.Screen != screen {
+		r.wrapped = &imageScreen{Screen: screen, renderer: r}
+	}
+	return r.wrapped
+}
+
+// Discard removes all images from the current frame. This is used when a modal is open,
+// as images would otherwise be drawn on top of it.
+func (r *Renderer) Discard() {
+	r.frame = nil
+}
+
+// Reset makes the renderer redraw all images, e.g. after the terminal was used by another program.
+func (r *Renderer) Reset() {
+	r.resized = true
+}
+
+// Draw draws the image at the given position of the screen, scaled to fill the given number of cells.
+// It returns false if the screen isn't a descendant of a screen returned by [Renderer.Wrap],
+// in which case the caller should draw something else instead.
+func Draw(screen mauview.Screen, img *Image, x, y, cols, rows int) bool {
+	area := image.Rect(x, y, x+cols, y+rows)
+	visible := area
+	for {
+		switch typedScreen := screen.(type) {
+		case *imageScreen:
+			width, height := typedScreen.Size()
+			if typedScreen.renderer.Protocol != ProtocolKitty {
+				// Drawing sixels or iTerm2 images on the last line would scroll the terminal.
+				height--
+			}
+			visible = visible.Intersect(image.Rect(0, 0, width, height))
+			if !visible.Empty() {
+				typedScreen.renderer.frame = append(typedScreen.renderer.frame, placement{
+					image:   img,
+					area:    area,
+					visible: visible,
+				})
+			}
+			return true
+		case *mauview.ProxyScreen:
+			bounds := image.Rectangle{Max: visible.Max}
+			if typedScreen.Width >= 0 {
+				bounds.Max.X = typedScreen.Width
+			}
+			if typedScreen.Height >= 0 {
+				bounds.Max.Y = typedScreen.Height
+			}
+			offset := image.Pt(typedScreen.OffsetX, typedScreen.OffsetY)
+			area = area.Add(offset)
+			visible = visible.Intersect(bounds).Add(offset)
+			screen = typedScreen.Parent
+		default:
+			return false
+		}
+	}
+}
+
+func (r *Renderer) updateCellSize(tty tcell.Tty) {
+	size, err := tty.WindowSize()
+	if err != nil {
+		return
+	}
+	width, height := size.CellDimensions()
+	if width > 0 && height > 0 && (width != cellWidth || height != cellHeight) {
+		cellWidth, cellHeight = width, height
+		clear(r.encoded)
+		clear(r.transmitted)
+	}
+}
+
+func (r *Renderer) unlockPrevious(screen tcell.Screen) {
+	for _, p := range r.prev {
+		// Unlocking marks the cells as dirty, so tcell will draw over the old image.
+		screen.LockRegion(p.visible.Min.X, p.visible.Min.Y, p.visible.Dx(), p.visible.Dy(), false)
+	}
+	r.prev = nil
+}
+
+// Flush writes the images in the current frame to the terminal. It must be called after the frame has been drawn,
+// but before it's shown. Nothing is written if the images haven't moved since the previous frame.
+func (r *Renderer) Flush(screen mauview.Screen) {
+	if r.Protocol == ProtocolANSI {
+		return
+	}
+	frame := r.frame
+	r.frame = nil
+	tcellScreen, ok := screen.(tcell.Screen)
+	if !ok {
+		return
+	}
+	tty, ok := tcellScreen.Tty()
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if r.resized {
+		// Resizing resets the cell buffer, so anything written now would be drawn over immediately.
+		r.resized = false
+		r.unlockPrevious(tcellScreen)
+		if r.Protocol == ProtocolKitty {
+			buf.WriteString(kittyDeleteAll)
+			_, _ = tty.Write(buf.Bytes())
+		}
+		if len(frame) > 0 && r.OnResize != nil {
+			go r.OnResize()
+		}
+		return
+	} else if slices.Equal(frame, r.prev) {
+		return
+	}
+	if r.Protocol == ProtocolKitty && len(r.prev) > 0 {
+		buf.WriteString(kittyDeleteAll)
+	}
+	r.unlockPrevious(tcellScreen)
+	encoded := make(map[encodeKey][]byte, len(frame))
+	for _, p := range frame {
+		tcellScreen.LockRegion(p.visible.Min.X, p.visible.Min.Y, p.visible.Dx(), p.visible.Dy(), true)
+		_, _ = fmt.Fprintf(&buf, "\x1b[%d;%dH", p.visible.Min.Y+1, p.visible.Min.X+1)
+		key := encodeKey{
+			image: p.image,
+			size:  p.area.Size(),
+			crop:  p.visible.Sub(p.area.Min),
+		}
+		r.write(&buf, key, encoded)
+	}
+	r.encoded = encoded
+	r.prev = frame
+	_, err := tty.Write(buf.Bytes())
+	if err != nil {
+		debug.Print("Failed to write images to terminal:", err)
+	}
+}
+
+func (r *Renderer) write(buf *bytes.Buffer, key encodeKey, encoded map[encodeKey][]byte) {
+	cellW, cellH := CellSize()
+	scaled := key.image.scaledTo(key.size.X, key.size.Y)
+	pixelCrop := image.Rect(key.crop.Min.X*cellW, key.crop.Min.Y*cellH, key.crop.Max.X*cellW, key.crop.Max.Y*cellH)
+	if r.Protocol == ProtocolKitty {
+		if r.transmitted[key.image.id] != key.size {
+			writeKittyTransmit(buf, key.image.id, scaled)
+			r.transmitted[key.image.id] = key.size
+		}
+		writeKittyPlace(buf, key.image.id, pixelCrop, key.crop.Dx(), key.crop.Dy())
+		return
+	}
+	data, ok := r.encoded[key]
+	if !ok {
+		cropped := image.NewNRGBA(image.Rect(0, 0, pixelCrop.Dx(), pixelCrop.Dy()))
+		draw.Draw(cropped, cropped.Bounds(), scaled, pixelCrop.Min, draw.Src)
+		var imgBuf bytes.Buffer
+		if r.Protocol == ProtocolSixel {
+			writeSixel(&imgBuf, cropped)
+		} else {
+			writeITerm2(&imgBuf, cropped, key.crop.Dx(), key.crop.Dy())
+		}
+		data = imgBuf.Bytes()
+	}
+	encoded[key] = data
+	buf.Write(data)
+}
@@ -0,0 +1,126 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2026 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package termimage draws images in the terminal using the kitty graphics protocol, iTerm2 inline images or sixel.
+package termimage
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+)
+
+type Protocol string
+
+const (
+	// ProtocolANSI means the terminal doesn't support any graphics protocol and images should be drawn with
+	// colored half blocks instead.
+	ProtocolANSI   Protocol = "ansi"
+	ProtocolKitty  Protocol = "kitty"
+	ProtocolITerm2 Protocol = "iterm2"
+	ProtocolSixel  Protocol = "sixel"
+)
+
+// Detect guesses which graphics protocol the terminal supports based on environment variables.
+func Detect() Protocol {
+	term := os.Getenv("TERM")
+	termProgram := os.Getenv("TERM_PROGRAM")
+	switch {
+	case os.Getenv("TMUX") != "", strings.HasPrefix(term, "screen"):
+		// Multiplexers don't pass graphics through without extra configuration.
+		return ProtocolANSI
+	case os.Getenv("KITTY_WINDOW_ID") != "", term == "xterm-kitty", term == "xterm-ghostty", termProgram == "ghostty":
+		return ProtocolKitty
+	case termProgram == "iTerm.app", termProgram == "WezTerm", os.Getenv("LC_TERMINAL") == "iTerm2":
+		return ProtocolITerm2
+	case term == "foot", strings.HasPrefix(term, "foot-"), term == "mlterm", term == "contour",
+		termProgram == "contour", os.Getenv("WT_SESSION") != "":
+		return ProtocolSixel
+	default:
+		return ProtocolANSI
+	}
+}
+
+// The size of a single terminal cell in pixels. These are updated from the terminal by [Renderer.Flush],
+// the defaults are only used if the terminal doesn't report its pixel size.
+var cellWidth, cellHeight = 10, 20
+
+// CellSize returns the size of a single terminal cell in pixels.
+func CellSize() (width, height int) {
+	return cellWidth, cellHeight
+}
+
+var nextImageID atomic.Uint32
+
+// Image is a decoded image that can be drawn on the screen with [Draw].
+type Image struct {
+	source image.Image
+	id     uint32
+
+	scaled     image.Image
+	scaledSize image.Point
+}
+
+// NewImage wraps an already decoded image.
+func NewImage(img image.Image) *Image {
+	return &Image{source: img, id: nextImageID.Add(1)}
+}
+
+// Decode decodes an image in any of the supported formats.
+func Decode(data []byte) (*Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return NewImage(img), nil
+}
+
+// Size calculates how many cells the image should take on the screen to preserve the aspect ratio without
+// upscaling the image or exceeding the given number of columns and rows.
+func (img *Image) Size(maxCols, maxRows int) (cols, rows int) {
+	bounds := img.source.Bounds()
+	if bounds.Dx() <= 0 || bounds.Dy() <= 0 || maxCols <= 0 || maxRows <= 0 {
+		return 0, 0
+	}
+	cellW, cellH := CellSize()
+	cols = min(maxCols, (bounds.Dx()+cellW-1)/cellW)
+	rows = (cols*cellW*bounds.Dy()/bounds.Dx() + cellH - 1) / cellH
+	if rows > maxRows {
+		rows = maxRows
+		cols = rows * cellH * bounds.Dx() / bounds.Dy() / cellW
+	}
+	return max(cols, 1), max(rows, 1)
+}
+
+// scaledTo returns the image scaled to fill the given number of cells exactly.
+func (img *Image) scaledTo(cols, rows int) image.Image {
+	cellW, cellH := CellSize()
+	size := image.Pt(cols*cellW, rows*cellH)
+	if img.scaled == nil || img.scaledSize != size {
+		img.scaled = imaging.Resize(img.source, size.X, size.Y, imaging.Lanczos)
+		img.scaledSize = size
+	}
+	return img.scaled
+}
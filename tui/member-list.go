@@ -27,6 +27,7 @@ import (
 	"maunium.net/go/mautrix/event"
 
 	"go.mau.fi/gomuks/pkg/rpc/store"
+	"go.mau.fi/gomuks/tui/config"
 	"go.mau.fi/gomuks/tui/widget"
 )
 
@@ -102,7 +103,7 @@ func (ml *MemberList) Update(data []*store.AutocompleteMemberEntry, levels *even
 
 func (ml *MemberList) Draw(screen mauview.Screen) {
 	width, _ := screen.Size()
-	sigilStyle := tcell.StyleDefault.Background(tcell.ColorGreen).Foreground(tcell.ColorDefault)
+	sigilStyle := tcell.StyleDefault.Background(config.CurrentTheme.PowerLevelBackground).Foreground(config.CurrentTheme.Text)
 	for y, member := range ml.list {
 		if member.Sigil != ' ' {
 			screen.SetCell(0, y, sigilStyle, member.Sigil)
@@ -19,18 +19,22 @@ package tui
 import (
 	"fmt"
 	"math"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"unicode"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/mattn/go-runewidth"
 	"go.mau.fi/mauview"
 	"maunium.net/go/mautrix/event"
 	"maunium.net/go/mautrix/format"
+	"maunium.net/go/mautrix/id"
 
 	"go.mau.fi/gomuks/pkg/hicli/database"
 	"go.mau.fi/gomuks/pkg/rpc/client"
+	"go.mau.fi/gomuks/pkg/rpc/store"
 	"go.mau.fi/gomuks/tui/config"
 	"go.mau.fi/gomuks/tui/messages"
 	"go.mau.fi/gomuks/tui/widget"
@@ -40,6 +44,7 @@ type MessageView struct {
 	parent *RoomView
 	config *config.Config
 	matrix *client.GomuksClient
+	thread *store.ThreadStore
 	lock   sync.RWMutex
 
 	SenderWidth     int
@@ -55,6 +60,10 @@ type MessageView struct {
 	prevTimeline *[]*database.Event
 	prevWidth    int
 	selected     database.EventRowID
+
+	// Read receipts of other users by event ID, cached until [store.RoomStore.ReceiptsVersion] changes.
+	receipts        map[id.EventID][]*database.Receipt
+	receiptsVersion uint64
 }
 
 func NewMessageView(parent *RoomView) *MessageView {
@@ -69,6 +78,35 @@ func NewMessageView(parent *RoomView) *MessageView {
 	return mv
 }
 
+// NewThreadMessageView creates a message view that shows the timeline of a single thread instead of the whole room.
+func NewThreadMessageView(parent *RoomView, thread *store.ThreadStore) *MessageView {
+	mv := NewMessageView(parent)
+	mv.thread = thread
+	return mv
+}
+
+func (view *MessageView) getTimeline() *[]*database.Event {
+	if view.thread != nil {
+		return view.thread.Timeline.Current()
+	}
+	return view.parent.Room.TimelineCache.Current()
+}
+
+func (view *MessageView) isPaginating() bool {
+	if view.thread != nil {
+		return view.thread.Paginating.Load()
+	}
+	return view.parent.Room.Paginating.Load()
+}
+
+func (view *MessageView) LoadHistory() {
+	if view.thread != nil {
+		view.parent.parent.LoadThreadHistory(view.thread)
+	} else {
+		view.parent.parent.LoadHistory(view.parent.Room.ID)
+	}
+}
+
 func (view *MessageView) SetSelected(message *messages.UIMessage) {
 	if message == nil || (view.selected == message.RowID || message.IsService) {
 		view.selected = 0
@@ -87,13 +125,128 @@ func (view *MessageView) GetSelected() *messages.UIMessage {
 	return evt.RenderMeta.(*messages.UIMessage)
 }
 
+// selectableMessages returns the messages in the buffer that can be selected, from oldest to newest,
+// and the index of the currently selected message in the list, or -1 if nothing is selected.
+func (view *MessageView) selectableMessages() (msgs []*messages.UIMessage, selectedIndex int) {
+	view.lock.RLock()
+	defer view.lock.RUnlock()
+	selectedIndex = -1
+	var prev *messages.UIMessage
+	for _, msg := range view.msgBuffer {
+		if msg != prev && !msg.IsService && msg.RowID != 0 {
+			if view.selected != 0 && msg.RowID == view.selected {
+				selectedIndex = len(msgs)
+			}
+			msgs = append(msgs, msg)
+		}
+		prev = msg
+	}
+	return
+}
+
+// selectMessage selects the given message and scrolls it into view.
+func (view *MessageView) selectMessage(message *messages.UIMessage) {
+	view.selected = message.RowID
+	view.scrollToSelected()
+}
+
+// scrollToSelected adjusts the scroll offset so that the selected message is visible.
+func (view *MessageView) scrollToSelected() {
+	view.lock.RLock()
+	start, end := -1, -1
+	for i, msg := range view.msgBuffer {
+		if view.selected != 0 && msg.RowID == view.selected && !msg.IsService {
+			if start < 0 {
+				start = i
+			}
+			end = i + 1
+		}
+	}
+	view.lock.RUnlock()
+	if start < 0 {
+		return
+	}
+	totalHeight := view.TotalHeight()
+	scrollOffset := view.GetScrollOffset()
+	if end > totalHeight-scrollOffset {
+		scrollOffset = totalHeight - end
+	} else if start < totalHeight-scrollOffset-view.Height() {
+		scrollOffset = totalHeight - start - view.Height()
+	}
+	view.ScrollOffset.Store(int32(max(scrollOffset, 0)))
+}
+
+// MoveSelection moves the selection by the given number of messages. Positive values move towards newer messages.
+// If no message is selected, the newest message is selected. Moving past the oldest loaded message loads more history.
+func (view *MessageView) MoveSelection(diff int) {
+	msgs, index := view.selectableMessages()
+	if len(msgs) == 0 {
+		return
+	}
+	if index < 0 {
+		index = len(msgs) - 1
+	} else {
+		index += diff
+	}
+	if index < 0 {
+		go view.LoadHistory()
+	}
+	view.selectMessage(msgs[min(max(index, 0), len(msgs)-1)])
+}
+
+// SelectedRange returns the selected message and up to count-1 newer messages after it.
+func (view *MessageView) SelectedRange(count int) []*messages.UIMessage {
+	msgs, index := view.selectableMessages()
+	if index < 0 {
+		return nil
+	}
+	return msgs[index:min(index+count, len(msgs))]
+}
+
+// SelectFirst selects the oldest loaded message.
+func (view *MessageView) SelectFirst() {
+	msgs, _ := view.selectableMessages()
+	if len(msgs) > 0 {
+		view.selectMessage(msgs[0])
+	}
+}
+
+// SelectLast selects the newest message.
+func (view *MessageView) SelectLast() {
+	msgs, _ := view.selectableMessages()
+	if len(msgs) > 0 {
+		view.selectMessage(msgs[len(msgs)-1])
+	}
+}
+
+// Search selects the next loaded message whose text contains the given query, ignoring case.
+// The search starts from the selected message, or the newest message if nothing is selected,
+// and goes towards older messages unless newer is true. It returns false if there are no matches.
+func (view *MessageView) Search(query string, newer bool) bool {
+	query = strings.ToLower(query)
+	msgs, index := view.selectableMessages()
+	if index < 0 {
+		index = len(msgs)
+	}
+	step := -1
+	if newer {
+		step = 1
+	}
+	for i := index + step; i >= 0 && i < len(msgs); i += step {
+		if strings.Contains(strings.ToLower(msgs[i].PlainText()), query) {
+			view.selectMessage(msgs[i])
+			return true
+		}
+	}
+	return false
+}
+
 func (view *MessageView) handleMessageClick(message *messages.UIMessage, mod tcell.ModMask) bool {
-	//if msg, ok := message.Renderer.(*messages.FileMessage); ok && mod > 0 && !msg.Thumbnail.IsEmpty() {
-	//	debug.Print("Opening thumbnail", msg.ThumbnailPath())
-	//	open.Open(msg.ThumbnailPath())
-	//	// No need to re-render
-	//	return false
-	//}
+	if msg, ok := message.Renderer.(*messages.FileMessage); ok && mod > 0 && !msg.URL.IsEmpty() {
+		go view.parent.Download(msg, "", true)
+		// No need to re-render
+		return false
+	}
 	view.SetSelected(message)
 	view.parent.OnSelect(message)
 	return true
@@ -145,7 +298,7 @@ func (view *MessageView) OnMouseEvent(event mauview.MouseEvent) bool {
 	switch event.Buttons() {
 	case tcell.WheelUp:
 		if view.IsAtTop() {
-			go view.parent.parent.LoadHistory(view.parent.Room.ID)
+			go view.LoadHistory()
 		} else {
 			view.AddScrollOffset(WheelScrollOffsetDiff)
 			return true
@@ -221,13 +374,55 @@ const (
 	TimestampSenderGap = 1
 	SenderSeparatorGap = 1
 	SenderMessageGap   = 3
+	// The space reserved for read receipts on the right side of messages: a gap and up to three markers.
+	ReceiptGutterWidth = 4
 )
 
+// drawReceipts draws a marker for each user whose latest read receipt is on the given message.
+// Each marker is the first letter of the user's name in the user's color. If there isn't enough space for all
+// markers, only the most recent ones are shown followed by a plus sign.
+func (view *MessageView) drawReceipts(screen mauview.Screen, msg *messages.UIMessage, x, y int) {
+	if msg.ID == "" {
+		return
+	}
+	receipts, ok := view.receipts[msg.ID]
+	if !ok {
+		receipts = slices.DeleteFunc(view.parent.Room.GetReceipts(msg.ID), func(receipt *database.Receipt) bool {
+			return receipt.UserID == view.matrix.UserID
+		})
+		view.receipts[msg.ID] = receipts
+	}
+	maxMarkers := ReceiptGutterWidth - 1
+	overflow := len(receipts) > maxMarkers
+	if overflow {
+		receipts = receipts[len(receipts)-maxMarkers+1:]
+	}
+	x++
+	for _, receipt := range receipts {
+		style := tcell.StyleDefault.Foreground(widget.GetHashColor(receipt.UserID))
+		screen.SetCell(x, y, style, receiptMarker(view.parent.Room.GetDisplayname(receipt.UserID)))
+		x++
+	}
+	if overflow {
+		screen.SetCell(x, y, tcell.StyleDefault.Foreground(config.CurrentTheme.ReceiptOverflow), '+')
+	}
+}
+
+func receiptMarker(name string) rune {
+	for _, char := range strings.TrimLeft(name, "@") {
+		if runewidth.RuneWidth(char) != 1 {
+			break
+		}
+		return unicode.ToUpper(char)
+	}
+	return '•'
+}
+
 func getScrollbarStyle(scrollbarHere, isTop, isBottom bool) (char rune, style tcell.Style) {
 	char = '│'
 	style = tcell.StyleDefault
 	if scrollbarHere {
-		style = style.Foreground(tcell.ColorGreen)
+		style = style.Foreground(config.CurrentTheme.Scrollbar)
 	}
 	if isTop {
 		if scrollbarHere {
@@ -262,10 +457,10 @@ func (view *MessageView) getIndexOffset(screen mauview.Screen, height, messageX
 	indexOffset = view.TotalHeight() - view.GetScrollOffset() - height
 	if indexOffset <= -PaddingAtTop {
 		message := "Scroll up to load more messages."
-		if view.parent.Room.Paginating.Load() {
+		if view.isPaginating() {
 			message = "Loading more messages..."
 		}
-		widget.WriteLineSimpleColor(screen, message, messageX, 0, tcell.ColorGreen)
+		widget.WriteLineSimpleColor(screen, message, messageX, 0, config.CurrentTheme.TimelineHint)
 	}
 	return
 }
@@ -302,6 +497,15 @@ func (view *MessageView) Draw(screen mauview.Screen) {
 	defer view.lock.Unlock()
 	width, height := screen.Size()
 	view.height.Store(uint32(height))
+	bareMode := view.config.Preferences.BareMessageView
+	showReceipts := !bareMode && view.parent.Room.GetPreferences().DisplayReadReceipts
+	if showReceipts {
+		width -= ReceiptGutterWidth
+		if version := view.parent.Room.ReceiptsVersion(); view.receipts == nil || version != view.receiptsVersion {
+			view.receipts = make(map[id.EventID][]*database.Receipt)
+			view.receiptsVersion = version
+		}
+	}
 	view.update(width)
 	scrollOffset := view.GetScrollOffset()
 
@@ -316,7 +520,6 @@ func (view *MessageView) Draw(screen mauview.Screen) {
 	}
 	messageX := usernameX + view.SenderWidth + SenderMessageGap
 
-	bareMode := view.config.Preferences.BareMessageView
 	if bareMode {
 		messageX = 0
 	}
@@ -365,17 +568,23 @@ func (view *MessageView) Draw(screen mauview.Screen) {
 		//}
 		if msg.LastEditRef != nil {
 			// TODO add better indicator for edits
-			screen.SetCell(usernameX+view.SenderWidth, line, tcell.StyleDefault.Foreground(tcell.ColorDarkRed), '*')
+			screen.SetCell(usernameX+view.SenderWidth, line, tcell.StyleDefault.Foreground(config.CurrentTheme.EditedMarker), '*')
+		}
+		if !bareMode && view.thread == nil && msg.RelationType == event.RelThread {
+			screen.SetCell(messageX-1, line, tcell.StyleDefault.Foreground(config.CurrentTheme.ThreadMarker), '↳')
 		}
 
 		msg.IsSelected = view.selected != 0 && msg.RowID == view.selected
 		msg.Draw(mauview.NewProxyScreen(screen, messageX, line, width-messageX, msg.Height()))
+		if showReceipts {
+			view.drawReceipts(screen, msg, width, line+msg.Height()-1)
+		}
 		line += msg.Height()
 	}
 }
 
 func (view *MessageView) update(width int) {
-	timelinePtr := view.parent.Room.TimelineCache.Current()
+	timelinePtr := view.getTimeline()
 	if timelinePtr == nil || timelinePtr == view.prevTimeline && width == view.prevWidth {
 		return
 	}
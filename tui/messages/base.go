@@ -70,7 +70,6 @@ type UIMessage struct {
 	Room               *store.RoomStore
 	MsgType            event.MessageType
 	OverrideSenderName string
-	DefaultSenderColor tcell.Color
 	IsService          bool
 	IsSelected         bool
 	ReplyTo            *UIMessage
@@ -104,7 +103,6 @@ func newUIMessage(
 	return &UIMessage{
 		Room:               room,
 		OverrideSenderName: displayname,
-		DefaultSenderColor: widget.GetHashColor(evt.Sender),
 		MsgType:            msgtype,
 		IsService:          false,
 		Event:              evt,
@@ -148,20 +146,20 @@ func (msg *UIMessage) NotificationContent() string {
 
 func (msg *UIMessage) getStateSpecificColor() tcell.Color {
 	if msg.Event.SendError != "" && msg.Event.SendError != "not sent" {
-		return tcell.ColorRed
+		return config.CurrentTheme.SendError
 	} else if msg.Event.Pending {
-		return tcell.ColorGray
+		return config.CurrentTheme.Pending
 	}
 	return tcell.ColorDefault
 }
 
 // SenderColor returns the color the name of the sender should be shown in.
 //
-// If the message is being sent, the color is gray.
-// If sending has failed, the color is red.
+// If the message is being sent or sending has failed, the pending or send error color of the theme is used.
 //
-// In any other case, the color is whatever is specified in the Message struct.
-// Usually that means it is the hash-based color of the sender (see ui/widget/color.go)
+// If the sender matches a rule in a subscribed policy list, the policy match color is used.
+//
+// In any other case, the color is the hash-based color of the sender (see ui/widget/color.go)
 func (msg *UIMessage) SenderColor() tcell.Color {
 	stateColor := msg.getStateSpecificColor()
 	switch {
@@ -170,9 +168,11 @@ func (msg *UIMessage) SenderColor() tcell.Color {
 	//case msg.Type == event.StateMember.Type:
 	//	return widget.GetHashColor(msg.SenderName)
 	case msg.IsService:
-		return tcell.ColorGray
+		return config.CurrentTheme.Notice
+	case msg.LocalContent.GetPolicyMatch() != nil:
+		return config.CurrentTheme.PolicyMatch
 	default:
-		return msg.DefaultSenderColor
+		return widget.GetHashColor(msg.Sender)
 	}
 }
 
@@ -183,11 +183,11 @@ func (msg *UIMessage) TextColor() tcell.Color {
 	case stateColor != tcell.ColorDefault:
 		return stateColor
 	case msg.IsService, msg.MsgType == event.MsgNotice:
-		return tcell.ColorGray
+		return config.CurrentTheme.Notice
 	case msg.UnreadType.Is(database.UnreadTypeHighlight):
-		return tcell.ColorYellow
+		return config.CurrentTheme.Highlight
 	case msg.Type == event.StateMember.Type:
-		return tcell.ColorGreen
+		return config.CurrentTheme.StateEvent
 	default:
 		return tcell.ColorDefault
 	}
@@ -195,15 +195,17 @@ func (msg *UIMessage) TextColor() tcell.Color {
 
 // TimestampColor returns the color the timestamp should be shown in.
 //
-// As with SenderColor(), messages being sent and messages that failed to be sent are
-// gray and red respectively.
+// As with SenderColor(), messages being sent and messages that failed to be sent use
+// the pending and send error colors respectively.
 //
-// However, other messages are the default color instead of a color stored in the struct.
+// Other messages use the timestamp color of the theme.
 func (msg *UIMessage) TimestampColor() tcell.Color {
 	if msg.IsService {
-		return tcell.ColorGray
+		return config.CurrentTheme.Notice
+	} else if stateColor := msg.getStateSpecificColor(); stateColor != tcell.ColorDefault {
+		return stateColor
 	}
-	return msg.getStateSpecificColor()
+	return config.CurrentTheme.Timestamp
 }
 
 func (msg *UIMessage) ReplyHeight() int {
@@ -262,7 +264,7 @@ func (msg *UIMessage) DrawReactions(screen mauview.Screen) {
 		if count == 0 {
 			continue
 		}
-		_, drawn := mauview.PrintWithStyle(screen, fmt.Sprintf("%d×%s", count, reaction), x, 0, width-x, mauview.AlignLeft, tcell.StyleDefault.Foreground(mauview.Styles.PrimaryTextColor).Background(tcell.ColorDarkGreen))
+		_, drawn := mauview.PrintWithStyle(screen, fmt.Sprintf("%d×%s", count, reaction), x, 0, width-x, mauview.AlignLeft, tcell.StyleDefault.Foreground(mauview.Styles.PrimaryTextColor).Background(config.CurrentTheme.ReactionBackground))
 		x += drawn + 1
 		if x >= width {
 			break
@@ -281,7 +283,7 @@ func (msg *UIMessage) Draw(screen mauview.Screen) {
 				mainc, combc, style, _ := screen.GetContent(x, y)
 				_, bg, _ := style.Decompose()
 				if bg == tcell.ColorDefault {
-					screen.SetContent(x, y, mainc, combc, style.Background(tcell.ColorDarkGreen))
+					screen.SetContent(x, y, mainc, combc, style.Background(config.CurrentTheme.ContrastBackground))
 				}
 			}
 		}
@@ -302,7 +304,16 @@ func (msg *UIMessage) calculateReplyBuffer(preferences config.UserPreferences, w
 	msg.ReplyTo.CalculateBuffer(preferences, width-1)
 }
 
+// asyncRenderer is implemented by renderers that load content in the background, like file previews.
+type asyncRenderer interface {
+	// applyLoadedPreview picks up content loaded in the background and returns true if the buffer must be recalculated.
+	applyLoadedPreview() bool
+}
+
 func (msg *UIMessage) CalculateBuffer(preferences config.UserPreferences, width int) {
+	if async, ok := msg.Renderer.(asyncRenderer); ok && async.applyLoadedPreview() {
+		msg.Invalidate()
+	}
 	// TODO check preferences (at least disable images and bare message view)
 	if msg.bufferedWidth == width {
 		return
@@ -312,13 +323,18 @@ func (msg *UIMessage) CalculateBuffer(preferences config.UserPreferences, width
 	msg.bufferedWidth = width
 }
 
+// Invalidate clears the cached buffer, so it will be recalculated the next time the message is drawn.
+func (msg *UIMessage) Invalidate() {
+	msg.bufferedWidth = 0
+}
+
 func (msg *UIMessage) DrawReply(screen mauview.Screen) mauview.Screen {
 	if msg.ReplyTo == nil {
 		return screen
 	}
 	width, height := screen.Size()
 	replyHeight := msg.ReplyTo.Height()
-	widget.WriteLineSimpleColor(screen, "In reply to", 1, 0, tcell.ColorGreen)
+	widget.WriteLineSimpleColor(screen, "In reply to", 1, 0, config.CurrentTheme.ReplyHeader)
 	widget.WriteLineSimpleColor(screen, msg.ReplyTo.GetRawSenderName(), 13, 0, msg.ReplyTo.SenderColor())
 	for y := 0; y < 1+replyHeight; y++ {
 		screen.SetCell(0, y, tcell.StyleDefault, '▊')
@@ -338,7 +354,7 @@ func (msg *UIMessage) String() string {
 }`,
 		msg.ID, msg.TransactionID,
 		msg.MsgType, msg.Timestamp.String(),
-		msg.Sender, msg.OverrideSenderName, msg.DefaultSenderColor.Hex(),
+		msg.Sender, msg.OverrideSenderName, msg.SenderColor().Hex(),
 		msg.IsService, msg.Renderer.String())
 }
 
@@ -20,7 +20,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/gdamore/tcell/v2"
 	"go.mau.fi/mauview"
 	"go.mau.fi/util/jsontime"
 	"maunium.net/go/mautrix/event"
@@ -71,7 +70,7 @@ func NewDateChangeMessage(room *store.RoomStore, text string) *UIMessage {
 		OverrideSenderName: "*",
 		IsService:          true,
 		Renderer: &ExpandedTextMessage{
-			Text: tstring.NewColorTString(text, tcell.ColorGreen),
+			Text: tstring.NewColorTString(text, config.CurrentTheme.TimelineHint),
 		},
 	}
 }
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"sync/atomic"
 
 	"github.com/gdamore/tcell/v2"
 	"go.mau.fi/mauview"
@@ -33,20 +34,39 @@ import (
 	"go.mau.fi/gomuks/tui/config"
 	"go.mau.fi/gomuks/tui/debug"
 	"go.mau.fi/gomuks/tui/lib/ansimage"
+	"go.mau.fi/gomuks/tui/lib/termimage"
 	"go.mau.fi/gomuks/tui/messages/tstring"
+	"go.mau.fi/gomuks/tui/widget"
 )
 
+// MaxImageHeight is the maximum number of lines an inline image can take.
+const MaxImageHeight = 20
+
+// OnPreviewLoaded is called after a preview image has been downloaded, so that the message view can be redrawn.
+var OnPreviewLoaded func()
+
 type FileMessage struct {
-	Type event.MessageType
-	Body string
+	Type     event.MessageType
+	Body     string
+	Filename string
 
 	URL         id.ContentURI
 	IsEncrypted bool
 
+	Thumbnail          id.ContentURI
+	ThumbnailEncrypted bool
+
 	eventID id.EventID
 
+	// The preview downloaded in the background, waiting to be picked up by the UI goroutine.
+	loadedPreview atomic.Pointer[filePreview]
+
+	// The preview used for rendering. These are only accessed on the UI goroutine.
 	imageData []byte
-	buffer    []tstring.TString
+	image     *termimage.Image
+	// The size of the image in cells, if it's drawn using a graphics protocol rather than in the buffer.
+	imageCols, imageRows int
+	buffer               []tstring.TString
 
 	matrix *client.GomuksClient
 }
@@ -61,26 +81,56 @@ func NewFileMessage(room *store.RoomStore, matrix *client.GomuksClient, evt *dat
 	} else {
 		url = content.URL.ParseOrIgnore()
 	}
+	var thumbnail id.ContentURI
+	var thumbnailEncrypted bool
+	if content.Info != nil {
+		if content.Info.ThumbnailFile != nil {
+			thumbnail = content.Info.ThumbnailFile.URL.ParseOrIgnore()
+			thumbnailEncrypted = true
+		} else {
+			thumbnail = content.Info.ThumbnailURL.ParseOrIgnore()
+		}
+	}
+	filename := content.FileName
+	if filename == "" {
+		filename = content.Body
+	}
 	return newUIMessage(room, evt, content, "", &FileMessage{
-		Type:        content.MsgType,
-		Body:        content.Body,
-		URL:         url,
-		IsEncrypted: isEncrypted,
-		eventID:     evt.ID,
-		matrix:      matrix,
+		Type:               content.MsgType,
+		Body:               content.Body,
+		Filename:           filename,
+		URL:                url,
+		IsEncrypted:        isEncrypted,
+		Thumbnail:          thumbnail,
+		ThumbnailEncrypted: thumbnailEncrypted,
+		eventID:            evt.ID,
+		matrix:             matrix,
 	})
 }
 
+type filePreview struct {
+	data  []byte
+	image *termimage.Image
+}
+
 func (msg *FileMessage) Clone() MessageRenderer {
 	data := make([]byte, len(msg.imageData))
 	copy(data, msg.imageData)
-	return &FileMessage{
-		Body:        msg.Body,
-		URL:         msg.URL,
-		IsEncrypted: msg.IsEncrypted,
-		imageData:   data,
-		matrix:      msg.matrix,
+	clone := &FileMessage{
+		Type:               msg.Type,
+		Body:               msg.Body,
+		Filename:           msg.Filename,
+		URL:                msg.URL,
+		IsEncrypted:        msg.IsEncrypted,
+		Thumbnail:          msg.Thumbnail,
+		ThumbnailEncrypted: msg.ThumbnailEncrypted,
+		eventID:            msg.eventID,
+		imageData:          data,
+		image:              msg.image,
+		matrix:             msg.matrix,
 	}
+	clone.loadedPreview.Store(msg.loadedPreview.Load())
+	return clone
 }
 
 func (msg *FileMessage) NotificationContent() string {
@@ -106,38 +156,53 @@ func (msg *FileMessage) String() string {
 	return fmt.Sprintf(`&messages.FileMessage{Body="%s", URL="%s", Encrypted=%t}`, msg.Body, msg.URL, msg.IsEncrypted)
 }
 
+// DownloadPreview downloads the thumbnail of the file, or the file itself for images without a thumbnail.
+// This is meant to be called in a goroutine: the image is handed over to the UI goroutine,
+// which starts using it the next time the message is rendered.
 func (msg *FileMessage) DownloadPreview() {
-	//var url id.ContentURI
-	//var file *attachment.EncryptedFile
-	//if !msg.Thumbnail.IsEmpty() {
-	//	url = msg.Thumbnail
-	//	file = msg.ThumbnailFile
-	//} else if msg.Type == event.MsgImage && !msg.URL.IsEmpty() {
-	//	msg.Thumbnail = msg.URL
-	//	url = msg.URL
-	//	file = msg.File
-	//} else {
-	//	return
-	//}
-	//debug.Print("Loading file:", url)
-	//data, err := msg.matrix.Download(url, file != nil)
-	//if err != nil {
-	//	debug.Printf("Failed to download file %s: %v", url, err)
-	//	return
-	//}
-	//debug.Print("File", url, "loaded.")
-	//msg.imageData = data
+	defer debug.Recover()
+	url, encrypted := msg.Thumbnail, msg.ThumbnailEncrypted
+	if url.IsEmpty() {
+		if msg.Type != event.MsgImage || msg.URL.IsEmpty() {
+			return
+		}
+		url, encrypted = msg.URL, msg.IsEncrypted
+	}
+	debug.Print("Loading file:", url)
+	data, err := msg.matrix.Download(url, encrypted)
+	if err != nil {
+		debug.Printf("Failed to download file %s: %v", url, err)
+		return
+	}
+	img, err := termimage.Decode(data)
+	if err != nil {
+		debug.Printf("Failed to decode file %s: %v", url, err)
+		return
+	}
+	debug.Print("File", url, "loaded.")
+	msg.loadedPreview.Store(&filePreview{data: data, image: img})
+	if OnPreviewLoaded != nil {
+		OnPreviewLoaded()
+	}
 }
 
-func (msg *FileMessage) ThumbnailPath() string {
-	return "" // FIXME
-	//return msg.matrix.GetCachePath(msg.Thumbnail)
+// applyLoadedPreview starts using the preview downloaded by DownloadPreview.
+// It returns true if there was a new preview, which means the buffer must be recalculated.
+func (msg *FileMessage) applyLoadedPreview() bool {
+	preview := msg.loadedPreview.Swap(nil)
+	if preview == nil {
+		return false
+	}
+	msg.imageData = preview.data
+	msg.image = preview.image
+	return true
 }
 
 func (msg *FileMessage) CalculateBuffer(prefs config.UserPreferences, width int, uiMsg *UIMessage) {
 	if width < 2 {
 		return
 	}
+	msg.imageCols, msg.imageRows = 0, 0
 
 	if prefs.BareMessageView || prefs.DisableImages || len(msg.imageData) == 0 {
 		url := msg.matrix.GetDownloadURL(msg.URL, msg.IsEncrypted, true)
@@ -154,6 +219,15 @@ func (msg *FileMessage) CalculateBuffer(prefs config.UserPreferences, width int,
 		return
 	}
 
+	if prefs.GetImageProtocol() != termimage.ProtocolANSI && msg.image != nil {
+		msg.imageCols, msg.imageRows = msg.image.Size(width, MaxImageHeight)
+		if msg.imageRows > 0 {
+			// The image itself is written to the terminal over these blank lines after the rest of the screen is drawn.
+			msg.buffer = make([]tstring.TString, msg.imageRows)
+			return
+		}
+	}
+
 	img, _, err := image.DecodeConfig(bytes.NewReader(msg.imageData))
 	if err != nil {
 		debug.Print("File could not be decoded:", err)
@@ -165,7 +239,7 @@ func (msg *FileMessage) CalculateBuffer(prefs config.UserPreferences, width int,
 
 	ansFile, err := ansimage.NewScaledFromReader(bytes.NewReader(msg.imageData), 0, imgWidth, color.Black)
 	if err != nil {
-		msg.buffer = []tstring.TString{tstring.NewColorTString("Failed to display image", tcell.ColorRed)}
+		msg.buffer = []tstring.TString{tstring.NewColorTString("Failed to display image", config.CurrentTheme.Error)}
 		debug.Print("Failed to display image:", err)
 		return
 	}
@@ -178,6 +252,12 @@ func (msg *FileMessage) Height() int {
 }
 
 func (msg *FileMessage) Draw(screen mauview.Screen, _ *UIMessage) {
+	if msg.imageRows > 0 {
+		if !termimage.Draw(screen, msg.image, 0, 0, msg.imageCols, msg.imageRows) {
+			widget.WriteLineSimple(screen, msg.Body, 0, 0)
+		}
+		return
+	}
 	for y, line := range msg.buffer {
 		line.Draw(screen, 0, y)
 	}
@@ -139,8 +139,8 @@ func (parser *htmlParser) basicFormatToEntity(node *html.Node) Entity {
 	case "u", "ins":
 		entity.AdjustStyle(AdjustStyleUnderline, AdjustStyleReasonNormal)
 	case "code":
-		bgColor := tcell.ColorDarkSlateGray
-		fgColor := tcell.ColorWhite
+		bgColor := config.CurrentTheme.CodeBlockBackground
+		fgColor := config.CurrentTheme.CodeBlockText
 		entity.AdjustStyle(AdjustStyleBackgroundColor(bgColor), AdjustStyleReasonNormal)
 		entity.AdjustStyle(AdjustStyleTextColor(fgColor), AdjustStyleReasonNormal)
 	case "font", "span":
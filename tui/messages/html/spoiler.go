@@ -22,6 +22,8 @@ import (
 
 	"github.com/gdamore/tcell/v2"
 	"go.mau.fi/mauview"
+
+	"go.mau.fi/gomuks/tui/config"
 )
 
 type SpoilerEntity struct {
@@ -30,12 +32,11 @@ type SpoilerEntity struct {
 	visible *ContainerEntity
 }
 
-const SpoilerColor = tcell.ColorYellow
-
 func NewSpoilerEntity(visible *ContainerEntity, reason string) *SpoilerEntity {
 	hidden := visible.Clone().(*ContainerEntity)
+	spoilerColor := config.CurrentTheme.Spoiler
 	hidden.AdjustStyle(func(style tcell.Style) tcell.Style {
-		return style.Foreground(SpoilerColor).Background(SpoilerColor)
+		return style.Foreground(spoilerColor).Background(spoilerColor)
 	}, AdjustStyleReasonHideSpoiler)
 	if len(reason) > 0 {
 		reasonEnt := NewTextEntity(fmt.Sprintf("(%s)", reason))
@@ -76,7 +77,8 @@ func (se *SpoilerEntity) Draw(screen mauview.Screen, ctx DrawContext) {
 func (se *SpoilerEntity) AdjustStyle(fn AdjustStyleFunc, reason AdjustStyleReason) Entity {
 	if reason != AdjustStyleReasonHideSpoiler {
 		se.hidden.AdjustStyle(func(style tcell.Style) tcell.Style {
-			return fn(style).Foreground(SpoilerColor).Background(SpoilerColor)
+			spoilerColor := config.CurrentTheme.Spoiler
+			return fn(style).Foreground(spoilerColor).Background(spoilerColor)
 		}, reason)
 		se.visible.AdjustStyle(fn, reason)
 	}
@@ -30,6 +30,9 @@ import (
 type HTMLMessage struct {
 	Root      html.Entity
 	TextColor tcell.Color
+
+	// The version of the message that isn't currently shown, if the message has been translated.
+	alternate html.Entity
 }
 
 func NewHTMLMessage(room *store.RoomStore, evt *database.Event, content *event.MessageEventContent, root html.Entity) *UIMessage {
@@ -39,9 +42,28 @@ func NewHTMLMessage(room *store.RoomStore, evt *database.Event, content *event.M
 }
 
 func (hw *HTMLMessage) Clone() MessageRenderer {
-	return &HTMLMessage{
+	clone := &HTMLMessage{
 		Root: hw.Root.Clone(),
 	}
+	if hw.alternate != nil {
+		clone.alternate = hw.alternate.Clone()
+	}
+	return clone
+}
+
+// SetTranslation shows the given translation instead of the original message. The original is kept for [HTMLMessage.ToggleTranslation].
+func (hw *HTMLMessage) SetTranslation(translation html.Entity) {
+	hw.alternate = hw.Root
+	hw.Root = translation
+}
+
+// ToggleTranslation swaps between the original message and the translation. It returns false if the message hasn't been translated.
+func (hw *HTMLMessage) ToggleTranslation() bool {
+	if hw.alternate == nil {
+		return false
+	}
+	hw.Root, hw.alternate = hw.alternate, hw.Root
+	return true
 }
 
 func (hw *HTMLMessage) Draw(screen mauview.Screen, msg *UIMessage) {
@@ -18,9 +18,12 @@ package messages
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/tidwall/gjson"
+	"go.mau.fi/util/exgjson"
 	"maunium.net/go/mautrix/event"
 	"maunium.net/go/mautrix/id"
 
@@ -34,12 +37,26 @@ import (
 	"go.mau.fi/gomuks/tui/widget"
 )
 
+var threadFallbackPath = exgjson.Path("m.relates_to", "is_falling_back")
+
+// isThreadFallbackReply checks if the reply in the event only exists for clients that don't support threads.
+func isThreadFallbackReply(evt *database.Event) bool {
+	if evt.RelationType != event.RelThread {
+		return false
+	}
+	content := evt.Content
+	if evt.Decrypted != nil {
+		content = evt.Decrypted
+	}
+	return gjson.GetBytes(content, threadFallbackPath).Bool()
+}
+
 func ParseEvent(matrix *client.GomuksClient, prefs *config.UserPreferences, room *store.RoomStore, evt *database.Event) *UIMessage {
 	msg := directParseEvent(matrix, prefs, room, evt)
 	if msg == nil {
 		return nil
 	}
-	if replyTo := evt.GetReplyTo(); len(replyTo) > 0 {
+	if replyTo := evt.GetReplyTo(); len(replyTo) > 0 && !isThreadFallbackReply(evt) {
 		if replyToEvt := room.GetEventByID(replyTo); replyToEvt != nil {
 			if replyToMsg, ok := replyToEvt.RenderMeta.(*UIMessage); ok {
 				if replyToMsg != nil {
@@ -74,8 +91,15 @@ func directParseEvent(matrix *client.GomuksClient, prefs *config.UserPreferences
 			return NewRedactedMessage(evt, room)
 		}
 		return ParseMessage(matrix, prefs, room, evt)
+	case event.EventUnstablePollStart:
+		if evt.RedactedBy != "" {
+			return NewRedactedMessage(evt, room)
+		}
+		return ParsePollMessage(matrix, room, evt)
 	case event.StateTopic, event.StateRoomName, event.StateCanonicalAlias:
 		return ParseStateEvent(room, evt)
+	case database.StateUnstableBeaconInfo:
+		return ParseBeaconInfoEvent(room, evt)
 	case event.StateMember:
 		return ParseMembershipEvent(room, evt)
 	default:
@@ -104,19 +128,19 @@ NewLoop:
 		addedList = append(addedList, tstring.NewStyleTString(string(newAlias), tcell.StyleDefault.Foreground(widget.GetHashColor(newAlias)).Underline(true)))
 	}
 	if len(addedList) == 1 {
-		addedStr = tstring.NewColorTString("added alternative address ", tcell.ColorGreen).AppendTString(addedList[0])
+		addedStr = tstring.NewColorTString("added alternative address ", config.CurrentTheme.StateEvent).AppendTString(addedList[0])
 	} else if len(addedList) != 0 {
 		addedStr = tstring.Join(addedList[:len(addedList)-1], ", ").
-			PrependColor("added alternative addresses ", tcell.ColorGreen).
-			AppendColor(" and ", tcell.ColorGreen).
+			PrependColor("added alternative addresses ", config.CurrentTheme.StateEvent).
+			AppendColor(" and ", config.CurrentTheme.StateEvent).
 			AppendTString(addedList[len(addedList)-1])
 	}
 	if len(removedList) == 1 {
-		removedStr = tstring.NewColorTString("removed alternative address ", tcell.ColorGreen).AppendTString(removedList[0])
+		removedStr = tstring.NewColorTString("removed alternative address ", config.CurrentTheme.StateEvent).AppendTString(removedList[0])
 	} else if len(removedList) != 0 {
 		removedStr = tstring.Join(removedList[:len(removedList)-1], ", ").
-			PrependColor("removed alternative addresses ", tcell.ColorGreen).
-			AppendColor(" and ", tcell.ColorGreen).
+			PrependColor("removed alternative addresses ", config.CurrentTheme.StateEvent).
+			AppendColor(" and ", config.CurrentTheme.StateEvent).
 			AppendTString(removedList[len(removedList)-1])
 	}
 	return
@@ -131,19 +155,19 @@ func ParseStateEvent(room *store.RoomStore, evt *database.Event) *UIMessage {
 	switch content := mEvt.Content.Parsed.(type) {
 	case *event.TopicEventContent:
 		if len(content.Topic) == 0 {
-			text = text.AppendColor("removed the topic.", tcell.ColorGreen)
+			text = text.AppendColor("removed the topic.", config.CurrentTheme.StateEvent)
 		} else {
-			text = text.AppendColor("changed the topic to ", tcell.ColorGreen).
+			text = text.AppendColor("changed the topic to ", config.CurrentTheme.StateEvent).
 				AppendStyle(content.Topic, tcell.StyleDefault.Underline(true)).
-				AppendColor(".", tcell.ColorGreen)
+				AppendColor(".", config.CurrentTheme.StateEvent)
 		}
 	case *event.RoomNameEventContent:
 		if len(content.Name) == 0 {
-			text = text.AppendColor("removed the room name.", tcell.ColorGreen)
+			text = text.AppendColor("removed the room name.", config.CurrentTheme.StateEvent)
 		} else {
-			text = text.AppendColor("changed the room name to ", tcell.ColorGreen).
+			text = text.AppendColor("changed the room name to ", config.CurrentTheme.StateEvent).
 				AppendStyle(content.Name, tcell.StyleDefault.Underline(true)).
-				AppendColor(".", tcell.ColorGreen)
+				AppendColor(".", config.CurrentTheme.StateEvent)
 		}
 	case *event.CanonicalAliasEventContent:
 		prevContent := &event.CanonicalAliasEventContent{}
@@ -153,10 +177,10 @@ func ParseStateEvent(room *store.RoomStore, evt *database.Event) *UIMessage {
 		}
 		debug.Printf("%+v -> %+v", prevContent, content)
 		if len(content.Alias) == 0 && len(prevContent.Alias) != 0 {
-			text = text.AppendColor("removed the main address of the room", tcell.ColorGreen)
+			text = text.AppendColor("removed the main address of the room", config.CurrentTheme.StateEvent)
 		} else if content.Alias != prevContent.Alias {
 			text = text.
-				AppendColor("changed the main address of the room to ", tcell.ColorGreen).
+				AppendColor("changed the main address of the room to ", config.CurrentTheme.StateEvent).
 				AppendStyle(string(content.Alias), tcell.StyleDefault.Underline(true))
 		} else {
 			added, removed := findAltAliasDifference(content.AltAliases, prevContent.AltAliases)
@@ -164,7 +188,7 @@ func ParseStateEvent(room *store.RoomStore, evt *database.Event) *UIMessage {
 				if len(removed) > 0 {
 					text = text.
 						AppendTString(added).
-						AppendColor(" and ", tcell.ColorGreen).
+						AppendColor(" and ", config.CurrentTheme.StateEvent).
 						AppendTString(removed)
 				} else {
 					text = text.AppendTString(added)
@@ -172,9 +196,9 @@ func ParseStateEvent(room *store.RoomStore, evt *database.Event) *UIMessage {
 			} else if len(removed) > 0 {
 				text = text.AppendTString(removed)
 			} else {
-				text = text.AppendColor("changed nothing", tcell.ColorGreen)
+				text = text.AppendColor("changed nothing", config.CurrentTheme.StateEvent)
 			}
-			text = text.AppendColor(" for this room", tcell.ColorGreen)
+			text = text.AppendColor(" for this room", config.CurrentTheme.StateEvent)
 		}
 	}
 	return NewExpandedTextMessage(evt, room, text)
@@ -191,50 +215,95 @@ func ParseMessage(matrix *client.GomuksClient, prefs *config.UserPreferences, ro
 			htmlEntity = html.Parse(prefs, room, content, evt, displayname)
 			if htmlEntity == nil {
 				htmlEntity = html.NewTextEntity("Malformed message")
-				htmlEntity.AdjustStyle(html.AdjustStyleTextColor(tcell.ColorRed), html.AdjustStyleReasonNormal)
+				htmlEntity.AdjustStyle(html.AdjustStyleTextColor(config.CurrentTheme.Error), html.AdjustStyleReasonNormal)
 			}
 		} else if len(content.Body) > 0 {
 			content.Body = strings.Replace(content.Body, "\t", "    ", -1)
 			htmlEntity = html.TextToEntity(content.Body, evt.ID, prefs.EnableInlineURLs())
 		} else {
 			htmlEntity = html.NewTextEntity("Blank message")
-			htmlEntity.AdjustStyle(html.AdjustStyleTextColor(tcell.ColorRed), html.AdjustStyleReasonNormal)
+			htmlEntity.AdjustStyle(html.AdjustStyleTextColor(config.CurrentTheme.Error), html.AdjustStyleReasonNormal)
+		}
+		msg := NewHTMLMessage(room, evt, content, htmlEntity)
+		translationSource := evt
+		if evt.LastEditRef != nil {
+			translationSource = evt.LastEditRef
 		}
-		return NewHTMLMessage(room, evt, content, htmlEntity)
+		if translation := translationSource.LocalContent.GetTranslation(); translation != nil {
+			msg.Renderer.(*HTMLMessage).SetTranslation(parseTranslation(prefs, evt, translation))
+		}
+		return msg
 	case event.MsgImage, event.MsgVideo, event.MsgAudio, event.MsgFile:
 		msg := NewFileMessage(room, matrix, evt, content)
 		if !prefs.DisableDownloads {
 			renderer := msg.Renderer.(*FileMessage)
-			renderer.DownloadPreview()
+			go renderer.DownloadPreview()
 		}
 		return msg
+	case event.MsgLocation:
+		return ParseLocationMessage(prefs, room, evt, content)
 	}
 	return nil
 }
 
+func parseTranslation(prefs *config.UserPreferences, evt *database.Event, translation *database.Translation) html.Entity {
+	note := fmt.Sprintf("(translated to %s)", translation.Language)
+	if translation.SourceLanguage != "" {
+		note = fmt.Sprintf("(translated from %s to %s)", translation.SourceLanguage, translation.Language)
+	}
+	text := strings.ReplaceAll(translation.Text, "\t", "    ") + "\n" + note
+	return html.TextToEntity(text, evt.ID, prefs.EnableInlineURLs())
+}
+
+func getOpenStreetMapLink(latitude, longitude float64) string {
+	return fmt.Sprintf("https://www.openstreetmap.org/?mlat=%[1]f&mlon=%[2]f#map=16/%[1]f/%[2]f", latitude, longitude)
+}
+
+func ParseLocationMessage(prefs *config.UserPreferences, room *store.RoomStore, evt *database.Event, content *event.MessageEventContent) *UIMessage {
+	text := content.Body
+	if latitude, longitude, err := database.ParseGeoURI(content.GeoURI); err == nil {
+		text = fmt.Sprintf("%s\n%s", text, getOpenStreetMapLink(latitude, longitude))
+	}
+	return NewHTMLMessage(room, evt, content, html.TextToEntity(text, evt.ID, prefs.EnableInlineURLs()))
+}
+
+func ParseBeaconInfoEvent(room *store.RoomStore, evt *database.Event) *UIMessage {
+	displayname := room.GetDisplayname(evt.Sender)
+	text := tstring.NewColorTString(displayname, widget.GetHashColor(evt.Sender)).Append(" ")
+	if gjson.GetBytes(evt.Content, "live").Bool() {
+		text = text.AppendColor("started sharing their live location", config.CurrentTheme.StateEvent)
+		if description := gjson.GetBytes(evt.Content, "description").Str; description != "" {
+			text = text.AppendColor(": ", config.CurrentTheme.StateEvent).AppendStyle(description, tcell.StyleDefault.Underline(true))
+		}
+	} else {
+		text = text.AppendColor("stopped sharing their live location", config.CurrentTheme.StateEvent)
+	}
+	return NewExpandedTextMessage(evt, room, text)
+}
+
 func getMembershipChangeMessage(evt *database.Event, content *event.MemberEventContent, prevMembership event.Membership, senderDisplayname, displayname, prevDisplayname string) (sender string, text tstring.TString) {
 	switch content.Membership {
 	case "invite":
 		sender = "---"
-		text = tstring.NewColorTString(fmt.Sprintf("%s invited %s.", senderDisplayname, displayname), tcell.ColorGreen)
+		text = tstring.NewColorTString(fmt.Sprintf("%s invited %s.", senderDisplayname, displayname), config.CurrentTheme.StateEvent)
 		text.Colorize(0, len(senderDisplayname), widget.GetHashColor(evt.Sender))
 		text.Colorize(len(senderDisplayname)+len(" invited "), len(displayname), widget.GetHashColor(evt.StateKey))
 	case "join":
 		sender = "-->"
 		if prevMembership == event.MembershipInvite {
-			text = tstring.NewColorTString(fmt.Sprintf("%s accepted the invite.", displayname), tcell.ColorGreen)
+			text = tstring.NewColorTString(fmt.Sprintf("%s accepted the invite.", displayname), config.CurrentTheme.StateEvent)
 		} else {
-			text = tstring.NewColorTString(fmt.Sprintf("%s joined the room.", displayname), tcell.ColorGreen)
+			text = tstring.NewColorTString(fmt.Sprintf("%s joined the room.", displayname), config.CurrentTheme.StateEvent)
 		}
 		text.Colorize(0, len(displayname), widget.GetHashColor(evt.StateKey))
 	case "leave":
 		sender = "<--"
 		if evt.Sender != id.UserID(*evt.StateKey) {
 			if prevMembership == event.MembershipBan {
-				text = tstring.NewColorTString(fmt.Sprintf("%s unbanned %s", senderDisplayname, displayname), tcell.ColorGreen)
+				text = tstring.NewColorTString(fmt.Sprintf("%s unbanned %s", senderDisplayname, displayname), config.CurrentTheme.StateEvent)
 				text.Colorize(len(senderDisplayname)+len(" unbanned "), len(displayname), widget.GetHashColor(evt.StateKey))
 			} else {
-				text = tstring.NewColorTString(fmt.Sprintf("%s kicked %s: %s", senderDisplayname, displayname, content.Reason), tcell.ColorRed)
+				text = tstring.NewColorTString(fmt.Sprintf("%s kicked %s: %s", senderDisplayname, displayname, content.Reason), config.CurrentTheme.StateEventRemoval)
 				text.Colorize(len(senderDisplayname)+len(" kicked "), len(displayname), widget.GetHashColor(evt.StateKey))
 			}
 			text.Colorize(0, len(senderDisplayname), widget.GetHashColor(evt.Sender))
@@ -243,14 +312,14 @@ func getMembershipChangeMessage(evt *database.Event, content *event.MemberEventC
 				displayname = prevDisplayname
 			}
 			if prevMembership == event.MembershipInvite {
-				text = tstring.NewColorTString(fmt.Sprintf("%s rejected the invite.", displayname), tcell.ColorRed)
+				text = tstring.NewColorTString(fmt.Sprintf("%s rejected the invite.", displayname), config.CurrentTheme.StateEventRemoval)
 			} else {
-				text = tstring.NewColorTString(fmt.Sprintf("%s left the room.", displayname), tcell.ColorRed)
+				text = tstring.NewColorTString(fmt.Sprintf("%s left the room.", displayname), config.CurrentTheme.StateEventRemoval)
 			}
 			text.Colorize(0, len(displayname), widget.GetHashColor(evt.StateKey))
 		}
 	case "ban":
-		text = tstring.NewColorTString(fmt.Sprintf("%s banned %s: %s", senderDisplayname, displayname, content.Reason), tcell.ColorRed)
+		text = tstring.NewColorTString(fmt.Sprintf("%s banned %s: %s", senderDisplayname, displayname, content.Reason), config.CurrentTheme.StateEventRemoval)
 		text.Colorize(len(senderDisplayname)+len(" banned "), len(displayname), widget.GetHashColor(evt.StateKey))
 		text.Colorize(0, len(senderDisplayname), widget.GetHashColor(evt.Sender))
 	}
@@ -290,9 +359,9 @@ func getMembershipEventContent(room *store.RoomStore, evt *database.Event) (send
 		color := widget.GetHashColor(evt.StateKey)
 		text = tstring.NewBlankTString().
 			AppendColor(prevDisplayname, color).
-			AppendColor(" changed their display name to ", tcell.ColorGreen).
+			AppendColor(" changed their display name to ", config.CurrentTheme.StateEvent).
 			AppendColor(displayname, color).
-			AppendColor(".", tcell.ColorGreen)
+			AppendColor(".", config.CurrentTheme.StateEvent)
 	}
 	return
 }
@@ -307,3 +376,39 @@ func ParseMembershipEvent(room *store.RoomStore, evt *database.Event) *UIMessage
 	ui.OverrideSenderName = displayname
 	return ui
 }
+
+func pluralize(count int, word string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, word)
+	}
+	return fmt.Sprintf("%d %ss", count, word)
+}
+
+func ParsePollMessage(matrix *client.GomuksClient, room *store.RoomStore, evt *database.Event) *UIMessage {
+	poll := evt.GetPollStart()
+	if poll == nil {
+		return nil
+	}
+	tally := evt.PollTally
+	var ownVote []string
+	if tally != nil {
+		ownVote = tally.Votes[matrix.UserID]
+	}
+	text := tstring.NewStyleTString(poll.Question.Text, tcell.StyleDefault.Bold(true))
+	if tally.IsEnded() {
+		text = text.AppendColor(" (ended)", config.CurrentTheme.SecondaryText)
+	}
+	for i, answer := range poll.Answers {
+		marker := "[ ]"
+		if slices.Contains(ownVote, answer.ID) {
+			marker = "[x]"
+		}
+		text = text.Append(fmt.Sprintf("\n%s %d. %s", marker, i+1, answer.Text))
+		if tally != nil {
+			text = text.AppendColor(fmt.Sprintf(" (%s)", pluralize(tally.Counts[answer.ID], "vote")), config.CurrentTheme.SecondaryText)
+		}
+	}
+	maxSelections := database.GetPollMaxSelections(poll)
+	text = text.AppendColor(fmt.Sprintf("\nMax %s per user", pluralize(maxSelections, "choice")), config.CurrentTheme.SecondaryText)
+	return NewExpandedTextMessage(evt, room, text)
+}
@@ -58,11 +58,10 @@ func (msg *RedactedMessage) Height() int {
 const RedactionChar = '█'
 const RedactionMaxWidth = 40
 
-var RedactionStyle = tcell.StyleDefault.Foreground(tcell.NewRGBColor(50, 0, 0))
-
 func (msg *RedactedMessage) Draw(screen mauview.Screen, _ *UIMessage) {
 	w, _ := screen.Size()
+	redactionStyle := tcell.StyleDefault.Foreground(config.CurrentTheme.Redacted)
 	for x := 0; x < w && x < RedactionMaxWidth; x++ {
-		screen.SetContent(x, 0, RedactionChar, nil, RedactionStyle)
+		screen.SetContent(x, 0, RedactionChar, nil, redactionStyle)
 	}
 }
@@ -0,0 +1,329 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2026 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"go.mau.fi/mauview"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/gomuks/tui/config"
+	"go.mau.fi/gomuks/tui/messages"
+)
+
+// normalMode contains the state of the vim-style normal mode of a room view.
+//
+// In normal mode, keys are looked up in the normal keybinding context instead of being typed into the input.
+// Message actions apply to the highlighted message, and input editing actions move the cursor of the input.
+type normalMode struct {
+	active bool
+	// The count prefix typed before the action, or zero if there is none.
+	count int
+	// The keys typed so far that are the start of a longer key sequence.
+	pending []config.Keybind
+	// A message shown in the status bar until the next key press, like "pattern not found".
+	notice string
+	// Messages that will be redacted if the next key press confirms it.
+	confirmRedact []*messages.UIMessage
+
+	searching   bool
+	searchInput *mauview.InputArea
+	lastSearch  string
+}
+
+// EnterNormalMode switches the room view to the vim-style normal mode.
+func (view *RoomView) EnterNormalMode() {
+	if view.selecting {
+		view.StopSelecting()
+	}
+	view.normal.active = true
+	view.normal.count = 0
+	view.normal.pending = nil
+	view.normal.confirmRedact = nil
+	view.input.Blur()
+}
+
+// ExitNormalMode returns to typing in the input. If keepSelection is false, the highlighted message is unselected.
+func (view *RoomView) ExitNormalMode(keepSelection bool) {
+	view.normal.active = false
+	view.normal.searching = false
+	view.normal.notice = ""
+	view.normal.confirmRedact = nil
+	if !keepSelection {
+		view.MessageView().SetSelected(nil)
+	}
+	view.input.Focus()
+}
+
+// normalModeStatus returns the normal mode indicator for the status bar.
+func (view *RoomView) normalModeStatus() string {
+	var buf strings.Builder
+	buf.WriteString("Normal mode")
+	if view.normal.count > 0 || len(view.normal.pending) > 0 {
+		buf.WriteString(" (")
+		if view.normal.count > 0 {
+			buf.WriteString(strconv.Itoa(view.normal.count))
+		}
+		for _, kb := range view.normal.pending {
+			if kb.Key == tcell.KeyRune {
+				buf.WriteRune(kb.Ch)
+			}
+		}
+		buf.WriteString(")")
+	}
+	if view.normal.notice != "" {
+		buf.WriteString(" - ")
+		buf.WriteString(view.normal.notice)
+	}
+	buf.WriteString(" - ")
+	return buf.String()
+}
+
+// drawSearchInput draws the search prompt over the status bar.
+func (view *RoomView) drawSearchInput() {
+	width, _ := view.statusScreen.Size()
+	view.statusScreen.Clear()
+	view.statusScreen.SetContent(0, 0, '/', nil, tcell.StyleDefault)
+	view.normal.searchInput.Draw(mauview.NewProxyScreen(view.statusScreen, 1, 0, width-1, 1))
+}
+
+func (view *RoomView) onNormalModeKey(event mauview.KeyEvent, kb config.Keybind) bool {
+	nm := &view.normal
+	if nm.searching {
+		return view.onSearchKey(event, kb)
+	}
+	nm.notice = ""
+	if nm.confirmRedact != nil {
+		msgs := nm.confirmRedact
+		nm.confirmRedact = nil
+		if (kb.Key == tcell.KeyRune && kb.Mod == 0 && kb.Ch == 'y') || view.config.Keybindings.Modal[kb] == "confirm" {
+			view.redactMessages(msgs)
+		} else {
+			nm.notice = "Redaction cancelled"
+		}
+		return true
+	}
+	if kb.Key == tcell.KeyRune && kb.Mod == 0 && len(nm.pending) == 0 &&
+		kb.Ch >= '0' && kb.Ch <= '9' && (kb.Ch != '0' || nm.count > 0) {
+		nm.count = nm.count*10 + int(kb.Ch-'0')
+		return true
+	}
+	nm.pending = append(nm.pending, kb)
+	action, partial := view.config.Keybindings.Normal.Get(nm.pending)
+	if partial {
+		return true
+	}
+	count := max(nm.count, 1)
+	nm.pending = nil
+	nm.count = 0
+	if action == "" {
+		// Unbound keys are swallowed instead of being typed into the input,
+		// but other keys like Ctrl+Enter still work like in the normal room view.
+		return kb.Key == tcell.KeyRune && kb.Mod&(tcell.ModCtrl|tcell.ModAlt) == 0
+	}
+	view.runNormalModeAction(action, count)
+	return true
+}
+
+func (view *RoomView) onSearchKey(event mauview.KeyEvent, kb config.Keybind) bool {
+	nm := &view.normal
+	switch view.config.Keybindings.Modal[kb] {
+	case "cancel":
+		nm.searching = false
+		return true
+	case "confirm":
+		nm.searching = false
+		nm.lastSearch = nm.searchInput.GetText()
+		view.searchMessages(nm.lastSearch, false)
+		return true
+	}
+	return nm.searchInput.OnKeyEvent(event)
+}
+
+func (view *RoomView) searchMessages(query string, newer bool) {
+	if query == "" {
+		view.normal.notice = "No previous search"
+	} else if !view.MessageView().Search(query, newer) {
+		view.normal.notice = fmt.Sprintf("Pattern not found: %s", query)
+	}
+}
+
+// sendInputKey sends a synthetic key event to the message input, so that the input area
+// handles the change the same way as if the key was pressed in insert mode.
+func (view *RoomView) sendInputKey(key tcell.Key, mod tcell.ModMask, count int) {
+	for i := 0; i < count; i++ {
+		view.input.OnKeyEvent(tcell.NewEventKey(key, 0, mod))
+	}
+}
+
+func (view *RoomView) runNormalModeAction(action string, count int) {
+	msgView := view.MessageView()
+	switch action {
+	case "clear", "insert":
+		view.ExitNormalMode(false)
+	case "append":
+		view.sendInputKey(tcell.KeyRight, 0, 1)
+		view.ExitNormalMode(false)
+	case "insert_start":
+		view.sendInputKey(tcell.KeyHome, 0, 1)
+		view.ExitNormalMode(false)
+	case "append_end":
+		view.sendInputKey(tcell.KeyEnd, 0, 1)
+		view.ExitNormalMode(false)
+	case "select_next":
+		msgView.MoveSelection(count)
+	case "select_prev":
+		msgView.MoveSelection(-count)
+	case "select_first":
+		msgView.SelectFirst()
+	case "select_last":
+		msgView.SelectLast()
+	case "scroll_up":
+		if msgView.IsAtTop() {
+			go msgView.LoadHistory()
+		}
+		msgView.AddScrollOffset(count * msgView.Height() / 2)
+	case "scroll_down":
+		msgView.AddScrollOffset(-count * msgView.Height() / 2)
+	case "search":
+		if view.normal.searchInput == nil {
+			view.normal.searchInput = mauview.NewInputArea().
+				SetTextColor(config.CurrentTheme.StatusBarText).
+				SetBackgroundColor(config.CurrentTheme.StatusBarBackground)
+		}
+		view.normal.searchInput.SetText("")
+		view.normal.searching = true
+	case "search_next", "search_prev":
+		for i := 0; i < count && view.normal.notice == ""; i++ {
+			view.searchMessages(view.normal.lastSearch, action == "search_prev")
+		}
+	case "redact":
+		msgs := msgView.SelectedRange(count)
+		if len(msgs) == 0 {
+			view.normal.notice = "No message selected"
+		} else if count == 1 {
+			view.redactMessages(msgs)
+		} else {
+			view.confirmRangeRedaction(msgs)
+		}
+	case "reply", "edit", "copy", "react", "thread", "open":
+		view.runNormalModeMessageAction(action, msgView.GetSelected())
+	case "send":
+		view.InputSubmit(view.input.GetText())
+	case "cursor_left":
+		view.sendInputKey(tcell.KeyLeft, 0, count)
+	case "cursor_right":
+		view.sendInputKey(tcell.KeyRight, 0, count)
+	case "word_next":
+		view.sendInputKey(tcell.KeyRight, tcell.ModCtrl, count)
+	case "word_prev":
+		view.sendInputKey(tcell.KeyLeft, tcell.ModCtrl, count)
+	case "line_start":
+		view.sendInputKey(tcell.KeyHome, 0, 1)
+	case "line_end":
+		view.sendInputKey(tcell.KeyEnd, 0, 1)
+	case "delete_char":
+		view.sendInputKey(tcell.KeyDelete, 0, count)
+	case "delete_to_end":
+		view.sendInputKey(tcell.KeyEnd, tcell.ModShift, 1)
+		view.sendInputKey(tcell.KeyDelete, 0, 1)
+	case "undo", "redo":
+		for i := 0; i < count; i++ {
+			if action == "undo" {
+				view.input.Undo()
+			} else {
+				view.input.Redo()
+			}
+		}
+		view.inputChanged(view.input.GetText())
+	default:
+		view.normal.notice = fmt.Sprintf("Unknown action %s", action)
+	}
+}
+
+// confirmRangeRedaction asks for confirmation before redacting the user's own messages in the given range.
+// Messages from other users are skipped, so that a count can't accidentally remove them.
+func (view *RoomView) confirmRangeRedaction(msgs []*messages.UIMessage) {
+	own := make([]*messages.UIMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.Sender == view.parent.matrix.UserID {
+			own = append(own, msg)
+		}
+	}
+	if len(own) == 0 {
+		view.normal.notice = "No own messages in range"
+		return
+	}
+	view.normal.confirmRedact = own
+	if skipped := len(msgs) - len(own); skipped > 0 {
+		view.normal.notice = fmt.Sprintf("Redact %d messages (skipping %d from others)? [y/N]", len(own), skipped)
+	} else {
+		view.normal.notice = fmt.Sprintf("Redact %d messages? [y/N]", len(own))
+	}
+}
+
+func (view *RoomView) redactMessages(msgs []*messages.UIMessage) {
+	for _, msg := range msgs {
+		go view.Redact(msg.ID, "")
+	}
+	if len(msgs) == 1 {
+		view.normal.notice = "Redacting message"
+	} else {
+		view.normal.notice = fmt.Sprintf("Redacting %d messages", len(msgs))
+	}
+}
+
+func (view *RoomView) runNormalModeMessageAction(action string, msg *messages.UIMessage) {
+	if msg == nil {
+		view.normal.notice = "No message selected"
+		return
+	}
+	switch action {
+	case "reply":
+		view.replying.Store(msg.Event)
+		view.ExitNormalMode(false)
+	case "edit":
+		if msg.Sender != view.parent.matrix.UserID {
+			view.normal.notice = "Can't edit messages from other users"
+			return
+		}
+		view.SetEditing(msg.Event)
+		view.ExitNormalMode(false)
+	case "copy":
+		go view.CopyToClipboard(msg.Renderer.PlainText(), "")
+	case "react":
+		// The selection is kept, so the /react command applies to the highlighted message.
+		view.input.SetTextAndMoveCursor("/react ")
+		view.ExitNormalMode(true)
+	case "thread":
+		rootID := msg.ID
+		if msg.RelationType == event.RelThread {
+			rootID = msg.RelatesTo
+		}
+		view.OpenThread(rootID)
+	case "open":
+		if fileMsg, ok := msg.Renderer.(*messages.FileMessage); ok {
+			go view.Download(fileMsg, "", true)
+		} else {
+			view.normal.notice = "Selected message doesn't have a file"
+		}
+	}
+}
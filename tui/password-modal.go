@@ -20,8 +20,9 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/gdamore/tcell/v2"
 	"go.mau.fi/mauview"
+
+	"go.mau.fi/gomuks/tui/config"
 )
 
 type PasswordModal struct {
@@ -117,7 +118,7 @@ func (pwm *PasswordModal) HandleChange(_ string) {
 	if pwm.input.GetText() == pwm.confirmInput.GetText() {
 		pwm.submit.SetBackgroundColor(mauview.Styles.ContrastBackgroundColor)
 	} else {
-		pwm.submit.SetBackgroundColor(tcell.ColorDefault)
+		pwm.submit.SetBackgroundColor(config.CurrentTheme.Background)
 	}
 }
 
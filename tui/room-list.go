@@ -26,7 +26,9 @@ import (
 	"go.mau.fi/mauview"
 	"maunium.net/go/mautrix/id"
 
+	"go.mau.fi/gomuks/pkg/hicli/database"
 	"go.mau.fi/gomuks/pkg/rpc/store"
+	"go.mau.fi/gomuks/tui/config"
 	"go.mau.fi/gomuks/tui/widget"
 )
 
@@ -36,18 +38,22 @@ type RoomList struct {
 	parent *MainView
 
 	rooms    []*store.RoomListEntry
+	rows     []roomListRow
 	selected id.RoomID
 
+	// The space that the room list is filtered by. Empty means all rooms are shown.
+	space     id.RoomID
+	spaceName string
+
 	scrollOffset int
 	height       int
 	width        int
+}
 
-	// The item main text color.
-	mainTextColor tcell.Color
-	// The text color for selected items.
-	selectedTextColor tcell.Color
-	// The background color for selected items.
-	selectedBackgroundColor tcell.Color
+// roomListRow is a single line in the room list, either a section header or a room.
+type roomListRow struct {
+	header string
+	entry  *store.RoomListEntry
 }
 
 func NewRoomList(parent *MainView) *RoomList {
@@ -55,17 +61,13 @@ func NewRoomList(parent *MainView) *RoomList {
 		parent: parent,
 
 		scrollOffset: 0,
-
-		mainTextColor:           tcell.ColorDefault,
-		selectedTextColor:       tcell.ColorWhite,
-		selectedBackgroundColor: tcell.ColorDarkGreen,
 	}
 	return list
 }
 
 func (list *RoomList) SetSelected(roomID id.RoomID) {
 	list.selected = roomID
-	pos := list.index(roomID)
+	pos := list.rowIndex(roomID)
 	if pos <= list.scrollOffset {
 		list.scrollOffset = pos - 1
 	} else if pos >= list.scrollOffset+list.height {
@@ -121,12 +123,100 @@ func (list *RoomList) NextWithActivity() id.RoomID {
 	return ""
 }
 
+// SelectedSpace returns the space that the room list is currently filtered by.
+func (list *RoomList) SelectedSpace() id.RoomID {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+	return list.space
+}
+
+// SetSpace filters the room list to only show rooms in the given space and its subspaces.
+// An empty space ID shows all rooms.
+func (list *RoomList) SetSpace(space *store.SpaceEntry) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	if space == nil {
+		list.space = ""
+		list.spaceName = ""
+	} else {
+		list.space = space.RoomID
+		list.spaceName = space.Name
+	}
+	list.scrollOffset = 0
+}
+
+// CycleSpace switches to the next or previous space in the space tree. "All rooms" is included in the cycle.
+func (list *RoomList) CycleSpace(forward bool) {
+	tree := list.parent.matrix.GetSpaceTree()
+	current := list.SelectedSpace()
+	// Index 0 is "All rooms", spaces start from index 1
+	idx := slices.IndexFunc(tree, func(entry *store.SpaceEntry) bool {
+		return entry.RoomID == current
+	}) + 1
+	if forward {
+		idx = (idx + 1) % (len(tree) + 1)
+	} else {
+		idx = (idx - 1 + len(tree) + 1) % (len(tree) + 1)
+	}
+	if idx == 0 {
+		list.SetSpace(nil)
+	} else {
+		list.SetSpace(tree[idx-1])
+	}
+}
+
+func formatUnreadBadge(counts database.UnreadCounts) string {
+	unreadMessageCount := "99+"
+	if counts.UnreadMessages < 1000 {
+		unreadMessageCount = strconv.Itoa(counts.UnreadMessages)
+	}
+	if counts.UnreadHighlights > 0 {
+		unreadMessageCount += "!"
+	}
+	return fmt.Sprintf("(%s)", unreadMessageCount)
+}
+
 func (list *RoomList) index(roomID id.RoomID) int {
 	return slices.IndexFunc(list.rooms, func(entry *store.RoomListEntry) bool {
 		return entry.RoomID == roomID
 	})
 }
 
+func (list *RoomList) rowIndex(roomID id.RoomID) int {
+	return slices.IndexFunc(list.rows, func(row roomListRow) bool {
+		return row.entry != nil && row.entry.RoomID == roomID
+	})
+}
+
+// updateRows splits the room list into sections. Invites are shown in their own section above other rooms.
+func (list *RoomList) updateRows() {
+	list.rows = list.rows[:0]
+	inviteCount := 0
+	for _, entry := range list.rooms {
+		if entry.IsInvite {
+			inviteCount++
+		}
+	}
+	if inviteCount == 0 {
+		for _, entry := range list.rooms {
+			list.rows = append(list.rows, roomListRow{entry: entry})
+		}
+		return
+	}
+	list.rows = append(list.rows, roomListRow{header: fmt.Sprintf("Invites (%d)", inviteCount)})
+	for _, entry := range list.rooms {
+		if entry.IsInvite {
+			list.rows = append(list.rows, roomListRow{entry: entry})
+		}
+	}
+	list.rows = append(list.rows, roomListRow{header: "Rooms"})
+	for _, entry := range list.rooms {
+		if !entry.IsInvite {
+			list.rows = append(list.rows, roomListRow{entry: entry})
+		}
+	}
+}
+
 func (list *RoomList) OnKeyEvent(_ mauview.KeyEvent) bool {
 	return false
 }
@@ -148,13 +238,20 @@ func (list *RoomList) OnMouseEvent(event mauview.MouseEvent) bool {
 		return true
 	case tcell.Button1:
 		_, y := event.Position()
+		if list.SelectedSpace() != "" {
+			if y < SpaceHeaderHeight {
+				list.parent.ShowSpaceSwitcher()
+				return true
+			}
+			y -= SpaceHeaderHeight
+		}
 		list.lock.RLock()
 		defer list.lock.RUnlock()
 		y += list.scrollOffset
-		if y < 0 || y > len(list.rooms) {
+		if y < 0 || y >= len(list.rows) || list.rows[y].entry == nil {
 			return false
 		}
-		list.parent.SwitchRoom(list.rooms[y].RoomID)
+		list.parent.SwitchRoom(list.rows[y].entry.RoomID)
 		return true
 	}
 	return false
@@ -162,8 +259,8 @@ func (list *RoomList) OnMouseEvent(event mauview.MouseEvent) bool {
 
 func (list *RoomList) addScrollOffset(offset int) {
 	list.scrollOffset += offset
-	if list.scrollOffset > len(list.rooms)-list.height {
-		list.scrollOffset = len(list.rooms) - list.height
+	if list.scrollOffset > len(list.rows)-list.height {
+		list.scrollOffset = len(list.rows) - list.height
 	}
 	if list.scrollOffset < 0 {
 		list.scrollOffset = 0
@@ -173,35 +270,64 @@ func (list *RoomList) addScrollOffset(offset int) {
 func (list *RoomList) Focus() {}
 func (list *RoomList) Blur()  {}
 
+const SpaceHeaderHeight = 1
+
+func (list *RoomList) drawSpaceHeader(screen mauview.Screen) {
+	theme := config.CurrentTheme
+	style := tcell.StyleDefault.Foreground(theme.SpaceHeaderText).Background(theme.SpaceHeaderBackground).Bold(true)
+	widget.WriteLinePadded(screen, mauview.AlignLeft, list.spaceName, 0, 0, list.width, style)
+	var counts database.UnreadCounts
+	for _, room := range list.rooms {
+		if room.IsInvite {
+			continue
+		}
+		counts.UnreadMessages += room.UnreadMessages
+		counts.UnreadHighlights += room.UnreadHighlights
+	}
+	if counts.UnreadMessages > 0 {
+		widget.WriteLine(screen, mauview.AlignRight, formatUnreadBadge(counts), list.width-7, 0, 7, style)
+	}
+}
+
 func (list *RoomList) Draw(screen mauview.Screen) {
 	list.lock.Lock()
 	list.rooms = list.parent.matrix.ReversedRoomList.Current()
+	if list.space != "" {
+		list.rooms = slices.DeleteFunc(slices.Clone(list.rooms), func(entry *store.RoomListEntry) bool {
+			return !entry.IsInvite && !list.parent.matrix.IsRoomInSpace(list.space, entry.RoomID)
+		})
+	}
+	list.updateRows()
 	list.width, list.height = screen.Size()
-	roomSlice := list.rooms[min(len(list.rooms), list.scrollOffset):min(len(list.rooms), list.scrollOffset+list.height)]
+	if list.space != "" {
+		list.drawSpaceHeader(screen)
+		screen = mauview.NewProxyScreen(screen, 0, SpaceHeaderHeight, list.width, list.height-SpaceHeaderHeight)
+		list.height -= SpaceHeaderHeight
+	}
+	rowSlice := slices.Clone(list.rows[min(len(list.rows), list.scrollOffset):min(len(list.rows), list.scrollOffset+list.height)])
 	list.lock.Unlock()
 
-	for y, room := range roomSlice {
+	theme := config.CurrentTheme
+	for y, row := range rowSlice {
+		if row.entry == nil {
+			headerStyle := tcell.StyleDefault.Foreground(theme.RoomListHeader).Bold(true)
+			widget.WriteLinePadded(screen, mauview.AlignLeft, row.header, 0, y, list.width, headerStyle)
+			continue
+		}
+		room := row.entry
 		style := tcell.StyleDefault.
-			Foreground(list.mainTextColor).
+			Foreground(theme.RoomListText).
 			Bold(room.MarkedUnread || room.UnreadNotifications > 0 || room.UnreadHighlights > 0)
 		if room.RoomID == list.selected {
 			style = style.
-				Foreground(list.selectedTextColor).
-				Background(list.selectedBackgroundColor)
+				Foreground(theme.RoomListSelectedText).
+				Background(theme.RoomListSelectedBackground)
 		}
 
 		widget.WriteLinePadded(screen, mauview.AlignLeft, room.Name, 0, y, list.width, style)
 
 		if room.UnreadMessages > 0 {
-			unreadMessageCount := "99+"
-			if room.UnreadMessages < 1000 {
-				unreadMessageCount = strconv.Itoa(room.UnreadMessages)
-			}
-			if room.UnreadHighlights > 0 {
-				unreadMessageCount += "!"
-			}
-			unreadMessageCount = fmt.Sprintf("(%s)", unreadMessageCount)
-			widget.WriteLine(screen, mauview.AlignRight, unreadMessageCount, list.width-7, y, 7, style)
+			widget.WriteLine(screen, mauview.AlignRight, formatUnreadBadge(room.UnreadCounts), list.width-7, y, 7, style)
 		}
 	}
 }
@@ -0,0 +1,424 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2026 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/tidwall/sjson"
+	"go.mau.fi/mauview"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+	"go.mau.fi/gomuks/tui/config"
+	"go.mau.fi/gomuks/tui/debug"
+)
+
+var joinRuleNames = map[event.JoinRule]string{
+	event.JoinRuleInvite:          "Invite only",
+	event.JoinRuleKnock:           "Invite only, anyone can ask to join",
+	event.JoinRulePublic:          "Public, anyone can join",
+	event.JoinRuleRestricted:      "Members of specific rooms can join",
+	event.JoinRuleKnockRestricted: "Members of specific rooms can join, others can ask",
+	event.JoinRulePrivate:         "Private",
+}
+
+var historyVisibilityNames = map[event.HistoryVisibility]string{
+	event.HistoryVisibilityWorldReadable: "Anyone, even without joining",
+	event.HistoryVisibilityShared:        "Members, including messages from before they joined",
+	event.HistoryVisibilityInvited:       "Members, since they were invited",
+	event.HistoryVisibilityJoined:        "Members, since they joined",
+}
+
+// powerLevelSetting is a power level field that can be edited in the room settings dialog.
+type powerLevelSetting struct {
+	label string
+	key   string
+	get   func(pl *event.PowerLevelsEventContent) int
+}
+
+var powerLevelSettings = []powerLevelSetting{
+	{"Default level", "users_default", func(pl *event.PowerLevelsEventContent) int { return pl.UsersDefault }},
+	{"Send messages", "events_default", func(pl *event.PowerLevelsEventContent) int { return pl.EventsDefault }},
+	{"Change settings", "state_default", (*event.PowerLevelsEventContent).StateDefault},
+	{"Invite users", "invite", (*event.PowerLevelsEventContent).Invite},
+	{"Kick users", "kick", (*event.PowerLevelsEventContent).Kick},
+	{"Ban users", "ban", (*event.PowerLevelsEventContent).Ban},
+	{"Remove messages", "redact", (*event.PowerLevelsEventContent).Redact},
+}
+
+type RoomSettingsModal struct {
+	mauview.Component
+
+	form *mauview.Form
+
+	name              *mauview.InputField
+	topic             *mauview.InputField
+	avatar            *mauview.InputField
+	joinRule          *mauview.Button
+	historyVisibility *mauview.Button
+	levels            []*mauview.InputField
+	status            *mauview.TextField
+
+	powerLevels *event.PowerLevelsEventContent
+	ownLevel    int
+
+	origName   string
+	origTopic  string
+	origAvatar string
+
+	joinRules    []event.JoinRule
+	origJoinRule int
+	joinRuleIdx  int
+	histories    []event.HistoryVisibility
+	origHistory  int
+	historyIdx   int
+	saving       bool
+
+	room   *RoomView
+	parent *MainView
+}
+
+// stateChange is a state event that will be sent when saving the room settings.
+type stateChange struct {
+	eventType event.Type
+	content   json.RawMessage
+}
+
+func (view *RoomView) ShowSettings() {
+	view.parent.ShowModal(NewRoomSettingsModal(view))
+	view.parent.parent.Render()
+}
+
+func NewRoomSettingsModal(roomView *RoomView) *RoomSettingsModal {
+	rm := &RoomSettingsModal{
+		form:              mauview.NewForm(),
+		name:              mauview.NewInputField(),
+		topic:             mauview.NewInputField(),
+		avatar:            mauview.NewInputField(),
+		joinRule:          mauview.NewButton(""),
+		historyVisibility: mauview.NewButton(""),
+		status:            mauview.NewTextField(),
+		joinRules:         []event.JoinRule{event.JoinRuleInvite, event.JoinRuleKnock, event.JoinRulePublic},
+		histories: []event.HistoryVisibility{
+			event.HistoryVisibilityShared, event.HistoryVisibilityInvited,
+			event.HistoryVisibilityJoined, event.HistoryVisibilityWorldReadable,
+		},
+		powerLevels: roomView.Room.GetPowerLevels(),
+		room:        roomView,
+		parent:      roomView.parent,
+	}
+	rm.ownLevel = rm.powerLevels.GetUserLevel(rm.parent.matrix.UserID)
+
+	rm.origName = rm.stateContent(event.StateRoomName).AsRoomName().Name
+	rm.origTopic = rm.stateContent(event.StateTopic).AsTopic().Topic
+	rm.origAvatar = string(rm.stateContent(event.StateRoomAvatar).AsRoomAvatar().URL)
+	rm.name.SetText(rm.origName).SetPlaceholder("No name").SetTextColor(config.CurrentTheme.InputText)
+	rm.topic.SetText(rm.origTopic).SetPlaceholder("No topic").SetTextColor(config.CurrentTheme.InputText)
+	rm.avatar.SetText(rm.origAvatar).SetPlaceholder("mxc:// URI or path to image").SetTextColor(config.CurrentTheme.InputText)
+
+	// Unusual values like restricted join rules can't be selected, but they're kept if they're already set.
+	joinRule := rm.stateContent(event.StateJoinRules).AsJoinRules().JoinRule
+	rm.origJoinRule = slices.Index(rm.joinRules, joinRule)
+	if rm.origJoinRule < 0 {
+		rm.joinRules = append(rm.joinRules, joinRule)
+		rm.origJoinRule = len(rm.joinRules) - 1
+	}
+	rm.joinRuleIdx = rm.origJoinRule
+	history := rm.stateContent(event.StateHistoryVisibility).AsHistoryVisibility().HistoryVisibility
+	rm.origHistory = slices.Index(rm.histories, history)
+	if rm.origHistory < 0 {
+		rm.histories = append(rm.histories, history)
+		rm.origHistory = len(rm.histories) - 1
+	}
+	rm.historyIdx = rm.origHistory
+	rm.joinRule.SetOnClick(func() {
+		rm.joinRuleIdx = (rm.joinRuleIdx + 1) % len(rm.joinRules)
+		rm.updateButtons()
+	})
+	rm.historyVisibility.SetOnClick(func() {
+		rm.historyIdx = (rm.historyIdx + 1) % len(rm.histories)
+		rm.updateButtons()
+	})
+	rm.updateButtons()
+
+	cancel := mauview.NewButton("Cancel").SetOnClick(rm.parent.HideModal)
+	submit := mauview.NewButton("Save").SetOnClick(rm.Submit)
+
+	const levelsStart = 8
+	rows := make([]int, levelsStart+len(powerLevelSettings)+4)
+	for i := range rows {
+		rows[i] = 1
+	}
+	buttonRow := len(rows) - 2
+	rm.form.
+		SetColumns([]int{1, 16, 1, -1, 1}).
+		SetRows(rows)
+	rm.form.
+		AddFormItem(rm.name, 3, 1, 1, 1).
+		AddFormItem(rm.topic, 3, 2, 1, 1).
+		AddFormItem(rm.avatar, 3, 3, 1, 1).
+		AddFormItem(rm.joinRule, 3, 4, 1, 1).
+		AddFormItem(rm.historyVisibility, 3, 5, 1, 1)
+	rm.form.
+		AddComponent(mauview.NewTextField().SetText("Name"), 1, 1, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Topic"), 1, 2, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Avatar"), 1, 3, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Join rule"), 1, 4, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("History"), 1, 5, 1, 1).
+		AddComponent(
+			mauview.NewTextField().SetText(fmt.Sprintf("Power levels (your level: %s)", formatPowerLevel(rm.ownLevel))),
+			1, levelsStart-1, 3, 1,
+		).
+		AddComponent(rm.status, 1, buttonRow-1, 3, 1)
+	for i, setting := range powerLevelSettings {
+		input := mauview.NewInputField().
+			SetText(strconv.Itoa(setting.get(rm.powerLevels))).
+			SetTextColor(config.CurrentTheme.InputText)
+		rm.levels = append(rm.levels, input)
+		rm.form.AddFormItem(input, 3, levelsStart+i, 1, 1)
+		rm.form.AddComponent(mauview.NewTextField().SetText(setting.label), 1, levelsStart+i, 1, 1)
+	}
+	rm.form.
+		AddFormItem(submit, 3, buttonRow, 1, 1).
+		AddFormItem(cancel, 1, buttonRow, 1, 1)
+
+	box := mauview.NewBox(rm.form).
+		SetBorder(true).
+		SetTitle("Room settings").
+		SetBlurCaptureFunc(func() bool {
+			rm.parent.HideModal()
+			return true
+		})
+	center := mauview.Center(box, 72, len(rows)+2).SetAlwaysFocusChild(true)
+	center.Focus()
+	rm.form.FocusNextItem()
+	rm.Component = center
+	return rm
+}
+
+func formatPowerLevel(level int) string {
+	if level == math.MaxInt {
+		return "creator"
+	}
+	return strconv.Itoa(level)
+}
+
+func (rm *RoomSettingsModal) stateContent(evtType event.Type) *event.Content {
+	evt := rm.room.Room.GetStateEvent(evtType, "")
+	if evt == nil {
+		return &event.Content{}
+	}
+	return evt.GetMautrixContent()
+}
+
+// rawStateContent returns the content of the given state event, or an empty object if the event isn't set.
+// Changes are made to the raw content so that unknown fields are preserved.
+func (rm *RoomSettingsModal) rawStateContent(evtType event.Type) []byte {
+	evt := rm.room.Room.GetStateEvent(evtType, "")
+	if evt == nil {
+		return []byte("{}")
+	}
+	return evt.GetContent()
+}
+
+func (rm *RoomSettingsModal) updateButtons() {
+	joinRule := rm.joinRules[rm.joinRuleIdx]
+	if name, ok := joinRuleNames[joinRule]; ok {
+		rm.joinRule.SetText(name)
+	} else {
+		rm.joinRule.SetText(string(joinRule))
+	}
+	history := rm.histories[rm.historyIdx]
+	if name, ok := historyVisibilityNames[history]; ok {
+		rm.historyVisibility.SetText(name)
+	} else {
+		rm.historyVisibility.SetText(string(history))
+	}
+}
+
+func (rm *RoomSettingsModal) setStatus(color tcell.Color, text string) {
+	rm.status.SetTextColor(color)
+	rm.status.SetText(text)
+}
+
+// canSend checks that the user's power level is high enough to send the given state event.
+func (rm *RoomSettingsModal) canSend(evtType event.Type, what string) error {
+	if required := rm.powerLevels.GetEventLevel(evtType); rm.ownLevel < required {
+		return fmt.Errorf("changing the %s requires power level %d", what, required)
+	}
+	return nil
+}
+
+func (rm *RoomSettingsModal) collectChanges() (changes []stateChange, avatarPath string, err error) {
+	addChange := func(evtType event.Type, what string, content []byte, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to update %s: %w", what, err)
+		} else if err = rm.canSend(evtType, what); err != nil {
+			return err
+		}
+		changes = append(changes, stateChange{eventType: evtType, content: content})
+		return nil
+	}
+	if name := strings.TrimSpace(rm.name.GetText()); name != rm.origName {
+		content, err := sjson.SetBytes(rm.rawStateContent(event.StateRoomName), "name", name)
+		if err = addChange(event.StateRoomName, "room name", content, err); err != nil {
+			return nil, "", err
+		}
+	}
+	if topic := strings.TrimSpace(rm.topic.GetText()); topic != rm.origTopic {
+		content, err := sjson.SetBytes(rm.rawStateContent(event.StateTopic), "topic", topic)
+		if err == nil {
+			// The extensible topic would still contain the old topic.
+			content, err = sjson.DeleteBytes(content, "m\\.topic")
+		}
+		if err = addChange(event.StateTopic, "topic", content, err); err != nil {
+			return nil, "", err
+		}
+	}
+	if avatar := strings.TrimSpace(rm.avatar.GetText()); avatar != rm.origAvatar {
+		if err = rm.canSend(event.StateRoomAvatar, "room avatar"); err != nil {
+			return nil, "", err
+		} else if avatar != "" && !strings.HasPrefix(avatar, "mxc://") {
+			// Local files are uploaded first, the avatar event is added after the upload is done.
+			avatarPath = expandHome(avatar)
+			if info, err := os.Stat(avatarPath); err != nil || info.IsDir() {
+				return nil, "", fmt.Errorf("avatar file not found")
+			}
+		} else {
+			content, err := sjson.SetBytes(rm.rawStateContent(event.StateRoomAvatar), "url", avatar)
+			if err = addChange(event.StateRoomAvatar, "room avatar", content, err); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	if rm.joinRuleIdx != rm.origJoinRule {
+		content, err := sjson.SetBytes(rm.rawStateContent(event.StateJoinRules), "join_rule", rm.joinRules[rm.joinRuleIdx])
+		if err = addChange(event.StateJoinRules, "join rule", content, err); err != nil {
+			return nil, "", err
+		}
+	}
+	if rm.historyIdx != rm.origHistory {
+		content, err := sjson.SetBytes(
+			rm.rawStateContent(event.StateHistoryVisibility), "history_visibility", rm.histories[rm.historyIdx],
+		)
+		if err = addChange(event.StateHistoryVisibility, "history visibility", content, err); err != nil {
+			return nil, "", err
+		}
+	}
+	plContent := rm.rawStateContent(event.StatePowerLevels)
+	plChanged := false
+	for i, setting := range powerLevelSettings {
+		oldLevel := setting.get(rm.powerLevels)
+		newLevel, err := strconv.Atoi(strings.TrimSpace(rm.levels[i].GetText()))
+		if err != nil {
+			return nil, "", fmt.Errorf("%s must be a number", setting.label)
+		} else if newLevel == oldLevel {
+			continue
+		} else if oldLevel > rm.ownLevel || newLevel > rm.ownLevel {
+			return nil, "", fmt.Errorf("%s can't be changed to or from a level above your own", setting.label)
+		}
+		plContent, err = sjson.SetBytes(plContent, setting.key, newLevel)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to update power levels: %w", err)
+		}
+		plChanged = true
+	}
+	if plChanged {
+		if err = addChange(event.StatePowerLevels, "power levels", plContent, nil); err != nil {
+			return nil, "", err
+		}
+	}
+	return
+}
+
+func (rm *RoomSettingsModal) Submit() {
+	if rm.saving {
+		return
+	}
+	changes, avatarPath, err := rm.collectChanges()
+	if err != nil {
+		rm.setStatus(config.CurrentTheme.Error, err.Error())
+		return
+	} else if len(changes) == 0 && avatarPath == "" {
+		rm.parent.HideModal()
+		return
+	}
+	rm.saving = true
+	rm.setStatus(config.CurrentTheme.Warning, "Saving...")
+	go rm.save(changes, avatarPath)
+}
+
+func (rm *RoomSettingsModal) save(changes []stateChange, avatarPath string) {
+	defer debug.Recover()
+	defer rm.parent.parent.Render()
+	defer func() {
+		rm.saving = false
+	}()
+	if avatarPath != "" {
+		uploaded, err := rm.parent.matrix.Upload(context.TODO(), &jsoncmd.UploadMediaParams{Path: avatarPath}, func(progress float64) {
+			rm.setStatus(config.CurrentTheme.Warning, fmt.Sprintf("Uploading avatar: %d%%", int(progress*100)))
+			rm.parent.parent.Render()
+		})
+		if err != nil {
+			rm.setStatus(config.CurrentTheme.Error, fmt.Sprintf("Failed to upload avatar: %v", err))
+			return
+		}
+		content, err := sjson.SetBytes(rm.rawStateContent(event.StateRoomAvatar), "url", uploaded.URL)
+		if err != nil {
+			rm.setStatus(config.CurrentTheme.Error, fmt.Sprintf("Failed to update room avatar: %v", err))
+			return
+		}
+		changes = append(changes, stateChange{eventType: event.StateRoomAvatar, content: content})
+	}
+	for _, change := range changes {
+		_, err := rm.parent.matrix.SetState(context.TODO(), &jsoncmd.SendStateEventParams{
+			RoomID:    rm.room.Room.ID,
+			EventType: change.eventType,
+			Content:   change.content,
+		})
+		if err != nil {
+			debug.Print("Failed to send", change.eventType.Type, "in", rm.room.Room.ID, err)
+			rm.setStatus(config.CurrentTheme.Error, fmt.Sprintf("Failed to send %s: %v", change.eventType.Type, err))
+			return
+		}
+	}
+	if rm.parent.modal == rm {
+		rm.parent.HideModal()
+	}
+}
+
+func (rm *RoomSettingsModal) OnKeyEvent(event mauview.KeyEvent) bool {
+	kb := config.Keybind{
+		Key: event.Key(),
+		Ch:  event.Rune(),
+		Mod: event.Modifiers(),
+	}
+	if rm.parent.config.Keybindings.Modal[kb] == "cancel" {
+		rm.parent.HideModal()
+		return true
+	}
+	return rm.Component.OnKeyEvent(event)
+}
@@ -21,23 +21,32 @@ import (
 	"encoding/json"
 	"fmt"
 	"html"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
 
-	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
 	"github.com/zyedidia/clipboard"
 	"go.mau.fi/mauview"
+	"go.mau.fi/util/exstrings"
 	"go.mau.fi/util/ptr"
 	"go.mau.fi/util/variationselector"
-	"maunium.net/go/mautrix/crypto/attachment"
 	"maunium.net/go/mautrix/event"
 	"maunium.net/go/mautrix/id"
 
+	"go.mau.fi/gomuks/pkg/hicli/cmdspec"
 	"go.mau.fi/gomuks/pkg/hicli/database"
 	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
 	"go.mau.fi/gomuks/pkg/rpc/store"
 	"go.mau.fi/gomuks/tui/config"
 	"go.mau.fi/gomuks/tui/debug"
+	"go.mau.fi/gomuks/tui/lib/open"
 	"go.mau.fi/gomuks/tui/messages"
 	"go.mau.fi/gomuks/tui/widget"
 )
@@ -69,7 +78,34 @@ type RoomView struct {
 	selectReason  SelectReason
 	selectContent string
 
-	replying *database.Event
+	// The event that the next message will reply to. This is an atomic pointer,
+	// as messages and uploads are sent from background goroutines.
+	replying atomic.Pointer[database.Event]
+	// Results of messages sent in background goroutines, which are applied to the input in Draw.
+	send struct {
+		lock sync.Mutex
+		// The text of a message that was rejected by secret scanning.
+		// Submitting the same text again sends it with the override flag.
+		overrideText string
+		// An uploaded file that wasn't sent because the filename or caption looked like a secret.
+		overrideUpload *jsoncmd.SendMessageParams
+		// A rejected message to put back in the input, unless the user has already typed something else.
+		restoreText  string
+		restoreReply *database.Event
+		// Whether a message was sent successfully, which means the draft can be cleared.
+		succeeded bool
+	}
+	// The progress of the file upload in progress, if any.
+	upload struct {
+		lock   sync.Mutex
+		status string
+	}
+	// When the last typing notification was sent, or zero if the user isn't currently marked as typing.
+	typingSentAt time.Time
+	loadingDraft bool
+	// The input text that was last loaded from or saved to the draft.
+	// If the input still contains it, drafts changed by other clients can replace the input.
+	draftText string
 
 	editing      *database.Event
 	editMoveText string
@@ -80,8 +116,26 @@ type RoomView struct {
 		time      time.Time
 	}
 
+	spellCheck struct {
+		lock         sync.Mutex
+		timer        *time.Timer
+		failed       bool
+		text         string
+		misspellings []*jsoncmd.Misspelling
+	}
+
+	// The thread that is currently open, if any. When a thread is open,
+	// threadView is shown instead of the room timeline and messages are sent to the thread.
+	thread         *store.ThreadStore
+	threadView     *MessageView
+	unlistenThread func()
+
+	normal normalMode
+
 	unlistenMeta     func()
 	unlistenTimeline func()
+	unlistenTyping   func()
+	unlistenReceipts func()
 }
 
 func NewRoomView(parent *MainView, room *store.RoomStore) *RoomView {
@@ -98,7 +152,7 @@ func NewRoomView(parent *MainView, room *store.RoomStore) *RoomView {
 		statusScreen:   &mauview.ProxyScreen{OffsetX: 0, Height: StatusBarHeight},
 		inputScreen:    &mauview.ProxyScreen{OffsetX: 0},
 		ulBorderScreen: &mauview.ProxyScreen{OffsetY: StatusBarHeight, Width: UserListBorderWidth},
-		ulScreen:       &mauview.ProxyScreen{OffsetY: StatusBarHeight, Width: UserListWidth},
+		ulScreen:       &mauview.ProxyScreen{OffsetY: StatusBarHeight},
 
 		parent: parent,
 		config: parent.config,
@@ -106,33 +160,156 @@ func NewRoomView(parent *MainView, room *store.RoomStore) *RoomView {
 	view.content = NewMessageView(view)
 
 	view.input.
-		SetTextColor(tcell.ColorDefault).
-		SetBackgroundColor(tcell.ColorDefault).
 		SetPlaceholder("Send a message...").
-		SetPlaceholderTextColor(tcell.ColorGray).
 		SetTabCompleteFunc(view.InputTabComplete).
+		SetChangedFunc(view.inputChanged).
 		SetPressKeyUpAtStartFunc(view.EditPrevious).
 		SetPressKeyDownAtEndFunc(view.EditNext)
-
-	view.topic.
-		SetTextColor(tcell.ColorWhite).
-		SetBackgroundColor(tcell.ColorDarkGreen)
-
-	view.status.SetBackgroundColor(tcell.ColorDimGray)
+	view.applyTheme()
 
 	view.Update(room.Meta.Current())
+	view.loadDraft()
 
 	view.unlistenMeta = room.Meta.Listen(view.Update)
 	view.unlistenTimeline = room.TimelineCache.Listen(func(_ *[]*database.Event) {
 		view.parent.parent.NeedsRender = true
 	})
+	view.unlistenTyping = room.Typing.Listen(func(_ []id.UserID) {
+		view.parent.parent.Render()
+	})
+	view.unlistenReceipts = room.ReceiptsUpdated.Listen(func(_ []id.EventID) {
+		view.parent.parent.NeedsRender = true
+	})
 
 	return view
 }
 
 func (view *RoomView) Unload() {
+	view.CloseThread()
 	view.unlistenTimeline()
 	view.unlistenMeta()
+	view.unlistenTyping()
+	view.unlistenReceipts()
+	view.SetTyping(false)
+	view.saveDraft()
+}
+
+func (view *RoomView) threadRoot() id.EventID {
+	if view.thread == nil {
+		return ""
+	}
+	return view.thread.RootID
+}
+
+func (view *RoomView) loadDraft() {
+	draft := view.parent.matrix.GetDraft(view.Room.ID, view.threadRoot())
+	if draft == nil {
+		draft = &database.Draft{}
+	}
+	view.loadingDraft = true
+	view.input.SetTextAndMoveCursor(draft.Text)
+	view.loadingDraft = false
+	view.draftText = draft.Text
+	view.replying.Store(nil)
+	if draft.ReplyTo != "" {
+		view.replying.Store(view.Room.GetEventByID(draft.ReplyTo))
+	}
+	view.editing = nil
+	if draft.EditTarget != "" {
+		view.editing = view.Room.GetEventByID(draft.EditTarget)
+	}
+}
+
+// reloadDraft applies changes made to the draft by other clients, unless the input has been changed locally.
+func (view *RoomView) reloadDraft() {
+	current := view.currentDraft()
+	if current.Text != view.draftText {
+		return
+	}
+	stored := view.parent.matrix.GetDraft(view.Room.ID, current.ThreadRoot)
+	if stored == nil {
+		stored = &database.Draft{}
+	}
+	if stored.Text == current.Text && stored.ReplyTo == current.ReplyTo && stored.EditTarget == current.EditTarget {
+		return
+	}
+	view.loadDraft()
+}
+
+func (view *RoomView) currentDraft() *database.Draft {
+	draft := &database.Draft{
+		RoomID:     view.Room.ID,
+		ThreadRoot: view.threadRoot(),
+		Text:       view.input.GetText(),
+	}
+	if replying := view.replying.Load(); replying != nil {
+		draft.ReplyTo = replying.ID
+	}
+	if view.editing != nil {
+		draft.EditTarget = view.editing.ID
+	}
+	return draft
+}
+
+// saveDraft stores the current input text, reply target and edit target in the backend,
+// so the draft is restored when the room is opened again in any client.
+func (view *RoomView) saveDraft() {
+	draft := view.currentDraft()
+	view.draftText = draft.Text
+	existing := view.parent.matrix.GetDraft(view.Room.ID, draft.ThreadRoot)
+	if existing == nil && draft.IsEmpty() {
+		return
+	} else if existing != nil && existing.Text == draft.Text && existing.ReplyTo == draft.ReplyTo && existing.EditTarget == draft.EditTarget {
+		return
+	}
+	go view.syncDraft(draft)
+}
+
+func (view *RoomView) syncDraft(draft *database.Draft) {
+	defer debug.Recover()
+	err := view.parent.matrix.SetDraft(context.TODO(), draft)
+	if err != nil {
+		debug.Print("Failed to save draft:", err)
+	}
+}
+
+func (view *RoomView) inputChanged(text string) {
+	view.scheduleSpellCheck(text)
+	if !view.loadingDraft {
+		view.parent.InputChanged(view, text)
+	}
+}
+
+// Typing notifications are sent with a timeout and refreshed periodically while the user keeps typing.
+const (
+	TypingNotificationTimeout  = 10 * time.Second
+	TypingNotificationInterval = 5 * time.Second
+)
+
+// SetTyping marks the user as typing or not typing in the room. Notifications are rate limited,
+// and stopping is only sent if a typing notification was sent previously.
+func (view *RoomView) SetTyping(typing bool) {
+	if typing && time.Since(view.typingSentAt) > TypingNotificationInterval {
+		view.typingSentAt = time.Now()
+		go view.sendTyping(TypingNotificationTimeout)
+	} else if !typing && !view.typingSentAt.IsZero() {
+		view.typingSentAt = time.Time{}
+		go view.sendTyping(0)
+	}
+}
+
+func (view *RoomView) sendTyping(timeout time.Duration) {
+	defer debug.Recover()
+	if view.config.Preferences.DisableTypingNotifs || !view.Room.GetPreferences().SendTypingNotifications {
+		return
+	}
+	err := view.parent.matrix.SetTyping(context.TODO(), &jsoncmd.SetTypingParams{
+		RoomID:  view.Room.ID,
+		Timeout: int(timeout.Milliseconds()),
+	})
+	if err != nil {
+		debug.Print("Failed to send typing notification:", err)
+	}
 }
 
 func (view *RoomView) SetInputChangedFunc(fn func(room *RoomView, text string)) *RoomView {
@@ -152,7 +329,9 @@ func (view *RoomView) GetInputText() string {
 }
 
 func (view *RoomView) Focus() {
-	view.input.Focus()
+	if !view.normal.active {
+		view.input.Focus()
+	}
 }
 
 func (view *RoomView) Blur() {
@@ -163,13 +342,17 @@ func (view *RoomView) Blur() {
 type SelectReason string
 
 const (
-	SelectReply    SelectReason = "reply to"
-	SelectReact    SelectReason = "react to"
-	SelectRedact   SelectReason = "redact"
-	SelectEdit     SelectReason = "edit"
-	SelectDownload SelectReason = "download"
-	SelectOpen     SelectReason = "open"
-	SelectCopy     SelectReason = "copy"
+	SelectReply     SelectReason = "reply to"
+	SelectReact     SelectReason = "react to"
+	SelectRedact    SelectReason = "redact"
+	SelectEdit      SelectReason = "edit"
+	SelectDownload  SelectReason = "download"
+	SelectOpen      SelectReason = "open"
+	SelectCopy      SelectReason = "copy"
+	SelectVote      SelectReason = "vote in"
+	SelectEndPoll   SelectReason = "end poll"
+	SelectTranslate SelectReason = "translate"
+	SelectThread    SelectReason = "open thread of"
 )
 
 func (view *RoomView) StartSelecting(reason SelectReason, content string) {
@@ -197,7 +380,7 @@ func (view *RoomView) OnSelect(message *messages.UIMessage) {
 	}
 	switch view.selectReason {
 	case SelectReply:
-		view.replying = message.Event
+		view.replying.Store(message.Event)
 		if len(view.selectContent) > 0 {
 			go view.SendMessage(event.MsgText, view.selectContent)
 		}
@@ -207,17 +390,35 @@ func (view *RoomView) OnSelect(message *messages.UIMessage) {
 		go view.SendReaction(message.ID, view.selectContent)
 	case SelectRedact:
 		go view.Redact(message.ID, view.selectContent)
+	case SelectVote:
+		go view.VotePoll(message.Event, view.selectContent)
+	case SelectEndPoll:
+		go view.EndPoll(message.ID)
+	case SelectTranslate:
+		if htmlMsg, ok := message.Renderer.(*messages.HTMLMessage); ok && view.selectContent == "" && htmlMsg.ToggleTranslation() {
+			view.parent.parent.Render()
+		} else {
+			targetEvt := message.Event
+			if targetEvt.LastEditRef != nil {
+				targetEvt = targetEvt.LastEditRef
+			}
+			go view.Translate(targetEvt.ID, view.selectContent)
+		}
+	case SelectThread:
+		rootID := message.ID
+		if message.RelationType == event.RelThread {
+			rootID = message.RelatesTo
+		}
+		view.StopSelecting()
+		view.OpenThread(rootID)
 	case SelectDownload, SelectOpen:
-		//msg, ok := message.Renderer.(*messages.FileMessage)
-		//if ok {
-		//	path := ""
-		//	if len(view.selectContent) > 0 {
-		//		path = view.selectContent
-		//	} else if view.selectReason == SelectDownload {
-		//		path = msg.Body
-		//	}
-		//	go view.Download(msg.URL, msg.IsEncrypted, path, view.selectReason == SelectOpen)
-		//}
+		if msg, ok := message.Renderer.(*messages.FileMessage); ok {
+			path := view.selectContent
+			if path == "" && view.selectReason == SelectDownload {
+				path = msg.Filename
+			}
+			go view.Download(msg, path, view.selectReason == SelectOpen)
+		}
 	case SelectCopy:
 		go view.CopyToClipboard(message.Renderer.PlainText(), view.selectContent)
 	}
@@ -230,11 +431,14 @@ func (view *RoomView) OnSelect(message *messages.UIMessage) {
 func (view *RoomView) GetStatus() string {
 	var buf strings.Builder
 
+	if view.normal.active {
+		buf.WriteString(view.normalModeStatus())
+	}
 	if view.editing != nil {
 		buf.WriteString("Editing message - ")
-	} else if view.replying != nil {
+	} else if replying := view.replying.Load(); replying != nil {
 		buf.WriteString("Replying to ")
-		buf.WriteString(string(view.replying.Sender))
+		buf.WriteString(string(replying.Sender))
 		buf.WriteString(" - ")
 	} else if view.selecting {
 		buf.WriteString("Selecting message to ")
@@ -242,6 +446,17 @@ func (view *RoomView) GetStatus() string {
 		buf.WriteString(" - ")
 	}
 
+	if view.thread != nil {
+		buf.WriteString("Viewing thread - ")
+	}
+	if unreadThreads := view.Room.UnreadThreadCount(); unreadThreads > 0 {
+		_, _ = fmt.Fprintf(&buf, "New replies in %d thread(s) - ", unreadThreads)
+	}
+	if uploadStatus := view.getUploadStatus(); uploadStatus != "" {
+		buf.WriteString(uploadStatus)
+		buf.WriteString(" - ")
+	}
+
 	if len(view.completions.list) > 0 {
 		if view.completions.textCache != view.input.GetText() || view.completions.time.Add(10*time.Second).Before(time.Now()) {
 			view.completions.list = []string{}
@@ -251,9 +466,22 @@ func (view *RoomView) GetStatus() string {
 		}
 	}
 
-	typing := view.Room.Typing.Current()
+	if misspellings := view.getMisspellings(view.input.GetText()); len(misspellings) > 0 {
+		buf.WriteString("Misspelled: ")
+		for i, misspelling := range misspellings {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(misspelling.Word)
+		}
+		buf.WriteString(" - ")
+	}
+
+	typing := slices.DeleteFunc(slices.Clone(view.Room.Typing.Current()), func(userID id.UserID) bool {
+		return userID == view.parent.matrix.UserID
+	})
 	if len(typing) == 1 {
-		buf.WriteString("Typing: " + string(typing[0]))
+		buf.WriteString("Typing: " + view.Room.GetDisplayname(typing[0]))
 		buf.WriteString(" - ")
 	} else if len(typing) > 1 {
 		buf.WriteString("Typing: ")
@@ -263,7 +491,7 @@ func (view *RoomView) GetStatus() string {
 			} else if i > 0 {
 				buf.WriteString(", ")
 			}
-			buf.WriteString(string(userID))
+			buf.WriteString(view.Room.GetDisplayname(userID))
 		}
 		buf.WriteString(" - ")
 	}
@@ -272,10 +500,9 @@ func (view *RoomView) GetStatus() string {
 }
 
 // Constants defining the size of the room view grid.
+// The width and position of the user list are defined in the layout of the theme.
 const (
-	UserListBorderWidth   = 1
-	UserListWidth         = 20
-	StaticHorizontalSpace = UserListBorderWidth + UserListWidth
+	UserListBorderWidth = 1
 
 	TopicBarHeight  = 1
 	StatusBarHeight = 1
@@ -283,11 +510,32 @@ const (
 	MaxInputHeight = 5
 )
 
+// applyTheme sets the colors of the widgets in the room view from the current theme.
+func (view *RoomView) applyTheme() {
+	theme := config.CurrentTheme
+	view.input.
+		SetTextColor(theme.Text).
+		SetBackgroundColor(theme.Background).
+		SetPlaceholderTextColor(theme.Placeholder)
+	view.topic.
+		SetTextColor(theme.TopicText).
+		SetBackgroundColor(theme.TopicBackground)
+	view.status.
+		SetTextColor(theme.StatusBarText).
+		SetBackgroundColor(theme.StatusBarBackground)
+	if view.normal.searchInput != nil {
+		view.normal.searchInput.
+			SetTextColor(theme.StatusBarText).
+			SetBackgroundColor(theme.StatusBarBackground)
+	}
+}
+
 func (view *RoomView) Draw(screen mauview.Screen) {
 	width, height := screen.Size()
 	if width <= 0 || height <= 0 {
 		return
 	}
+	view.applySendResults()
 
 	if view.prevScreen != screen {
 		view.topicScreen.Parent = screen
@@ -306,8 +554,9 @@ func (view *RoomView) Draw(screen mauview.Screen) {
 	} else if inputHeight < 1 {
 		inputHeight = 1
 	}
+	layout := config.CurrentTheme.Layout
 	contentHeight := height - inputHeight - TopicBarHeight - StatusBarHeight
-	contentWidth := width - StaticHorizontalSpace
+	contentWidth := width - UserListBorderWidth - layout.MemberListWidth
 	if view.config.Preferences.HideUserList {
 		contentWidth = width
 	}
@@ -320,16 +569,31 @@ func (view *RoomView) Draw(screen mauview.Screen) {
 	view.inputScreen.Width = width
 	view.inputScreen.OffsetY = view.statusScreen.YEnd()
 	view.inputScreen.Height = inputHeight
-	view.ulBorderScreen.OffsetX = view.contentScreen.XEnd()
 	view.ulBorderScreen.Height = contentHeight
-	view.ulScreen.OffsetX = view.ulBorderScreen.XEnd()
+	view.ulScreen.Width = layout.MemberListWidth
 	view.ulScreen.Height = contentHeight
+	if layout.MemberListPosition == config.PositionLeft && !view.config.Preferences.HideUserList {
+		view.ulScreen.OffsetX = 0
+		view.ulBorderScreen.OffsetX = view.ulScreen.XEnd()
+		view.contentScreen.OffsetX = view.ulBorderScreen.XEnd()
+	} else {
+		view.contentScreen.OffsetX = 0
+		view.ulBorderScreen.OffsetX = view.contentScreen.XEnd()
+		view.ulScreen.OffsetX = view.ulBorderScreen.XEnd()
+	}
 
 	// Draw everything
+	if view.thread != nil {
+		view.topic.SetText(view.getThreadTopic())
+	}
 	view.topic.Draw(view.topicScreen)
-	view.content.Draw(view.contentScreen)
-	view.status.SetText(view.GetStatus())
-	view.status.Draw(view.statusScreen)
+	view.MessageView().Draw(view.contentScreen)
+	if view.normal.searching {
+		view.drawSearchInput()
+	} else {
+		view.status.SetText(view.GetStatus())
+		view.status.Draw(view.statusScreen)
+	}
 	view.input.Draw(view.inputScreen)
 	if !view.config.Preferences.HideUserList {
 		view.ulBorder.Draw(view.ulBorderScreen)
@@ -340,8 +604,12 @@ func (view *RoomView) Draw(screen mauview.Screen) {
 func (view *RoomView) ClearAllContext() {
 	view.SetEditing(nil)
 	view.StopSelecting()
-	view.replying = nil
-	view.input.Focus()
+	view.replying.Store(nil)
+	if view.normal.active {
+		view.ExitNormalMode(false)
+	} else {
+		view.input.Focus()
+	}
 }
 
 func (view *RoomView) OnKeyEvent(event mauview.KeyEvent) bool {
@@ -352,7 +620,9 @@ func (view *RoomView) OnKeyEvent(event mauview.KeyEvent) bool {
 		Mod: event.Modifiers(),
 	}
 
-	if view.selecting {
+	if view.normal.active && view.onNormalModeKey(event, kb) {
+		return true
+	} else if view.selecting {
 		switch view.config.Keybindings.Visual[kb] {
 		case "clear":
 			view.ClearAllContext()
@@ -370,11 +640,16 @@ func (view *RoomView) OnKeyEvent(event mauview.KeyEvent) bool {
 
 	switch view.config.Keybindings.Room[kb] {
 	case "clear":
-		view.ClearAllContext()
+		if view.thread != nil && view.editing == nil && view.replying.Load() == nil {
+			view.CloseThread()
+			view.parent.MarkRead(view)
+		} else {
+			view.ClearAllContext()
+		}
 		return true
 	case "scroll_up":
 		if msgView.IsAtTop() {
-			go view.parent.LoadHistory(view.Room.ID)
+			go msgView.LoadHistory()
 		}
 		msgView.AddScrollOffset(+msgView.Height() / 2)
 		return true
@@ -384,18 +659,33 @@ func (view *RoomView) OnKeyEvent(event mauview.KeyEvent) bool {
 	case "send":
 		view.InputSubmit(view.input.GetText())
 		return true
+	case "normal_mode":
+		view.EnterNormalMode()
+		return true
+	}
+	if view.normal.active {
+		return false
 	}
 	return view.input.OnKeyEvent(event)
 }
 
 func (view *RoomView) OnPasteEvent(event mauview.PasteEvent) bool {
+	if view.input.GetText() == "" {
+		// Terminals usually paste dragged-and-dropped files as quoted paths or file URIs.
+		path := strings.Trim(strings.TrimSpace(event.Text()), `'"`)
+		path = expandHome(strings.TrimPrefix(path, "file://"))
+		if info, err := os.Stat(path); err == nil && !info.IsDir() && filepath.IsAbs(path) {
+			view.parent.ShowModal(NewUploadModal(view, path))
+			return true
+		}
+	}
 	return view.input.OnPasteEvent(event)
 }
 
 func (view *RoomView) OnMouseEvent(event mauview.MouseEvent) bool {
 	switch {
 	case view.contentScreen.IsInArea(event.Position()):
-		return view.content.OnMouseEvent(view.contentScreen.OffsetMouseEvent(event))
+		return view.MessageView().OnMouseEvent(view.contentScreen.OffsetMouseEvent(event))
 	case view.topicScreen.IsInArea(event.Position()):
 		return view.topic.OnMouseEvent(view.topicScreen.OffsetMouseEvent(event))
 	case view.inputScreen.IsInArea(event.Position()):
@@ -503,7 +793,7 @@ func (view *RoomView) EditNext() {
 }
 
 func (view *RoomView) EditPrevious() {
-	if view.replying != nil {
+	if view.replying.Load() != nil {
 		return
 	}
 	foundMsg := view.findMessage(view.editing, false, view.filterOwnOnly)
@@ -513,32 +803,11 @@ func (view *RoomView) EditPrevious() {
 }
 
 func (view *RoomView) SelectNext() {
-	//msgView := view.MessageView()
-	//if msgView.selected == 0 {
-	//	return
-	//}
-	//var filter findFilter
-	//if view.selectReason == SelectDownload || view.selectReason == SelectOpen {
-	//	filter = view.filterMediaOnly
-	//}
-	//foundMsg := view.findMessage(msgView.selected.GetEvent(), true, filter)
-	//if foundMsg != nil {
-	//	msgView.SetSelected(foundMsg)
-	//	// TODO scroll selected message into view
-	//}
+	view.MessageView().MoveSelection(1)
 }
 
 func (view *RoomView) SelectPrevious() {
-	//msgView := view.MessageView()
-	//var filter findFilter
-	//if view.selectReason == SelectDownload || view.selectReason == SelectOpen {
-	//	filter = view.filterMediaOnly
-	//}
-	//foundMsg := view.findMessage(msgView.selected.GetEvent(), false, filter)
-	//if foundMsg != nil {
-	//	msgView.SetSelected(foundMsg)
-	//	// TODO scroll selected message into view
-	//}
+	view.MessageView().MoveSelection(-1)
 }
 
 type completion struct {
@@ -576,41 +845,149 @@ func (view *RoomView) AutocompleteRoom(existingText string) (completions []compl
 	return
 }
 
+func emojiToMarkdown(shortcode string, image *database.ImagePackImage) string {
+	name := ":" + shortcode + ":"
+	title := name
+	if image.Body != "" && image.Body != shortcode {
+		title = image.Body
+	}
+	return fmt.Sprintf("![%s](%s %q)", name, image.URL, "Emoji: "+title)
+}
+
+type packImage struct {
+	Pack      *jsoncmd.ImagePackInfo
+	Shortcode string
+	Image     *database.ImagePackImage
+}
+
+// getImagePacks returns the image packs available in the room. The packs are cached in the store
+// until they change, so the backend is only asked after a relevant state or account data change.
+func (view *RoomView) getImagePacks() ([]*jsoncmd.ImagePackInfo, error) {
+	if packs, ok := view.parent.matrix.GetCachedImagePacks(view.Room.ID); ok {
+		return packs, nil
+	}
+	generation := view.parent.matrix.CompletionCacheGeneration()
+	packs, err := view.parent.matrix.GetImagePacks(context.TODO(), &jsoncmd.GetImagePacksParams{RoomID: view.Room.ID})
+	if err != nil {
+		return nil, err
+	}
+	view.parent.matrix.CacheImagePacks(view.Room.ID, packs, generation)
+	return packs, nil
+}
+
+// getPackImages returns the images with the given usage from all image packs available in the room.
+// If multiple packs contain the same shortcode, only the first one is included.
+func (view *RoomView) getPackImages(usage database.ImagePackUsage) map[string]*packImage {
+	packs, err := view.getImagePacks()
+	if err != nil {
+		debug.Print("Failed to get image packs:", err)
+		return nil
+	}
+	images := make(map[string]*packImage)
+	for _, pack := range packs {
+		for shortcode, image := range pack.Content.Images {
+			if _, alreadyAdded := images[shortcode]; !alreadyAdded && pack.Content.ImageHasUsage(image, usage) {
+				images[shortcode] = &packImage{Pack: pack, Shortcode: shortcode, Image: image}
+			}
+		}
+	}
+	return images
+}
+
+// AutocompleteEmoji completes custom emoji shortcodes from the image packs available in the room.
+// If there's only one match, the returned list contains the markdown for the emoji instead of the shortcode.
 func (view *RoomView) AutocompleteEmoji(word string) (completions []string) {
-	//if word[0] != ':' {
-	//	return
-	//}
-	//var valueCompletion1 string
-	//var manyValues bool
-	//for name, value := range emoji.CodeMap() {
-	//	if name == word {
-	//		return []string{value}
-	//	} else if strings.HasPrefix(name, word) {
-	//		completions = append(completions, name)
-	//		if valueCompletion1 == "" {
-	//			valueCompletion1 = value
-	//		} else if valueCompletion1 != value {
-	//			manyValues = true
-	//		}
-	//	}
-	//}
-	//if !manyValues && len(completions) > 0 {
-	//	return []string{emoji.CodeMap()[completions[0]]}
-	//}
+	if len(word) < 2 || word[0] != ':' {
+		return
+	}
+	images := view.getPackImages(database.ImagePackUsageEmoticon)
+	if exactMatch, ok := images[strings.Trim(word, ":")]; ok && strings.HasSuffix(word, ":") {
+		return []string{emojiToMarkdown(exactMatch.Shortcode, exactMatch.Image)}
+	}
+	var lastMatch *packImage
+	for shortcode, image := range images {
+		if name := ":" + shortcode + ":"; strings.HasPrefix(name, word) {
+			completions = append(completions, name)
+			lastMatch = image
+		}
+	}
+	if len(completions) == 1 {
+		return []string{emojiToMarkdown(lastMatch.Shortcode, lastMatch.Image)}
+	}
 	return
 }
 
-//func findWordToTabComplete(text string) string {
-//	output := ""
-//	runes := []rune(text)
-//	for i := len(runes) - 1; i >= 0; i-- {
-//		if unicode.IsSpace(runes[i]) {
-//			break
-//		}
-//		output = string(runes[i]) + output
-//	}
-//	return output
-//}
+// AutocompleteSticker completes sticker shortcodes for the /sticker command.
+func (view *RoomView) AutocompleteSticker(word string) (completions []string) {
+	for shortcode := range view.getPackImages(database.ImagePackUsageSticker) {
+		if strings.HasPrefix(shortcode, word) {
+			completions = append(completions, shortcode)
+		}
+	}
+	return
+}
+
+// AutocompleteSnippet completes snippet names for the /snippet command.
+func (view *RoomView) AutocompleteSnippet(word string) (completions []string) {
+	ad := view.parent.matrix.GetAccountData(jsoncmd.AccountDataSnippets)
+	if ad == nil {
+		return
+	}
+	var snippets jsoncmd.Snippets
+	if err := json.Unmarshal(ad.Content, &snippets); err != nil {
+		debug.Print("Failed to parse snippets:", err)
+		return
+	}
+	for name := range snippets.Snippets {
+		if strings.HasPrefix(name, word) {
+			completions = append(completions, name)
+		}
+	}
+	return
+}
+
+// AutocompleteCommand completes command names and arguments using the parameter schemas known by the backend.
+// The returned start index is the byte offset in the text where the completed word begins.
+// Responses are cached in the store until the room's commands or members change.
+func (view *RoomView) AutocompleteCommand(text string) (start int, completions []string) {
+	resp := view.parent.matrix.GetCachedCommandCompletion(view.Room.ID, text)
+	if resp == nil {
+		generation := view.parent.matrix.CompletionCacheGeneration()
+		var err error
+		resp, err = view.parent.matrix.CompleteCommand(context.TODO(), &jsoncmd.CompleteCommandParams{
+			RoomID: view.Room.ID,
+			Text:   text,
+		})
+		if err != nil {
+			debug.Print("Failed to complete command:", err)
+			return len(text), nil
+		}
+		view.parent.matrix.CacheCommandCompletion(view.Room.ID, text, resp, generation)
+	}
+	for _, item := range resp.Completions {
+		completions = append(completions, item.Value)
+	}
+	if resp.Command == nil {
+		for _, cmd := range LocalCommands {
+			if strings.HasPrefix(cmd.Command, text[resp.Start:]) {
+				completions = append(completions, cmd.Command)
+			}
+		}
+	}
+	return resp.Start, completions
+}
+
+func findWordToTabComplete(text string) string {
+	output := ""
+	runes := []rune(text)
+	for i := len(runes) - 1; i >= 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			break
+		}
+		output = string(runes[i]) + output
+	}
+	return output
+}
 
 //var (
 //	mentionMarkdown  = "[%[1]s](https://matrix.to/#/%[2]s)"
@@ -652,41 +1029,126 @@ func (view *RoomView) AutocompleteEmoji(word string) (completions []string) {
 //	return
 //}
 
+// spellCheckDelay is how long to wait after the last keypress before spell checking the input.
+const spellCheckDelay = 500 * time.Millisecond
+
+func (view *RoomView) scheduleSpellCheck(text string) {
+	if view.config.Preferences.DisableSpellCheck {
+		return
+	}
+	view.spellCheck.lock.Lock()
+	defer view.spellCheck.lock.Unlock()
+	if view.spellCheck.failed {
+		return
+	} else if view.spellCheck.timer != nil {
+		view.spellCheck.timer.Stop()
+	}
+	view.spellCheck.timer = time.AfterFunc(spellCheckDelay, func() {
+		view.runSpellCheck(text)
+	})
+}
+
+func (view *RoomView) runSpellCheck(text string) {
+	defer debug.Recover()
+	var misspellings []*jsoncmd.Misspelling
+	if strings.TrimSpace(text) != "" {
+		resp, err := view.parent.matrix.SpellCheck(context.TODO(), &jsoncmd.SpellCheckParams{Text: text})
+		if err != nil {
+			// Most likely there are no dictionaries installed, so don't keep retrying on every keypress
+			debug.Print("Failed to spell check input, disabling spell check for room:", err)
+			view.spellCheck.lock.Lock()
+			view.spellCheck.failed = true
+			view.spellCheck.lock.Unlock()
+			return
+		}
+		misspellings = resp.Misspellings
+	}
+	view.spellCheck.lock.Lock()
+	view.spellCheck.text = text
+	view.spellCheck.misspellings = misspellings
+	view.spellCheck.lock.Unlock()
+	view.parent.parent.Render()
+}
+
+// getMisspellings returns the spell check results for the given text, or nil if the input has changed since.
+func (view *RoomView) getMisspellings(text string) []*jsoncmd.Misspelling {
+	view.spellCheck.lock.Lock()
+	defer view.spellCheck.lock.Unlock()
+	if view.spellCheck.text != text {
+		return nil
+	}
+	return view.spellCheck.misspellings
+}
+
+// tabCompleteSpelling replaces a misspelled word ending at the cursor with the best suggestion
+// and shows the other suggestions in the status bar.
+func (view *RoomView) tabCompleteSpelling(text, beforeCursor string) bool {
+	for _, misspelling := range view.getMisspellings(text) {
+		if misspelling.End != len(beforeCursor) || len(misspelling.Suggestions) == 0 {
+			continue
+		}
+		view.input.SetTextAndMoveCursor(beforeCursor[:misspelling.Start] + misspelling.Suggestions[0] + text[len(beforeCursor):])
+		view.SetCompletions(misspelling.Suggestions)
+		return true
+	}
+	return false
+}
+
 func (view *RoomView) InputTabComplete(text string, cursorOffset int) {
-	//if len(text) == 0 {
-	//	return
-	//}
-	//
-	//str := runewidth.Truncate(text, cursorOffset, "")
-	//word := findWordToTabComplete(str)
-	//startIndex := len(str) - len(word)
-	//
-	//var strCompletion string
-	//
-	//strCompletions, newText, ok := view.parent.cmdProcessor.Autocomplete(view, text, cursorOffset)
-	//if !ok {
-	//	strCompletions, strCompletion = view.defaultAutocomplete(word, startIndex)
-	//}
-	//
-	//if len(strCompletions) > 0 {
-	//	strCompletion = exstrings.LongestCommonPrefix(strCompletions)
-	//	sort.Sort(sort.StringSlice(strCompletions))
-	//}
-	//if len(strCompletion) > 0 && len(strCompletions) < 2 {
-	//	strCompletion += " "
-	//	strCompletions = []string{}
-	//}
-	//
-	//if len(strCompletion) > 0 && newText == text {
-	//	newText = str[0:startIndex] + strCompletion + text[len(str):]
-	//}
-	//
-	//view.input.SetTextAndMoveCursor(newText)
-	//view.SetCompletions(strCompletions)
+	if len(text) == 0 {
+		return
+	}
+
+	str := runewidth.Truncate(text, cursorOffset, "")
+	word := findWordToTabComplete(str)
+	startIndex := len(str) - len(word)
+
+	var strCompletion string
+	var strCompletions []string
+	switch strings.TrimRight(str[:startIndex], " ") {
+	case "/" + CmdUpload:
+		strCompletions = completePath(word)
+	case "/" + CmdSticker:
+		strCompletions = view.AutocompleteSticker(word)
+	case "/" + cmdspec.Snippet:
+		strCompletions = view.AutocompleteSnippet(word)
+	default:
+		if strings.HasPrefix(str, "/") {
+			startIndex, strCompletions = view.AutocompleteCommand(str)
+			break
+		}
+		if view.tabCompleteSpelling(text, str) {
+			return
+		}
+		strCompletions = view.AutocompleteEmoji(word)
+	}
+	if len(strCompletions) == 1 {
+		strCompletion = strCompletions[0]
+		if !strings.HasSuffix(strCompletion, string(os.PathSeparator)) {
+			strCompletion += " "
+		}
+		strCompletions = []string{}
+	} else if len(strCompletions) > 1 {
+		strCompletion = exstrings.LongestCommonPrefix(strCompletions)
+		slices.Sort(strCompletions)
+	}
+
+	if len(strCompletion) > 0 {
+		view.input.SetTextAndMoveCursor(str[0:startIndex] + strCompletion + text[len(str):])
+	}
+	view.SetCompletions(strCompletions)
 }
 
 func (view *RoomView) InputSubmit(text string) {
 	if len(text) == 0 {
+		view.send.lock.Lock()
+		params := view.send.overrideUpload
+		view.send.overrideUpload = nil
+		view.send.lock.Unlock()
+		if params != nil {
+			params.Override = true
+			go view.sendUpload(params)
+		}
 		return
 	} else if cmd, err := view.ParseCommand(text); err != nil {
 		view.Room.ApplyPending(database.MakeFakeEvent(view.Room.ID,
@@ -698,9 +1160,32 @@ func (view *RoomView) InputSubmit(text string) {
 		go view.SendMessage(event.MsgText, text)
 	}
 	view.editMoveText = ""
+	// The draft is only cleared after the message is sent successfully (see applySendResults)
 	view.SetInputText("")
 }
 
+// messageSent is called from sending goroutines after a message was sent successfully.
+func (view *RoomView) messageSent() {
+	view.send.lock.Lock()
+	view.send.succeeded = true
+	view.send.lock.Unlock()
+}
+
+// applySendResults applies the results of messages sent in the background. It must be called on the UI goroutine.
+func (view *RoomView) applySendResults() {
+	view.send.lock.Lock()
+	restoreText, restoreReply, succeeded := view.send.restoreText, view.send.restoreReply, view.send.succeeded
+	view.send.restoreText, view.send.restoreReply, view.send.succeeded = "", nil, false
+	view.send.lock.Unlock()
+	if restoreText != "" && view.input.GetText() == "" {
+		view.SetInputText(restoreText)
+		view.replying.Store(restoreReply)
+	} else if succeeded {
+		// Saving the current input clears the draft, or replaces it if the user already typed something new
+		view.saveDraft()
+	}
+}
+
 func (view *RoomView) CopyToClipboard(text string, register string) {
 	if register == "clipboard" || register == "primary" {
 		err := clipboard.WriteAll(text, register)
@@ -714,19 +1199,40 @@ func (view *RoomView) CopyToClipboard(text string, register string) {
 	}
 }
 
-func (view *RoomView) Download(url id.ContentURI, file *attachment.EncryptedFile, filename string, openFile bool) {
-	//path, err := view.parent.matrix.DownloadToDisk(url, file, filename)
-	//if err != nil {
-	//	view.AddServiceMessage(fmt.Sprintf("Failed to download media: %v", err))
-	//	view.parent.parent.Render()
-	//	return
-	//}
-	//view.AddServiceMessage(fmt.Sprintf("File downloaded to %s", path))
-	//view.parent.parent.Render()
-	//if openFile {
-	//	debug.Print("Opening file", path)
-	//	open.Open(path)
-	//}
+// Download saves the file in the given message to disk. If the path is empty, the file is saved to a temporary
+// directory. If openFile is true, the file is opened with the default application for the file type afterwards.
+func (view *RoomView) Download(msg *messages.FileMessage, path string, openFile bool) {
+	defer debug.Recover()
+	data, err := view.parent.matrix.Download(msg.URL, msg.IsEncrypted)
+	if err != nil {
+		view.AddServiceMessage("Failed to download media: %v", err)
+		view.parent.parent.Render()
+		return
+	}
+	if path == "" {
+		var file *os.File
+		file, err = os.CreateTemp("", "gomuks-*-"+filepath.Base(msg.Filename))
+		if err == nil {
+			path = file.Name()
+			_, err = file.Write(data)
+			_ = file.Close()
+		}
+	} else {
+		path = expandHome(path)
+		err = os.WriteFile(path, data, 0600)
+	}
+	if err != nil {
+		view.AddServiceMessage("Failed to save media: %v", err)
+		view.parent.parent.Render()
+		return
+	}
+	if openFile {
+		debug.Print("Opening file", path)
+		_ = open.Open(path)
+	} else {
+		view.AddServiceMessage("File downloaded to %s", path)
+		view.parent.parent.Render()
+	}
 }
 
 func (view *RoomView) Redact(eventID id.EventID, reason string) {
@@ -742,6 +1248,192 @@ func (view *RoomView) Redact(eventID id.EventID, reason string) {
 	}
 }
 
+func (view *RoomView) VotePoll(evt *database.Event, answerNumbers string) {
+	defer debug.Recover()
+	poll := evt.GetPollStart()
+	if poll == nil {
+		view.AddServiceMessage("Selected message is not a poll")
+		view.parent.parent.Render()
+		return
+	}
+	answers := make([]string, 0)
+	for _, field := range strings.FieldsFunc(answerNumbers, func(r rune) bool {
+		return r == ',' || r == ' '
+	}) {
+		index, err := strconv.Atoi(field)
+		if err != nil || index < 1 || index > len(poll.Answers) {
+			view.AddServiceMessage("Invalid answer number %q", field)
+			view.parent.parent.Render()
+			return
+		}
+		answers = append(answers, poll.Answers[index-1].ID)
+	}
+	_, err := view.parent.matrix.VotePoll(context.TODO(), &jsoncmd.VotePollParams{
+		RoomID:  view.Room.ID,
+		EventID: evt.ID,
+		Answers: answers,
+	})
+	if err != nil {
+		view.AddServiceMessage("Failed to vote in poll: %v", err)
+		view.parent.parent.Render()
+	}
+}
+
+func (view *RoomView) EndPoll(eventID id.EventID) {
+	defer debug.Recover()
+	_, err := view.parent.matrix.EndPoll(context.TODO(), &jsoncmd.EndPollParams{
+		RoomID:  view.Room.ID,
+		EventID: eventID,
+	})
+	if err != nil {
+		view.AddServiceMessage("Failed to end poll: %v", err)
+		view.parent.parent.Render()
+	}
+}
+
+func (view *RoomView) Translate(eventID id.EventID, language string) {
+	defer debug.Recover()
+	_, err := view.parent.matrix.TranslateEvent(context.TODO(), &jsoncmd.TranslateEventParams{
+		RoomID:   view.Room.ID,
+		EventID:  eventID,
+		Language: language,
+	})
+	if err != nil {
+		view.AddServiceMessage("Failed to translate message: %v", err)
+		view.parent.parent.Render()
+	}
+}
+
+func (view *RoomView) SendSticker(shortcode string) {
+	defer debug.Recover()
+	shortcode = strings.Trim(shortcode, ":")
+	sticker, ok := view.getPackImages(database.ImagePackUsageSticker)[shortcode]
+	if !ok {
+		view.AddServiceMessage("Sticker %q not found", shortcode)
+		view.parent.parent.Render()
+		return
+	}
+	relatesTo := view.makeRelatesTo(view.replying.Swap(nil))
+	_, err := view.parent.matrix.SendSticker(context.TODO(), &jsoncmd.SendStickerParams{
+		RoomID:     view.Room.ID,
+		PackRoomID: sticker.Pack.RoomID,
+		PackID:     sticker.Pack.PackID,
+		Shortcode:  sticker.Shortcode,
+		RelatesTo:  relatesTo,
+	})
+	if err != nil {
+		view.AddServiceMessage("Failed to send sticker: %v", err)
+	}
+	view.parent.parent.Render()
+}
+
+// AddPackImage adds an image to the user's personal image pack. The path can either be
+// a local file, which is uploaded first, or the mxc:// URI of an already uploaded image.
+func (view *RoomView) AddPackImage(shortcode, path string) {
+	defer debug.Recover()
+	shortcode = strings.Trim(shortcode, ":")
+	image := &database.ImagePackImage{Body: shortcode}
+	if strings.HasPrefix(path, "mxc://") {
+		image.URL = id.ContentURIString(path)
+	} else {
+		path = expandHome(path)
+		name := filepath.Base(path)
+		view.setUploadStatus(fmt.Sprintf("Uploading %s", name))
+		content, err := view.parent.matrix.Upload(context.TODO(), &jsoncmd.UploadMediaParams{Path: path}, func(progress float64) {
+			view.setUploadStatus(fmt.Sprintf("Uploading %s: %d%%", name, int(progress*100)))
+		})
+		view.setUploadStatus("")
+		if err != nil {
+			view.AddServiceMessage("Failed to upload %s: %v", name, err)
+			view.parent.parent.Render()
+			return
+		}
+		image.URL = content.URL
+		image.Info = content.Info
+	}
+	err := view.parent.matrix.SetPackImage(context.TODO(), &jsoncmd.SetPackImageParams{Shortcode: shortcode, Image: image})
+	if err != nil {
+		view.AddServiceMessage("Failed to add :%s: to your image pack: %v", shortcode, err)
+	} else {
+		view.AddServiceMessage("Added :%s: to your image pack", shortcode)
+	}
+	view.parent.parent.Render()
+}
+
+// StartUpload uploads the file at the given path, or opens the upload dialog if no path is given.
+func (view *RoomView) StartUpload(path, caption string) {
+	if path == "" {
+		view.parent.ShowModal(NewUploadModal(view, ""))
+		view.parent.parent.Render()
+		return
+	}
+	path = expandHome(path)
+	if info, err := os.Stat(path); err != nil {
+		view.AddServiceMessage("Failed to read %s: %v", path, err)
+		view.parent.parent.Render()
+		return
+	} else if info.IsDir() {
+		view.AddServiceMessage("%s is a directory", path)
+		view.parent.parent.Render()
+		return
+	}
+	go view.Upload(&jsoncmd.UploadMediaParams{
+		Path:    path,
+		Encrypt: view.Room.Meta.Current().EncryptionEvent != nil,
+	}, caption)
+}
+
+func (view *RoomView) setUploadStatus(status string) {
+	view.upload.lock.Lock()
+	view.upload.status = status
+	view.upload.lock.Unlock()
+	view.parent.parent.Render()
+}
+
+func (view *RoomView) getUploadStatus() string {
+	view.upload.lock.Lock()
+	defer view.upload.lock.Unlock()
+	return view.upload.status
+}
+
+// Upload uploads a file from the local disk and sends it to the room with the given caption.
+func (view *RoomView) Upload(params *jsoncmd.UploadMediaParams, caption string) {
+	defer debug.Recover()
+	relatesTo := view.makeRelatesTo(view.replying.Swap(nil))
+	name := filepath.Base(params.Path)
+	view.setUploadStatus(fmt.Sprintf("Uploading %s", name))
+	content, err := view.parent.matrix.Upload(context.TODO(), params, func(progress float64) {
+		view.setUploadStatus(fmt.Sprintf("Uploading %s: %d%%", name, int(progress*100)))
+	})
+	view.setUploadStatus("")
+	if err != nil {
+		view.AddServiceMessage("Failed to upload %s: %v", name, err)
+		view.parent.parent.Render()
+		return
+	}
+	view.sendUpload(&jsoncmd.SendMessageParams{
+		RoomID:      view.Room.ID,
+		BaseContent: content,
+		Text:        caption,
+		RelatesTo:   relatesTo,
+	})
+}
+
+func (view *RoomView) sendUpload(params *jsoncmd.SendMessageParams) {
+	defer debug.Recover()
+	err := view.parent.matrix.SendMessage(context.TODO(), params)
+	if err != nil && strings.HasPrefix(err.Error(), jsoncmd.ErrCodeSecretDetected) {
+		view.send.lock.Lock()
+		view.send.overrideUpload = params
+		view.send.lock.Unlock()
+		view.AddServiceMessage("File not sent: %s. Press enter with an empty input to send it anyway.",
+			strings.TrimPrefix(err.Error(), jsoncmd.ErrCodeSecretDetected+": "))
+	} else if err != nil {
+		view.AddServiceMessage("Failed to send %s: %v", params.BaseContent.Body, err)
+	}
+	view.parent.parent.Render()
+}
+
 func (view *RoomView) SendReaction(eventID id.EventID, reaction string) {
 	defer debug.Recover()
 	reaction = variationselector.Add(strings.TrimSpace(reaction))
@@ -764,11 +1456,12 @@ func (view *RoomView) SendReaction(eventID id.EventID, reaction string) {
 
 func (view *RoomView) SendMessage(msgtype event.MessageType, text string) {
 	defer debug.Recover()
-	var relatesTo *event.RelatesTo
-	if view.replying != nil {
-		relatesTo = (&event.RelatesTo{}).SetReplyTo(view.replying.ID)
-		view.replying = nil
-	}
+	replying := view.replying.Swap(nil)
+	relatesTo := view.makeRelatesTo(replying)
+	view.send.lock.Lock()
+	override := view.send.overrideText != "" && view.send.overrideText == text
+	view.send.overrideText = ""
+	view.send.lock.Unlock()
 	err := view.parent.matrix.SendMessage(context.TODO(), &jsoncmd.SendMessageParams{
 		RoomID:      view.Room.ID,
 		BaseContent: nil,
@@ -777,10 +1470,21 @@ func (view *RoomView) SendMessage(msgtype event.MessageType, text string) {
 		RelatesTo:   relatesTo,
 		Mentions:    nil,
 		URLPreviews: nil,
+		Override:    override,
 	})
-	if err != nil {
+	if err != nil && strings.HasPrefix(err.Error(), jsoncmd.ErrCodeSecretDetected) {
+		view.send.lock.Lock()
+		view.send.overrideText = text
+		view.send.restoreText = text
+		view.send.restoreReply = replying
+		view.send.lock.Unlock()
+		view.AddServiceMessage("Message not sent: %s. Press enter again to send it anyway.",
+			strings.TrimPrefix(err.Error(), jsoncmd.ErrCodeSecretDetected+": "))
+	} else if err != nil {
 		debug.Print("Failed to send message:", err)
 		view.AddServiceMessage("Failed to send message: %v", err)
+	} else {
+		view.messageSent()
 	}
 	debug.Print("Rendering after sending message")
 	view.parent.parent.Render()
@@ -797,7 +1501,83 @@ func (view *RoomView) SendMessageHTML(msgtype event.MessageType, text, html stri
 	//view.addLocalEcho(evt)
 }
 
+// makeRelatesTo returns the relation for a new message, which includes the open thread and the reply target.
+func (view *RoomView) makeRelatesTo(replyTo *database.Event) *event.RelatesTo {
+	thread := view.thread
+	if thread == nil && replyTo == nil {
+		return nil
+	}
+	relatesTo := &event.RelatesTo{}
+	if replyTo != nil {
+		relatesTo.SetReplyTo(replyTo.ID)
+	}
+	if thread != nil {
+		// Clients without thread support will see the message as a reply to the latest event in the thread
+		fallbackID := thread.RootID
+		for _, evt := range slices.Backward(ptr.Val(thread.Timeline.Current())) {
+			if strings.HasPrefix(evt.ID.String(), "$") && evt.Sender != cmdspec.FakeGomuksSender {
+				fallbackID = evt.ID
+				break
+			}
+		}
+		relatesTo.SetThread(thread.RootID, fallbackID)
+	}
+	return relatesTo
+}
+
+// OpenThread replaces the room timeline with the timeline of the given thread.
+func (view *RoomView) OpenThread(rootID id.EventID) {
+	if view.thread != nil {
+		if view.thread.RootID == rootID {
+			return
+		}
+		view.CloseThread()
+	}
+	view.SetEditing(nil)
+	view.saveDraft()
+	view.replying.Store(nil)
+	view.input.SetText("")
+	view.thread = view.Room.OpenThread(rootID)
+	view.threadView = NewThreadMessageView(view, view.thread)
+	view.unlistenThread = view.thread.Timeline.Listen(func(_ *[]*database.Event) {
+		view.parent.parent.NeedsRender = true
+	})
+	view.loadDraft()
+	go view.parent.LoadThreadHistory(view.thread)
+	view.parent.parent.Render()
+}
+
+// CloseThread returns to the room timeline if a thread is open.
+func (view *RoomView) CloseThread() {
+	if view.thread == nil {
+		return
+	}
+	view.saveDraft()
+	view.replying.Store(nil)
+	view.input.SetText("")
+	view.unlistenThread()
+	view.Room.CloseThread(view.thread.RootID)
+	view.thread = nil
+	view.threadView = nil
+	view.unlistenThread = nil
+	view.loadDraft()
+	view.Update(view.Room.Meta.Current())
+}
+
+func (view *RoomView) getThreadTopic() string {
+	root := view.thread.GetRoot()
+	if root == nil {
+		return "Thread"
+	}
+	body := root.GetMautrixContent().AsMessage().Body
+	body, _, _ = strings.Cut(strings.TrimSpace(body), "\n")
+	return fmt.Sprintf("Thread: %s: %s", view.Room.GetDisplayname(root.Sender), body)
+}
+
 func (view *RoomView) MessageView() *MessageView {
+	if view.threadView != nil {
+		return view.threadView
+	}
 	return view.content
 }
 
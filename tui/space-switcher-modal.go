@@ -0,0 +1,124 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2026 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.mau.fi/mauview"
+
+	"go.mau.fi/gomuks/pkg/rpc/store"
+	"go.mau.fi/gomuks/tui/config"
+)
+
+type SpaceSwitcherModal struct {
+	mauview.Component
+
+	container *mauview.Box
+	results   *mauview.TextView
+
+	// The first entry is nil, which represents all rooms.
+	spaces   []*store.SpaceEntry
+	selected int
+
+	parent *MainView
+}
+
+func NewSpaceSwitcherModal(mainView *MainView, width int, height int) *SpaceSwitcherModal {
+	ss := &SpaceSwitcherModal{
+		parent: mainView,
+		spaces: append([]*store.SpaceEntry{nil}, mainView.matrix.GetSpaceTree()...),
+	}
+	currentSpace := mainView.roomList.SelectedSpace()
+	ss.selected = max(0, slices.IndexFunc(ss.spaces, func(entry *store.SpaceEntry) bool {
+		return entry != nil && entry.RoomID == currentSpace
+	}))
+
+	ss.results = mauview.NewTextView().SetRegions(true)
+	for i, space := range ss.spaces {
+		_, _ = fmt.Fprintf(ss.results, `["%d"]%s[""]%s`, i, mauview.Escape(describeSpace(space)), "\n")
+	}
+	ss.results.Highlight(strconv.Itoa(ss.selected))
+	ss.results.ScrollToHighlight()
+
+	ss.container = mauview.NewBox(ss.results).
+		SetBorder(true).
+		SetTitle("Switch Space").
+		SetBlurCaptureFunc(func() bool {
+			ss.parent.HideModal()
+			return true
+		})
+
+	ss.Component = mauview.Center(ss.container, width, height).SetAlwaysFocusChild(true)
+
+	return ss
+}
+
+func describeSpace(space *store.SpaceEntry) string {
+	if space == nil {
+		return "All rooms"
+	}
+	var buf strings.Builder
+	buf.WriteString(strings.Repeat("  ", space.Depth))
+	buf.WriteString(space.Name)
+	if space.UnreadMessages > 0 {
+		buf.WriteRune(' ')
+		buf.WriteString(formatUnreadBadge(space.UnreadCounts))
+	} else if space.MarkedUnread > 0 {
+		buf.WriteString(" (*)")
+	}
+	return buf.String()
+}
+
+func (ss *SpaceSwitcherModal) Focus() {
+	ss.container.Focus()
+}
+
+func (ss *SpaceSwitcherModal) Blur() {
+	ss.container.Blur()
+}
+
+func (ss *SpaceSwitcherModal) OnKeyEvent(event mauview.KeyEvent) bool {
+	kb := config.Keybind{
+		Key: event.Key(),
+		Ch:  event.Rune(),
+		Mod: event.Modifiers(),
+	}
+	switch ss.parent.config.Keybindings.Modal[kb] {
+	case "cancel":
+		ss.parent.HideModal()
+		return true
+	case "select_next":
+		ss.selected = (ss.selected + 1) % len(ss.spaces)
+		ss.results.Highlight(strconv.Itoa(ss.selected))
+		ss.results.ScrollToHighlight()
+		return true
+	case "select_prev":
+		ss.selected = (ss.selected - 1 + len(ss.spaces)) % len(ss.spaces)
+		ss.results.Highlight(strconv.Itoa(ss.selected))
+		ss.results.ScrollToHighlight()
+		return true
+	case "confirm":
+		ss.parent.roomList.SetSpace(ss.spaces[ss.selected])
+		ss.parent.HideModal()
+		return true
+	}
+	return ss.results.OnKeyEvent(event)
+}
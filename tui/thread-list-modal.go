@@ -0,0 +1,192 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2026 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.mau.fi/mauview"
+	"maunium.net/go/mautrix/event"
+
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+	"go.mau.fi/gomuks/pkg/rpc/store"
+	"go.mau.fi/gomuks/tui/config"
+	"go.mau.fi/gomuks/tui/debug"
+)
+
+// The maximum number of threads to show in the thread list.
+const maxThreadListSize = 50
+
+type ThreadListModal struct {
+	mauview.Component
+
+	container *mauview.Box
+	results   *mauview.TextView
+
+	lock     sync.Mutex
+	threads  []*store.ThreadSummary
+	loading  bool
+	selected int
+
+	room   *RoomView
+	parent *MainView
+}
+
+func NewThreadListModal(roomView *RoomView, width int, height int) *ThreadListModal {
+	tl := &ThreadListModal{
+		room:    roomView,
+		parent:  roomView.parent,
+		loading: true,
+	}
+
+	tl.results = mauview.NewTextView().SetRegions(true)
+	tl.container = mauview.NewBox(tl.results).
+		SetBorder(true).
+		SetTitle("Threads").
+		SetBlurCaptureFunc(func() bool {
+			tl.parent.HideModal()
+			return true
+		})
+
+	tl.Component = mauview.Center(tl.container, width, height).SetAlwaysFocusChild(true)
+
+	tl.render()
+	go tl.loadThreads()
+	return tl
+}
+
+func (tl *ThreadListModal) Focus() {
+	tl.container.Focus()
+}
+
+func (tl *ThreadListModal) Blur() {
+	tl.container.Blur()
+}
+
+// loadThreads fetches the threads in the room from the backend, as the room store only knows about
+// thread events that have been loaded into the timeline. Root events that aren't in the store are fetched too.
+func (tl *ThreadListModal) loadThreads() {
+	defer debug.Recover()
+	roomID := tl.room.Room.ID
+	threads, err := tl.parent.matrix.GetThreads(context.TODO(), tl.room.Room)
+	if err != nil {
+		debug.Print("Failed to get threads in", roomID, err)
+	}
+	if len(threads) > maxThreadListSize {
+		threads = threads[:maxThreadListSize]
+	}
+	for _, thread := range threads {
+		if thread.Root != nil {
+			continue
+		}
+		thread.Root, err = tl.parent.matrix.GetEvent(context.TODO(), &jsoncmd.GetEventParams{
+			RoomID:  roomID,
+			EventID: thread.RootID,
+		})
+		if err != nil {
+			debug.Print("Failed to get thread root", thread.RootID, err)
+		}
+	}
+	tl.lock.Lock()
+	tl.threads = threads
+	tl.loading = false
+	tl.lock.Unlock()
+	tl.render()
+	tl.parent.parent.Render()
+}
+
+func (tl *ThreadListModal) describeThread(thread *store.ThreadSummary) string {
+	var buf strings.Builder
+	if thread.Unread {
+		buf.WriteString("● ")
+	}
+	if thread.Root == nil {
+		buf.WriteString("Unknown message")
+	} else {
+		body := thread.Root.GetMautrixContent().AsMessage().Body
+		body, _, _ = strings.Cut(strings.TrimSpace(body), "\n")
+		if body == "" {
+			body = thread.Root.GetType().Type
+		}
+		buf.WriteString(tl.room.Room.GetDisplayname(thread.Root.Sender))
+		buf.WriteString(": ")
+		buf.WriteString(body)
+	}
+	if thread.ReplyCount == 1 {
+		buf.WriteString(" (1 reply)")
+	} else {
+		_, _ = fmt.Fprintf(&buf, " (%d replies)", thread.ReplyCount)
+	}
+	return buf.String()
+}
+
+func (tl *ThreadListModal) render() {
+	tl.lock.Lock()
+	defer tl.lock.Unlock()
+	tl.results.Clear()
+	if tl.loading {
+		_, _ = fmt.Fprint(tl.results, "Loading threads...")
+		return
+	} else if len(tl.threads) == 0 {
+		_, _ = fmt.Fprint(tl.results, "No threads found in this room")
+		return
+	}
+	for i, thread := range tl.threads {
+		_, _ = fmt.Fprintf(tl.results, `["%d"]%s[""]%s`, i, mauview.Escape(tl.describeThread(thread)), "\n")
+	}
+	tl.results.Highlight(strconv.Itoa(tl.selected))
+}
+
+func (tl *ThreadListModal) OnKeyEvent(event mauview.KeyEvent) bool {
+	kb := config.Keybind{
+		Key: event.Key(),
+		Ch:  event.Rune(),
+		Mod: event.Modifiers(),
+	}
+	tl.lock.Lock()
+	defer tl.lock.Unlock()
+	switch tl.parent.config.Keybindings.Modal[kb] {
+	case "cancel":
+		tl.parent.HideModal()
+		return true
+	case "select_next":
+		if len(tl.threads) > 0 {
+			tl.selected = (tl.selected + 1) % len(tl.threads)
+			tl.results.Highlight(strconv.Itoa(tl.selected))
+			tl.results.ScrollToHighlight()
+		}
+		return true
+	case "select_prev":
+		if len(tl.threads) > 0 {
+			tl.selected = (tl.selected - 1 + len(tl.threads)) % len(tl.threads)
+			tl.results.Highlight(strconv.Itoa(tl.selected))
+			tl.results.ScrollToHighlight()
+		}
+		return true
+	case "confirm":
+		tl.parent.HideModal()
+		if len(tl.threads) > 0 {
+			tl.room.OpenThread(tl.threads[tl.selected].RootID)
+		}
+		return true
+	}
+	return tl.results.OnKeyEvent(event)
+}
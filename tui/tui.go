@@ -21,9 +21,9 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 
-	"github.com/gdamore/tcell/v2"
 	"github.com/zyedidia/clipboard"
 	"go.mau.fi/mauview"
 	"go.mau.fi/util/exerrors"
@@ -55,15 +55,14 @@ type GomuksTUI struct {
 	LoginView *LoginView
 
 	NeedsRender bool
+	// A theme that was reloaded by the theme watcher and will be applied on the next render.
+	pendingTheme atomic.Pointer[config.Theme]
 
 	views map[View]mauview.Component
 }
 
 func init() {
-	mauview.Styles.PrimitiveBackgroundColor = tcell.ColorDefault
-	mauview.Styles.PrimaryTextColor = tcell.ColorDefault
-	mauview.Styles.BorderColor = tcell.ColorDefault
-	mauview.Styles.ContrastBackgroundColor = tcell.ColorDarkGreen
+	applyThemeStyles()
 	if tcellDB := os.Getenv("TCELLDB"); len(tcellDB) == 0 {
 		if info, err := os.Stat("/usr/share/tcell/database"); err == nil && info.IsDir() {
 			_ = os.Setenv("TCELLDB", "/usr/share/tcell/database")
@@ -84,6 +83,11 @@ func (ui *GomuksTUI) Run() {
 	ui.Config.LoadAll()
 	log := exerrors.Must(ui.Config.LogConfig.Compile())
 	exzerolog.SetupDefaults(log)
+	applyThemeStyles()
+	ui.Config.WatchTheme(func(theme *config.Theme) {
+		ui.pendingTheme.Store(theme)
+		ui.Render()
+	})
 	loggedIn := false
 	if ui.Config.Server != "" && ui.Config.Username != "" && ui.Config.Password != "" {
 		ui.gmx = exerrors.Must(client.NewGomuksClient(ui.Config.Server))
@@ -131,8 +135,19 @@ func (ui *GomuksTUI) Connect() {
 
 func (ui *GomuksTUI) gomuksEventHandler(ctx context.Context, rawEvt any) {
 	switch rawEvt.(type) {
+	case *jsoncmd.InitComplete:
+		// Drafts are loaded before handling any further events, so that rooms opened after this have their drafts.
+		// Rooms that were opened before this get their draft when the current room is reloaded.
+		err := ui.gmx.LoadDrafts(ctx)
+		if err != nil {
+			debug.Print("Failed to load drafts:", err)
+		}
+		ui.MainView.OnDraftsChanged()
+		ui.MainView.PromptVerification()
+	case *jsoncmd.DraftUpdated:
+		ui.MainView.OnDraftsChanged()
 	case *jsoncmd.SyncComplete:
-		if ui.NeedsRender {
+		if ui.NeedsRender || ui.MainView.HasPendingJoin() {
 			debug.Print("Rendering...")
 			ui.Render()
 		}
@@ -166,6 +181,40 @@ func (ui *GomuksTUI) OnLogout() {
 	ui.SetView(ViewLogin)
 }
 
+// applyThemeStyles copies the general colors of the current theme to the mauview default styles.
+func applyThemeStyles() {
+	theme := config.CurrentTheme
+	mauview.Styles.PrimitiveBackgroundColor = theme.Background
+	mauview.Styles.PrimaryTextColor = theme.Text
+	mauview.Styles.BorderColor = theme.Border
+	mauview.Styles.ContrastBackgroundColor = theme.ContrastBackground
+}
+
+// OnThemeChanged applies the colors of the current theme to the UI. It must be called on the UI goroutine.
+func (ui *GomuksTUI) OnThemeChanged() {
+	ui.applyTheme()
+	ui.Render()
+}
+
+func (ui *GomuksTUI) applyTheme() {
+	applyThemeStyles()
+	if ui.LoginView != nil {
+		ui.LoginView.applyTheme()
+	}
+	if ui.MainView != nil {
+		ui.MainView.applyTheme()
+	}
+}
+
+// applyPendingTheme switches to the theme reloaded by the theme watcher, if there is one.
+// It's called at the start of rendering, so the theme is only changed on the UI goroutine.
+func (ui *GomuksTUI) applyPendingTheme() {
+	if theme := ui.pendingTheme.Swap(nil); theme != nil {
+		config.CurrentTheme = theme
+		ui.applyTheme()
+	}
+}
+
 func (ui *GomuksTUI) HandleNewPreferences() {
 	ui.Render()
 }
@@ -182,7 +231,11 @@ func (ui *GomuksTUI) RunExternal(executablePath string, args ...string) error {
 		cmd.Stderr = os.Stderr
 		cmd.Stdin = os.Stdin
 		cmd.Env = os.Environ()
-		callback <- cmd.Run()
+		err := cmd.Run()
+		if ui.MainView != nil {
+			ui.MainView.images.Reset()
+		}
+		callback <- err
 	})
 	return <-callback
 }
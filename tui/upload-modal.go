@@ -0,0 +1,379 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2026 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tui
+
+import (
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"go.mau.fi/mauview"
+	"go.mau.fi/util/exstrings"
+
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+	"go.mau.fi/gomuks/tui/config"
+	"go.mau.fi/gomuks/tui/debug"
+	"go.mau.fi/gomuks/tui/lib/filepicker"
+)
+
+// The re-encoding targets supported by the backend, based on the type of the original file.
+var (
+	imageReencodeTargets = []string{"image/webp", "image/jpeg", "image/png", "image/gif"}
+	videoReencodeTargets = []string{"video/webm", "video/mp4", "image/webp+anim"}
+	audioReencodeTargets = []string{"audio/ogg; codecs=opus", "audio/mpeg", "audio/mp4"}
+)
+
+type UploadModal struct {
+	mauview.Component
+
+	form *mauview.Form
+
+	path     *mauview.InputField
+	info     *mauview.TextField
+	filename *mauview.InputField
+	caption  *mauview.InputField
+	reencode *mauview.Button
+	resize   *mauview.InputField
+	quality  *mauview.InputField
+	encrypt  *mauview.Button
+	sendAs   *mauview.Button
+
+	pathFocused     bool
+	reencodeTargets []string
+	reencodeTo      string
+	encryptUpload   bool
+	forceFile       bool
+
+	room   *RoomView
+	parent *MainView
+}
+
+func NewUploadModal(roomView *RoomView, path string) *UploadModal {
+	um := &UploadModal{
+		form:          mauview.NewForm(),
+		path:          mauview.NewInputField(),
+		info:          mauview.NewTextField(),
+		filename:      mauview.NewInputField(),
+		caption:       mauview.NewInputField(),
+		reencode:      mauview.NewButton(""),
+		resize:        mauview.NewInputField(),
+		quality:       mauview.NewInputField(),
+		encrypt:       mauview.NewButton(""),
+		sendAs:        mauview.NewButton(""),
+		encryptUpload: roomView.Room.Meta.Current().EncryptionEvent != nil,
+		room:          roomView,
+		parent:        roomView.parent,
+	}
+
+	um.path.
+		SetPlaceholder("Path to file").
+		SetTextColor(config.CurrentTheme.InputText).
+		SetChangedFunc(um.pathChanged)
+	um.filename.SetPlaceholder("Same as original").SetTextColor(config.CurrentTheme.InputText)
+	um.caption.SetPlaceholder("No caption").SetTextColor(config.CurrentTheme.InputText)
+	um.resize.SetPlaceholder("100").SetTextColor(config.CurrentTheme.InputText)
+	um.quality.SetPlaceholder("80").SetTextColor(config.CurrentTheme.InputText)
+	um.reencode.SetOnClick(um.cycleReencode)
+	um.encrypt.SetOnClick(func() {
+		um.encryptUpload = !um.encryptUpload
+		um.updateButtons()
+	})
+	um.sendAs.SetOnClick(func() {
+		um.forceFile = !um.forceFile
+		um.updateButtons()
+	})
+	cancel := mauview.NewButton("Cancel").SetOnClick(um.parent.HideModal)
+	submit := mauview.NewButton("Upload").SetOnClick(um.Submit)
+
+	um.form.
+		SetColumns([]int{1, 10, 1, -1, 1}).
+		SetRows([]int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1})
+	um.form.
+		AddFormItem(um.path, 3, 1, 1, 1).
+		AddFormItem(um.filename, 3, 3, 1, 1).
+		AddFormItem(um.caption, 3, 4, 1, 1).
+		AddFormItem(um.reencode, 3, 6, 1, 1).
+		AddFormItem(um.resize, 3, 7, 1, 1).
+		AddFormItem(um.quality, 3, 8, 1, 1).
+		AddFormItem(um.encrypt, 3, 9, 1, 1).
+		AddFormItem(um.sendAs, 3, 10, 1, 1).
+		AddFormItem(submit, 3, 12, 1, 1).
+		AddFormItem(cancel, 1, 12, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("File"), 1, 1, 1, 1).
+		AddComponent(um.info, 3, 2, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Name"), 1, 3, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Caption"), 1, 4, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Re-encode"), 1, 6, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Resize %"), 1, 7, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Quality"), 1, 8, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Encrypt"), 1, 9, 1, 1).
+		AddComponent(mauview.NewTextField().SetText("Send as"), 1, 10, 1, 1)
+	if filepicker.IsSupported() {
+		browse := mauview.NewButton("Browse").SetOnClick(func() { go um.browse() })
+		um.form.AddFormItem(browse, 1, 2, 1, 1)
+	}
+	um.form.SetOnFocusChanged(func(_, to mauview.Component) {
+		um.pathFocused = to == um.path
+	})
+
+	box := mauview.NewBox(um.form).
+		SetBorder(true).
+		SetTitle("Upload file").
+		SetBlurCaptureFunc(func() bool {
+			um.parent.HideModal()
+			return true
+		})
+	center := mauview.Center(box, 64, 15).SetAlwaysFocusChild(true)
+	center.Focus()
+	um.form.FocusNextItem()
+	um.Component = center
+
+	um.path.SetTextAndMoveCursor(path)
+	um.pathChanged(path)
+	return um
+}
+
+// expandHome replaces a leading tilde in the path with the user's home directory.
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// completePath lists files and directories that start with the given path.
+// Directories have a trailing slash so that completion can continue into them.
+func completePath(prefix string) (completions []string) {
+	dirPart, filePart := filepath.Split(prefix)
+	dir := expandHome(dirPart)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, filePart) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(filePart, ".")) {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.IsDir() {
+			name += string(os.PathSeparator)
+		}
+		completions = append(completions, dirPart+name)
+	}
+	return
+}
+
+func formatFileSize(bytes int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	size := float64(bytes)
+	unit := 0
+	for size >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", bytes, units[unit])
+	}
+	return fmt.Sprintf("%.2f %s", size, units[unit])
+}
+
+func (um *UploadModal) pathChanged(path string) {
+	um.info.SetTextColor(config.CurrentTheme.SecondaryText)
+	info, err := os.Stat(expandHome(path))
+	mimeType := mime.TypeByExtension(filepath.Ext(path))
+	switch {
+	case path == "":
+		um.info.SetText("Press tab to complete the path")
+	case err != nil:
+		um.info.SetText("File not found")
+	case info.IsDir():
+		um.info.SetText("Directory")
+	case mimeType != "":
+		um.info.SetText(fmt.Sprintf("%s, %s", mimeType, formatFileSize(info.Size())))
+	default:
+		um.info.SetText(formatFileSize(info.Size()))
+	}
+	mediaType, _, _ := strings.Cut(mimeType, "/")
+	switch mediaType {
+	case "image":
+		um.reencodeTargets = imageReencodeTargets
+	case "video":
+		um.reencodeTargets = videoReencodeTargets
+	case "audio":
+		um.reencodeTargets = audioReencodeTargets
+	default:
+		um.reencodeTargets = nil
+	}
+	if !slices.Contains(um.reencodeTargets, um.reencodeTo) {
+		um.reencodeTo = ""
+	}
+	um.updateButtons()
+}
+
+func (um *UploadModal) updateButtons() {
+	switch {
+	case um.reencodeTargets == nil:
+		um.reencode.SetText("Not available")
+	case um.reencodeTo == "":
+		um.reencode.SetText("No re-encoding")
+	default:
+		um.reencode.SetText(um.reencodeTo)
+	}
+	if um.encryptUpload {
+		um.encrypt.SetText("Yes")
+	} else {
+		um.encrypt.SetText("No")
+	}
+	if um.forceFile {
+		um.sendAs.SetText("File")
+	} else {
+		um.sendAs.SetText("Media")
+	}
+}
+
+func (um *UploadModal) cycleReencode() {
+	if um.reencodeTargets == nil {
+		return
+	}
+	// Index -1 (no re-encoding) cycles to the first target, and the last target cycles back to no re-encoding.
+	next := slices.Index(um.reencodeTargets, um.reencodeTo) + 1
+	if next >= len(um.reencodeTargets) {
+		um.reencodeTo = ""
+	} else {
+		um.reencodeTo = um.reencodeTargets[next]
+	}
+	um.updateButtons()
+}
+
+func (um *UploadModal) browse() {
+	defer debug.Recover()
+	path, err := filepicker.Open()
+	if err != nil {
+		debug.Print("Failed to open file picker:", err)
+		um.showError("Failed to open file picker")
+	} else if path != "" {
+		um.path.SetTextAndMoveCursor(path)
+		um.pathChanged(path)
+	}
+	um.parent.parent.Render()
+}
+
+func (um *UploadModal) showError(text string) {
+	um.info.SetTextColor(config.CurrentTheme.Error)
+	um.info.SetText(text)
+}
+
+// tabCompletePath completes the path input. It returns false if there was nothing to complete,
+// in which case tab moves to the next field as usual.
+func (um *UploadModal) tabCompletePath() bool {
+	text := um.path.GetText()
+	completions := completePath(text)
+	var completion string
+	if len(completions) == 1 {
+		completion = completions[0]
+	} else if len(completions) > 1 {
+		completion = exstrings.LongestCommonPrefix(completions)
+		slices.Sort(completions)
+		names := make([]string, len(completions))
+		for i, path := range completions {
+			names[i] = filepath.Base(path)
+		}
+		um.info.SetTextColor(config.CurrentTheme.SecondaryText)
+		um.info.SetText(strings.Join(names, ", "))
+	}
+	if completion == "" || completion == text {
+		return len(completions) > 1
+	}
+	um.path.SetTextAndMoveCursor(completion)
+	if len(completions) == 1 {
+		um.pathChanged(completion)
+	}
+	return true
+}
+
+func parseOptionalInt(text string, minVal, maxVal int) (int, bool) {
+	if text == "" {
+		return 0, true
+	}
+	val, err := strconv.Atoi(text)
+	return val, err == nil && val >= minVal && val <= maxVal
+}
+
+func (um *UploadModal) Submit() {
+	path := expandHome(um.path.GetText())
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		um.showError("File not found")
+		return
+	}
+	resizePercent, ok := parseOptionalInt(um.resize.GetText(), 1, 100)
+	if !ok {
+		um.showError("Resize percentage must be between 1 and 100")
+		return
+	}
+	quality, ok := parseOptionalInt(um.quality.GetText(), 1, 100)
+	if !ok {
+		um.showError("Quality must be between 1 and 100")
+		return
+	}
+	params := &jsoncmd.UploadMediaParams{
+		Path:      path,
+		Filename:  um.filename.GetText(),
+		Encrypt:   um.encryptUpload,
+		ForceFile: um.forceFile,
+		EncodeTo:  um.reencodeTo,
+	}
+	if params.EncodeTo == "" && ((resizePercent > 0 && resizePercent < 100) || quality > 0) {
+		// Resizing and quality are only applied when re-encoding, so re-encode to the original type.
+		params.EncodeTo = mime.TypeByExtension(filepath.Ext(path))
+		if !slices.Contains(um.reencodeTargets, params.EncodeTo) {
+			um.showError("Select a type to re-encode to")
+			return
+		}
+	}
+	if resizePercent < 100 {
+		params.ResizePercent = resizePercent
+	}
+	params.Quality = quality
+	um.parent.HideModal()
+	go um.room.Upload(params, um.caption.GetText())
+}
+
+func (um *UploadModal) OnKeyEvent(event mauview.KeyEvent) bool {
+	kb := config.Keybind{
+		Key: event.Key(),
+		Ch:  event.Rune(),
+		Mod: event.Modifiers(),
+	}
+	if um.parent.config.Keybindings.Modal[kb] == "cancel" {
+		um.parent.HideModal()
+		return true
+	} else if event.Key() == tcell.KeyTab && um.pathFocused && um.tabCompletePath() {
+		return true
+	}
+	return um.Component.OnKeyEvent(event)
+}
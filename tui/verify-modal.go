@@ -0,0 +1,360 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2026 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"go.mau.fi/mauview"
+	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/pkg/hicli/jsoncmd"
+	"go.mau.fi/gomuks/tui/config"
+	"go.mau.fi/gomuks/tui/debug"
+)
+
+type VerifyModal struct {
+	mauview.Component
+
+	form   *mauview.Form
+	input  *mauview.InputField
+	status *mauview.TextField
+
+	inputFocused bool
+	verifying    bool
+
+	parent *MainView
+}
+
+func (view *MainView) ShowVerifyModal() {
+	view.ShowModal(NewVerifyModal(view))
+	view.parent.Render()
+}
+
+// PromptVerification schedules the verification dialog to be opened on the next draw if the current session
+// isn't verified. The dialog is only opened automatically once, it can be reopened with the verify command.
+func (view *MainView) PromptVerification() {
+	view.verificationPending.Store(true)
+	view.parent.Render()
+}
+
+// showPendingVerificationPrompt opens the dialog requested by PromptVerification.
+// It must only be called on the UI goroutine.
+func (view *MainView) showPendingVerificationPrompt() {
+	if !view.verificationPending.Swap(false) ||
+		view.verificationPrompted || !view.matrix.IsLoggedIn || view.matrix.IsVerified || view.modal != nil {
+		return
+	}
+	view.verificationPrompted = true
+	view.ShowModal(NewVerifyModal(view))
+}
+
+func NewVerifyModal(parent *MainView) *VerifyModal {
+	vm := &VerifyModal{
+		form:   mauview.NewForm(),
+		input:  mauview.NewInputField(),
+		status: mauview.NewTextField(),
+		parent: parent,
+	}
+
+	state := parent.matrix.ClientState
+	var text string
+	if state.IsVerified {
+		text = fmt.Sprintf("This session (%s) is already verified.", state.DeviceID)
+	} else if state.VerificationState.StateChecked && !state.VerificationState.HasSSSS {
+		text = "Your account doesn't have a recovery key yet. Set one up in another client, then verify this session here."
+	} else {
+		text = fmt.Sprintf(
+			"Enter your recovery key or recovery phrase to verify this session (%s) and access encrypted message history.",
+			state.DeviceID,
+		)
+	}
+	vm.input.
+		SetPlaceholder("Recovery key or phrase").
+		SetMaskCharacter('*').
+		SetTextColor(config.CurrentTheme.InputText)
+	cancel := mauview.NewButton("Cancel").SetOnClick(parent.HideModal)
+	submit := mauview.NewButton("Verify").SetOnClick(vm.Submit)
+
+	vm.form.
+		SetColumns([]int{1, 20, 1, -1, 1}).
+		SetRows([]int{1, 3, 1, 1, 1, 1, 1, 1})
+	vm.form.
+		AddFormItem(vm.input, 1, 3, 3, 1).
+		AddFormItem(submit, 3, 6, 1, 1).
+		AddFormItem(cancel, 1, 6, 1, 1).
+		AddComponent(mauview.NewTextView().SetText(text).SetTextColor(config.CurrentTheme.Text), 1, 1, 3, 1).
+		AddComponent(vm.status, 1, 4, 3, 1)
+	vm.form.SetOnFocusChanged(func(_, to mauview.Component) {
+		vm.inputFocused = to == vm.input
+	})
+
+	box := mauview.NewBox(vm.form).
+		SetBorder(true).
+		SetTitle("Verify session").
+		SetBlurCaptureFunc(func() bool {
+			vm.parent.HideModal()
+			return true
+		})
+	center := mauview.Center(box, 60, 12).SetAlwaysFocusChild(true)
+	center.Focus()
+	vm.form.FocusNextItem()
+	vm.Component = center
+	return vm
+}
+
+func (vm *VerifyModal) setStatus(color tcell.Color, text string) {
+	vm.status.SetTextColor(color)
+	vm.status.SetText(text)
+}
+
+func (vm *VerifyModal) Submit() {
+	key := strings.TrimSpace(vm.input.GetText())
+	if key == "" || vm.verifying {
+		return
+	}
+	vm.verifying = true
+	vm.setStatus(config.CurrentTheme.Warning, "Verifying...")
+	go vm.verify(key)
+}
+
+func (vm *VerifyModal) verify(key string) {
+	defer debug.Recover()
+	err := vm.parent.matrix.Verify(context.TODO(), &jsoncmd.VerifyParams{RecoveryKey: key})
+	vm.verifying = false
+	if err != nil {
+		debug.Print("Failed to verify session:", err)
+		vm.setStatus(config.CurrentTheme.Error, fmt.Sprintf("Failed to verify: %v", err))
+	} else if vm.parent.modal == vm {
+		vm.parent.HideModal()
+	}
+	vm.parent.parent.Render()
+}
+
+func (vm *VerifyModal) OnKeyEvent(event mauview.KeyEvent) bool {
+	kb := config.Keybind{
+		Key: event.Key(),
+		Ch:  event.Rune(),
+		Mod: event.Modifiers(),
+	}
+	switch vm.parent.config.Keybindings.Modal[kb] {
+	case "cancel":
+		vm.parent.HideModal()
+		return true
+	case "confirm":
+		if vm.inputFocused {
+			vm.Submit()
+			return true
+		}
+	}
+	return vm.Component.OnKeyEvent(event)
+}
+
+// EncryptionInfoModal shows the cross-signing status and devices of a user.
+type EncryptionInfoModal struct {
+	mauview.Component
+
+	form   *mauview.Form
+	info   *mauview.TextView
+	status *mauview.TextField
+
+	userID    id.UserID
+	masterKey string
+	keyChange bool
+	loading   bool
+
+	parent *MainView
+}
+
+func (view *MainView) ShowEncryptionInfo(userID id.UserID) {
+	view.ShowModal(NewEncryptionInfoModal(view, userID))
+	view.parent.Render()
+}
+
+func NewEncryptionInfoModal(parent *MainView, userID id.UserID) *EncryptionInfoModal {
+	em := &EncryptionInfoModal{
+		form:   mauview.NewForm(),
+		info:   mauview.NewTextView(),
+		status: mauview.NewTextField(),
+		userID: userID,
+		parent: parent,
+	}
+	em.info.SetScrollable(true).SetWrap(true).SetTextColor(config.CurrentTheme.Text)
+	reload := mauview.NewButton("Reload devices").SetOnClick(em.Reload)
+	trust := mauview.NewButton("Trust new key").SetOnClick(em.TrustMasterKey)
+	closeButton := mauview.NewButton("Close").SetOnClick(parent.HideModal)
+
+	em.form.
+		SetColumns([]int{1, 16, 1, 16, 1, -1, 1, 10, 1}).
+		SetRows([]int{1, -1, 1, 1, 1, 1})
+	em.form.
+		AddFormItem(reload, 1, 4, 1, 1).
+		AddFormItem(trust, 3, 4, 1, 1).
+		AddFormItem(closeButton, 7, 4, 1, 1).
+		AddComponent(em.info, 1, 1, 7, 1).
+		AddComponent(em.status, 1, 2, 7, 1)
+
+	box := mauview.NewBox(em.form).
+		SetBorder(true).
+		SetTitle(fmt.Sprintf("Encryption info of %s", userID)).
+		SetBlurCaptureFunc(func() bool {
+			em.parent.HideModal()
+			return true
+		})
+	center := mauview.FractionalCenter(box, 72, 20, 0.6, 0.6).SetAlwaysFocusChild(true)
+	center.Focus()
+	em.form.FocusNextItem()
+	em.Component = center
+
+	em.run("Loading...", func(ctx context.Context) (*jsoncmd.ProfileEncryptionInfo, error) {
+		return em.parent.matrix.GetProfileEncryptionInfo(ctx, &jsoncmd.GetProfileParams{UserID: userID})
+	})
+	return em
+}
+
+// Reload starts tracking the user's devices if they weren't tracked already and fetches the device list again.
+func (em *EncryptionInfoModal) Reload() {
+	em.run("Reloading device list...", func(ctx context.Context) (*jsoncmd.ProfileEncryptionInfo, error) {
+		return em.parent.matrix.TrackUserDevices(ctx, &jsoncmd.GetProfileParams{UserID: em.userID})
+	})
+}
+
+// TrustMasterKey resets the trust-on-first-use state to the user's current master key.
+func (em *EncryptionInfoModal) TrustMasterKey() {
+	if !em.keyChange {
+		em.setStatus(config.CurrentTheme.Warning, "The master key of this user hasn't changed")
+		return
+	}
+	masterKey := em.masterKey
+	em.run("Trusting new master key...", func(ctx context.Context) (*jsoncmd.ProfileEncryptionInfo, error) {
+		return em.parent.matrix.ResetMasterKeyTOFU(ctx, &jsoncmd.ResetMasterKeyTOFUParams{
+			UserID:    em.userID,
+			MasterKey: masterKey,
+		})
+	})
+}
+
+func (em *EncryptionInfoModal) setStatus(color tcell.Color, text string) {
+	em.status.SetTextColor(color)
+	em.status.SetText(text)
+}
+
+func (em *EncryptionInfoModal) run(status string, fn func(ctx context.Context) (*jsoncmd.ProfileEncryptionInfo, error)) {
+	if em.loading {
+		return
+	}
+	em.loading = true
+	em.setStatus(config.CurrentTheme.Warning, status)
+	go func() {
+		defer debug.Recover()
+		info, err := fn(context.TODO())
+		em.loading = false
+		if err != nil {
+			debug.Print("Failed to get encryption info of", em.userID, err)
+			em.setStatus(config.CurrentTheme.Error, fmt.Sprintf("Failed to get encryption info: %v", err))
+		} else {
+			em.setInfo(info)
+		}
+		em.parent.parent.Render()
+	}()
+}
+
+func (em *EncryptionInfoModal) setInfo(info *jsoncmd.ProfileEncryptionInfo) {
+	em.masterKey = info.MasterKey
+	em.keyChange = info.MasterKey != "" && info.MasterKey != info.FirstMasterKey && !info.UserTrusted
+	em.info.SetText(formatEncryptionInfo(info))
+	if len(info.Errors) > 0 {
+		em.setStatus(config.CurrentTheme.Error, strings.Join(info.Errors, "; "))
+	} else if em.keyChange {
+		em.setStatus(config.CurrentTheme.Error, "The master key has changed! Verify it before trusting it.")
+	} else {
+		em.setStatus(config.CurrentTheme.Text, "")
+	}
+}
+
+func formatEncryptionInfo(info *jsoncmd.ProfileEncryptionInfo) string {
+	if !info.DevicesTracked {
+		return "The device list of this user is not being tracked.\nPress \"Reload devices\" to start tracking it."
+	}
+	var buf strings.Builder
+	hasCSKeys := info.MasterKey != ""
+	switch {
+	case info.UserTrusted:
+		buf.WriteString("You have verified this user.\n")
+	case !hasCSKeys:
+		buf.WriteString("This user doesn't have cross-signing keys.\n")
+	case info.MasterKey == info.FirstMasterKey:
+		buf.WriteString("The master key was trusted on first use.\n")
+	default:
+		buf.WriteString("The master key has changed since it was first seen.\n")
+		buf.WriteString("Confirm the new key with the user before pressing \"Trust new key\".\n")
+		_, _ = fmt.Fprintf(&buf, "First seen key: %s\n", info.FirstMasterKey)
+	}
+	if hasCSKeys {
+		_, _ = fmt.Fprintf(&buf, "Master key: %s\n", info.MasterKey)
+	}
+	_, _ = fmt.Fprintf(&buf, "\n%d devices\n", len(info.Devices))
+	for _, device := range info.Devices {
+		buf.WriteString("\n")
+		if device.Name != "" {
+			_, _ = fmt.Fprintf(&buf, "%s (%s)\n", device.Name, device.DeviceID)
+		} else {
+			_, _ = fmt.Fprintf(&buf, "%s\n", device.DeviceID)
+		}
+		_, _ = fmt.Fprintf(&buf, "  %s\n", trustStateDescription(device.Trust, hasCSKeys))
+		_, _ = fmt.Fprintf(&buf, "  Fingerprint: %s\n", device.Fingerprint)
+	}
+	return buf.String()
+}
+
+func trustStateDescription(state id.TrustState, hasCSKeys bool) string {
+	switch state {
+	case id.TrustStateBlacklisted:
+		return "Blacklisted manually"
+	case id.TrustStateUnset:
+		if hasCSKeys {
+			return "Not verified by cross-signing keys"
+		}
+		return "Not verified, no cross-signing keys were found"
+	case id.TrustStateVerified:
+		return "Verified manually"
+	case id.TrustStateCrossSignedUntrusted:
+		return "Cross-signed, but the cross-signing keys are NOT trusted"
+	case id.TrustStateCrossSignedTOFU:
+		return "Cross-signed, cross-signing keys were trusted on first use"
+	case id.TrustStateCrossSignedVerified:
+		return "Cross-signed, cross-signing keys were verified manually"
+	default:
+		return fmt.Sprintf("Unknown trust state %s", state)
+	}
+}
+
+func (em *EncryptionInfoModal) OnKeyEvent(event mauview.KeyEvent) bool {
+	kb := config.Keybind{
+		Key: event.Key(),
+		Ch:  event.Rune(),
+		Mod: event.Modifiers(),
+	}
+	if em.parent.config.Keybindings.Modal[kb] == "cancel" {
+		em.parent.HideModal()
+		return true
+	}
+	return em.Component.OnKeyEvent(event)
+}
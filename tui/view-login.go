@@ -20,12 +20,12 @@ import (
 	"context"
 	"math"
 
-	"github.com/gdamore/tcell/v2"
 	"github.com/mattn/go-runewidth"
 	"go.mau.fi/mauview"
 
 	"go.mau.fi/gomuks/pkg/rpc"
 	"go.mau.fi/gomuks/pkg/rpc/client"
+	"go.mau.fi/gomuks/tui/config"
 	"go.mau.fi/gomuks/tui/debug"
 )
 
@@ -69,20 +69,13 @@ func (ui *GomuksTUI) NewLoginView() mauview.Component {
 		parent: ui,
 	}
 
-	view.server.SetPlaceholder("http://localhost:29325").SetText(view.parent.Config.Server).SetTextColor(tcell.ColorWhite)
-	view.username.SetPlaceholder("username").SetText(view.parent.Config.Username).SetTextColor(tcell.ColorWhite)
-	view.password.SetPlaceholder("correct horse battery staple").SetMaskCharacter('*').SetTextColor(tcell.ColorWhite)
-
-	view.quitButton.
-		SetOnClick(func() { ui.Finish() }).
-		SetBackgroundColor(tcell.ColorDarkCyan).
-		SetForegroundColor(tcell.ColorWhite).
-		SetFocusedForegroundColor(tcell.ColorWhite)
-	view.loginButton.
-		SetOnClick(view.Login).
-		SetBackgroundColor(tcell.ColorDarkCyan).
-		SetForegroundColor(tcell.ColorWhite).
-		SetFocusedForegroundColor(tcell.ColorWhite)
+	view.server.SetPlaceholder("http://localhost:29325").SetText(view.parent.Config.Server)
+	view.username.SetPlaceholder("username").SetText(view.parent.Config.Username)
+	view.password.SetPlaceholder("correct horse battery staple").SetMaskCharacter('*')
+
+	view.quitButton.SetOnClick(func() { ui.Finish() })
+	view.loginButton.SetOnClick(view.Login)
+	view.applyTheme()
 
 	view.
 		SetColumns([]int{1, 10, 1, 30, 1}).
@@ -104,6 +97,28 @@ func (ui *GomuksTUI) NewLoginView() mauview.Component {
 	return view.container
 }
 
+// applyTheme sets the colors of the widgets in the login view from the current theme.
+func (view *LoginView) applyTheme() {
+	theme := config.CurrentTheme
+	for _, input := range []*mauview.InputField{view.server, view.username, view.password} {
+		input.SetTextColor(theme.InputText)
+	}
+	for _, button := range []*mauview.Button{view.quitButton, view.loginButton} {
+		button.
+			SetBackgroundColor(theme.ButtonBackground).
+			SetForegroundColor(theme.ButtonText).
+			SetFocusedForegroundColor(theme.ButtonText)
+	}
+	if view.error != nil {
+		view.error.SetTextColor(theme.Error)
+	}
+}
+
+func (view *LoginView) Draw(screen mauview.Screen) {
+	view.parent.applyPendingTheme()
+	view.Form.Draw(screen)
+}
+
 func (view *LoginView) Error(err string) {
 	if len(err) == 0 && view.error != nil {
 		debug.Print("Hiding error")
@@ -114,7 +129,7 @@ func (view *LoginView) Error(err string) {
 	} else if len(err) > 0 {
 		debug.Print("Showing error", err)
 		if view.error == nil {
-			view.error = mauview.NewTextView().SetTextColor(tcell.ColorRed)
+			view.error = mauview.NewTextView().SetTextColor(config.CurrentTheme.Error)
 			view.AddComponent(view.error, 1, 11, 3, 1)
 		}
 		view.error.SetText(err + "\n\nMake sure you enter your gomuks backend\naddress, not a Matrix homeserver.")
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
@@ -34,20 +35,39 @@ import (
 	"go.mau.fi/gomuks/tui/config"
 	"go.mau.fi/gomuks/tui/debug"
 	"go.mau.fi/gomuks/tui/lib/notification"
+	"go.mau.fi/gomuks/tui/lib/termimage"
+	"go.mau.fi/gomuks/tui/messages"
 	"go.mau.fi/gomuks/tui/widget"
 )
 
 type MainView struct {
 	flex *mauview.Flex
+	// The layout that the flex was last built with.
+	layout config.Layout
 
 	roomList    *RoomList
 	roomView    *mauview.Box
 	currentRoom *RoomView
+	// The invite view that is open instead of a room, if any.
+	currentInvite *InviteView
+	// The invite that was accepted or the room that was created most recently. The view switches to
+	// the room once it comes down sync. This is set from background goroutines and applied in Draw.
+	pendingJoin atomic.Pointer[id.RoomID]
+	// A room that should be selected in the room list on the next draw.
+	pendingSelect atomic.Pointer[id.RoomID]
+	// Whether the verification dialog has been opened automatically for an unverified session.
+	verificationPrompted bool
+	// Set when the verification dialog should be opened on the next draw.
+	verificationPending atomic.Bool
+	// Set when drafts are changed by the backend. They're applied to the current room on the next draw.
+	draftsChanged atomic.Bool
 	//cmdProcessor *CommandProcessor
 	focused mauview.Focusable
 
 	modal mauview.Component
 
+	images *termimage.Renderer
+
 	lastFocusTime time.Time
 
 	matrix *client.GomuksClient
@@ -57,20 +77,19 @@ type MainView struct {
 
 func (ui *GomuksTUI) NewMainView() mauview.Component {
 	mainView := &MainView{
-		flex:     mauview.NewFlex().SetDirection(mauview.FlexColumn),
 		roomView: mauview.NewBox(nil).SetBorder(false),
+		images:   termimage.NewRenderer(ui.Config.Preferences.GetImageProtocol()),
 
 		matrix: ui.gmx,
 		config: ui.Config,
 		parent: ui,
 	}
+	mainView.images.OnResize = ui.Render
+	messages.OnPreviewLoaded = ui.Render
 	mainView.roomList = NewRoomList(mainView)
 	//mainView.cmdProcessor = NewCommandProcessor(mainView)
 
-	mainView.flex.
-		AddFixedComponent(mainView.roomList, 25).
-		AddFixedComponent(widget.NewBorder(), 1).
-		AddProportionalComponent(mainView.roomView, 1)
+	mainView.ApplyLayout()
 	mainView.BumpFocus(nil)
 
 	ui.MainView = mainView
@@ -78,6 +97,49 @@ func (ui *GomuksTUI) NewMainView() mauview.Component {
 	return mainView
 }
 
+// ApplyLayout rebuilds the main flex if the layout of the current theme has changed.
+func (view *MainView) ApplyLayout() {
+	layout := config.CurrentTheme.Layout
+	if view.flex != nil && view.layout == layout {
+		return
+	}
+	view.layout = layout
+	view.flex = mauview.NewFlex().SetDirection(mauview.FlexColumn)
+	if layout.RoomListPosition == config.PositionRight {
+		view.flex.
+			AddProportionalComponent(view.roomView, 1).
+			AddFixedComponent(widget.NewBorder(), 1).
+			AddFixedComponent(view.roomList, layout.RoomListWidth)
+	} else {
+		view.flex.
+			AddFixedComponent(view.roomList, layout.RoomListWidth).
+			AddFixedComponent(widget.NewBorder(), 1).
+			AddProportionalComponent(view.roomView, 1)
+	}
+	if view.currentRoom != nil {
+		view.flex.SetFocused(view.roomView)
+	}
+}
+
+// SetTheme switches to the given theme and saves it in the config.
+// If the name is empty, the current theme is reloaded.
+func (view *MainView) SetTheme(name string) error {
+	prevTheme := view.config.ThemeName()
+	if name != "" {
+		view.config.SetThemeName(name)
+	}
+	err := view.config.LoadTheme()
+	if err != nil {
+		view.config.SetThemeName(prevTheme)
+		return err
+	}
+	if view.config.ThemeName() != prevTheme {
+		view.config.Save()
+	}
+	view.parent.OnThemeChanged()
+	return nil
+}
+
 func (view *MainView) ShowModal(modal mauview.Component) {
 	view.modal = modal
 	var ok bool
@@ -94,16 +156,56 @@ func (view *MainView) HideModal() {
 	view.focused = view.roomView
 }
 
+// closingModal is implemented by modals that finish their work in a background goroutine.
+// The modal is closed at the start of the next draw after shouldClose returns true.
+type closingModal interface {
+	shouldClose() bool
+}
+
+// themedComponent is implemented by components that copy colors from the theme when they're created.
+type themedComponent interface {
+	applyTheme()
+}
+
+// applyTheme re-applies the current theme to the open views after the theme changes.
+func (view *MainView) applyTheme() {
+	if view.currentRoom != nil {
+		view.currentRoom.applyTheme()
+	}
+	if view.currentInvite != nil {
+		view.currentInvite.applyTheme()
+	}
+	if themed, ok := view.modal.(themedComponent); ok {
+		themed.applyTheme()
+	}
+}
+
 func (view *MainView) Draw(screen mauview.Screen) {
+	view.parent.applyPendingTheme()
+	if view.draftsChanged.Swap(false) && view.currentRoom != nil {
+		view.currentRoom.reloadDraft()
+	}
+	if closing, ok := view.modal.(closingModal); ok && closing.shouldClose() {
+		view.HideModal()
+	}
+	if roomID := view.pendingSelect.Swap(nil); roomID != nil {
+		view.roomList.SetSelected(*roomID)
+	}
+	view.switchPendingJoin()
+	view.showPendingVerificationPrompt()
+	view.ApplyLayout()
+	imageScreen := view.images.Wrap(screen)
 	if view.config.Preferences.HideRoomList {
-		view.roomView.Draw(screen)
+		view.roomView.Draw(imageScreen)
 	} else {
-		view.flex.Draw(screen)
+		view.flex.Draw(imageScreen)
 	}
 
 	if view.modal != nil {
+		view.images.Discard()
 		view.modal.Draw(screen)
 	}
+	view.images.Flush(screen)
 }
 
 func (view *MainView) BumpFocus(roomView *RoomView) {
@@ -115,7 +217,12 @@ func (view *MainView) BumpFocus(roomView *RoomView) {
 
 func (view *MainView) MarkRead(roomView *RoomView) {
 	if roomView != nil && roomView == view.currentRoom && roomView.MessageView().GetScrollOffset() == 0 {
-		req := roomView.Room.GetMarkAsReadParams()
+		var req *jsoncmd.MarkReadParams
+		if thread := roomView.thread; thread != nil {
+			req = thread.GetMarkAsReadParams()
+		} else {
+			req = roomView.Room.GetMarkAsReadParams()
+		}
 		if req != nil {
 			go func() {
 				defer debug.Recover()
@@ -129,9 +236,7 @@ func (view *MainView) MarkRead(roomView *RoomView) {
 }
 
 func (view *MainView) InputChanged(roomView *RoomView, text string) {
-	//if !roomView.config.Preferences.DisableTypingNotifs {
-	//	view.matrix.SendTyping(roomView.Room.ID, len(text) > 0 && text[0] != '/')
-	//}
+	roomView.SetTyping(len(text) > 0 && text[0] != '/')
 }
 
 func (view *MainView) ShowBare(roomView *RoomView) {
@@ -152,6 +257,11 @@ func (view *MainView) ShowBare(roomView *RoomView) {
 	})
 }
 
+func (view *MainView) ShowSpaceSwitcher() {
+	view.ShowModal(NewSpaceSwitcherModal(view, 42, 16))
+	view.parent.Render()
+}
+
 func (view *MainView) OpenSyncingModal() *SyncingModal {
 	component, modal := NewSyncingModal(view)
 	view.ShowModal(component)
@@ -177,12 +287,22 @@ func (view *MainView) OnKeyEvent(event mauview.KeyEvent) bool {
 		view.SwitchRoom(view.roomList.Previous())
 	case "search_rooms":
 		view.ShowModal(NewFuzzySearchModal(view, 42, 12))
+	case "next_space":
+		view.roomList.CycleSpace(true)
+	case "prev_space":
+		view.roomList.CycleSpace(false)
+	case "switch_space":
+		view.ShowSpaceSwitcher()
 	case "scroll_up":
-		msgView := view.currentRoom.MessageView()
-		msgView.AddScrollOffset(msgView.TotalHeight())
+		if view.currentRoom != nil {
+			msgView := view.currentRoom.MessageView()
+			msgView.AddScrollOffset(msgView.TotalHeight())
+		}
 	case "scroll_down":
-		msgView := view.currentRoom.MessageView()
-		msgView.AddScrollOffset(-msgView.TotalHeight())
+		if view.currentRoom != nil {
+			msgView := view.currentRoom.MessageView()
+			msgView.AddScrollOffset(-msgView.TotalHeight())
+		}
 	case "add_newline":
 		return view.flex.OnKeyEvent(tcell.NewEventKey(tcell.KeyEnter, '\n', event.Modifiers()|tcell.ModShift))
 	case "next_active_room":
@@ -238,12 +358,34 @@ func (view *MainView) Blur() {
 	}
 }
 
+// OnDraftsChanged schedules the draft of the current room to be reloaded in case it was changed by another client.
+func (view *MainView) OnDraftsChanged() {
+	view.draftsChanged.Store(true)
+	view.parent.Render()
+}
+
 func (view *MainView) SwitchRoom(roomID id.RoomID) {
 	roomData := view.matrix.GetRoom(roomID)
 	if roomData == nil {
-		debug.Print("Tried to switch to nonexistent room!", roomID)
+		if invite := view.matrix.GetInviteRoom(roomID); invite != nil {
+			view.SwitchInvite(invite)
+		} else {
+			debug.Print("Tried to switch to nonexistent room!", roomID)
+		}
 		return
 	}
+	// Follow tombstones to the replacement room if we've already joined it.
+	// The iteration limit protects against tombstone loops.
+	for i := 0; i < 10; i++ {
+		replacement := roomData.Meta.Current().Tombstone.GetReplacementRoom()
+		replacementData := view.matrix.GetRoom(replacement)
+		if replacement == "" || replacementData == nil {
+			break
+		}
+		debug.Print("Following tombstone from", roomID, "to", replacement)
+		roomID = replacement
+		roomData = replacementData
+	}
 	debug.Print("Selecting room", roomID)
 	view.roomList.SetSelected(roomID)
 	view.flex.SetFocused(view.roomView)
@@ -252,12 +394,22 @@ func (view *MainView) SwitchRoom(roomID id.RoomID) {
 	}
 	currentRoom := NewRoomView(view, roomData)
 	view.currentRoom = currentRoom
+	view.currentInvite = nil
 	view.roomView.SetInnerComponent(currentRoom)
 	view.roomView.Focus()
 	view.MarkRead(currentRoom)
 	if len(ptr.Val(roomData.TimelineCache.Current())) < 50 {
 		go view.LoadHistory(roomID)
 	}
+	if _, ok := view.matrix.GetCachedImagePacks(roomID); !ok {
+		// Prefetch image packs so that emoji autocompletion doesn't have to wait for them
+		go func() {
+			defer debug.Recover()
+			if _, err := currentRoom.getImagePacks(); err != nil {
+				debug.Print("Failed to prefetch image packs for", roomID, err)
+			}
+		}()
+	}
 	if !roomData.FullMembersLoaded.Load() {
 		// TODO only load necessary members rather than all?
 		go func() {
@@ -274,6 +426,51 @@ func (view *MainView) SwitchRoom(roomID id.RoomID) {
 	view.parent.Render()
 }
 
+func (view *MainView) SwitchInvite(invite *store.InvitedRoom) {
+	debug.Print("Selecting invite", invite.RoomID)
+	view.roomList.SetSelected(invite.RoomID)
+	view.flex.SetFocused(view.roomView)
+	if view.currentRoom != nil {
+		view.currentRoom.Unload()
+		view.currentRoom = nil
+	}
+	view.currentInvite = NewInviteView(view, invite)
+	view.roomView.SetInnerComponent(view.currentInvite)
+	view.roomView.Focus()
+	view.parent.Render()
+}
+
+// SetPendingJoin makes the view switch to the given room once it comes down sync.
+// It's safe to call from any goroutine, the switch happens on the next draw.
+func (view *MainView) SetPendingJoin(roomID id.RoomID) {
+	view.pendingJoin.Store(&roomID)
+	view.parent.Render()
+}
+
+// HasPendingJoin returns true if the view is waiting for a room to switch to.
+func (view *MainView) HasPendingJoin() bool {
+	return view.pendingJoin.Load() != nil
+}
+
+// SwitchToNewRoom selects a room that was just created or looked up in a background goroutine
+// and switches to it once it comes down sync. Like SetPendingJoin, it's safe to call from any goroutine.
+func (view *MainView) SwitchToNewRoom(roomID id.RoomID) {
+	view.pendingSelect.Store(&roomID)
+	view.SetPendingJoin(roomID)
+}
+
+// switchPendingJoin switches to the most recently accepted invite if the room has been received from sync.
+// It must only be called on the UI goroutine.
+func (view *MainView) switchPendingJoin() {
+	roomID := view.pendingJoin.Load()
+	if roomID == nil || view.matrix.GetRoom(*roomID) == nil || !view.pendingJoin.CompareAndSwap(roomID, nil) {
+		return
+	}
+	if view.roomList.SelectedRoom() == *roomID {
+		view.SwitchRoom(*roomID)
+	}
+}
+
 func (view *MainView) NotifyMessage(room *store.RoomStore, notif jsoncmd.SyncNotification) {
 	if view.config.Preferences.DisableNotifications {
 		return
@@ -321,3 +518,16 @@ func (view *MainView) LoadHistory(roomID id.RoomID) {
 		view.MarkRead(room)
 	}
 }
+
+func (view *MainView) LoadThreadHistory(thread *store.ThreadStore) {
+	defer debug.Recover()
+	err := view.matrix.LoadThreadHistory(context.TODO(), thread)
+	if err != nil {
+		debug.Print("Failed to fetch thread history for", thread.RootID, err)
+		return
+	}
+	view.parent.Render()
+	if room := view.currentRoom; room != nil && room.thread == thread {
+		view.MarkRead(room)
+	}
+}
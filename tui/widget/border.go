@@ -26,26 +26,30 @@ import (
 // If the width of the box is 1, the bar will be vertical.
 // If the height is 1, the bar will be horizontal.
 // If the width nor the height are 1, nothing will be rendered.
+//
+// If Style is not set, the border color from the mauview default styles is used.
 type Border struct {
 	Style tcell.Style
 }
 
 // NewBorder wraps a new tview Box into a new Border.
 func NewBorder() *Border {
-	return &Border{
-		Style: tcell.StyleDefault.Foreground(mauview.Styles.BorderColor),
-	}
+	return &Border{}
 }
 
 func (border *Border) Draw(screen mauview.Screen) {
 	width, height := screen.Size()
+	style := border.Style
+	if style == tcell.StyleDefault {
+		style = style.Foreground(mauview.Styles.BorderColor)
+	}
 	if width == 1 {
 		for borderY := 0; borderY < height; borderY++ {
-			screen.SetContent(0, borderY, mauview.Borders.Vertical, nil, border.Style)
+			screen.SetContent(0, borderY, mauview.Borders.Vertical, nil, style)
 		}
 	} else if height == 1 {
 		for borderX := 0; borderX < width; borderX++ {
-			screen.SetContent(borderX, 0, mauview.Borders.Horizontal, nil, border.Style)
+			screen.SetContent(borderX, 0, mauview.Borders.Horizontal, nil, style)
 		}
 	}
 }
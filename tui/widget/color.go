@@ -23,6 +23,8 @@ import (
 	"github.com/gdamore/tcell/v2"
 
 	"maunium.net/go/mautrix/id"
+
+	"go.mau.fi/gomuks/tui/config"
 )
 
 var colorNames = []string{
@@ -204,20 +206,35 @@ func GetHashColorName(s string) string {
 
 // GetHashColor gets the tcell Color value for the given string.
 //
-// GetHashColor calls GetHashColorName() and gets the Color value from the tcell.ColorNames map.
+// If the current theme defines sender colors, the color is picked from that list using the same hash.
+// Otherwise, GetHashColor calls GetHashColorName() and gets the Color value from the tcell.ColorNames map.
 func GetHashColor(val interface{}) tcell.Color {
 	switch str := val.(type) {
 	case string:
-		return tcell.ColorNames[GetHashColorName(str)]
+		return getHashColor(str)
 	case *string:
-		return tcell.ColorNames[GetHashColorName(*str)]
+		return getHashColor(*str)
 	case id.UserID:
-		return tcell.ColorNames[GetHashColorName(string(str))]
+		return getHashColor(string(str))
 	default:
 		return tcell.ColorNames["red"]
 	}
 }
 
+func getHashColor(s string) tcell.Color {
+	senderColors := config.CurrentTheme.SenderColors
+	switch s {
+	case "-->", "<--", "---":
+	default:
+		if len(senderColors) > 0 {
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(s))
+			return senderColors[h.Sum32()%uint32(len(senderColors))]
+		}
+	}
+	return tcell.ColorNames[GetHashColorName(s)]
+}
+
 // AddColor adds tview color tags to the given string.
 func AddColor(s, color string) string {
 	return fmt.Sprintf("[%s]%s[white]", color, s)
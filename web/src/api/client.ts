@@ -31,6 +31,7 @@ import {
 	ImagePackRooms,
 	LocalSearchParams,
 	MemDBEvent,
+	ModerationProgress,
 	RPCEvent,
 	RawDBEvent,
 	RelationType,
@@ -48,6 +49,7 @@ export default class Client {
 	readonly profile = new NonNullCachedEventDispatcher<UserProfile>({})
 	readonly syncStatus = new NonNullCachedEventDispatcher<SyncStatus>({ type: "waiting", error_count: 0 })
 	readonly initComplete = new NonNullCachedEventDispatcher<boolean>(false)
+	readonly moderationProgress = new CachedEventDispatcher<ModerationProgress>()
 	readonly store = new StateStore()
 	#stateRequests: RoomStateGUID[] = []
 	#stateRequestPromise: Promise<void> | null = null
@@ -345,6 +347,10 @@ export default class Client {
 		} else if (ev.command === "init_complete") {
 			this.initComplete.emit(true)
 			this.store.stateCache?.tryFlush()
+			this.rpc.getDrafts().then(
+				drafts => this.store.setDrafts(drafts),
+				err => console.error("Failed to load drafts:", err),
+			)
 		} else if (ev.command === "sync_complete") {
 			this.store.applySync(ev.data)
 		} else if (ev.command === "events_decrypted") {
@@ -355,6 +361,10 @@ export default class Client {
 			this.store.imageAuthToken = ev.data
 		} else if (ev.command === "typing") {
 			this.store.applyTyping(ev.data)
+		} else if (ev.command === "moderation_progress") {
+			this.moderationProgress.emit(ev.data)
+		} else if (ev.command === "draft_updated") {
+			this.store.applyDraftUpdate(ev.data)
 		}
 	}
 
@@ -676,11 +686,15 @@ export default class Client {
 			// If the reset is done without the flash, the user might think nothing happened.
 			room.timeline = []
 			room.hasMoreHistory = false
+			room.paginationRoomID = roomID
 			room.notifyTimelineSubscribers()
 
 			console.log("Requesting 50 messages of history and a timeline reset in", roomID)
 			const resp = await this.rpc.paginate(roomID, 0, 50, true)
 			room.hasMoreHistory = resp.has_more
+			if (!resp.has_more) {
+				this.#continueWithPredecessor(room)
+			}
 			room.applyPagination(resp.events, resp.related_events, resp.receipts)
 		} finally {
 			room.paginating = false
@@ -696,21 +710,41 @@ export default class Client {
 		}
 		room.paginating = true
 		try {
-			const oldestRowID = room.timeline[0]?.timeline_rowid
+			const paginationRoomID = room.paginationRoomID
+			const oldestRow = room.timeline[0]
+			// Timeline row IDs aren't comparable across rooms, so start from the end of the predecessor's timeline
+			// if the oldest event is still from the successor room.
+			const oldestRowID = oldestRow && room.eventsByRowID.get(oldestRow.event_rowid)?.room_id === paginationRoomID
+				? oldestRow.timeline_rowid
+				: 0
 			// Request 50 messages at a time first, increase batch size when going further
 			const count = room.timeline.length < 100 ? 50 : 100
-			console.log("Requesting", count, "messages of history in", roomID)
-			const resp = await this.rpc.paginate(roomID, oldestRowID ?? 0, count)
-			if (room.timeline[0]?.timeline_rowid !== oldestRowID) {
+			console.log("Requesting", count, "messages of history in", paginationRoomID)
+			const resp = await this.rpc.paginate(paginationRoomID, oldestRowID, count)
+			if (room.timeline[0] !== oldestRow || room.paginationRoomID !== paginationRoomID) {
 				throw new Error("Timeline changed while loading history")
 			}
 			room.hasMoreHistory = resp.has_more
+			if (!resp.has_more) {
+				this.#continueWithPredecessor(room)
+			}
 			room.applyPagination(resp.events, resp.related_events, resp.receipts)
 		} finally {
 			room.paginating = false
 		}
 	}
 
+	// Once the history of the room being paginated runs out, continue with the predecessor room if it's joined,
+	// so that the timeline of an upgraded room includes the history of the old room.
+	#continueWithPredecessor(room: RoomStateStore) {
+		const paginationRoom = this.store.rooms.get(room.paginationRoomID)
+		const predecessorID = paginationRoom?.meta.current.creation_content?.predecessor?.room_id
+		if (predecessorID && this.store.rooms.has(predecessorID)) {
+			room.paginationRoomID = predecessorID
+			room.hasMoreHistory = true
+		}
+	}
+
 	clearState() {
 		this.initComplete.emit(false)
 		this.syncStatus.emit({ type: "waiting", error_count: 0 })
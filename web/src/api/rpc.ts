@@ -17,6 +17,8 @@ import { CachedEventDispatcher, EventDispatcher } from "../util/eventdispatcher.
 import { CancellablePromise } from "../util/promise.ts"
 import {
 	ClientWellKnown,
+	CommandCompletion,
+	DBDraft,
 	DBPushRegistration,
 	Direction,
 	EventContextResponse,
@@ -25,6 +27,11 @@ import {
 	EventType,
 	GetOwnDevicesResponse,
 	GetProfileResponse,
+	ImagePack,
+	ImagePackEntry,
+	ImagePackInfo,
+	ImagePackRef,
+	InviteRules,
 	JSONValue,
 	LocalSearchParams,
 	LoginFlowsResponse,
@@ -34,6 +41,7 @@ import {
 	MembershipAction,
 	Mentions,
 	MessageEventContent,
+	ModerationJobResponse,
 	MutualRoomsResponse,
 	OAuthAuthorizationState,
 	OAuthClientMetadata,
@@ -43,6 +51,9 @@ import {
 	OAuthGenerateDeviceCodeParams,
 	OAuthGetAuthorizationURLParams,
 	PaginationResponse,
+	PolicyBanTarget,
+	PolicyList,
+	PolicyRule,
 	ProfileEncryptionInfo,
 	PushRuleKind,
 	PutPushRuleRequest,
@@ -51,6 +62,7 @@ import {
 	RawDBEvent,
 	ReceiptType,
 	RecoveryKeyResponse,
+	RejectInvitesResponse,
 	RelatesTo,
 	RelationType,
 	ReqCreateRoom,
@@ -68,6 +80,9 @@ import {
 	RoomStateGUID,
 	RoomSummary,
 	ServerSearchParams,
+	Snippet,
+	Snippets,
+	SpellCheckResponse,
 	TimelineRowID,
 	URLPreview,
 	UnreadType,
@@ -96,8 +111,13 @@ export interface SendMessageParams {
 	relates_to?: RelatesTo
 	mentions?: Mentions
 	url_previews?: URLPreview[]
+	/** Send the message even if it looks like it contains secrets */
+	override?: boolean
 }
 
+/** Prefix of the error returned by send_message when the message looks like it contains secrets */
+export const ErrCodeSecretDetected = "FI.MAU.GOMUKS.SECRET_DETECTED"
+
 export default abstract class RPCClient {
 	public readonly connect: CachedEventDispatcher<ConnectionEvent> = new CachedEventDispatcher()
 	public readonly event: EventDispatcher<RPCEvent> = new EventDispatcher()
@@ -525,4 +545,166 @@ export default abstract class RPCClient {
 	rerequestSession(room_id: RoomID, session_id: string, sender: UserID): Promise<void> {
 		return this.request("rerequest_session", { room_id, session_id, sender })
 	}
+
+	upgradeRoom(room_id: RoomID, new_version: string): Promise<{ replacement_room: RoomID }> {
+		return this.request("upgrade_room", { room_id, new_version })
+	}
+
+	bulkRedact(
+		user_id: UserID, room_id?: RoomID, limit?: number, reason?: string,
+	): Promise<ModerationJobResponse> {
+		return this.request("bulk_redact", { user_id, room_id, limit, reason })
+	}
+
+	bulkBan(space_id: RoomID, user_id: UserID, reason?: string): Promise<ModerationJobResponse> {
+		return this.request("bulk_ban", { space_id, user_id, reason })
+	}
+
+	bulkKick(room_id: RoomID, pattern: string, reason?: string): Promise<ModerationJobResponse> {
+		return this.request("bulk_kick", { room_id, pattern, reason })
+	}
+
+	cancelModerationJob(job_id: number): Promise<boolean> {
+		return this.request("cancel_moderation_job", { job_id })
+	}
+
+	getPolicyLists(): Promise<PolicyList[]> {
+		return this.request("get_policy_lists", {})
+	}
+
+	setPolicyList(room_id: RoomID, subscribe: boolean, auto_ban?: boolean): Promise<void> {
+		return this.request("set_policy_list", { room_id, subscribe, auto_ban })
+	}
+
+	getPolicyMatches(room_id: RoomID): Promise<Record<UserID, PolicyRule>> {
+		return this.request("get_policy_matches", { room_id })
+	}
+
+	getPolicyReport(): Promise<PolicyBanTarget[]> {
+		return this.request("get_policy_report", {})
+	}
+
+	applyPolicyBans(): Promise<ModerationJobResponse> {
+		return this.request("apply_policy_bans", {})
+	}
+
+	getIgnoredUsers(): Promise<UserID[]> {
+		return this.request("get_ignored_users", {})
+	}
+
+	setIgnored(user_id: UserID, ignored: boolean): Promise<void> {
+		return this.request("set_ignored", { user_id, ignored })
+	}
+
+	getInviteRules(): Promise<InviteRules> {
+		return this.request("get_invite_rules", {})
+	}
+
+	setInviteRules(rules: InviteRules): Promise<void> {
+		return this.request("set_invite_rules", rules)
+	}
+
+	rejectInvites(room_ids: RoomID[], quarantined?: boolean): Promise<RejectInvitesResponse> {
+		return this.request("reject_invites", { room_ids, quarantined })
+	}
+
+	votePoll(room_id: RoomID, event_id: EventID, answers: string[]): Promise<RawDBEvent> {
+		return this.request("vote_poll", { room_id, event_id, answers })
+	}
+
+	endPoll(room_id: RoomID, event_id: EventID): Promise<RawDBEvent> {
+		return this.request("end_poll", { room_id, event_id })
+	}
+
+	setDraft(draft: DBDraft): Promise<void> {
+		return this.request("set_draft", draft)
+	}
+
+	getDrafts(): Promise<DBDraft[]> {
+		return this.request("get_drafts", {})
+	}
+
+	getImagePacks(room_id?: RoomID): Promise<ImagePackInfo[]> {
+		return this.request("get_image_packs", { room_id })
+	}
+
+	createImagePack(ref: ImagePackRef, pack: ImagePack["pack"]): Promise<void> {
+		return this.request("create_image_pack", { ...ref, pack })
+	}
+
+	setPackImage(ref: ImagePackRef, shortcode: string, image: ImagePackEntry): Promise<void> {
+		return this.request("set_pack_image", { ...ref, shortcode, image })
+	}
+
+	renamePackImage(ref: ImagePackRef, shortcode: string, new_shortcode: string): Promise<void> {
+		return this.request("rename_pack_image", { ...ref, shortcode, new_shortcode })
+	}
+
+	deletePackImage(ref: ImagePackRef, shortcode: string): Promise<void> {
+		return this.request("delete_pack_image", { ...ref, shortcode })
+	}
+
+	setImagePackEnabled(room_id: RoomID, pack_id: string, enabled: boolean): Promise<void> {
+		return this.request("set_image_pack_enabled", { room_id, pack_id, enabled })
+	}
+
+	sendSticker(
+		room_id: RoomID,
+		pack: ImagePackRef,
+		shortcode: string,
+		relates_to?: RelatesTo,
+		mentions?: Mentions,
+	): Promise<RawDBEvent> {
+		return this.request("send_sticker", {
+			room_id,
+			pack_room_id: pack.room_id,
+			pack_id: pack.pack_id,
+			shortcode,
+			relates_to,
+			mentions,
+		})
+	}
+
+	sendLocation(
+		room_id: RoomID,
+		latitude: number,
+		longitude: number,
+		description?: string,
+		uncertainty?: number,
+		relates_to?: RelatesTo,
+	): Promise<RawDBEvent> {
+		return this.request("send_location", { room_id, latitude, longitude, uncertainty, description, relates_to })
+	}
+
+	startLiveLocation(room_id: RoomID, description?: string, timeout?: number): Promise<EventID> {
+		return this.request("start_live_location", { room_id, description, timeout })
+	}
+
+	updateLiveLocation(room_id: RoomID, latitude: number, longitude: number, uncertainty?: number): Promise<void> {
+		return this.request("update_live_location", { room_id, latitude, longitude, uncertainty })
+	}
+
+	stopLiveLocation(room_id: RoomID): Promise<void> {
+		return this.request("stop_live_location", { room_id })
+	}
+
+	getSnippets(): Promise<Snippets> {
+		return this.request("get_snippets", {})
+	}
+
+	setSnippet(name: string, snippet: Snippet | null): Promise<void> {
+		return this.request("set_snippet", { name, snippet })
+	}
+
+	completeCommand(room_id: RoomID, text: string): Promise<CommandCompletion> {
+		return this.request("complete_command", { room_id, text })
+	}
+
+	translateEvent(room_id: RoomID, event_id: EventID, language?: string): Promise<RawDBEvent> {
+		return this.request("translate_event", { room_id, event_id, language })
+	}
+
+	spellCheck(text: string, language?: string): Promise<SpellCheckResponse> {
+		return this.request("spell_check", { text, language })
+	}
 }
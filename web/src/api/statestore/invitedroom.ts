@@ -46,10 +46,13 @@ export class InvitedRoomStore implements RoomListEntry, RoomSummary {
 	readonly inviter_profile?: MemberEventContent
 	readonly is_direct: boolean
 	readonly is_invite = true
+	readonly quarantined: boolean
 
 	constructor(public readonly meta: DBInvitedRoom, parent: StateStore) {
 		this.room_id = meta.room_id
-		this.sorting_timestamp = 1000000000000000 + meta.created_at
+		this.quarantined = Boolean(meta.quarantined)
+		// Pin normal invites to the top of the room list, but keep quarantined ones in chronological order
+		this.sorting_timestamp = this.quarantined ? meta.created_at : 1000000000000000 + meta.created_at
 		this.date = new Date(meta.created_at - new Date().getTimezoneOffset() * 60000)
 			.toISOString().replace("T", " ").replace("Z", "")
 		const members = new Map<UserID, StrippedStateEvent>()
@@ -122,7 +125,7 @@ export class InvitedRoomStore implements RoomListEntry, RoomSummary {
 	}
 
 	get unread_messages(): number {
-		return 0
+		return this.quarantined ? 1 : 0
 	}
 
 	get unread_notifications(): number {
@@ -130,11 +133,11 @@ export class InvitedRoomStore implements RoomListEntry, RoomSummary {
 	}
 
 	get unread_highlights(): number {
-		return 1
+		return this.quarantined ? 0 : 1
 	}
 
 	get marked_unread(): boolean {
-		return true
+		return !this.quarantined
 	}
 
 	get low_priority(): boolean {
@@ -24,7 +24,10 @@ import Subscribable, { MultiSubscribable, NoDataSubscribable } from "@/util/subs
 import { getDisplayname } from "@/util/validation.ts"
 import {
 	ContentURI,
+	DBDraft,
 	DBRoom,
+	DraftUpdatedData,
+	EventID,
 	EventRowID,
 	EventsDecryptedData,
 	ImagePackRooms,
@@ -44,6 +47,7 @@ import { InvitedRoomStore } from "./invitedroom.ts"
 import { RoomStateStore } from "./room.ts"
 import {
 	DirectChatSpace,
+	RequestsSpace,
 	HomeSpace,
 	RoomListFilter,
 	Space,
@@ -65,6 +69,7 @@ export interface RoomListEntry {
 	unread_highlights: number
 	marked_unread: boolean
 	is_invite?: boolean
+	quarantined?: boolean
 	favorite_order?: number
 	low_priority?: boolean
 }
@@ -142,14 +147,18 @@ export class StateStore {
 	readonly homeSpace = new HomeSpace()
 	readonly directChatsSpace = new DirectChatSpace()
 	readonly unreadsSpace = new UnreadsSpace(this)
+	readonly requestsSpace = new RequestsSpace()
 	readonly pseudoSpaces = [
 		this.spaceOrphans,
 		this.directChatsSpace,
 		this.unreadsSpace,
+		this.requestsSpace,
 	] as const
 	currentRoomListQuery: string = ""
 	currentRoomListFilter: RoomListFilter | null = null
 	readonly accountData: Map<string, UnknownEventContent> = new Map()
+	readonly drafts: Map<string, DBDraft> = new Map()
+	readonly draftSubs = new MultiSubscribable()
 	readonly accountDataSubs = new MultiSubscribable()
 	readonly emojiRoomsSub = new Subscribable()
 	readonly preferences = getPreferenceProxy(this)
@@ -184,6 +193,9 @@ export class StateStore {
 			return false
 		} else if (this.currentRoomListFilter && !this.currentRoomListFilter.include(entry)) {
 			return false
+		} else if (entry.quarantined && this.currentRoomListFilter !== this.requestsSpace) {
+			// Quarantined invites are only shown in the requests section
+			return false
 		}
 		return true
 	}
@@ -268,7 +280,11 @@ export class StateStore {
 	}
 
 	get roomListFilterFunc(): ((entry: RoomListEntry) => boolean) | null {
-		if (!this.currentRoomListFilter && !this.currentRoomListQuery) {
+		if (
+			!this.currentRoomListFilter
+			&& !this.currentRoomListQuery
+			&& !this.inviteRooms.values().some(room => room.quarantined)
+		) {
 			return null
 		}
 		return this.#roomListFilterFunc
@@ -369,6 +385,14 @@ export class StateStore {
 		if (!someMeta) {
 			return
 		}
+		if (this.requestsSpace.include(someMeta)) {
+			this.requestsSpace.applyUnreads(meta, oldMeta)
+			return
+		} else if (oldMeta && this.requestsSpace.include(oldMeta)) {
+			// The quarantined invite was accepted, so its old counts were never included in other spaces
+			this.requestsSpace.applyUnreads(null, oldMeta)
+			oldMeta = null
+		}
 		this.homeSpace.applyUnreads(meta, oldMeta)
 		if (this.directChatsSpace.include(someMeta)) {
 			this.directChatsSpace.applyUnreads(meta, oldMeta)
@@ -743,6 +767,37 @@ export class StateStore {
 		room.applyTyping(typing.user_ids)
 	}
 
+	static makeDraftKey(roomID: RoomID, threadRoot?: EventID): string {
+		return threadRoot ? `${roomID}|${threadRoot}` : roomID
+	}
+
+	setDrafts(drafts: DBDraft[]) {
+		const changedKeys = new Set(this.drafts.keys())
+		this.drafts.clear()
+		for (const draft of drafts) {
+			const key = StateStore.makeDraftKey(draft.room_id, draft.thread_root)
+			this.drafts.set(key, draft)
+			changedKeys.add(key)
+		}
+		for (const key of changedKeys) {
+			this.draftSubs.notify(key)
+		}
+	}
+
+	applyDraftUpdate(data: DraftUpdatedData) {
+		const key = StateStore.makeDraftKey(data.room_id, data.thread_root)
+		if (data.draft) {
+			this.drafts.set(key, data.draft)
+		} else {
+			this.drafts.delete(key)
+		}
+		this.draftSubs.notify(key)
+	}
+
+	getDraft(roomID: RoomID, threadRoot?: EventID): DBDraft | undefined {
+		return this.drafts.get(StateStore.makeDraftKey(roomID, threadRoot))
+	}
+
 	clearTyping() {
 		for (const room of this.rooms.values()) {
 			room.clearTyping()
@@ -773,6 +828,7 @@ export class StateStore {
 		this.roomList.emit([])
 		this.topLevelSpaces.emit([])
 		this.accountData.clear()
+		this.drafts.clear()
 		this.currentRoomListQuery = ""
 		this.currentRoomListFilter = null
 		this.#frequentlyUsedEmoji = null
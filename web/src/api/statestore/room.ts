@@ -100,7 +100,7 @@ function otherMetaIsEqual(meta1: DBRoom, meta2: DBRoom): boolean {
 
 function memToRawEvent(evt: MemDBEvent): RawDBEvent {
 	const content = evt.orig_content ?? evt.content
-	const { mem, pending, viewing_redacted, ...rest } = evt
+	const { mem, pending, viewing_redacted, viewing_original, ...rest } = evt
 	return {
 		...rest,
 		decrypted: evt.encrypted ? content : undefined,
@@ -196,6 +196,9 @@ export class RoomStateStore {
 	paginationRequestedForRow = -1
 	readUpToRow = -1
 	hasMoreHistory = true
+	// The room whose history is being paginated. This moves on to the predecessor room
+	// once the history of this room runs out, so that the histories are merged.
+	paginationRoomID: RoomID
 	activeWidgets = new Set<string>()
 	hidden = false
 	tombstoned = false
@@ -207,6 +210,7 @@ export class RoomStateStore {
 
 	constructor(meta: DBRoom, private parent: StateStore) {
 		this.roomID = meta.room_id
+		this.paginationRoomID = meta.room_id
 		this.meta = new NonNullCachedEventDispatcher(meta)
 		this.searchString = this.#makeSearchString(meta)
 		this.localPreferenceCache = getLocalStoragePreferences(`prefs-${this.roomID}`, this.preferenceSub.notify)
@@ -496,6 +500,15 @@ export class RoomStateStore {
 		this.notifyTimelineSubscribers()
 	}
 
+	setViewingOriginal(evt: MemDBEvent, view: boolean) {
+		const modified = {
+			...evt,
+			viewing_original: view,
+		}
+		this.#saveEventToMaps(modified)
+		this.notifyTimelineSubscribers()
+	}
+
 	#saveEventToMaps(evt: MemDBEvent) {
 		this.eventsByRowID.set(evt.rowid, evt)
 		this.eventsByID.set(evt.event_id, evt)
@@ -641,6 +654,7 @@ export class RoomStateStore {
 		if (sync.reset) {
 			this.newTimelineEventSub.emit(null)
 			this.timeline = sync.timeline ?? []
+			this.paginationRoomID = this.roomID
 			this.pendingEvents.splice(0, this.pendingEvents.length)
 		} else if (sync.timeline) {
 			this.timeline.push(...sync.timeline)
@@ -849,6 +863,7 @@ export class RoomStateStore {
 		this.#membersCache = null
 		this.paginationRequestedForRow = -1
 		this.hasMoreHistory = true
+		this.paginationRoomID = this.roomID
 		this.timeline = []
 		this.notifyTimelineSubscribers()
 		const eventsToKeepList = this.eventsByRowID.values()
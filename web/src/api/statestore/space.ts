@@ -105,6 +105,14 @@ export class UnreadsSpace extends Space {
 	}
 }
 
+export class RequestsSpace extends Space {
+	id = "fi.mau.gomuks.requests"
+
+	include(room: RoomListEntry): boolean {
+		return Boolean(room.quarantined)
+	}
+}
+
 export class SpaceEdgeStore extends Space {
 	#children: DBSpaceEdge[] = []
 	#childRooms: Set<RoomID> = new Set()
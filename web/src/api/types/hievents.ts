@@ -15,6 +15,7 @@
 // along with this program.  If not, see <https://www.gnu.org/licenses/>.
 import {
 	DBAccountData,
+	DBDraft,
 	DBInvitedRoom,
 	DBReceipt,
 	DBRoom,
@@ -166,6 +167,33 @@ export interface RunIDEvent extends BaseRPCCommand<RunData> {
 	command: "run_id"
 }
 
+export type ModerationAction = "redact" | "ban" | "kick"
+
+export interface ModerationProgress {
+	job_id: number
+	action: ModerationAction
+	total: number
+	done: number
+	failed: number
+	error?: string
+	finished?: boolean
+	cancelled?: boolean
+}
+
+export interface ModerationProgressEvent extends BaseRPCCommand<ModerationProgress> {
+	command: "moderation_progress"
+}
+
+export interface DraftUpdatedData {
+	room_id: RoomID
+	thread_root?: EventID
+	draft: DBDraft | null
+}
+
+export interface DraftUpdatedEvent extends BaseRPCCommand<DraftUpdatedData> {
+	command: "draft_updated"
+}
+
 export interface ResponseCommand extends BaseRPCCommand<unknown> {
 	command: "response"
 }
@@ -187,6 +215,8 @@ export type RPCEvent =
 	SyncCompleteEvent |
 	ImageAuthTokenEvent |
 	InitCompleteEvent |
-	RunIDEvent
+	RunIDEvent |
+	ModerationProgressEvent |
+	DraftUpdatedEvent
 
 export type RPCCommand = RPCEvent | ResponseCommand | ErrorCommand | PingCommand
@@ -14,6 +14,7 @@
 // You should have received a copy of the GNU Affero General Public License
 // along with this program.  If not, see <https://www.gnu.org/licenses/>.
 import type {
+	BotCommand,
 	ContentURI,
 	CreateEventContent,
 	DeviceID,
@@ -21,7 +22,9 @@ import type {
 	EncryptionEventContent,
 	EventID,
 	EventType,
+	ImagePack,
 	LazyLoadSummary,
+	Mentions,
 	ReceiptType,
 	RelationType,
 	RoomAlias,
@@ -90,6 +93,7 @@ export interface DBInvitedRoom {
 	room_id: RoomID
 	created_at: number
 	invite_state: StrippedStateEvent[]
+	quarantined?: boolean
 }
 
 export enum UnreadType {
@@ -107,6 +111,14 @@ export interface LocalContent {
 	was_plaintext?: boolean
 	big_emoji?: boolean
 	has_math?: boolean
+	policy_match?: PolicyRule
+	translation?: Translation
+}
+
+export interface Translation {
+	text: string
+	language: string
+	source_language?: string
 }
 
 export interface BaseDBEvent {
@@ -136,6 +148,7 @@ export interface BaseDBEvent {
 	reactions?: Record<string, number>
 	last_edit_rowid?: EventRowID
 	unread_type: UnreadType
+	poll_tally?: PollTally
 
 	sticky_duration_ms?: number
 }
@@ -153,6 +166,7 @@ export interface MemDBEvent extends BaseDBEvent {
 	orig_local_content?: LocalContent
 	last_edit?: MemDBEvent
 	viewing_redacted?: boolean
+	viewing_original?: boolean
 	receipt_flattening?: EventID[]
 }
 
@@ -203,6 +217,115 @@ export interface MutualRoomsResponse {
 	next_batch?: string
 }
 
+export interface ModerationJobResponse {
+	job_id: number
+	total: number
+}
+
+export type PolicyEntityType = "user" | "server" | "room"
+
+export interface PolicyList {
+	room_id: RoomID
+	auto_ban: boolean
+	rule_count: number
+}
+
+export interface PolicyRule {
+	room_id: RoomID
+	entity_type: PolicyEntityType
+	state_key: string
+	entity: string
+	recommendation: string
+	reason?: string
+	event_rowid: EventRowID
+}
+
+export interface InviteRules {
+	blocked_users?: string[]
+	blocked_servers?: string[]
+	require_mutual_room?: boolean
+}
+
+export interface DBDraft {
+	room_id: RoomID
+	thread_root?: EventID
+	text: string
+	reply_to?: EventID
+	edit_target?: EventID
+	mentions?: Mentions
+	updated_at?: number
+}
+
+export interface ImagePackInfo {
+	room_id?: RoomID
+	pack_id: string
+	enabled: boolean
+	content: ImagePack
+}
+
+export interface ImagePackRef {
+	room_id?: RoomID
+	pack_id: string
+}
+
+export interface Snippet {
+	template: string
+	description?: string
+}
+
+export interface Snippets {
+	snippets: Record<string, Snippet>
+}
+
+export interface CommandCompletionItem {
+	value: string
+	label?: string
+}
+
+export interface CommandCompletion {
+	command?: BotCommand
+	source?: UserID
+	parameter?: string
+	start: number
+	completions: CommandCompletionItem[]
+	errors?: Record<string, string>
+}
+
+export interface Misspelling {
+	/** UTF-8 byte offsets of the word in the input */
+	start: number
+	end: number
+	word: string
+	suggestions: string[]
+}
+
+export interface SpellCheckResponse {
+	language: string
+	misspellings: Misspelling[]
+}
+
+export interface SpellCheckWords {
+	words: string[]
+}
+
+export interface PollTally {
+	votes: Record<UserID, string[]>
+	counts: Record<string, number>
+	ended_at?: number
+	ended_by?: UserID
+}
+
+export interface RejectInvitesResponse {
+	rejected: RoomID[]
+	failed: Record<RoomID, string>
+}
+
+export interface PolicyBanTarget {
+	room_id: RoomID
+	user_id: UserID
+	rule: PolicyRule
+}
+
 export interface SanitizedBio {
 	html: string
 	edit_source?: string
@@ -104,11 +104,14 @@ class ContextFields implements MainScreenContextFields {
 
 	setActiveRoom = (
 		roomID: RoomID | null,
-		{ previewMeta, toSpace, pushState, openEventID }: SetActiveRoomExtra = {},
+		{ previewMeta, toSpace, pushState, openEventID, followTombstone }: SetActiveRoomExtra = {},
 	) => {
 		console.log("Switching to room", roomID)
 		if (roomID) {
-			const room = this.client.store.rooms.get(roomID)
+			let room = this.client.store.rooms.get(roomID)
+			if (room && !openEventID && (followTombstone ?? true)) {
+				room = this.#followTombstones(room)
+			}
 			if (room) {
 				this.#setActiveRoom(room, toSpace, pushState ?? true, openEventID)
 			} else {
@@ -119,6 +122,21 @@ class ContextFields implements MainScreenContextFields {
 		}
 	}
 
+	#followTombstones(room: RoomStateStore): RoomStateStore {
+		// Follow tombstones to the replacement room if we've already joined it.
+		// The iteration limit protects against tombstone loops.
+		for (let i = 0; i < 10; i++) {
+			const replacementRoomID = room.meta.current.tombstone?.replacement_room
+			const replacementRoom = replacementRoomID ? this.client.store.rooms.get(replacementRoomID) : undefined
+			if (!replacementRoom) {
+				break
+			}
+			console.log("Following tombstone from", room.roomID, "to", replacementRoom.roomID)
+			room = replacementRoom
+		}
+		return room
+	}
+
 	setSpace = (space: RoomListFilter | null, pushState = true) => {
 		if (space === this.client.store.currentRoomListFilter) {
 			if (space && space.id.startsWith("!") && pushState && !this.client.store.activeRoomID) {
@@ -391,6 +409,7 @@ const MainScreen = () => {
 						via: ensureStringArray(evt.state?.source_via),
 					},
 					pushState: false,
+					followTombstone: false,
 					// This isn't actually a part of the history state, but does appear in hash change events.
 					openEventID: evt.state?.event_id,
 				})
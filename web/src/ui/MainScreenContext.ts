@@ -24,6 +24,7 @@ export interface SetActiveRoomExtra {
 	toSpace?: RoomListFilter,
 	pushState?: boolean,
 	openEventID?: string | null,
+	followTombstone?: boolean,
 }
 
 export interface MainScreenContextFields {
@@ -13,15 +13,17 @@
 //
 // You should have received a copy of the GNU Affero General Public License
 // along with this program.  If not, see <https://www.gnu.org/licenses/>.
-import React, { JSX } from "react"
-import { RoomStateStore } from "@/api/statestore"
+import React, { JSX, use } from "react"
+import { RoomStateStore, useAccountData } from "@/api/statestore"
 import {
 	BotArgumentValue,
 	BotParameter,
 	SingleBotArgumentValue,
+	Snippets,
 	commandArgsToString,
 	unpackExtensibleText,
 } from "@/api/types"
+import ClientContext from "../ClientContext.ts"
 import { ComposerState } from "./MessageComposer.tsx"
 
 interface CommandArgumentProps {
@@ -29,6 +31,7 @@ interface CommandArgumentProps {
 	spec: BotParameter
 	value: BotArgumentValue
 	setValue: (value: BotArgumentValue) => void
+	suggestions?: string[]
 }
 
 function renderArgumentContent(
@@ -39,6 +42,7 @@ function renderArgumentContent(
 	autoFocus: boolean,
 	onKeyDown: (evt: React.KeyboardEvent) => void,
 	key?: number,
+	suggestions?: string[],
 ): JSX.Element {
 	if (spec.schema.schema_type === "primitive" && spec.schema.type === "boolean") {
 		return <input
@@ -76,20 +80,26 @@ function renderArgumentContent(
 			{spec.enum.map(option => <option key={option} value={option}>{option}</option>)}
 		</select>
 	} */ else {
-		return <input
-			key={key}
-			id={contentID}
-			autoFocus={autoFocus}
-			type="text"
-			value={(value ?? "") as string}
-			onChange={evt => setValue(evt.target.value)}
-			onKeyDown={onKeyDown}
-			placeholder={description}
-		/>
+		const listID = suggestions?.length ? `${contentID}-suggestions` : undefined
+		return <React.Fragment key={key}>
+			<input
+				id={contentID}
+				autoFocus={autoFocus}
+				type="text"
+				value={(value ?? "") as string}
+				onChange={evt => setValue(evt.target.value)}
+				onKeyDown={onKeyDown}
+				placeholder={description}
+				list={listID}
+			/>
+			{listID ? <datalist id={listID}>
+				{suggestions!.map(item => <option key={item} value={item}/>)}
+			</datalist> : null}
+		</React.Fragment>
 	}
 }
 
-const CommandArgument = ({ index, spec, value, setValue }: CommandArgumentProps) => {
+const CommandArgument = ({ index, spec, value, setValue, suggestions }: CommandArgumentProps) => {
 	const description = unpackExtensibleText(spec.description) || spec.key
 	const contentID = `cmd-arg-${index}`
 	const onKeyDown = (evt: React.KeyboardEvent) => {
@@ -113,11 +123,13 @@ const CommandArgument = ({ index, spec, value, setValue }: CommandArgumentProps)
 		content = <div className="variadic-items">
 			{(value as SingleBotArgumentValue[]).map((item, itemIdx) =>
 				renderArgumentContent(
-					spec, item, valueSetter(itemIdx), description, contentID, false, onKeyDown, itemIdx,
+					spec, item, valueSetter(itemIdx), description, contentID, false, onKeyDown, itemIdx, suggestions,
 				))}
 		</div>
 	} else {
-		content = renderArgumentContent(spec, value, setValue, description, contentID, false, onKeyDown)
+		content = renderArgumentContent(
+			spec, value, setValue, description, contentID, false, onKeyDown, undefined, suggestions,
+		)
 	}
 	return <>
 		<label htmlFor={contentID} title={description}>{spec.key}</label>
@@ -132,7 +144,15 @@ export interface CommandInputProps {
 }
 
 const CommandInput = ({ state, setState }: CommandInputProps) => {
+	const client = use(ClientContext)!
+	const snippets = (useAccountData(client.store, "fi.mau.gomuks.snippets") as Snippets | null)?.snippets
 	const cmd = state.command!
+	const getSuggestions = (spec: BotParameter) => {
+		if (cmd.spec.command === "snippet" && spec.key === "name" && snippets) {
+			return Object.keys(snippets).sort()
+		}
+		return undefined
+	}
 	return <div className="command-arguments">
 		{cmd.spec.parameters?.map((spec, index) => {
 			return <CommandArgument
@@ -140,6 +160,7 @@ const CommandInput = ({ state, setState }: CommandInputProps) => {
 				index={index}
 				spec={spec}
 				value={cmd.inputArgs[spec.key]}
+				suggestions={getSuggestions(spec)}
 				setValue={val => {
 					const inputArgs = {
 						...cmd.inputArgs,
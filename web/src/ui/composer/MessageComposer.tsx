@@ -24,9 +24,12 @@ import React, {
 	useRef,
 	useState,
 } from "react"
-import { useRoomEvent, useRoomState } from "@/api/statestore"
+import type Client from "@/api/client.ts"
+import { ErrCodeSecretDetected, ErrorResponse, SendMessageParams } from "@/api/rpc.ts"
+import { StateStore, useRoomEvent, useRoomState } from "@/api/statestore"
 import {
 	BotArgumentValue,
+	DBDraft,
 	EventID,
 	MediaEncodingOptions,
 	MediaMessageEventContent,
@@ -127,6 +130,14 @@ const composerReducer = (
 	uninited: undefined,
 })
 
+// Drafts are saved to localStorage immediately and synced to the backend after a short delay,
+// so that other clients (and other tabs) can pick up the draft when the room is opened there.
+const DRAFT_SYNC_DELAY = 1000
+const draftSyncTimeouts = new Map<string, ReturnType<typeof setTimeout>>()
+
+// A stored draft is the composer state along with the event being edited, if the draft is an edit.
+type StoredDraft = ComposerState & { editTarget?: EventID }
+
 const draftStore = {
 	makeDraftKey(roomID: RoomID, threadID?: EventID): string {
 		if (threadID) {
@@ -134,23 +145,66 @@ const draftStore = {
 		}
 		return `draft-${roomID}`
 	},
-	get: (roomID: RoomID, threadID?: EventID): ComposerState | null => {
+	get: (client: Client, roomID: RoomID, threadID?: EventID): StoredDraft | null => {
 		const data = localStorage.getItem(draftStore.makeDraftKey(roomID, threadID))
-		if (!data) {
-			return null
-		}
+		const serverDraft = client.store.getDraft(roomID, threadID)
+		let parsed: (StoredDraft & { saved_at?: number }) | null = null
 		try {
-			const parsed = JSON.parse(data)
-			parsed.loadingPreviews = []
-			return parsed
+			parsed = data ? JSON.parse(data) : null
 		} catch {
+			parsed = null
+		}
+		if (serverDraft && (!parsed || (serverDraft.updated_at ?? 0) > (parsed.saved_at ?? 0))) {
+			return {
+				...emptyComposer,
+				text: serverDraft.text,
+				replyTo: serverDraft.reply_to ?? null,
+				editTarget: serverDraft.edit_target,
+			}
+		} else if (!parsed) {
 			return null
 		}
+		delete parsed.saved_at
+		parsed.loadingPreviews = []
+		return parsed
+	},
+	set: (client: Client, roomID: RoomID, data: ComposerState, threadID?: EventID, editTarget?: EventID) => {
+		localStorage.setItem(
+			draftStore.makeDraftKey(roomID, threadID),
+			JSON.stringify({ ...data, editTarget, saved_at: Date.now() }),
+		)
+		draftStore.sync(client, {
+			room_id: roomID,
+			thread_root: threadID,
+			text: data.text,
+			reply_to: data.replyTo ?? undefined,
+			edit_target: editTarget,
+		})
+	},
+	clear: (client: Client, roomID: RoomID, threadID?: EventID) => {
+		localStorage.removeItem(draftStore.makeDraftKey(roomID, threadID))
+		draftStore.sync(client, { room_id: roomID, thread_root: threadID, text: "" })
+	},
+	sync: (client: Client, draft: DBDraft) => {
+		const key = draftStore.makeDraftKey(draft.room_id, draft.thread_root)
+		clearTimeout(draftSyncTimeouts.get(key))
+		draftSyncTimeouts.delete(key)
+		const existing = client.store.getDraft(draft.room_id, draft.thread_root)
+		if (
+			(existing?.text ?? "") === draft.text
+			&& existing?.reply_to === draft.reply_to
+			&& existing?.edit_target === draft.edit_target
+		) {
+			return
+		}
+		draftSyncTimeouts.set(key, setTimeout(() => {
+			draftSyncTimeouts.delete(key)
+			client.rpc.setDraft(draft).catch(err => console.error("Failed to sync draft to backend:", err))
+		}, DRAFT_SYNC_DELAY))
+	},
+	hasPendingSync: (roomID: RoomID, threadID?: EventID): boolean => {
+		return draftSyncTimeouts.has(draftStore.makeDraftKey(roomID, threadID))
 	},
-	set: (roomID: RoomID, data: ComposerState, threadID?: EventID) =>
-		localStorage.setItem(draftStore.makeDraftKey(roomID, threadID), JSON.stringify(data)),
-	clear: (roomID: RoomID, threadID?: EventID) =>
-		localStorage.removeItem(draftStore.makeDraftKey(roomID, threadID)),
 }
 
 type CaretEvent<T> = React.MouseEvent<T> | React.KeyboardEvent<T> | React.ChangeEvent<T>
@@ -174,6 +228,12 @@ const MessageComposer = () => {
 	const composerRef = useRef<HTMLDivElement>(null)
 	const textRows = useRef(1)
 	const typingSentAt = useRef(0)
+	// The composer state from before an edit was started, restored when the edit is sent or cancelled
+	const preEditDraft = useRef<ComposerState | null>(null)
+	// The edit target of a draft whose target event is still being fetched
+	const pendingEditTarget = useRef<EventID | null>(null)
+	const draftLoadCounter = useRef(0)
+	const lastSavedDraft = useRef<DBDraft | null>(null)
 	const replyToEvt = useRoomEvent(room, state.replyTo)
 	const tombstoneEvent = useRoomState(room, "m.room.tombstone", "")
 	const createEvent = useRoomState(room, "m.room.create", "")
@@ -211,9 +271,11 @@ const MessageComposer = () => {
 		}
 	}, [])
 	roomCtx.setEditing = useCallback((evt: MemDBEvent | null, failed?: true) => {
+		pendingEditTarget.current = null
 		if (evt === null) {
 			rawSetEditing(null)
-			setState(draftStore.get(room.roomID, roomCtx.threadRoot) ?? emptyComposer)
+			setState(preEditDraft.current ?? emptyComposer)
+			preEditDraft.current = null
 			return
 		}
 		const evtContent = evt.content as MessageEventContent
@@ -226,6 +288,9 @@ const MessageComposer = () => {
 		let replyTo: EventID | null = null
 		let silentReply  = false
 		let explicitReplyInThread = false
+		if (!editing) {
+			preEditDraft.current = state
+		}
 		if (!failed) {
 			rawSetEditing(evt)
 		} else if (evt.relation_type === "m.replace" && evt.relates_to) {
@@ -259,7 +324,7 @@ const MessageComposer = () => {
 				[],
 		})
 		textInput.current?.focus()
-	}, [room, roomCtx.threadRoot])
+	}, [room, editing, state])
 	const canSend = Boolean(state.text || state.media || state.location)
 	const onClickSend = (evt: React.FormEvent) => {
 		evt.preventDefault()
@@ -273,7 +338,8 @@ const MessageComposer = () => {
 	}
 	const doSendMessage = (state: ComposerState) => {
 		if (editing) {
-			setState(draftStore.get(room.roomID, roomCtx.threadRoot) ?? emptyComposer)
+			setState(preEditDraft.current ?? emptyComposer)
+			preEditDraft.current = null
 		} else {
 			setState(emptyComposer)
 		}
@@ -360,7 +426,7 @@ const MessageComposer = () => {
 				return
 			}
 		}
-		client.sendMessage({
+		const params: SendMessageParams = {
 			room_id: room.roomID,
 			base_content,
 			extra,
@@ -368,7 +434,16 @@ const MessageComposer = () => {
 			relates_to,
 			mentions,
 			url_previews: state.previews,
-		}).catch(err => window.alert("Failed to send message: " + err))
+		}
+		const onSendError = (err: unknown) => window.alert("Failed to send message: " + err)
+		client.sendMessage(params).catch(err => {
+			const errStr = err instanceof ErrorResponse ? String(err.data) : ""
+			if (!errStr.startsWith(ErrCodeSecretDetected)) {
+				onSendError(err)
+			} else if (window.confirm(errStr.slice(ErrCodeSecretDetected.length + 2) + "\n\nSend anyway?")) {
+				client.sendMessage({ ...params, override: true }).catch(onSendError)
+			}
+		})
 	}
 	const onComposerCaretChange = (
 		evt: CaretEvent<HTMLTextAreaElement>, newText?: string, newCommand?: CommandState | null,
@@ -718,9 +793,52 @@ const MessageComposer = () => {
 	}, [isEncrypted])
 	// To ensure the cursor jumps to the end, do this in an effect rather than as the initial value of useState
 	// To try to avoid the input bar flashing, use useLayoutEffect instead of useEffect
+	const applyDraft = useCallback((draft: StoredDraft | null) => {
+		const loadID = ++draftLoadCounter.current
+		preEditDraft.current = null
+		pendingEditTarget.current = null
+		const startEditing = (evt: MemDBEvent, draft: StoredDraft) => {
+			roomCtx.setEditing(evt)
+			// Edits don't have an earlier draft to return to
+			preEditDraft.current = null
+			setState({ text: draft.text })
+		}
+		const editTarget = draft?.editTarget
+		if (!draft || !editTarget) {
+			rawSetEditing(null)
+			setState(draft ?? emptyComposer)
+			return
+		}
+		const evt = room.eventsByID.get(editTarget)
+		if (evt) {
+			startEditing(evt, draft)
+			return
+		}
+		// Show the text while the edit target is being fetched, but keep the draft marked as an edit
+		rawSetEditing(null)
+		setState({ ...draft, replyTo: null })
+		pendingEditTarget.current = editTarget
+		client.rpc.getEvent(room.roomID, editTarget).then(
+			rawEvt => {
+				if (draftLoadCounter.current !== loadID || pendingEditTarget.current !== editTarget) {
+					return
+				}
+				room.applyEvent(rawEvt)
+				const evt = room.eventsByID.get(editTarget)
+				if (evt) {
+					startEditing(evt, draft)
+				}
+			},
+			err => {
+				console.error("Failed to fetch edit target of draft:", err)
+				if (draftLoadCounter.current === loadID) {
+					pendingEditTarget.current = null
+				}
+			},
+		)
+	}, [client, room, roomCtx])
 	useLayoutEffect(() => {
-		const draft = draftStore.get(room.roomID, roomCtx.threadRoot)
-		setState(draft ?? emptyComposer)
+		applyDraft(draftStore.get(client, room.roomID, roomCtx.threadRoot))
 		setAutocomplete(null)
 		return () => {
 			if (typingSentAt.current > 0) {
@@ -731,7 +849,29 @@ const MessageComposer = () => {
 				}
 			}
 		}
-	}, [client, room, roomCtx])
+	}, [client, room, roomCtx, applyDraft])
+	useEffect(() => client.store.draftSubs.getSubscriber(StateStore.makeDraftKey(room.roomID, roomCtx.threadRoot))(
+		() => {
+			if (draftStore.hasPendingSync(room.roomID, roomCtx.threadRoot)) {
+				// Local changes that haven't been synced yet take precedence
+				return
+			}
+			const serverDraft = client.store.getDraft(room.roomID, roomCtx.threadRoot)
+			const lastSaved = lastSavedDraft.current
+			if (
+				(serverDraft?.text ?? "") === (lastSaved?.text ?? "")
+				&& serverDraft?.reply_to === lastSaved?.reply_to
+				&& serverDraft?.edit_target === lastSaved?.edit_target
+			) {
+				// This is the draft that this composer saved
+				return
+			}
+			if (!serverDraft) {
+				localStorage.removeItem(draftStore.makeDraftKey(room.roomID, roomCtx.threadRoot))
+			}
+			applyDraft(draftStore.get(client, room.roomID, roomCtx.threadRoot))
+		},
+	), [client, room, roomCtx, applyDraft])
 	useEffect(() => {
 		if (mainScreen.pendingShare) {
 			console.info("Processing pending share")
@@ -763,15 +903,23 @@ const MessageComposer = () => {
 	// Saving to localStorage could be done in the reducer, but that's not very proper, so do it in an effect.
 	useEffect(() => {
 		roomCtx.isEditing.emit(editing !== null)
-		if (state.uninited || editing) {
+		if (state.uninited) {
 			return
 		}
-		if (!state.text && !state.media && !state.replyTo && !state.location) {
-			draftStore.clear(room.roomID, roomCtx.threadRoot)
+		const editTarget = editing?.event_id ?? pendingEditTarget.current ?? undefined
+		if (!state.text && !state.media && !state.replyTo && !state.location && !editTarget) {
+			lastSavedDraft.current = null
+			draftStore.clear(client, room.roomID, roomCtx.threadRoot)
 		} else {
-			draftStore.set(room.roomID, state, roomCtx.threadRoot)
+			lastSavedDraft.current = {
+				room_id: room.roomID,
+				text: state.text,
+				reply_to: state.replyTo ?? undefined,
+				edit_target: editTarget,
+			}
+			draftStore.set(client, room.roomID, state, roomCtx.threadRoot, editTarget)
 		}
-	}, [roomCtx, room, state, editing])
+	}, [client, roomCtx, room, state, editing])
 	useEffect(() => {
 		if (state.uninited) {
 			return
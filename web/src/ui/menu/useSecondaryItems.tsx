@@ -32,6 +32,7 @@ import RefreshIcon from "@/icons/refresh.svg?react"
 import ReportIcon from "@/icons/report.svg?react"
 import RestoreTrashIcon from "@/icons/restore-trash.svg?react"
 import ShareIcon from "@/icons/share.svg?react"
+import TranslateIcon from "@/icons/translate.svg?react"
 import UnpinIcon from "@/icons/unpin.svg?react"
 
 export const useSecondaryItems = (
@@ -109,6 +110,17 @@ export const useSecondaryItems = (
 			client.requestEvent(roomCtx.store, evt.event_id, true)
 		}
 	}
+	const onClickTranslate = () => {
+		closeModal()
+		client.rpc.translateEvent(evt.room_id, evt.last_edit?.event_id ?? evt.event_id).then(
+			() => evt.viewing_original && roomCtx.store.setViewingOriginal(evt, false),
+			err => window.alert(`Failed to translate message: ${err}`),
+		)
+	}
+	const onClickViewOriginal = (view: boolean) => () => {
+		closeModal()
+		roomCtx.store.setViewingOriginal(evt, view)
+	}
 	const onClickPin = (pin: boolean) => () => {
 		closeModal()
 		client.pinMessage(roomCtx.store, evt.event_id, pin)
@@ -142,6 +154,7 @@ export const useSecondaryItems = (
 		&& (evt.sender === client.userID || ownPL >= redactOtherPL)
 	// TODO check server admin status and room PLs
 	const canUnredact = displayAsRedacted(evt, memberEvt, roomCtx.store)
+	const canTranslate = evt.type === "m.room.message" && !evt.redacted_by && typeof evt.content.body === "string"
 
 	return <>
 		<button onClick={onClickViewSource}><ViewSourceIcon/>{names && "View source"}</button>
@@ -152,6 +165,13 @@ export const useSecondaryItems = (
 		{evt.decryption_error && evt.content.session_id &&
 			<button onClick={onClickRerequestSession}><RefreshIcon/>{names && "Request key"}</button>}
 		<button onClick={onClickShareEvent}><ShareIcon/>{names && "Share"}</button>
+		{canTranslate && (!evt.local_content?.translation
+			? <button onClick={onClickTranslate} disabled={isPending} title={pendingTitle}>
+				<TranslateIcon/>{names && "Translate"}
+			</button>
+			: <button onClick={onClickViewOriginal(!evt.viewing_original)}>
+				<TranslateIcon/>{names && (evt.viewing_original ? "Show translation" : "Show original")}
+			</button>)}
 		{ownPL >= pinPL && (pins.includes(evt.event_id)
 			? <button onClick={onClickPin(false)}>
 				<UnpinIcon/>{names && "Unpin message"}
@@ -29,17 +29,13 @@ const UserIgnoreButton = ({ userID, client }: { userID: string; client: Client }
 		if (!window.confirm(`Are you sure you want to ignore ${userID}?`)) {
 			return
 		}
-		const newIgnoredUsers = { ...(ignoredUsers || { ignored_users: {}}) }
-		newIgnoredUsers.ignored_users[userID] = {}
-		client.rpc.setAccountData("m.ignored_user_list", newIgnoredUsers).catch(err => {
+		client.rpc.setIgnored(userID, true).catch(err => {
 			console.error("Failed to ignore user", err)
 			window.alert(`Failed to ignore ${userID}: ${err}`)
 		})
 	}
 	const unignoreUser = () => {
-		const newIgnoredUsers = { ...(ignoredUsers || { ignored_users: {}}) }
-		delete newIgnoredUsers.ignored_users[userID]
-		client.rpc.setAccountData("m.ignored_user_list", newIgnoredUsers).catch(err => {
+		client.rpc.setIgnored(userID, false).catch(err => {
 			console.error("Failed to unignore user", err)
 			window.alert(`Failed to unignore ${userID}: ${err}`)
 		})
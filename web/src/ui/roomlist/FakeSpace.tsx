@@ -19,6 +19,7 @@ import { useEventAsState } from "@/util/eventdispatcher.ts"
 import UnreadCount from "./UnreadCount.tsx"
 import HomeIcon from "@/icons/home.svg?react"
 import NotificationsIcon from "@/icons/notifications.svg?react"
+import InviteIcon from "@/icons/person-add.svg?react"
 import PersonIcon from "@/icons/person.svg?react"
 import TagIcon from "@/icons/tag.svg?react"
 import "./RoomList.css"
@@ -40,6 +41,8 @@ const getFakeSpaceMeta = (space: RoomListFilter | null): [string | undefined, JS
 		return ["Unread chats", <NotificationsIcon />]
 	case "fi.mau.gomuks.space_orphans":
 		return ["Rooms outside spaces", <TagIcon />]
+	case "fi.mau.gomuks.requests":
+		return ["Invite requests", <InviteIcon />]
 	default:
 		return [undefined, null]
 	}
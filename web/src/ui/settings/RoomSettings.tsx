@@ -103,7 +103,7 @@ const RoomSettings = ({ room }: RoomSettingsProps) => {
 	}
 	const previousRoomID = roomMeta?.creation_content?.predecessor?.room_id
 	const openPredecessorRoom = () => {
-		window.mainScreenContext.setActiveRoom(previousRoomID!)
+		window.mainScreenContext.setActiveRoom(previousRoomID!, { followTombstone: false })
 		closeModal()
 	}
 	const undoChanges = () => {
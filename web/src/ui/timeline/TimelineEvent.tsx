@@ -381,6 +381,13 @@ const TimelineEvent = ({
 					{mainMemberEventDisplayname}
 				</span>
 			</>}
+			{evt.local_content?.policy_match && <span
+				className="policy-match"
+				title={`Matches policy ${evt.local_content.policy_match.entity}`
+					+ (evt.local_content.policy_match.reason ? `: ${evt.local_content.policy_match.reason}` : "")}
+			>
+				{evt.local_content.policy_match.recommendation}
+			</span>}
 			<span className="event-time" title={fullTime} onClick={onClickTimestamp}>{shortTime}</span>
 		</div> : <div className="event-time-only" onClick={onClickTimestamp}>
 			<span className="event-time" title={fullTime}>{shortTime}</span>
@@ -76,13 +76,20 @@ const PollMessageBody = ({ event, room }: EventContentProps) => {
 	const content = event.content as PollStartEventContent
 	const pollStart = content["org.matrix.msc3381.poll.start"] ?? {}
 
-	const [votes, setVotes] = useState<Record<UserID, string[]> | null>(null)
-	const [pollEndTS, setPollEndTS] = useState<number>(0)
+	const [votes, setVotes] = useState<Record<UserID, string[]> | null>(event.poll_tally?.votes ?? null)
+	const [pollEndTS, setPollEndTS] = useState<number>(event.poll_tally?.ended_at ?? 0)
 	const [loading, setLoading] = useState<null | string>(null)
 	const maxSelections = getMaxSelections(content)
 	const answers = Array.isArray(pollStart.answers) ? pollStart.answers : []
 	const [pls, ownPL, createEvent] = getPowerLevels(room, client)
 
+	useEffect(() => {
+		if (event.poll_tally) {
+			setVotes(event.poll_tally.votes)
+			setPollEndTS(event.poll_tally.ended_at ?? 0)
+		}
+	}, [event.poll_tally])
+
 	useEffect(() => pollEndTS > 0 ? undefined : room.newTimelineEventSub.listen(evt => {
 		if (evt === null) {
 			setVotes(null)
@@ -181,17 +188,8 @@ const PollMessageBody = ({ event, room }: EventContentProps) => {
 		} else {
 			ownVote = ownVote.filter(id => id !== answerID)
 		}
-		const answerContent: PollResponseEventContent = {
-			"m.relates_to": {
-				event_id: event.event_id,
-				rel_type: "m.reference",
-			},
-			"org.matrix.msc3381.poll.response": {
-				answers: ownVote,
-			},
-		}
 		try {
-			await client.rpc.sendEvent(room.roomID, "org.matrix.msc3381.poll.response", answerContent)
+			await client.rpc.votePoll(room.roomID, event.event_id, ownVote)
 			setVotes(oldVotes => ({
 				...oldVotes,
 				[client.userID]: ownVote,
@@ -210,14 +208,7 @@ const PollMessageBody = ({ event, room }: EventContentProps) => {
 			return
 		}
 		setLoading(closeLoadingKey)
-		client.rpc.sendEvent(room.roomID, "org.matrix.msc3381.poll.end", {
-			"m.relates_to": {
-				event_id: event.event_id,
-				rel_type: "m.reference",
-			},
-			"org.matrix.msc3381.poll.end": {},
-			"org.matrix.msc1767.text": "Poll closed",
-		}).catch(err => {
+		client.rpc.endPoll(room.roomID, event.event_id).catch(err => {
 			console.error("Failed to close poll:", err)
 			window.alert(`Failed to close poll: ${err}`)
 		}).finally(() => setLoading(null))
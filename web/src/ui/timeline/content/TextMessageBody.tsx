@@ -184,6 +184,18 @@ const TextMessageBody = ({ event, sender }: EventContentProps) => {
 		classNames.push("math-body")
 		importMath()
 	}
+	const translation = event.local_content?.translation
+	if (translation && !event.viewing_original) {
+		classNames.push("plaintext-body", "translated-body")
+		const source = translation.source_language ? ` from ${translation.source_language}` : ""
+		return <div
+			className={classNames.join(" ")}
+			data-event-sender={eventSenderName}
+			title={`Translated${source} to ${translation.language}`}
+		>
+			{translation.text}
+		</div>
+	}
 	if (event.local_content?.sanitized_html) {
 		classNames.push("html-body")
 		return <div